@@ -0,0 +1,250 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"goyave.dev/goyave/v5"
+
+	errorutil "goyave.dev/goyave/v5/util/errors"
+)
+
+const (
+	// DefaultAPIKeyHeader the default name of the header containing the API key.
+	DefaultAPIKeyHeader = "X-API-Key"
+
+	// MetaAPIKeyScopes the route meta key used to define the scopes an API key must have
+	// to access the route. The meta value is expected to be a `[]string`. The key must
+	// have all the listed scopes, otherwise the request is rejected with "403 Forbidden".
+	MetaAPIKeyScopes = "goyave.api-key-scopes"
+)
+
+// ExtraAPIKey when using the built-in `APIKeyAuthenticator`, this
+// key can be used to retrieve the authenticated `*APIKey[T]` in the request's `Extra`.
+type ExtraAPIKey struct{}
+
+// APIKey the stored representation of an API key. The secret part of the key is never
+// stored: only its hash is, as returned by `HashAPIKey`.
+type APIKey[T any] struct {
+	// ExpiresAt the time after which the key is rejected. The key never
+	// expires if this is the zero value.
+	ExpiresAt time.Time
+
+	// User the owner of the key, set as `request.User` on successful authentication.
+	User *T
+
+	// Prefix the public identifier of the key, used to look it up.
+	Prefix string
+
+	// Hash the hex-encoded SHA-256 hash of the secret part of the key.
+	Hash string
+
+	// Scopes the permissions granted to the key.
+	Scopes []string
+}
+
+// HasScope returns true if the key has the given scope.
+func (k *APIKey[T]) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+// KeyService is the dependency of the `APIKeyAuthenticator` used to retrieve an API key by its prefix.
+//
+// If the key could not be found, the error returned should be of type `gorm.ErrRecordNotFound`.
+type KeyService[T any] interface {
+	FindByPrefix(ctx context.Context, prefix string) (*APIKey[T], error)
+}
+
+// GeneratedAPIKey a new API key returned by `GenerateAPIKey`.
+type GeneratedAPIKey struct {
+	// Key the full key in the "prefix_secret" format. It should be shown once
+	// to the client and never stored.
+	Key string
+
+	// Prefix the public identifier of the key, to store alongside the hash.
+	Prefix string
+
+	// Hash the hash of the secret part of the key, to store.
+	Hash string
+}
+
+// GenerateAPIKey generates a new random API key in the "prefix_secret" format. The prefix
+// is 12 hexadecimal characters and the secret contains 256 bits of entropy.
+func GenerateAPIKey() (*GeneratedAPIKey, error) {
+	prefix := make([]byte, 6)
+	if _, err := rand.Read(prefix); err != nil {
+		return nil, errorutil.New(err)
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, errorutil.New(err)
+	}
+	key := &GeneratedAPIKey{
+		Prefix: hex.EncodeToString(prefix),
+	}
+	secretString := hex.EncodeToString(secret)
+	key.Key = key.Prefix + "_" + secretString
+	key.Hash = HashAPIKey(secretString)
+	return key, nil
+}
+
+// ParseAPIKey splits the given key in the "prefix_secret" format. Returns false if
+// the key doesn't have this format.
+func ParseAPIKey(key string) (prefix string, secret string, ok bool) {
+	prefix, secret, ok = strings.Cut(key, "_")
+	return prefix, secret, ok && prefix != "" && secret != ""
+}
+
+// HashAPIKey returns the hex-encoded SHA-256 hash of the given API key secret. As the
+// secrets are long random strings, a fast hash is sufficient and allows to check keys
+// without slowing down every request.
+func HashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// insufficientScopeError returned by the `APIKeyAuthenticator` when the key is valid
+// but doesn't have the scopes required by the route.
+type insufficientScopeError struct {
+	message string
+}
+
+func (e *insufficientScopeError) Error() string {
+	return e.message
+}
+
+// APIKeyAuthenticator implementation of Authenticator using API keys in the "prefix_secret"
+// format, intended for machine-to-machine access. The key is looked up by its prefix using the
+// `KeyService`, then its secret is verified against the stored hash.
+//
+// On success, the key's owner is set as `request.User` and the key is added to `request.Extra`
+// with the key `ExtraAPIKey{}`. Routes can require scopes using the `MetaAPIKeyScopes` meta.
+//
+// The T parameter represents the user DTO and should not be a pointer.
+type APIKeyAuthenticator[T any] struct {
+	goyave.Component
+
+	KeyService KeyService[T]
+
+	// Header the name of the header containing the key.
+	// Defaults to `DefaultAPIKeyHeader`.
+	Header string
+
+	// QueryParameter if not empty, the key is read from this query parameter
+	// when the header is absent. Keep in mind that URLs are often logged.
+	QueryParameter string
+
+	// Optional defines if the authenticator allows requests that
+	// don't provide credentials. Handlers should therefore check
+	// if `request.User` is not `nil` before accessing it.
+	Optional bool
+}
+
+// NewAPIKeyAuthenticator create a new authenticator for API keys.
+//
+// The T parameter represents the user DTO and should not be a pointer.
+func NewAPIKeyAuthenticator[T any](keyService KeyService[T]) *APIKeyAuthenticator[T] {
+	return &APIKeyAuthenticator[T]{
+		KeyService: keyService,
+	}
+}
+
+// HasCredentials returns true if the request has an API key.
+func (a *APIKeyAuthenticator[T]) HasCredentials(request *goyave.Request) bool {
+	return a.getKey(request) != ""
+}
+
+// Authenticate fetch the user owning the API key found in the given request
+// and returns it. If no user can be authenticated, returns an error.
+func (a *APIKeyAuthenticator[T]) Authenticate(request *goyave.Request) (*T, error) {
+	rawKey := a.getKey(request)
+	if rawKey == "" {
+		if a.Optional {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s", request.Lang.Get("auth.no-credentials-provided"))
+	}
+
+	prefix, secret, ok := ParseAPIKey(rawKey)
+	if !ok {
+		return nil, fmt.Errorf("%s", request.Lang.Get("auth.invalid-credentials"))
+	}
+
+	key, err := a.KeyService.FindByPrefix(request.Context(), prefix)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%s", request.Lang.Get("auth.invalid-credentials"))
+		}
+		panic(errorutil.New(err))
+	}
+
+	if subtle.ConstantTimeCompare([]byte(HashAPIKey(secret)), []byte(key.Hash)) != 1 {
+		return nil, fmt.Errorf("%s", request.Lang.Get("auth.invalid-credentials"))
+	}
+	if !key.ExpiresAt.IsZero() && !time.Now().Before(key.ExpiresAt) {
+		return nil, fmt.Errorf("%s", request.Lang.Get("auth.api-key-expired"))
+	}
+
+	for _, scope := range a.getRequiredScopes(request) {
+		if !key.HasScope(scope) {
+			return nil, &insufficientScopeError{message: request.Lang.Get("auth.insufficient-scope")}
+		}
+	}
+
+	if key.User == nil {
+		return nil, fmt.Errorf("%s", request.Lang.Get("auth.invalid-credentials"))
+	}
+
+	request.Extra[ExtraAPIKey{}] = key
+	return key.User, nil
+}
+
+// OnUnauthorized responds with "403 Forbidden" if the key doesn't have the scopes
+// required by the route, and "401 Unauthorized" otherwise.
+func (a *APIKeyAuthenticator[T]) OnUnauthorized(response *goyave.Response, _ *goyave.Request, err error) {
+	status := http.StatusUnauthorized
+	var scopeErr *insufficientScopeError
+	if errors.As(err, &scopeErr) {
+		status = http.StatusForbidden
+	}
+	response.JSON(status, map[string]string{"error": err.Error()})
+}
+
+func (a *APIKeyAuthenticator[T]) getKey(request *goyave.Request) string {
+	header := a.Header
+	if header == "" {
+		header = DefaultAPIKeyHeader
+	}
+	if key := request.Header().Get(header); key != "" {
+		return key
+	}
+	if a.QueryParameter != "" {
+		return request.URL().Query().Get(a.QueryParameter)
+	}
+	return ""
+}
+
+func (a *APIKeyAuthenticator[T]) getRequiredScopes(request *goyave.Request) []string {
+	if request.Route == nil {
+		return nil
+	}
+	meta, ok := request.Route.LookupMeta(MetaAPIKeyScopes)
+	if !ok || meta == nil {
+		return nil
+	}
+	scopes, ok := meta.([]string)
+	if !ok {
+		panic(errorutil.NewSkip(fmt.Errorf("auth: route meta %q is not a []string", MetaAPIKeyScopes), 3))
+	}
+	return scopes
+}
@@ -0,0 +1,325 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/slog"
+	"goyave.dev/goyave/v5/util/testutil"
+)
+
+type MockKeyService[T any] struct {
+	key *APIKey[T]
+	err error
+}
+
+func (s *MockKeyService[T]) FindByPrefix(_ context.Context, prefix string) (*APIKey[T], error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	if s.key == nil || s.key.Prefix != prefix {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return s.key, nil
+}
+
+func TestGenerateAPIKey(t *testing.T) {
+	key, err := GenerateAPIKey()
+	require.NoError(t, err)
+	assert.Len(t, key.Prefix, 12)
+
+	prefix, secret, ok := ParseAPIKey(key.Key)
+	require.True(t, ok)
+	assert.Equal(t, key.Prefix, prefix)
+	assert.Len(t, secret, 64)
+	assert.Equal(t, HashAPIKey(secret), key.Hash)
+
+	other, err := GenerateAPIKey()
+	require.NoError(t, err)
+	assert.NotEqual(t, key.Key, other.Key)
+}
+
+func TestParseAPIKey(t *testing.T) {
+	cases := []struct {
+		key    string
+		prefix string
+		secret string
+		ok     bool
+	}{
+		{key: "abc_def", prefix: "abc", secret: "def", ok: true},
+		{key: "abc_def_ghi", prefix: "abc", secret: "def_ghi", ok: true},
+		{key: "abcdef", ok: false},
+		{key: "_def", ok: false},
+		{key: "abc_", ok: false},
+		{key: "", ok: false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.key, func(t *testing.T) {
+			prefix, secret, ok := ParseAPIKey(c.key)
+			assert.Equal(t, c.ok, ok)
+			if c.ok {
+				assert.Equal(t, c.prefix, prefix)
+				assert.Equal(t, c.secret, secret)
+			}
+		})
+	}
+}
+
+func TestAPIKeyAuthenticator(t *testing.T) {
+	prepareKey := func(t *testing.T, user *TestUser) (*GeneratedAPIKey, *MockKeyService[TestUser]) {
+		generated, err := GenerateAPIKey()
+		require.NoError(t, err)
+		service := &MockKeyService[TestUser]{
+			key: &APIKey[TestUser]{
+				User:   user,
+				Prefix: generated.Prefix,
+				Hash:   generated.Hash,
+				Scopes: []string{"read", "write"},
+			},
+		}
+		return generated, service
+	}
+
+	assertError := func(t *testing.T, server *testutil.TestServer, resp *http.Response, status int, message string) {
+		assert.Equal(t, status, resp.StatusCode)
+		body, err := testutil.ReadJSONBody[map[string]string](resp.Body)
+		assert.NoError(t, resp.Body.Close())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"error": server.Lang.GetDefault().Get(message)}, body)
+	}
+
+	t.Run("success", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		generated, service := prepareKey(t, user)
+		authenticator := Middleware(NewAPIKeyAuthenticator(service))
+
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Header().Set(DefaultAPIKeyHeader, generated.Key)
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true, MetaAPIKeyScopes: []string{"read"}}}
+		resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, request *goyave.Request) {
+			assert.Equal(t, user.ID, request.User.(*TestUser).ID)
+			assert.Equal(t, service.key, request.Extra[ExtraAPIKey{}])
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("custom_header", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		generated, service := prepareKey(t, user)
+		a := NewAPIKeyAuthenticator(service)
+		a.Header = "Api-Token"
+		authenticator := Middleware(a)
+
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Header().Set("Api-Token", generated.Key)
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, request *goyave.Request) {
+			assert.Equal(t, user.ID, request.User.(*TestUser).ID)
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("query_parameter", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		generated, service := prepareKey(t, user)
+		a := NewAPIKeyAuthenticator(service)
+		a.QueryParameter = "api_key"
+		authenticator := Middleware(a)
+
+		request := server.NewTestRequest(http.MethodGet, "/protected?api_key="+generated.Key, nil)
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, request *goyave.Request) {
+			assert.Equal(t, user.ID, request.User.(*TestUser).ID)
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("query_parameter_disabled", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		generated, service := prepareKey(t, user)
+		authenticator := Middleware(NewAPIKeyAuthenticator(service))
+
+		request := server.NewTestRequest(http.MethodGet, "/protected?api_key="+generated.Key, nil)
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware passed despite failed authentication")
+			response.Status(http.StatusOK)
+		})
+		assertError(t, server, resp, http.StatusUnauthorized, "auth.no-credentials-provided")
+	})
+
+	t.Run("wrong_secret", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		generated, service := prepareKey(t, user)
+		authenticator := Middleware(NewAPIKeyAuthenticator(service))
+
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Header().Set(DefaultAPIKeyHeader, generated.Prefix+"_wrong")
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware passed despite failed authentication")
+			response.Status(http.StatusOK)
+		})
+		assertError(t, server, resp, http.StatusUnauthorized, "auth.invalid-credentials")
+	})
+
+	t.Run("unknown_prefix", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		_, service := prepareKey(t, user)
+		authenticator := Middleware(NewAPIKeyAuthenticator(service))
+
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Header().Set(DefaultAPIKeyHeader, "unknown_secret")
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware passed despite failed authentication")
+			response.Status(http.StatusOK)
+		})
+		assertError(t, server, resp, http.StatusUnauthorized, "auth.invalid-credentials")
+	})
+
+	t.Run("malformed", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		_, service := prepareKey(t, user)
+		authenticator := Middleware(NewAPIKeyAuthenticator(service))
+
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Header().Set(DefaultAPIKeyHeader, "malformed")
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware passed despite failed authentication")
+			response.Status(http.StatusOK)
+		})
+		assertError(t, server, resp, http.StatusUnauthorized, "auth.invalid-credentials")
+	})
+
+	t.Run("no_user", func(t *testing.T) {
+		server, _ := prepareAuthenticatorTest(t)
+		generated, service := prepareKey(t, nil)
+		authenticator := Middleware(NewAPIKeyAuthenticator(service))
+
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Header().Set(DefaultAPIKeyHeader, generated.Key)
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware passed despite failed authentication")
+			response.Status(http.StatusOK)
+		})
+		assertError(t, server, resp, http.StatusUnauthorized, "auth.invalid-credentials")
+		assert.NotContains(t, request.Extra, ExtraAPIKey{})
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		generated, service := prepareKey(t, user)
+		service.key.ExpiresAt = time.Now().Add(-time.Minute)
+		authenticator := Middleware(NewAPIKeyAuthenticator(service))
+
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Header().Set(DefaultAPIKeyHeader, generated.Key)
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware passed despite failed authentication")
+			response.Status(http.StatusOK)
+		})
+		assertError(t, server, resp, http.StatusUnauthorized, "auth.api-key-expired")
+	})
+
+	t.Run("not_expired", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		generated, service := prepareKey(t, user)
+		service.key.ExpiresAt = time.Now().Add(time.Hour)
+		authenticator := Middleware(NewAPIKeyAuthenticator(service))
+
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Header().Set(DefaultAPIKeyHeader, generated.Key)
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, _ *goyave.Request) {
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("insufficient_scope", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		generated, service := prepareKey(t, user)
+		authenticator := Middleware(NewAPIKeyAuthenticator(service))
+
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Header().Set(DefaultAPIKeyHeader, generated.Key)
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true, MetaAPIKeyScopes: []string{"read", "admin"}}}
+		resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware passed despite failed authentication")
+			response.Status(http.StatusOK)
+		})
+		assertError(t, server, resp, http.StatusForbidden, "auth.insufficient-scope")
+	})
+
+	t.Run("invalid_scopes_meta", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		buf := &bytes.Buffer{}
+		server.Logger = slog.New(slog.NewHandler(false, buf))
+		generated, service := prepareKey(t, user)
+		authenticator := Middleware(NewAPIKeyAuthenticator(service))
+
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Header().Set(DefaultAPIKeyHeader, generated.Key)
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true, MetaAPIKeyScopes: "read"}}
+		resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware passed despite invalid meta")
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("service_error", func(t *testing.T) {
+		server, _ := prepareAuthenticatorTest(t)
+		buf := &bytes.Buffer{}
+		server.Logger = slog.New(slog.NewHandler(false, buf))
+		authenticator := Middleware(NewAPIKeyAuthenticator(&MockKeyService[TestUser]{err: fmt.Errorf("service_error")}))
+
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Header().Set(DefaultAPIKeyHeader, "prefix_secret")
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware passed despite failed authentication")
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("optional_no_key", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		_, service := prepareKey(t, user)
+		a := NewAPIKeyAuthenticator(service)
+		a.Optional = true
+		authenticator := Middleware(a)
+
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, request *goyave.Request) {
+			assert.Nil(t, request.User)
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+}
@@ -4,11 +4,12 @@ import (
 	"crypto/subtle"
 	"errors"
 	"fmt"
+	"net/http"
 	"reflect"
 
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/auth/hash"
 	"goyave.dev/goyave/v5/config"
 	errorutil "goyave.dev/goyave/v5/util/errors"
 )
@@ -27,6 +28,19 @@ type BasicAuthenticator[T any] struct {
 	// It will be used to compare the password hash with the user input.
 	PasswordField string
 
+	// Hasher used to verify the passwords. Defaults to `hash.Default()`,
+	// which uses bcrypt and recognizes argon2id and scrypt hashes.
+	Hasher hash.Hasher
+
+	// OnRehash if not `nil`, called on successful authentication if the stored
+	// password hash needs to be upgraded to the preferred algorithm or parameters
+	// of the `Hasher`.
+	OnRehash RehashFunc[T]
+
+	// Throttler if not `nil`, limits the failed authentication attempts per username
+	// and client IP. Throttled requests are rejected with "429 Too Many Requests".
+	Throttler *LoginThrottler
+
 	// Optional defines if the authenticator allows requests that
 	// don't provide credentials. Handlers should therefore check
 	// if `request.User` is not `nil` before accessing it.
@@ -47,10 +61,18 @@ func NewBasicAuthenticator[T any](userService UserService[T], passwordField stri
 	}
 }
 
+// HasCredentials returns true if the request has a basic auth header.
+func (a *BasicAuthenticator[T]) HasCredentials(request *goyave.Request) bool {
+	_, _, ok := request.BasicAuth()
+	return ok
+}
+
 // Authenticate fetch the user corresponding to the credentials
 // found in the given request and returns it.
 // If no user can be authenticated, returns an error.
-// The password is checked using bcrypt.
+// The password is checked using the `Hasher`, even if the user doesn't exist so the
+// response time doesn't reveal it. If the authenticator has a `Throttler`, failed
+// attempts are counted and throttled requests are rejected.
 func (a *BasicAuthenticator[T]) Authenticate(request *goyave.Request) (*T, error) {
 	username, password, ok := request.BasicAuth()
 
@@ -61,6 +83,15 @@ func (a *BasicAuthenticator[T]) Authenticate(request *goyave.Request) (*T, error
 		return nil, fmt.Errorf("%s", request.Lang.Get("auth.no-credentials-provided"))
 	}
 
+	guard := newLoginGuard(a.Throttler, &a.Component, request, username)
+	throttled, err := guard.reserve()
+	if err != nil {
+		panic(err)
+	}
+	if throttled != nil {
+		return nil, throttled
+	}
+
 	user, err := a.UserService.FindByUsername(request.Context(), username)
 
 	notFound := errors.Is(err, gorm.ErrRecordNotFound)
@@ -68,22 +99,39 @@ func (a *BasicAuthenticator[T]) Authenticate(request *goyave.Request) (*T, error
 		panic(errorutil.New(err))
 	}
 
-	t := reflect.Indirect(reflect.ValueOf(user))
-	for t.Kind() == reflect.Ptr {
-		t = t.Elem()
-	}
-	pass := t.FieldByName(a.PasswordField)
-	if pass.Kind() == reflect.Invalid {
-		panic(errorutil.Errorf("could not find valid field/column %q in type %T", a.PasswordField, user))
+	verifier := passwordVerifier[T]{hasher: a.Hasher, onRehash: a.OnRehash, field: a.PasswordField}
+	if notFound {
+		verifier.verifyDummy(password)
+		ok = false
+	} else {
+		ok, err = verifier.verify(&a.Component, request, user, password)
+		if err != nil {
+			panic(err)
+		}
 	}
 
-	if notFound || bcrypt.CompareHashAndPassword([]byte(pass.String()), []byte(password)) != nil {
+	if !ok {
+		guard.fail()
 		return nil, fmt.Errorf("%s", request.Lang.Get("auth.invalid-credentials"))
 	}
 
+	if err := guard.succeed(); err != nil {
+		panic(err)
+	}
 	return user, nil
 }
 
+// OnUnauthorized responds with "429 Too Many Requests" and the "Retry-After" header
+// if the attempt was rejected by the `Throttler`, and "401 Unauthorized" otherwise.
+func (a *BasicAuthenticator[T]) OnUnauthorized(response *goyave.Response, _ *goyave.Request, err error) {
+	var throttled *loginThrottledError
+	if errors.As(err, &throttled) {
+		throttled.respond(response)
+		return
+	}
+	response.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+}
+
 //--------------------------------------------
 
 func init() {
@@ -112,6 +160,12 @@ type ConfigBasicAuthenticator struct {
 	goyave.Component
 }
 
+// HasCredentials returns true if the request has a basic auth header.
+func (a *ConfigBasicAuthenticator) HasCredentials(request *goyave.Request) bool {
+	_, _, ok := request.BasicAuth()
+	return ok
+}
+
 // Authenticate check if the request basic auth header matches the
 // "auth.basic.username" and "auth.basic.password" config entries.
 func (a *ConfigBasicAuthenticator) Authenticate(request *goyave.Request) (*BasicUser, error) {
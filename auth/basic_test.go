@@ -2,13 +2,18 @@ package auth
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/auth/hash"
 	"goyave.dev/goyave/v5/config"
 	"goyave.dev/goyave/v5/slog"
 	"goyave.dev/goyave/v5/util/testutil"
@@ -183,6 +188,182 @@ func TestBasicAuthenticator(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
 		assert.NoError(t, resp.Body.Close())
 	})
+
+	t.Run("rehash", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		mockUserService := &MockUserService[TestUser]{user: user}
+		a := NewBasicAuthenticator(mockUserService, "Password")
+		argon := hash.NewArgon2id()
+		argon.Memory = 64
+		a.Hasher = hash.NewMulti(argon, hash.NewBcrypt())
+		rehashed := ""
+		a.OnRehash = func(_ context.Context, u *TestUser, h string) error {
+			assert.Equal(t, user, u)
+			rehashed = h
+			return nil
+		}
+		authenticator := Middleware(a)
+
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Request().SetBasicAuth(user.Email, "secret")
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, _ *goyave.Request) {
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+		require.NotEmpty(t, rehashed)
+		assert.True(t, argon.Identify(rehashed))
+		ok, err := argon.Compare(rehashed, "secret")
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		// The hash is up to date: no rehash
+		user.Password = rehashed
+		rehashed = ""
+		request = server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Request().SetBasicAuth(user.Email, "secret")
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp = server.TestMiddleware(authenticator, request, func(response *goyave.Response, _ *goyave.Request) {
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+		assert.Empty(t, rehashed)
+	})
+
+	t.Run("rehash_error", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		buf := &bytes.Buffer{}
+		server.Logger = slog.New(slog.NewHandler(false, buf))
+		mockUserService := &MockUserService[TestUser]{user: user}
+		a := NewBasicAuthenticator(mockUserService, "Password")
+		a.Hasher = hash.NewMulti(&hash.Bcrypt{Cost: bcrypt.MinCost})
+		a.OnRehash = func(_ context.Context, _ *TestUser, _ string) error {
+			return fmt.Errorf("rehash error")
+		}
+		authenticator := Middleware(a)
+
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Request().SetBasicAuth(user.Email, "secret")
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, _ *goyave.Request) {
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+		assert.Contains(t, buf.String(), "rehash error")
+	})
+
+	t.Run("argon2id_hash", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		argon := hash.NewArgon2id()
+		argon.Memory = 64
+		var err error
+		user.Password, err = argon.Hash("secret")
+		require.NoError(t, err)
+		mockUserService := &MockUserService[TestUser]{user: user}
+		authenticator := Middleware(NewBasicAuthenticator(mockUserService, "Password"))
+
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Request().SetBasicAuth(user.Email, "secret")
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, _ *goyave.Request) {
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("user_not_found_compares_dummy_hash", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		mockUserService := &MockUserService[TestUser]{err: fmt.Errorf("test errors: %w", gorm.ErrRecordNotFound)}
+		a := NewBasicAuthenticator(mockUserService, "Password")
+		compared := 0
+		a.Hasher = countingHasher{Hasher: &hash.Bcrypt{Cost: bcrypt.MinCost}, compared: &compared}
+		authenticator := Middleware(a)
+
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Request().SetBasicAuth(user.Email, "secret")
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware passed despite failed authentication")
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+		assert.Equal(t, 1, compared)
+	})
+
+	t.Run("throttle", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		buf := &bytes.Buffer{}
+		server.Logger = slog.New(slog.NewHandler(false, buf))
+		mockUserService := &MockUserService[TestUser]{user: user}
+		a := NewBasicAuthenticator(mockUserService, "Password")
+		now := time.Now()
+		a.Throttler = NewLoginThrottler(NewMemoryLoginAttemptStore())
+		a.Throttler.MaxAttempts = 2
+		a.Throttler.BaseDelay = 0
+		a.Throttler.now = func() time.Time { return now }
+		authenticator := Middleware(a)
+
+		authenticate := func(password string) *http.Response {
+			request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+			request.Request().SetBasicAuth(user.Email, password)
+			request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+			return server.TestMiddleware(authenticator, request, func(response *goyave.Response, _ *goyave.Request) {
+				response.Status(http.StatusOK)
+			})
+		}
+
+		// A successful login resets the failures
+		resp := authenticate("wrong password")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+		resp = authenticate("secret")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+		resp = authenticate("wrong password")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+		assert.Empty(t, buf.String())
+
+		resp = authenticate("wrong password")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+		assert.Contains(t, buf.String(), "login locked out after too many failed attempts")
+		assert.Contains(t, buf.String(), user.Email)
+
+		resp = authenticate("secret")
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Equal(t, "900", resp.Header.Get("Retry-After"))
+		body, err := testutil.ReadJSONBody[map[string]string](resp.Body)
+		assert.NoError(t, resp.Body.Close())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"error": server.Lang.GetDefault().Get("auth.too-many-attempts")}, body)
+	})
+
+	t.Run("throttle_store_error", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		buf := &bytes.Buffer{}
+		server.Logger = slog.New(slog.NewHandler(false, buf))
+		mockUserService := &MockUserService[TestUser]{user: user}
+		a := NewBasicAuthenticator(mockUserService, "Password")
+		a.Throttler = NewLoginThrottler(errLoginAttemptStore{err: fmt.Errorf("store error")})
+		authenticator := Middleware(a)
+
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Request().SetBasicAuth(user.Email, "secret")
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware passed despite store error")
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+		assert.Contains(t, buf.String(), "store error")
+	})
 }
 
 func TestConfigBasicAuthenticator(t *testing.T) {
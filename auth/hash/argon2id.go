@@ -0,0 +1,114 @@
+package hash
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+
+	errorutil "goyave.dev/goyave/v5/util/errors"
+)
+
+// Argon2id a `Hasher` using argon2id. Hashes are encoded in the PHC string format:
+//
+//	$argon2id$v=19$m=19456,t=2,p=1$<salt>$<key>
+type Argon2id struct {
+	// Memory the amount of memory used, in KiB.
+	Memory uint32
+
+	// Iterations the number of passes over the memory.
+	Iterations uint32
+
+	// SaltLength the length of the random salt, in bytes.
+	SaltLength uint32
+
+	// KeyLength the length of the derived key, in bytes.
+	KeyLength uint32
+
+	// Parallelism the number of threads used.
+	Parallelism uint8
+}
+
+// NewArgon2id create a new `Argon2id` hasher using the minimum parameters recommended
+// by OWASP: 19 MiB of memory, 2 iterations and a parallelism of 1.
+func NewArgon2id() *Argon2id {
+	return &Argon2id{
+		Memory:      19 * 1024,
+		Iterations:  2,
+		Parallelism: 1,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
+type argon2idParams struct {
+	salt        []byte
+	key         []byte
+	version     int
+	memory      uint32
+	iterations  uint32
+	parallelism uint8
+}
+
+// Hash implementation of `Hasher`.
+func (h *Argon2id) Hash(password string) (string, error) {
+	salt, err := generateSalt(h.SaltLength)
+	if err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, h.Iterations, h.Memory, h.Parallelism, h.KeyLength)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version, h.Memory, h.Iterations, h.Parallelism, b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+}
+
+// Compare implementation of `Hasher`.
+func (h *Argon2id) Compare(hash, password string) (bool, error) {
+	params, err := h.decode(hash)
+	if err != nil {
+		return false, err
+	}
+	key := argon2.IDKey([]byte(password), params.salt, params.iterations, params.memory, params.parallelism, uint32(len(params.key)))
+	return equal(key, params.key), nil
+}
+
+// NeedsRehash implementation of `Hasher`. Returns true if the parameters of the given
+// hash are different from the parameters of the hasher.
+func (h *Argon2id) NeedsRehash(hash string) bool {
+	params, err := h.decode(hash)
+	if err != nil {
+		return true
+	}
+	return params.version != argon2.Version ||
+		params.memory != h.Memory ||
+		params.iterations != h.Iterations ||
+		params.parallelism != h.Parallelism ||
+		uint32(len(params.salt)) != h.SaltLength ||
+		uint32(len(params.key)) != h.KeyLength
+}
+
+// Identify implementation of `Hasher`.
+func (h *Argon2id) Identify(hash string) bool {
+	return strings.HasPrefix(hash, "$argon2id$")
+}
+
+func (h *Argon2id) decode(hash string) (*argon2idParams, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return nil, errorutil.New(ErrUnknownFormat)
+	}
+	params := &argon2idParams{}
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &params.version); err != nil {
+		return nil, errorutil.New(ErrInvalidHash)
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.memory, &params.iterations, &params.parallelism); err != nil {
+		return nil, errorutil.New(ErrInvalidHash)
+	}
+	if params.iterations == 0 || params.parallelism == 0 {
+		return nil, errorutil.New(ErrInvalidHash)
+	}
+	var err error
+	params.salt, params.key, err = decodeSaltAndKey(parts[4], parts[5])
+	if err != nil {
+		return nil, err
+	}
+	return params, nil
+}
@@ -0,0 +1,58 @@
+package hash
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestArgon2id(t *testing.T) {
+	_, h, _ := testHashers()
+	hash, err := h.Hash("secret")
+	require.NoError(t, err)
+	assert.Regexp(t, `^\$argon2id\$v=19\$m=64,t=1,p=1\$[A-Za-z0-9+/]{22}\$[A-Za-z0-9+/]{43}$`, hash)
+	assert.True(t, h.Identify(hash))
+	assert.False(t, h.NeedsRehash(hash))
+
+	ok, err := h.Compare(hash, "secret")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = h.Compare(hash, "wrong")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	other, err := h.Hash("secret")
+	require.NoError(t, err)
+	assert.NotEqual(t, hash, other)
+
+	// The parameters of the hash are used, not the ones of the hasher
+	h2 := *h
+	h2.Memory = 128
+	ok, err = h2.Compare(hash, "secret")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, h2.NeedsRehash(hash))
+
+	h3 := *h
+	h3.KeyLength = 16
+	assert.True(t, h3.NeedsRehash(hash))
+
+	cases := map[string]error{
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdA":            ErrUnknownFormat,
+		"$argon2i$v=19$m=64,t=1,p=1$c2FsdA$a2V5":        ErrUnknownFormat,
+		"$argon2id$v=x$m=64,t=1,p=1$c2FsdA$a2V5":        ErrInvalidHash,
+		"$argon2id$v=19$m=64,t=x,p=1$c2FsdA$a2V5":       ErrInvalidHash,
+		"$argon2id$v=19$m=64,t=0,p=1$c2FsdA$a2V5":       ErrInvalidHash,
+		"$argon2id$v=19$m=64,t=1,p=1$!!$a2V5":           ErrInvalidHash,
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdA$":           ErrInvalidHash,
+		"$argon2id$v=19$m=64,t=1,p=1$c2FsdA$not base64": ErrInvalidHash,
+	}
+	for hash, expected := range cases {
+		ok, err := h.Compare(hash, "secret")
+		assert.False(t, ok, hash)
+		assert.True(t, errors.Is(err, expected), hash)
+		assert.True(t, h.NeedsRehash(hash), hash)
+	}
+}
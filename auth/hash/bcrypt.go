@@ -0,0 +1,55 @@
+package hash
+
+import (
+	"errors"
+	"strings"
+
+	"golang.org/x/crypto/bcrypt"
+
+	errorutil "goyave.dev/goyave/v5/util/errors"
+)
+
+// Bcrypt a `Hasher` using bcrypt. Only the first 72 bytes of a
+// password are used by bcrypt: longer passwords cannot be hashed.
+type Bcrypt struct {
+	// Cost the bcrypt cost parameter, between `bcrypt.MinCost` and `bcrypt.MaxCost`.
+	Cost int
+}
+
+// NewBcrypt create a new `Bcrypt` hasher using `bcrypt.DefaultCost`.
+func NewBcrypt() *Bcrypt {
+	return &Bcrypt{Cost: bcrypt.DefaultCost}
+}
+
+// Hash implementation of `Hasher`.
+func (h *Bcrypt) Hash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), h.Cost)
+	if err != nil {
+		return "", errorutil.New(err)
+	}
+	return string(hash), nil
+}
+
+// Compare implementation of `Hasher`.
+func (h *Bcrypt) Compare(hash, password string) (bool, error) {
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+	if err != nil {
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return false, nil
+		}
+		return false, errorutil.New(err)
+	}
+	return true, nil
+}
+
+// NeedsRehash implementation of `Hasher`. Returns true if the cost of
+// the given hash is different from the cost of the hasher.
+func (h *Bcrypt) NeedsRehash(hash string) bool {
+	cost, err := bcrypt.Cost([]byte(hash))
+	return err != nil || cost != h.Cost
+}
+
+// Identify implementation of `Hasher`.
+func (h *Bcrypt) Identify(hash string) bool {
+	return strings.HasPrefix(hash, "$2a$") || strings.HasPrefix(hash, "$2b$") || strings.HasPrefix(hash, "$2y$")
+}
@@ -0,0 +1,39 @@
+package hash
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestBcrypt(t *testing.T) {
+	h := &Bcrypt{Cost: bcrypt.MinCost}
+	hash, err := h.Hash("secret")
+	require.NoError(t, err)
+	assert.True(t, h.Identify(hash))
+	assert.False(t, h.NeedsRehash(hash))
+
+	ok, err := h.Compare(hash, "secret")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = h.Compare(hash, "wrong")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	h.Cost++
+	assert.True(t, h.NeedsRehash(hash))
+	assert.True(t, h.NeedsRehash("invalid"))
+
+	ok, err = h.Compare("$2a$invalid", "secret")
+	assert.False(t, ok)
+	assert.Error(t, err)
+
+	assert.True(t, h.Identify("$2y$10$abc"))
+	assert.True(t, h.Identify("$2b$10$abc"))
+	assert.False(t, h.Identify("$argon2id$v=19$m=64,t=1,p=1$c2FsdA$a2V5"))
+
+	_, err = h.Hash(string(make([]byte, 73)))
+	assert.Error(t, err)
+}
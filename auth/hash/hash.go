@@ -0,0 +1,125 @@
+// Package hash provides password hashing with bcrypt, argon2id and scrypt.
+//
+// The hashes are encoded with their algorithm and parameters so they can be verified
+// with the right algorithm, and so hashes using outdated parameters can be detected
+// and upgraded on the next successful login.
+package hash
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+
+	errorutil "goyave.dev/goyave/v5/util/errors"
+)
+
+// ErrUnknownFormat returned when trying to verify a password against a hash
+// whose format is not recognized by the hasher.
+var ErrUnknownFormat = errors.New("hash: unknown hash format")
+
+// ErrInvalidHash returned when a hash has a recognized format but cannot be decoded.
+var ErrInvalidHash = errors.New("hash: invalid hash")
+
+// Hasher hashes passwords and verifies passwords against hashes.
+//
+// Implementations must be safe for concurrent use.
+type Hasher interface {
+	// Hash returns the encoded hash of the given password, including
+	// the algorithm, its parameters and a random salt.
+	Hash(password string) (string, error)
+
+	// Compare returns true if the given password matches the given hash.
+	// Returns an error if the hash cannot be decoded.
+	Compare(hash, password string) (bool, error)
+
+	// NeedsRehash returns true if the given hash was not created by this hasher with
+	// its current parameters. The password should be hashed again and the new
+	// hash persisted on the next successful login.
+	NeedsRehash(hash string) bool
+
+	// Identify returns true if the given hash uses the format of this hasher.
+	Identify(hash string) bool
+}
+
+// Multi a `Hasher` creating new hashes with its preferred hasher and verifying passwords
+// with the hasher that recognizes the format of the stored hash. This allows migrating
+// from an algorithm to another: the hashes that don't use the preferred hasher
+// need to be rehashed.
+type Multi struct {
+	hashers []Hasher
+}
+
+// NewMulti create a new `Multi` hasher. New hashes are created with the preferred hasher.
+// Existing hashes can be verified with any of the given hashers.
+func NewMulti(preferred Hasher, others ...Hasher) *Multi {
+	return &Multi{
+		hashers: append([]Hasher{preferred}, others...),
+	}
+}
+
+// Default returns a new `Multi` hasher preferring bcrypt with its default
+// cost and recognizing argon2id and scrypt hashes.
+func Default() *Multi {
+	return NewMulti(NewBcrypt(), NewArgon2id(), NewScrypt())
+}
+
+// Hash implementation of `Hasher`. Uses the preferred hasher.
+func (m *Multi) Hash(password string) (string, error) {
+	return m.hashers[0].Hash(password)
+}
+
+// Compare implementation of `Hasher`. Uses the first hasher recognizing the
+// format of the given hash. Returns `ErrUnknownFormat` if none recognizes it.
+func (m *Multi) Compare(hash, password string) (bool, error) {
+	for _, h := range m.hashers {
+		if h.Identify(hash) {
+			return h.Compare(hash, password)
+		}
+	}
+	return false, errorutil.New(ErrUnknownFormat)
+}
+
+// NeedsRehash implementation of `Hasher`. Returns true if the given hash was not
+// created by the preferred hasher or uses outdated parameters.
+func (m *Multi) NeedsRehash(hash string) bool {
+	preferred := m.hashers[0]
+	return !preferred.Identify(hash) || preferred.NeedsRehash(hash)
+}
+
+// Identify implementation of `Hasher`. Returns true if any of the hashers
+// recognizes the format of the given hash.
+func (m *Multi) Identify(hash string) bool {
+	for _, h := range m.hashers {
+		if h.Identify(hash) {
+			return true
+		}
+	}
+	return false
+}
+
+var b64 = base64.RawStdEncoding
+
+func generateSalt(length uint32) ([]byte, error) {
+	salt := make([]byte, length)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, errorutil.New(err)
+	}
+	return salt, nil
+}
+
+func decodeSaltAndKey(encodedSalt, encodedKey string) ([]byte, []byte, error) {
+	salt, err := b64.DecodeString(encodedSalt)
+	if err != nil {
+		return nil, nil, errorutil.New(ErrInvalidHash)
+	}
+	key, err := b64.DecodeString(encodedKey)
+	if err != nil || len(key) == 0 {
+		return nil, nil, errorutil.New(ErrInvalidHash)
+	}
+	return salt, key, nil
+}
+
+func equal(a, b []byte) bool {
+	return subtle.ConstantTimeCompare(a, b) == 1
+}
@@ -0,0 +1,67 @@
+package hash
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// testHashers hashers with low parameters to keep the tests fast.
+func testHashers() (*Bcrypt, *Argon2id, *Scrypt) {
+	argon := NewArgon2id()
+	argon.Memory = 64
+	argon.Iterations = 1
+	s := NewScrypt()
+	s.LogN = 4
+	return &Bcrypt{Cost: bcrypt.MinCost}, argon, s
+}
+
+func TestMulti(t *testing.T) {
+	b, a, s := testHashers()
+	multi := NewMulti(a, b, s)
+
+	argonHash, err := multi.Hash("secret")
+	require.NoError(t, err)
+	assert.True(t, a.Identify(argonHash))
+	assert.False(t, multi.NeedsRehash(argonHash))
+
+	bcryptHash, err := b.Hash("secret")
+	require.NoError(t, err)
+	scryptHash, err := s.Hash("secret")
+	require.NoError(t, err)
+
+	for _, hash := range []string{argonHash, bcryptHash, scryptHash} {
+		assert.True(t, multi.Identify(hash))
+		ok, err := multi.Compare(hash, "secret")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = multi.Compare(hash, "wrong")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	}
+
+	// Hashes not created by the preferred hasher must be upgraded
+	assert.True(t, multi.NeedsRehash(bcryptHash))
+	assert.True(t, multi.NeedsRehash(scryptHash))
+
+	a.Iterations = 2
+	assert.True(t, multi.NeedsRehash(argonHash))
+
+	assert.False(t, multi.Identify("plain"))
+	ok, err := multi.Compare("plain", "plain")
+	assert.False(t, ok)
+	assert.True(t, errors.Is(err, ErrUnknownFormat))
+}
+
+func TestDefault(t *testing.T) {
+	multi := Default()
+	hash, err := multi.Hash("secret")
+	require.NoError(t, err)
+	assert.True(t, NewBcrypt().Identify(hash))
+	assert.False(t, multi.NeedsRehash(hash))
+	assert.True(t, multi.Identify("$argon2id$v=19$m=64,t=1,p=1$c2FsdA$a2V5"))
+	assert.True(t, multi.Identify("$scrypt$ln=4,r=8,p=1$c2FsdA$a2V5"))
+}
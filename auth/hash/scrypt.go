@@ -0,0 +1,115 @@
+package hash
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/scrypt"
+
+	errorutil "goyave.dev/goyave/v5/util/errors"
+)
+
+// Scrypt a `Hasher` using scrypt. Hashes are encoded in the following format,
+// where "ln" is the base 2 logarithm of the CPU/memory cost parameter N:
+//
+//	$scrypt$ln=15,r=8,p=1$<salt>$<key>
+type Scrypt struct {
+	// SaltLength the length of the random salt, in bytes.
+	SaltLength uint32
+
+	// KeyLength the length of the derived key, in bytes.
+	KeyLength uint32
+
+	// LogN the base 2 logarithm of the CPU/memory cost parameter N.
+	LogN uint8
+
+	// R the block size parameter.
+	R int
+
+	// P the parallelization parameter.
+	P int
+}
+
+// NewScrypt create a new `Scrypt` hasher using N=32768, r=8 and p=1.
+func NewScrypt() *Scrypt {
+	return &Scrypt{
+		LogN:       15,
+		R:          8,
+		P:          1,
+		SaltLength: 16,
+		KeyLength:  32,
+	}
+}
+
+type scryptParams struct {
+	salt []byte
+	key  []byte
+	logN uint8
+	r    int
+	p    int
+}
+
+// Hash implementation of `Hasher`.
+func (h *Scrypt) Hash(password string) (string, error) {
+	salt, err := generateSalt(h.SaltLength)
+	if err != nil {
+		return "", err
+	}
+	key, err := scrypt.Key([]byte(password), salt, 1<<h.LogN, h.R, h.P, int(h.KeyLength))
+	if err != nil {
+		return "", errorutil.New(err)
+	}
+	return fmt.Sprintf("$scrypt$ln=%d,r=%d,p=%d$%s$%s", h.LogN, h.R, h.P, b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+}
+
+// Compare implementation of `Hasher`.
+func (h *Scrypt) Compare(hash, password string) (bool, error) {
+	params, err := h.decode(hash)
+	if err != nil {
+		return false, err
+	}
+	key, err := scrypt.Key([]byte(password), params.salt, 1<<params.logN, params.r, params.p, len(params.key))
+	if err != nil {
+		return false, errorutil.New(err)
+	}
+	return equal(key, params.key), nil
+}
+
+// NeedsRehash implementation of `Hasher`. Returns true if the parameters of the given
+// hash are different from the parameters of the hasher.
+func (h *Scrypt) NeedsRehash(hash string) bool {
+	params, err := h.decode(hash)
+	if err != nil {
+		return true
+	}
+	return params.logN != h.LogN ||
+		params.r != h.R ||
+		params.p != h.P ||
+		uint32(len(params.salt)) != h.SaltLength ||
+		uint32(len(params.key)) != h.KeyLength
+}
+
+// Identify implementation of `Hasher`.
+func (h *Scrypt) Identify(hash string) bool {
+	return strings.HasPrefix(hash, "$scrypt$")
+}
+
+func (h *Scrypt) decode(hash string) (*scryptParams, error) {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 5 || parts[1] != "scrypt" {
+		return nil, errorutil.New(ErrUnknownFormat)
+	}
+	params := &scryptParams{}
+	if _, err := fmt.Sscanf(parts[2], "ln=%d,r=%d,p=%d", &params.logN, &params.r, &params.p); err != nil {
+		return nil, errorutil.New(ErrInvalidHash)
+	}
+	if params.logN < 1 || params.logN > 31 || params.r <= 0 || params.p <= 0 {
+		return nil, errorutil.New(ErrInvalidHash)
+	}
+	var err error
+	params.salt, params.key, err = decodeSaltAndKey(parts[3], parts[4])
+	if err != nil {
+		return nil, err
+	}
+	return params, nil
+}
@@ -0,0 +1,47 @@
+package hash
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScrypt(t *testing.T) {
+	_, _, h := testHashers()
+	hash, err := h.Hash("secret")
+	require.NoError(t, err)
+	assert.Regexp(t, `^\$scrypt\$ln=4,r=8,p=1\$[A-Za-z0-9+/]{22}\$[A-Za-z0-9+/]{43}$`, hash)
+	assert.True(t, h.Identify(hash))
+	assert.False(t, h.NeedsRehash(hash))
+
+	ok, err := h.Compare(hash, "secret")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = h.Compare(hash, "wrong")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	h2 := *h
+	h2.LogN = 5
+	ok, err = h2.Compare(hash, "secret")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.True(t, h2.NeedsRehash(hash))
+
+	cases := map[string]error{
+		"$scrypt$ln=4,r=8,p=1$c2FsdA":      ErrUnknownFormat,
+		"$scrypt$ln=x,r=8,p=1$c2FsdA$a2V5": ErrInvalidHash,
+		"$scrypt$ln=0,r=8,p=1$c2FsdA$a2V5": ErrInvalidHash,
+		"$scrypt$ln=4,r=0,p=1$c2FsdA$a2V5": ErrInvalidHash,
+		"$scrypt$ln=4,r=8,p=0$c2FsdA$a2V5": ErrInvalidHash,
+		"$scrypt$ln=4,r=8,p=1$!!$a2V5":     ErrInvalidHash,
+	}
+	for hash, expected := range cases {
+		ok, err := h.Compare(hash, "secret")
+		assert.False(t, ok, hash)
+		assert.True(t, errors.Is(err, expected), hash)
+		assert.True(t, h.NeedsRehash(hash), hash)
+	}
+}
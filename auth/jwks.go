@@ -0,0 +1,345 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	errorutil "goyave.dev/goyave/v5/util/errors"
+)
+
+// ErrKeyNotFound returned by `KeySet.LookupKey` when the set doesn't contain
+// any key matching the requested key ID.
+var ErrKeyNotFound = errors.New("auth: JWK not found")
+
+// KeySet a source of public keys used by the `JWTAuthenticator` to verify the tokens
+// having a "kid" (key ID) header.
+//
+// Implementations must be safe for concurrent use.
+type KeySet interface {
+	// LookupKey returns the key identified by the given key ID. Returns `ErrKeyNotFound`
+	// if the set doesn't contain such a key.
+	LookupKey(ctx context.Context, kid string) (*JWK, error)
+}
+
+// JWK a public JSON Web Key (RFC 7517). Only RSA ("RSA"), elliptic curve ("EC")
+// and Ed25519 ("OKP") keys are supported.
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Use string `json:"use,omitempty"`
+	Alg string `json:"alg,omitempty"`
+
+	// RSA
+	N string `json:"n,omitempty"`
+	E string `json:"e,omitempty"`
+
+	// EC and OKP
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// NewJWK create a new `JWK` from the given RSA, ECDSA or Ed25519 key. If a private key
+// is given, only its public part is used.
+func NewJWK(kid, alg string, key any) (*JWK, error) {
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return NewJWK(kid, alg, &k.PublicKey)
+	case *ecdsa.PrivateKey:
+		return NewJWK(kid, alg, &k.PublicKey)
+	case ed25519.PrivateKey:
+		return NewJWK(kid, alg, k.Public())
+	case *rsa.PublicKey:
+		return &JWK{
+			Kty: "RSA",
+			Kid: kid,
+			Use: "sig",
+			Alg: alg,
+			N:   base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+		}, nil
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		return &JWK{
+			Kty: "EC",
+			Kid: kid,
+			Use: "sig",
+			Alg: alg,
+			Crv: k.Curve.Params().Name,
+			X:   base64.RawURLEncoding.EncodeToString(k.X.FillBytes(make([]byte, size))),
+			Y:   base64.RawURLEncoding.EncodeToString(k.Y.FillBytes(make([]byte, size))),
+		}, nil
+	case ed25519.PublicKey:
+		return &JWK{
+			Kty: "OKP",
+			Kid: kid,
+			Use: "sig",
+			Alg: alg,
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(k),
+		}, nil
+	default:
+		return nil, errorutil.Errorf("auth: unsupported JWK key type %T", key)
+	}
+}
+
+// PublicKey returns the `*rsa.PublicKey`, `*ecdsa.PublicKey` or `ed25519.PublicKey`
+// represented by this JWK.
+func (k *JWK) PublicKey() (any, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeJWKInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeJWKInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() > 1<<31-1 {
+			return nil, errorutil.Errorf("auth: invalid JWK %q: invalid exponent", k.Kid)
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, errorutil.Errorf("auth: invalid JWK %q: unsupported curve %q", k.Kid, k.Crv)
+		}
+		x, err := decodeJWKInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeJWKInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		if !curve.IsOnCurve(x, y) {
+			return nil, errorutil.Errorf("auth: invalid JWK %q: point is not on curve", k.Kid)
+		}
+		return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return nil, errorutil.Errorf("auth: invalid JWK %q: unsupported curve %q", k.Kid, k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil || len(x) != ed25519.PublicKeySize {
+			return nil, errorutil.Errorf("auth: invalid JWK parameter %q", k.X)
+		}
+		return ed25519.PublicKey(x), nil
+	default:
+		return nil, errorutil.Errorf("auth: invalid JWK %q: unsupported key type %q", k.Kid, k.Kty)
+	}
+}
+
+func decodeJWKInt(value string) (*big.Int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(b) == 0 {
+		return nil, errorutil.Errorf("auth: invalid JWK parameter %q", value)
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// JWKSet a set of JSON Web Keys, as served by a "/.well-known/jwks.json" endpoint.
+// A `*JWKSet` is a static `KeySet`.
+type JWKSet struct {
+	Keys []*JWK `json:"keys"`
+}
+
+// LookupKey implementation of `KeySet`.
+func (s *JWKSet) LookupKey(_ context.Context, kid string) (*JWK, error) {
+	for _, k := range s.Keys {
+		if k.Kid == kid {
+			return k, nil
+		}
+	}
+	return nil, ErrKeyNotFound
+}
+
+// LoadJWKSet reads the JWK set from the JSON file at the given path.
+func LoadJWKSet(fsys fs.FS, path string) (*JWKSet, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, errorutil.New(err)
+	}
+	set := &JWKSet{}
+	if err := json.Unmarshal(data, set); err != nil {
+		return nil, errorutil.Errorf("auth: invalid JWK set %q: %w", path, err)
+	}
+	return set, nil
+}
+
+// RemoteJWKSet a `KeySet` fetching the keys from a remote JWKS endpoint, typically
+// the "/.well-known/jwks.json" endpoint of an identity provider.
+//
+// The fetched set is cached for `CacheDuration`. If a token references an unknown
+// key ID, the set is fetched again, at most once every `MinRefreshInterval`, so keys
+// rotated by the provider are picked up without waiting for the cache to expire.
+//
+// Concurrent lookups needing a fetch share a single request. The lookups are not blocked
+// by the request: they return as soon as their context is canceled.
+type RemoteJWKSet struct {
+	set         *JWKSet
+	fetching    *remoteCall[*JWKSet]
+	fetchedAt   time.Time
+	lastAttempt time.Time
+	now         func() time.Time
+	mu          sync.Mutex
+
+	// Client the HTTP client used to fetch the keys. Defaults to a client
+	// with a 10 seconds timeout.
+	Client *http.Client
+
+	// URL the address of the JWKS endpoint.
+	URL string
+
+	// CacheDuration the duration during which the fetched keys are used without
+	// fetching them again. Defaults to one hour.
+	CacheDuration time.Duration
+
+	// MinRefreshInterval the minimum duration between two fetches triggered by
+	// an unknown key ID. Defaults to one minute.
+	MinRefreshInterval time.Duration
+}
+
+// NewRemoteJWKSet create a new `RemoteJWKSet` fetching the keys from the given URL.
+func NewRemoteJWKSet(url string) *RemoteJWKSet {
+	return &RemoteJWKSet{
+		URL:                url,
+		CacheDuration:      time.Hour,
+		MinRefreshInterval: time.Minute,
+	}
+}
+
+// LookupKey implementation of `KeySet`.
+func (s *RemoteJWKSet) LookupKey(ctx context.Context, kid string) (*JWK, error) {
+	set, err := s.load(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := set.LookupKey(ctx, kid)
+	if errors.Is(err, ErrKeyNotFound) {
+		refreshed, err := s.load(ctx, true)
+		if err != nil {
+			return nil, err
+		}
+		if refreshed != set {
+			return refreshed.LookupKey(ctx, kid)
+		}
+	}
+	return key, err
+}
+
+// load returns the cached set, or fetches it if it expired. If `refresh` is true,
+// the set is fetched again unless the last attempt is more recent than `MinRefreshInterval`.
+func (s *RemoteJWKSet) load(ctx context.Context, refresh bool) (*JWKSet, error) {
+	s.mu.Lock()
+	now := time.Now()
+	if s.now != nil {
+		now = s.now()
+	}
+	expired := s.set == nil || now.Sub(s.fetchedAt) >= s.CacheDuration
+	if !expired && (!refresh || now.Sub(s.lastAttempt) < s.MinRefreshInterval) {
+		set := s.set
+		s.mu.Unlock()
+		return set, nil
+	}
+
+	call := s.fetching
+	if call == nil {
+		call = newRemoteCall[*JWKSet]()
+		s.fetching = call
+		s.lastAttempt = now
+		go s.fetch(context.WithoutCancel(ctx), call, now)
+	}
+	s.mu.Unlock()
+	return call.wait(ctx)
+}
+
+func (s *RemoteJWKSet) fetch(ctx context.Context, call *remoteCall[*JWKSet], now time.Time) {
+	set, err := s.fetchSet(ctx)
+	s.mu.Lock()
+	if err == nil {
+		s.set = set
+		s.fetchedAt = now
+	}
+	s.fetching = nil
+	s.mu.Unlock()
+	call.complete(set, err)
+}
+
+func (s *RemoteJWKSet) fetchSet(ctx context.Context) (*JWKSet, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return nil, errorutil.New(err)
+	}
+	req.Header.Set("Accept", "application/json")
+	client := s.Client
+	if client == nil {
+		client = defaultHTTPClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errorutil.New(err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errorutil.Errorf("auth: could not fetch JWK set from %q: unexpected status %d", s.URL, resp.StatusCode)
+	}
+	data, err := readRemoteDocument(resp, s.URL)
+	if err != nil {
+		return nil, err
+	}
+	set := &JWKSet{}
+	if err := json.Unmarshal(data, set); err != nil {
+		return nil, errorutil.Errorf("auth: invalid JWK set fetched from %q: %w", s.URL, err)
+	}
+	return set, nil
+}
+
+// checkKeyMethod returns an error if the given signing method cannot be used with the given key.
+func checkKeyMethod(method jwt.SigningMethod, key any) error {
+	switch key.(type) {
+	case *rsa.PublicKey:
+		switch method.(type) {
+		case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		if _, ok := method.(*jwt.SigningMethodECDSA); ok {
+			return nil
+		}
+	case ed25519.PublicKey:
+		if _, ok := method.(*jwt.SigningMethodEd25519); ok {
+			return nil
+		}
+	case []byte:
+		if _, ok := method.(*jwt.SigningMethodHMAC); ok {
+			return nil
+		}
+	}
+	return fmt.Errorf("Unexpected signing method: %v", method.Alg())
+}
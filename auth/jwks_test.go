@@ -0,0 +1,283 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5/util/fsutil/osfs"
+	"goyave.dev/goyave/v5/util/testutil"
+)
+
+func loadTestRSAKey(t *testing.T) *rsa.PrivateKey {
+	data, err := os.ReadFile(path.Join(testutil.FindRootDirectory(), "resources/rsa/private.pem"))
+	require.NoError(t, err)
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+	require.NoError(t, err)
+	return key
+}
+
+func generateTestECDSAKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return key
+}
+
+func TestJWK(t *testing.T) {
+	t.Run("rsa", func(t *testing.T) {
+		key := loadTestRSAKey(t)
+		jwk, err := NewJWK("kid", "RS256", key)
+		require.NoError(t, err)
+		assert.Equal(t, "RSA", jwk.Kty)
+		assert.Equal(t, "kid", jwk.Kid)
+		assert.Equal(t, "sig", jwk.Use)
+		assert.Equal(t, "RS256", jwk.Alg)
+		assert.Equal(t, "AQAB", jwk.E)
+
+		publicKey, err := jwk.PublicKey()
+		require.NoError(t, err)
+		assert.True(t, key.PublicKey.Equal(publicKey))
+	})
+
+	t.Run("ecdsa", func(t *testing.T) {
+		for _, curve := range []elliptic.Curve{elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+			key, err := ecdsa.GenerateKey(curve, rand.Reader)
+			require.NoError(t, err)
+			jwk, err := NewJWK("kid", "", &key.PublicKey)
+			require.NoError(t, err)
+			assert.Equal(t, "EC", jwk.Kty)
+			assert.Equal(t, curve.Params().Name, jwk.Crv)
+
+			publicKey, err := jwk.PublicKey()
+			require.NoError(t, err)
+			assert.True(t, key.PublicKey.Equal(publicKey))
+		}
+	})
+
+	t.Run("ed25519", func(t *testing.T) {
+		publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+		require.NoError(t, err)
+		jwk, err := NewJWK("kid", "EdDSA", privateKey)
+		require.NoError(t, err)
+		assert.Equal(t, "OKP", jwk.Kty)
+		assert.Equal(t, "Ed25519", jwk.Crv)
+
+		key, err := jwk.PublicKey()
+		require.NoError(t, err)
+		assert.True(t, publicKey.Equal(key))
+	})
+
+	t.Run("unsupported", func(t *testing.T) {
+		_, err := NewJWK("kid", "HS256", []byte("secret"))
+		require.Error(t, err)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		cases := []*JWK{
+			{Kty: "oct"},
+			{Kty: "RSA", N: "!", E: "AQAB"},
+			{Kty: "RSA", N: "AQAB", E: ""},
+			{Kty: "RSA", N: "AQAB", E: "AQAAAAAAAAAA"},
+			{Kty: "EC", Crv: "P-224"},
+			{Kty: "EC", Crv: "P-256", X: "!", Y: "AQAB"},
+			{Kty: "EC", Crv: "P-256", X: "AQAB", Y: "!"},
+			{Kty: "EC", Crv: "P-256", X: "AQAB", Y: "AQAB"},
+			{Kty: "OKP", Crv: "X25519", X: "AQAB"},
+			{Kty: "OKP", Crv: "Ed25519", X: "AQAB"},
+		}
+		for _, c := range cases {
+			_, err := c.PublicKey()
+			assert.Error(t, err, c)
+		}
+	})
+}
+
+func TestJWKSet(t *testing.T) {
+	key := generateTestECDSAKey(t)
+	jwk, err := NewJWK("kid", "ES256", key)
+	require.NoError(t, err)
+	set := &JWKSet{Keys: []*JWK{jwk}}
+
+	t.Run("lookup", func(t *testing.T) {
+		k, err := set.LookupKey(context.Background(), "kid")
+		require.NoError(t, err)
+		assert.Equal(t, jwk, k)
+
+		_, err = set.LookupKey(context.Background(), "unknown")
+		require.ErrorIs(t, err, ErrKeyNotFound)
+	})
+
+	t.Run("load", func(t *testing.T) {
+		dir := t.TempDir()
+		data, err := json.Marshal(set)
+		require.NoError(t, err)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "jwks.json"), data, 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "invalid.json"), []byte("{"), 0o644))
+
+		loaded, err := LoadJWKSet(&osfs.FS{}, filepath.Join(dir, "jwks.json"))
+		require.NoError(t, err)
+		assert.Equal(t, set, loaded)
+
+		_, err = LoadJWKSet(&osfs.FS{}, filepath.Join(dir, "invalid.json"))
+		require.Error(t, err)
+		_, err = LoadJWKSet(&osfs.FS{}, filepath.Join(dir, "notafile.json"))
+		require.Error(t, err)
+	})
+}
+
+func TestRemoteJWKSet(t *testing.T) {
+	prepareTest := func(t *testing.T) (*RemoteJWKSet, *atomic.Pointer[JWKSet], *atomic.Int32, *time.Time) {
+		current := &atomic.Pointer[JWKSet]{}
+		current.Store(&JWKSet{Keys: []*JWK{}})
+		fetches := &atomic.Int32{}
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fetches.Add(1)
+			set := current.Load()
+			if set == nil {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(set)
+		}))
+		t.Cleanup(srv.Close)
+
+		now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+		set := NewRemoteJWKSet(srv.URL)
+		set.Client = srv.Client()
+		set.now = func() time.Time { return now }
+		return set, current, fetches, &now
+	}
+
+	t.Run("cache", func(t *testing.T) {
+		set, current, fetches, now := prepareTest(t)
+		jwk, err := NewJWK("kid1", "ES256", generateTestECDSAKey(t))
+		require.NoError(t, err)
+		current.Store(&JWKSet{Keys: []*JWK{jwk}})
+
+		k, err := set.LookupKey(context.Background(), "kid1")
+		require.NoError(t, err)
+		assert.Equal(t, jwk, k)
+		k, err = set.LookupKey(context.Background(), "kid1")
+		require.NoError(t, err)
+		assert.Equal(t, jwk, k)
+		assert.Equal(t, int32(1), fetches.Load())
+
+		*now = now.Add(time.Hour)
+		_, err = set.LookupKey(context.Background(), "kid1")
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), fetches.Load())
+	})
+
+	t.Run("unknown_kid_refresh", func(t *testing.T) {
+		set, current, fetches, now := prepareTest(t)
+		jwk1, err := NewJWK("kid1", "ES256", generateTestECDSAKey(t))
+		require.NoError(t, err)
+		jwk2, err := NewJWK("kid2", "ES256", generateTestECDSAKey(t))
+		require.NoError(t, err)
+		current.Store(&JWKSet{Keys: []*JWK{jwk1}})
+
+		_, err = set.LookupKey(context.Background(), "kid2")
+		require.ErrorIs(t, err, ErrKeyNotFound)
+		assert.Equal(t, int32(1), fetches.Load())
+
+		// The provider rotates its keys
+		current.Store(&JWKSet{Keys: []*JWK{jwk1, jwk2}})
+		_, err = set.LookupKey(context.Background(), "kid2")
+		require.ErrorIs(t, err, ErrKeyNotFound)
+		assert.Equal(t, int32(1), fetches.Load(), "refresh should be rate limited")
+
+		*now = now.Add(time.Minute)
+		k, err := set.LookupKey(context.Background(), "kid2")
+		require.NoError(t, err)
+		assert.Equal(t, jwk2, k)
+		assert.Equal(t, int32(2), fetches.Load())
+	})
+
+	t.Run("error", func(t *testing.T) {
+		set, current, _, _ := prepareTest(t)
+		current.Store(nil)
+		_, err := set.LookupKey(context.Background(), "kid")
+		require.Error(t, err)
+		assert.NotErrorIs(t, err, ErrKeyNotFound)
+
+		set.URL = "http://" + string([]byte{0x7f})
+		_, err = set.LookupKey(context.Background(), "kid")
+		require.Error(t, err)
+	})
+
+	t.Run("invalid_body", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte("{"))
+		}))
+		t.Cleanup(srv.Close)
+		set := NewRemoteJWKSet(srv.URL)
+		_, err := set.LookupKey(context.Background(), "kid")
+		require.Error(t, err)
+	})
+
+	t.Run("body_too_large", func(t *testing.T) {
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write([]byte(`{"keys":[],"padding":"`))
+			_, _ = w.Write(bytes.Repeat([]byte("a"), MaxRemoteDocumentSize))
+			_, _ = w.Write([]byte(`"}`))
+		}))
+		t.Cleanup(srv.Close)
+		set := NewRemoteJWKSet(srv.URL)
+		_, err := set.LookupKey(context.Background(), "kid")
+		require.ErrorContains(t, err, "exceeds")
+	})
+
+	t.Run("single_flight", func(t *testing.T) {
+		release := make(chan struct{})
+		fetches := &atomic.Int32{}
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			fetches.Add(1)
+			<-release
+			_ = json.NewEncoder(w).Encode(&JWKSet{Keys: []*JWK{}})
+		}))
+		t.Cleanup(srv.Close)
+		set := NewRemoteJWKSet(srv.URL)
+		set.Client = srv.Client()
+
+		// A caller giving up doesn't wait for the request to complete.
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := set.LookupKey(ctx, "kid")
+		require.ErrorIs(t, err, context.Canceled)
+
+		wg := sync.WaitGroup{}
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := set.LookupKey(context.Background(), "kid")
+				assert.ErrorIs(t, err, ErrKeyNotFound)
+			}()
+		}
+		close(release)
+		wg.Wait()
+		assert.Equal(t, int32(1), fetches.Load())
+	})
+
+	t.Run("default_client", func(t *testing.T) {
+		assert.Equal(t, 10*time.Second, defaultHTTPClient.Timeout)
+	})
+}
@@ -1,14 +1,20 @@
 package auth
 
 import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
 	"errors"
 	"fmt"
 	"io/fs"
+	"net/http"
 	"reflect"
+	"slices"
 	"sync"
 	"time"
 
-	"github.com/golang-jwt/jwt"
+	"github.com/golang-jwt/jwt/v5"
 	"gorm.io/gorm"
 	"goyave.dev/goyave/v5"
 	"goyave.dev/goyave/v5/config"
@@ -34,11 +40,37 @@ func init() {
 		IsSlice:          false,
 		AuthorizedValues: []any{},
 	})
+	config.Register("auth.jwt.refreshExpiry", config.Entry{
+		Value:            604800,
+		Type:             reflect.Int,
+		IsSlice:          false,
+		AuthorizedValues: []any{},
+	})
+	config.Register("auth.jwt.leeway", config.Entry{
+		Value:            0,
+		Type:             reflect.Int,
+		IsSlice:          false,
+		AuthorizedValues: []any{},
+	})
+	config.Register("auth.jwt.issuer", config.Entry{
+		Value:            "",
+		Type:             reflect.String,
+		IsSlice:          false,
+		AuthorizedValues: []any{},
+	})
+	config.Register("auth.jwt.audience", config.Entry{
+		Value:            "",
+		Type:             reflect.String,
+		IsSlice:          false,
+		AuthorizedValues: []any{},
+	})
 	registerKeyConfigEntry("auth.jwt.secret")
 	registerKeyConfigEntry("auth.jwt.rsa.public")
 	registerKeyConfigEntry("auth.jwt.rsa.private")
 	registerKeyConfigEntry("auth.jwt.ecdsa.public")
 	registerKeyConfigEntry("auth.jwt.ecdsa.private")
+	registerKeyConfigEntry("auth.jwt.eddsa.public")
+	registerKeyConfigEntry("auth.jwt.eddsa.private")
 }
 
 func registerKeyConfigEntry(name string) {
@@ -50,13 +82,48 @@ func registerKeyConfigEntry(name string) {
 	})
 }
 
+// SigningKey a signature key identified by a key ID. Tokens signed with a `SigningKey`
+// have a "kid" header containing the key ID, used to find the key verifying them.
+type SigningKey struct {
+	// Method the signing method used with this key.
+	Method jwt.SigningMethod
+
+	// Key the private key (`*rsa.PrivateKey`, `*ecdsa.PrivateKey` or
+	// `ed25519.PrivateKey`) or the HMAC secret (`[]byte`).
+	Key any
+
+	// ID the key ID, unique among the keys of the `JWTService`.
+	ID string
+}
+
+// publicKey returns the key used to verify the signatures made with this key.
+func (k *SigningKey) publicKey() any {
+	switch key := k.Key.(type) {
+	case *rsa.PrivateKey:
+		return &key.PublicKey
+	case *ecdsa.PrivateKey:
+		return &key.PublicKey
+	case ed25519.PrivateKey:
+		return key.Public()
+	default:
+		return k.Key
+	}
+}
+
 // JWTService providing signature keys cache and JWT generation.
 //
+// Besides the keys defined in the configuration, the service holds a ring of
+// `SigningKey` identified by a key ID, allowing key rotation: new tokens are signed
+// with the most recently added key matching the signing method, while the tokens
+// signed with the older keys can still be verified until these keys are removed.
+//
 // This service is identified by `auth.JWTServiceName`.
 type JWTService struct {
-	fs     fs.FS
-	config *config.Config
-	cache  sync.Map
+	fs          fs.FS
+	config      *config.Config
+	cache       sync.Map
+	signingKeys []*SigningKey
+	mu          sync.RWMutex
 }
 
 // NewJWTService create a new `JWTService` with the given config and file system.
@@ -94,13 +161,19 @@ func (s *JWTService) GenerateToken(username any) (string, error) {
 // will be used:
 //   - RSA: `auth.jwt.rsa.private`: path to the private PEM-encoded RSA key.
 //   - ECDSA: `auth.jwt.ecdsa.private`: path to the private PEM-encoded ECDSA key.
+//   - EdDSA: `auth.jwt.eddsa.private`: path to the private PEM-encoded Ed25519 key.
 //   - HMAC: `auth.jwt.secret`: HMAC secret
 //
+// If the service has a signing key for the given signing method (see `AddSigningKey`),
+// the most recent one is used instead and its ID is added to the "kid" header.
+//
 // The generated token will also contain the following claims:
 //   - `nbf`: "Not before", the current timestamp is used
 //   - `exp`: "Expiry", the current timestamp plus the `auth.jwt.expiry` config entry.
+//   - `iss`: "Issuer", the `auth.jwt.issuer` config entry, if set.
+//   - `aud`: "Audience", the `auth.jwt.audience` config entry, if set.
 //
-// `nbf` and `exp` can be overridden if they are set in the `claims` parameter.
+// These claims can be overridden if they are set in the `claims` parameter.
 func (s *JWTService) GenerateTokenWithClaims(claims jwt.MapClaims, signingMethod jwt.SigningMethod) (string, error) {
 	exp := time.Duration(s.config.GetInt("auth.jwt.expiry")) * time.Second
 	now := time.Now()
@@ -108,19 +181,122 @@ func (s *JWTService) GenerateTokenWithClaims(claims jwt.MapClaims, signingMethod
 		"nbf": now.Unix(),          // Not Before
 		"exp": now.Add(exp).Unix(), // Expiry
 	}
+	if issuer := s.config.GetString("auth.jwt.issuer"); issuer != "" {
+		customClaims["iss"] = issuer
+	}
+	if audience := s.config.GetString("auth.jwt.audience"); audience != "" {
+		customClaims["aud"] = audience
+	}
 	for k, c := range claims {
 		customClaims[k] = c
 	}
 	token := jwt.NewWithClaims(signingMethod, customClaims)
 
-	key, err := s.GetPrivateKey(signingMethod)
-	if err != nil {
-		return "", err
+	var key any
+	if signingKey := s.currentSigningKey(signingMethod); signingKey != nil {
+		token.Header["kid"] = signingKey.ID
+		key = signingKey.Key
+	} else {
+		var err error
+		key, err = s.GetPrivateKey(signingMethod)
+		if err != nil {
+			return "", err
+		}
 	}
 	result, err := token.SignedString(key)
 	return result, errorutil.New(err)
 }
 
+// AddSigningKey adds a key to the ring of signing keys. The new key becomes the key
+// used to sign the new tokens generated with the same signing method. The keys
+// previously added are kept to verify the tokens they signed.
+func (s *JWTService) AddSigningKey(key *SigningKey) error {
+	if key.ID == "" {
+		return errorutil.New("auth: signing key ID cannot be empty")
+	}
+	if key.Method == nil {
+		return errorutil.Errorf("auth: signing key %q has no signing method", key.ID)
+	}
+	if err := checkKeyMethod(key.Method, key.publicKey()); err != nil {
+		return errorutil.Errorf("auth: signing key %q cannot be used with %s", key.ID, key.Method.Alg())
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range s.signingKeys {
+		if k.ID == key.ID {
+			return errorutil.Errorf("auth: duplicate signing key ID %q", key.ID)
+		}
+	}
+	s.signingKeys = append(s.signingKeys, key)
+	return nil
+}
+
+// RemoveSigningKey removes the signing key identified by the given key ID. The tokens
+// signed with this key cannot be verified anymore.
+func (s *JWTService) RemoveSigningKey(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.signingKeys = slices.DeleteFunc(s.signingKeys, func(k *SigningKey) bool {
+		return k.ID == id
+	})
+}
+
+// LookupSigningKey returns the signing key identified by the given key ID.
+func (s *JWTService) LookupSigningKey(id string) (*SigningKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, k := range s.signingKeys {
+		if k.ID == id {
+			return k, true
+		}
+	}
+	return nil, false
+}
+
+func (s *JWTService) currentSigningKey(signingMethod jwt.SigningMethod) *SigningKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for i := len(s.signingKeys) - 1; i >= 0; i-- {
+		if s.signingKeys[i].Method.Alg() == signingMethod.Alg() {
+			return s.signingKeys[i]
+		}
+	}
+	return nil
+}
+
+// JWKSet returns the JWK set containing the public part of the asymmetric (RSA, ECDSA
+// and EdDSA) signing keys of the ring. HMAC keys are secret and are not included.
+func (s *JWTService) JWKSet() (*JWKSet, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	set := &JWKSet{Keys: make([]*JWK, 0, len(s.signingKeys))}
+	for _, k := range s.signingKeys {
+		if _, ok := k.Method.(*jwt.SigningMethodHMAC); ok {
+			continue
+		}
+		jwk, err := NewJWK(k.ID, k.Method.Alg(), k.publicKey())
+		if err != nil {
+			return nil, err
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set, nil
+}
+
+// ServeJWKS handler responding with the JWK set of the service, for example:
+//
+//	router.Get("/.well-known/jwks.json", jwtService.ServeJWKS)
+func (s *JWTService) ServeJWKS(response *goyave.Response, _ *goyave.Request) {
+	set, err := s.JWKSet()
+	if err != nil {
+		response.Error(err)
+		return
+	}
+	response.Header().Set("Cache-Control", "public, max-age=300")
+	response.JSON(http.StatusOK, set)
+}
+
 // GetKey load a JWT signature key from the config.
 // List of `entry` parameter possible values:
 //
@@ -128,6 +304,8 @@ func (s *JWTService) GenerateTokenWithClaims(claims jwt.MapClaims, signingMethod
 //   - `auth.jwt.rsa.private`
 //   - `auth.jwt.ecdsa.public`
 //   - `auth.jwt.ecdsa.private`
+//   - `auth.jwt.eddsa.public`
+//   - `auth.jwt.eddsa.private`
 //   - `auth.jwt.secret`
 //
 // To optimize subsequent requests and avoid IO for keys that are stored on the
@@ -152,6 +330,10 @@ func (s *JWTService) GetKey(entry string) (any, error) {
 		key, err = jwt.ParseECPrivateKeyFromPEM(data)
 	case "auth.jwt.ecdsa.public":
 		key, err = jwt.ParseECPublicKeyFromPEM(data)
+	case "auth.jwt.eddsa.private":
+		key, err = jwt.ParseEdPrivateKeyFromPEM(data)
+	case "auth.jwt.eddsa.public":
+		key, err = jwt.ParseEdPublicKeyFromPEM(data)
 	}
 
 	if err == nil {
@@ -167,6 +349,8 @@ func (s *JWTService) GetPrivateKey(signingMethod jwt.SigningMethod) (any, error)
 		return s.GetKey("auth.jwt.rsa.private")
 	case *jwt.SigningMethodECDSA:
 		return s.GetKey("auth.jwt.ecdsa.private")
+	case *jwt.SigningMethodEd25519:
+		return s.GetKey("auth.jwt.eddsa.private")
 	case *jwt.SigningMethodHMAC:
 		return []byte(s.config.GetString("auth.jwt.secret")), nil
 	default:
@@ -185,13 +369,24 @@ type JWTAuthenticator[T any] struct {
 	UserService UserService[T]
 
 	// SigningMethod expected by this authenticator when parsing JWT.
-	// Defaults to HMAC.
+	// Defaults to HMAC. Ignored for tokens having a "kid" header: the signing
+	// method must then match the key identified by this header.
 	SigningMethod jwt.SigningMethod
 
+	// KeySet if not `nil`, the tokens having a "kid" header are verified using the key
+	// of this set identified by the header. Otherwise, the key is taken from the
+	// signing keys of the `JWTService`.
+	KeySet KeySet
+
 	// ClaimName the name of the claim used to retrieve the user.
 	// Defaults to "sub".
 	ClaimName string
 
+	// TokenStore if not `nil`, the "jti" claim of the tokens is checked against
+	// the denylist of this store and revoked tokens are rejected. Tokens without
+	// a "jti" claim cannot be revoked and are accepted.
+	TokenStore TokenStore
+
 	// Optional defines if the authenticator allows requests that
 	// don't provide credentials. Handlers should therefore check
 	// if `request.User` is not `nil` before accessing it.
@@ -220,6 +415,12 @@ func (a *JWTAuthenticator[T]) Init(server *goyave.Server) {
 	a.service = service.(*JWTService)
 }
 
+// HasCredentials returns true if the request has a bearer token.
+func (a *JWTAuthenticator[T]) HasCredentials(request *goyave.Request) bool {
+	token, ok := request.BearerToken()
+	return ok && token != ""
+}
+
 // Authenticate fetch the user corresponding to the token
 // found in the given request and returns it.
 // If no user can be authenticated, returns an error.
@@ -234,10 +435,24 @@ func (a *JWTAuthenticator[T]) Authenticate(request *goyave.Request) (*T, error)
 		return nil, fmt.Errorf("%s", request.Lang.Get("auth.no-credentials-provided"))
 	}
 
-	token, err := jwt.Parse(tokenString, a.keyFunc)
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (any, error) {
+		return a.keyFunc(request.Context(), token)
+	}, a.parserOptions()...)
 
 	if err == nil && token.Valid {
 		if claims, ok := token.Claims.(jwt.MapClaims); ok {
+			if a.TokenStore != nil {
+				if jti, ok := claims["jti"].(string); ok {
+					revoked, err := a.TokenStore.IsRevoked(request.Context(), jti)
+					if err != nil {
+						panic(errorutil.New(err))
+					}
+					if revoked {
+						return nil, fmt.Errorf("%s", request.Lang.Get("auth.jwt-revoked"))
+					}
+				}
+			}
+
 			request.Extra[ExtraJWTClaims{}] = claims
 
 			claimName := a.ClaimName
@@ -256,10 +471,30 @@ func (a *JWTAuthenticator[T]) Authenticate(request *goyave.Request) (*T, error)
 		}
 	}
 
-	return nil, a.makeError(request.Lang, err.(*jwt.ValidationError).Errors)
+	return nil, makeJWTError(request.Lang, err)
+}
+
+// parserOptions returns the validation options of the parser depending on the
+// `auth.jwt.leeway`, `auth.jwt.issuer` and `auth.jwt.audience` config entries.
+func (a *JWTAuthenticator[T]) parserOptions() []jwt.ParserOption {
+	cfg := a.Config()
+	options := []jwt.ParserOption{
+		jwt.WithLeeway(time.Duration(cfg.GetInt("auth.jwt.leeway")) * time.Second),
+	}
+	if issuer := cfg.GetString("auth.jwt.issuer"); issuer != "" {
+		options = append(options, jwt.WithIssuer(issuer))
+	}
+	if audience := cfg.GetString("auth.jwt.audience"); audience != "" {
+		options = append(options, jwt.WithAudience(audience))
+	}
+	return options
 }
 
-func (a *JWTAuthenticator[T]) keyFunc(token *jwt.Token) (any, error) {
+func (a *JWTAuthenticator[T]) keyFunc(ctx context.Context, token *jwt.Token) (any, error) {
+	if kid, ok := token.Header["kid"].(string); ok {
+		return a.lookupKey(ctx, token, kid)
+	}
+
 	switch a.SigningMethod.(type) {
 	case *jwt.SigningMethodRSA:
 		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
@@ -279,6 +514,15 @@ func (a *JWTAuthenticator[T]) keyFunc(token *jwt.Token) (any, error) {
 			panic(err)
 		}
 		return key, nil
+	case *jwt.SigningMethodEd25519:
+		if _, ok := token.Method.(*jwt.SigningMethodEd25519); !ok {
+			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+		}
+		key, err := a.service.GetKey("auth.jwt.eddsa.public")
+		if err != nil {
+			panic(err)
+		}
+		return key, nil
 	case *jwt.SigningMethodHMAC, nil:
 		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
 			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
@@ -289,10 +533,44 @@ func (a *JWTAuthenticator[T]) keyFunc(token *jwt.Token) (any, error) {
 	}
 }
 
-func (a *JWTAuthenticator[T]) makeError(language *lang.Language, bitfield uint32) error {
-	if bitfield&jwt.ValidationErrorNotValidYet != 0 {
+func (a *JWTAuthenticator[T]) lookupKey(ctx context.Context, token *jwt.Token, kid string) (any, error) {
+	if a.KeySet == nil {
+		signingKey, ok := a.service.LookupSigningKey(kid)
+		if !ok {
+			return nil, fmt.Errorf("Unknown key ID: %q", kid)
+		}
+		if signingKey.Method.Alg() != token.Method.Alg() {
+			return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+		}
+		return signingKey.publicKey(), nil
+	}
+
+	jwk, err := a.KeySet.LookupKey(ctx, kid)
+	if err != nil {
+		if errors.Is(err, ErrKeyNotFound) {
+			return nil, fmt.Errorf("Unknown key ID: %q", kid)
+		}
+		panic(errorutil.New(err))
+	}
+	if jwk.Alg != "" && jwk.Alg != token.Method.Alg() {
+		return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+	}
+	key, err := jwk.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkKeyMethod(token.Method, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// makeJWTError returns the localized error corresponding to the given JWT parsing error.
+func makeJWTError(language *lang.Language, err error) error {
+	switch {
+	case errors.Is(err, jwt.ErrTokenNotValidYet):
 		return fmt.Errorf("%s", language.Get("auth.jwt-not-valid-yet"))
-	} else if bitfield&jwt.ValidationErrorExpired != 0 {
+	case errors.Is(err, jwt.ErrTokenExpired):
 		return fmt.Errorf("%s", language.Get("auth.jwt-expired"))
 	}
 	return fmt.Errorf("%s", language.Get("auth.jwt-invalid"))
@@ -3,13 +3,13 @@ package auth
 import (
 	"errors"
 	"net/http"
-	"reflect"
+	"time"
 
-	"github.com/golang-jwt/jwt"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/samber/lo"
-	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/auth/hash"
 	"goyave.dev/goyave/v5/middleware/parse"
 	errorutil "goyave.dev/goyave/v5/util/errors"
 	"goyave.dev/goyave/v5/util/fsutil/osfs"
@@ -17,14 +17,20 @@ import (
 )
 
 // TokenFunc is the function used by JWTController to generate tokens
-// during login process.
+// during login process. When a refresh token is exchanged, the request body contains
+// the refresh token instead of the user's credentials.
 type TokenFunc[T any] func(request *goyave.Request, user *T) (string, error)
 
 // JWTController controller adding a login route returning a JWT for quick prototyping.
 //
+// If the controller has a `TokenStore`, the login route also returns a refresh token, and the
+// "/refresh" and "/logout" routes are registered. Refresh tokens are opaque, single-use and
+// rotated: exchanging one returns a new access token and a new refresh token. If a refresh token
+// is used twice, it is considered stolen and all the tokens obtained from the same login are revoked.
+//
 // The T parameter represents the user DTO and should not be a pointer. The DTO used should be
 // different from the DTO returned to clients as a response because it needs to contain the user's password.
-type JWTController[T any] struct { // TODO refresh token
+type JWTController[T any] struct {
 	goyave.Component
 
 	jwtService *JWTService
@@ -37,9 +43,14 @@ type JWTController[T any] struct { // TODO refresh token
 
 	// The function generating the token on a successful authentication.
 	// Defaults to a JWT signed with HS256 and containing the username as the
-	// "sub" claim.
+	// "sub" claim and a random identifier as the "jti" claim.
 	TokenFunc TokenFunc[T]
 
+	// TokenStore if not `nil`, enables refresh tokens and revocation. The refresh tokens
+	// expire after the amount of seconds defined by the `auth.jwt.refreshExpiry` config entry.
+	// Use the same store in the `JWTAuthenticator` so revoked access tokens are rejected.
+	TokenStore TokenStore
+
 	// UsernameRequestField the name of the request's body field
 	// used as username in the authentication process.
 	// Defaults to "username"
@@ -48,9 +59,26 @@ type JWTController[T any] struct { // TODO refresh token
 	// used as password in the authentication process.
 	// Defaults to "password"
 	PasswordRequestField string
+	// RefreshTokenRequestField the name of the request's body field
+	// containing the refresh token in the refresh and logout routes.
+	// Defaults to "refresh_token"
+	RefreshTokenRequestField string
 	// PasswordField the name of T's struct field that holds the user's hashed password.
 	// It will be used to compare the password hash with the user input.
 	PasswordField string
+
+	// Hasher used to verify the passwords. Defaults to `hash.Default()`,
+	// which uses bcrypt and recognizes argon2id and scrypt hashes.
+	Hasher hash.Hasher
+
+	// OnRehash if not `nil`, called on successful login if the stored
+	// password hash needs to be upgraded to the preferred algorithm or parameters
+	// of the `Hasher`.
+	OnRehash RehashFunc[T]
+
+	// Throttler if not `nil`, limits the failed login attempts per username and
+	// client IP. Throttled requests are rejected with "429 Too Many Requests".
+	Throttler *LoginThrottler
 }
 
 // NewJWTController create a new JWTController that registers a login route returning a JWT for quick prototyping.
@@ -78,8 +106,15 @@ func (c *JWTController[T]) Init(server *goyave.Server) {
 }
 
 // RegisterRoutes register the "/login" route (with validation) on the given router.
+// If the controller has a `TokenStore`, the "/refresh" and "/logout" routes are registered too.
+// The "/logout" route has the `MetaAuth` meta so the access token can be revoked. Use an
+// optional `JWTAuthenticator` to let clients log out without sending their access token.
 func (c *JWTController[T]) RegisterRoutes(router *goyave.Router) {
 	router.Post("/login", c.Login).Middleware(&parse.Middleware{}).ValidateBody(c.validationRules)
+	if c.TokenStore != nil {
+		router.Post("/refresh", c.Refresh).Middleware(&parse.Middleware{}).ValidateBody(c.refreshValidationRules)
+		router.Post("/logout", c.Logout).SetMeta(MetaAuth, true).Middleware(&parse.Middleware{}).ValidateBody(c.refreshValidationRules)
+	}
 }
 
 func (c *JWTController[T]) validationRules(_ *goyave.Request) validation.RuleSet {
@@ -99,15 +134,41 @@ func (c *JWTController[T]) validationRules(_ *goyave.Request) validation.RuleSet
 	}
 }
 
+func (c *JWTController[T]) refreshValidationRules(_ *goyave.Request) validation.RuleSet {
+	return validation.RuleSet{
+		{Path: validation.CurrentElement, Rules: validation.List{
+			validation.Required(),
+			validation.Object(),
+		}},
+		{Path: c.refreshTokenField(), Rules: validation.List{
+			validation.Required(),
+			validation.String(),
+		}},
+	}
+}
+
 // Login POST handler for token-based authentication.
 // Creates a new token for the user authenticated with the body fields
 // defined in the controller and returns it as a response.
-// The password is checked using bcrypt.
+// The password is checked using the `Hasher`, even if the user doesn't exist so the
+// response time doesn't reveal it. If the controller has a `Throttler`, failed attempts
+// are counted and throttled requests are rejected with "429 Too Many Requests".
 func (c *JWTController[T]) Login(response *goyave.Response, request *goyave.Request) {
 	body := request.Data.(map[string]any)
 	username := body[lo.Ternary(c.UsernameRequestField == "", "username", c.UsernameRequestField)].(string)
 	password := body[lo.Ternary(c.PasswordRequestField == "", "password", c.PasswordRequestField)].(string)
 
+	guard := newLoginGuard(c.Throttler, &c.Component, request, username)
+	throttled, err := guard.reserve()
+	if err != nil {
+		response.Error(err)
+		return
+	}
+	if throttled != nil {
+		throttled.respond(response)
+		return
+	}
+
 	user, err := c.UserService.FindByUsername(request.Context(), username)
 
 	notFound := errors.Is(err, gorm.ErrRecordNotFound)
@@ -116,41 +177,170 @@ func (c *JWTController[T]) Login(response *goyave.Response, request *goyave.Requ
 		return
 	}
 
+	verifier := passwordVerifier[T]{hasher: c.Hasher, onRehash: c.OnRehash, field: c.PasswordField}
+	ok := false
 	if notFound {
+		verifier.verifyDummy(password)
+	} else {
+		ok, err = verifier.verify(&c.Component, request, user, password)
+		if err != nil {
+			response.Error(err)
+			return
+		}
+	}
+
+	if !ok {
+		guard.fail()
 		response.JSON(http.StatusUnauthorized, map[string]string{"error": request.Lang.Get("auth.invalid-credentials")})
 		return
 	}
 
-	t := reflect.Indirect(reflect.ValueOf(user))
-	for t.Kind() == reflect.Ptr {
-		t = t.Elem()
-	}
-	pass := t.FieldByName(c.PasswordField)
-	if pass.Kind() == reflect.Invalid {
-		response.Error(errorutil.Errorf("Could not find valid field/column %q in type %T", c.PasswordField, user))
+	if err := guard.succeed(); err != nil {
+		response.Error(err)
 		return
 	}
 
-	if bcrypt.CompareHashAndPassword([]byte(pass.String()), []byte(password)) == nil {
-		tokenFunc := lo.Ternary(c.TokenFunc == nil, c.defaultTokenFunc, c.TokenFunc)
-		token, err := tokenFunc(request, user)
+	family := ""
+	if c.TokenStore != nil {
+		family, err = generateTokenID()
 		if err != nil {
-			response.Error(errorutil.New(err))
+			response.Error(err)
+			return
+		}
+	}
+	c.respondWithTokens(response, request, user, username, family)
+}
+
+// Refresh POST handler exchanging a refresh token for a new access token and a new
+// refresh token. The given refresh token cannot be used anymore afterwards.
+//
+// If the refresh token was already used, all the refresh tokens of its family
+// are revoked and the request is rejected.
+func (c *JWTController[T]) Refresh(response *goyave.Response, request *goyave.Request) {
+	body := request.Data.(map[string]any)
+	refreshToken := body[c.refreshTokenField()].(string)
+
+	token, err := c.TokenStore.UseRefreshToken(request.Context(), HashRefreshToken(refreshToken))
+	if err != nil {
+		response.Error(err)
+		return
+	}
+	if token == nil {
+		response.JSON(http.StatusUnauthorized, map[string]string{"error": request.Lang.Get("auth.invalid-refresh-token")})
+		return
+	}
+	if token.Used {
+		c.Logger().WarnContext(request.Context(), "refresh token reuse detected, revoking token family", "subject", token.Subject)
+		if err := c.TokenStore.RevokeFamily(request.Context(), token.Family); err != nil {
+			response.Error(err)
+			return
+		}
+		response.JSON(http.StatusUnauthorized, map[string]string{"error": request.Lang.Get("auth.invalid-refresh-token")})
+		return
+	}
+
+	user, err := c.UserService.FindByUsername(request.Context(), token.Subject)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.JSON(http.StatusUnauthorized, map[string]string{"error": request.Lang.Get("auth.invalid-refresh-token")})
+			return
+		}
+		response.Error(errorutil.New(err))
+		return
+	}
+
+	c.respondWithTokens(response, request, user, token.Subject, token.Family)
+}
+
+// Logout POST handler revoking the given refresh token and all the refresh tokens
+// obtained from the same login.
+//
+// If the request was authenticated by a `JWTAuthenticator`, the access token's "jti"
+// claim is also added to the denylist of the `TokenStore` until the token expires. To
+// enable this, the route must require authentication (see `MetaAuth`), which is the case
+// of the route registered by `RegisterRoutes`.
+func (c *JWTController[T]) Logout(response *goyave.Response, request *goyave.Request) {
+	body := request.Data.(map[string]any)
+	refreshToken := body[c.refreshTokenField()].(string)
+
+	token, err := c.TokenStore.UseRefreshToken(request.Context(), HashRefreshToken(refreshToken))
+	if err != nil {
+		response.Error(err)
+		return
+	}
+	if token != nil {
+		if err := c.TokenStore.RevokeFamily(request.Context(), token.Family); err != nil {
+			response.Error(err)
 			return
 		}
+	}
+
+	if claims, ok := request.Extra[ExtraJWTClaims{}].(jwt.MapClaims); ok {
+		if jti, ok := claims["jti"].(string); ok {
+			expiresAt := time.Now().Add(time.Duration(c.Config().GetInt("auth.jwt.expiry")) * time.Second)
+			if exp, ok := claims["exp"].(float64); ok {
+				expiresAt = time.Unix(int64(exp), 0)
+			}
+			if err := c.TokenStore.Revoke(request.Context(), jti, expiresAt); err != nil {
+				response.Error(err)
+				return
+			}
+		}
+	}
+
+	response.Status(http.StatusNoContent)
+}
+
+// respondWithTokens generates an access token for the given user and writes it to the response.
+// If the controller has a `TokenStore`, a new refresh token of the given family is also issued.
+func (c *JWTController[T]) respondWithTokens(response *goyave.Response, request *goyave.Request, user *T, subject, family string) {
+	var token string
+	var err error
+	if c.TokenFunc != nil {
+		token, err = c.TokenFunc(request, user)
+	} else {
+		token, err = c.defaultToken(subject)
+	}
+	if err != nil {
+		response.Error(errorutil.New(err))
+		return
+	}
+
+	if c.TokenStore == nil {
 		response.JSON(http.StatusOK, map[string]string{"token": token})
 		return
 	}
 
-	response.JSON(http.StatusUnauthorized, map[string]string{"error": request.Lang.Get("auth.invalid-credentials")})
+	refreshToken, err := generateTokenID()
+	if err != nil {
+		response.Error(err)
+		return
+	}
+	err = c.TokenStore.SaveRefreshToken(request.Context(), &RefreshToken{
+		ExpiresAt: time.Now().Add(time.Duration(c.Config().GetInt("auth.jwt.refreshExpiry")) * time.Second),
+		ID:        HashRefreshToken(refreshToken),
+		Family:    family,
+		Subject:   subject,
+	})
+	if err != nil {
+		response.Error(err)
+		return
+	}
+	response.JSON(http.StatusOK, map[string]string{"token": token, "refresh_token": refreshToken})
 }
 
-func (c *JWTController[T]) defaultTokenFunc(r *goyave.Request, _ *T) (string, error) {
+func (c *JWTController[T]) defaultToken(subject string) (string, error) {
 	signingMethod := c.SigningMethod
 	if signingMethod == nil {
 		signingMethod = jwt.SigningMethodHS256
 	}
-	body := r.Data.(map[string]any)
-	usernameField := lo.Ternary(c.UsernameRequestField == "", "username", c.UsernameRequestField)
-	return c.jwtService.GenerateTokenWithClaims(jwt.MapClaims{"sub": body[usernameField]}, signingMethod)
+	jti, err := generateTokenID()
+	if err != nil {
+		return "", err
+	}
+	return c.jwtService.GenerateTokenWithClaims(jwt.MapClaims{"sub": subject, "jti": jti}, signingMethod)
+}
+
+func (c *JWTController[T]) refreshTokenField() string {
+	return lo.Ternary(c.RefreshTokenRequestField == "", "refresh_token", c.RefreshTokenRequestField)
 }
@@ -2,16 +2,19 @@ package auth
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
 	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/auth/hash"
 	"goyave.dev/goyave/v5/slog"
 	"goyave.dev/goyave/v5/util/testutil"
 	"goyave.dev/goyave/v5/validation"
@@ -231,6 +234,133 @@ func TestJWTController(t *testing.T) {
 		assert.NotEmpty(t, respBody["token"])
 	})
 
+	t.Run("Login_rehash", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		server.Config().Set("auth.jwt.secret", "secret")
+
+		mockUserService := &MockUserService[TestUser]{user: user}
+		controller := NewJWTController(mockUserService, "Password")
+		argon := hash.NewArgon2id()
+		argon.Memory = 64
+		controller.Hasher = hash.NewMulti(argon, hash.NewBcrypt())
+		rehashed := ""
+		controller.OnRehash = func(_ context.Context, _ *TestUser, h string) error {
+			rehashed = h
+			return nil
+		}
+		server.RegisterRoutes(func(_ *goyave.Server, router *goyave.Router) {
+			router.Controller(controller)
+		})
+
+		data := map[string]any{
+			"username": user.Email,
+			"password": "secret",
+		}
+		body, err := json.Marshal(data)
+		require.NoError(t, err)
+		request := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		resp := server.TestRequest(request)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+		assert.True(t, argon.Identify(rehashed))
+
+		// No rehash on failed login
+		rehashed = ""
+		data["password"] = "wrong"
+		body, err = json.Marshal(data)
+		require.NoError(t, err)
+		request = httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		resp = server.TestRequest(request)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+		assert.Empty(t, rehashed)
+	})
+
+	t.Run("Login_invalid_username_compares_dummy_hash", func(t *testing.T) {
+		server, _ := prepareAuthenticatorTest(t)
+		server.Config().Set("auth.jwt.secret", "secret")
+
+		mockUserService := &MockUserService[TestUser]{err: fmt.Errorf("test errors: %w", gorm.ErrRecordNotFound)}
+		controller := NewJWTController(mockUserService, "Password")
+		compared := 0
+		controller.Hasher = countingHasher{Hasher: &hash.Bcrypt{Cost: 4}, compared: &compared}
+		server.RegisterRoutes(func(_ *goyave.Server, router *goyave.Router) {
+			router.Controller(controller)
+		})
+
+		body, err := json.Marshal(map[string]any{"username": "wrong username", "password": "secret"})
+		require.NoError(t, err)
+		request := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		resp := server.TestRequest(request)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+		assert.Equal(t, 1, compared)
+	})
+
+	t.Run("Login_throttle", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		server.Config().Set("auth.jwt.secret", "secret")
+		buf := &bytes.Buffer{}
+		server.Logger = slog.New(slog.NewHandler(false, buf))
+
+		mockUserService := &MockUserService[TestUser]{user: user}
+		controller := NewJWTController(mockUserService, "Password")
+		now := time.Now()
+		store := NewMemoryLoginAttemptStore()
+		controller.Throttler = NewLoginThrottler(store)
+		controller.Throttler.MaxAttempts = 3
+		controller.Throttler.now = func() time.Time { return now }
+		server.RegisterRoutes(func(_ *goyave.Server, router *goyave.Router) {
+			router.Controller(controller)
+		})
+
+		login := func(password string) *http.Response {
+			body, err := json.Marshal(map[string]any{"username": user.Email, "password": password})
+			require.NoError(t, err)
+			request := httptest.NewRequest(http.MethodPost, "/login", bytes.NewReader(body))
+			request.Header.Set("Content-Type", "application/json")
+			return server.TestRequest(request)
+		}
+
+		resp := login("wrong")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+
+		// Progressive delay: even the right password is rejected
+		resp = login("secret")
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Equal(t, "1", resp.Header.Get("Retry-After"))
+		respBody, err := testutil.ReadJSONBody[map[string]string](resp.Body)
+		assert.NoError(t, resp.Body.Close())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"error": server.Lang.GetDefault().Get("auth.too-many-attempts")}, respBody)
+
+		now = now.Add(time.Second)
+		resp = login("wrong")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+		now = now.Add(2 * time.Second)
+		resp = login("wrong")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+		assert.Contains(t, buf.String(), "login locked out after too many failed attempts")
+
+		now = now.Add(time.Minute)
+		resp = login("secret")
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Equal(t, "840", resp.Header.Get("Retry-After"))
+		assert.NoError(t, resp.Body.Close())
+
+		now = now.Add(14 * time.Minute)
+		resp = login("secret")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+		assert.Equal(t, 0, store.Len())
+	})
+
 	t.Run("Login_validation", func(t *testing.T) {
 		server, _ := prepareAuthenticatorTest(t)
 		server.Config().Set("auth.jwt.secret", "secret")
@@ -256,3 +386,191 @@ func TestJWTController(t *testing.T) {
 		}
 	})
 }
+
+func TestJWTControllerRefreshToken(t *testing.T) {
+	prepareTest := func(t *testing.T, userService UserService[TestUser]) (*testutil.TestServer, *MemoryTokenStore) {
+		server, _ := prepareAuthenticatorTest(t)
+		server.Config().Set("auth.jwt.secret", "secret")
+		store := NewMemoryTokenStore()
+
+		controller := NewJWTController(userService, "Password")
+		controller.TokenStore = store
+		authenticator := NewJWTAuthenticator(userService)
+		authenticator.TokenStore = store
+		authenticator.Optional = true
+		server.RegisterRoutes(func(_ *goyave.Server, router *goyave.Router) {
+			router.GlobalMiddleware(Middleware(authenticator))
+			router.Controller(controller)
+			router.Get("/protected", func(response *goyave.Response, request *goyave.Request) {
+				if user, _ := request.User.(*TestUser); user == nil {
+					response.Status(http.StatusUnauthorized)
+					return
+				}
+				response.Status(http.StatusNoContent)
+			}).SetMeta(MetaAuth, true)
+		})
+		return server, store
+	}
+
+	post := func(t *testing.T, server *testutil.TestServer, path string, data map[string]any, accessToken string) (*http.Response, map[string]string) {
+		body, err := json.Marshal(data)
+		require.NoError(t, err)
+		request := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		request.Header.Set("Content-Type", "application/json")
+		if accessToken != "" {
+			request.Header.Set("Authorization", "Bearer "+accessToken)
+		}
+		resp := server.TestRequest(request)
+		respBody := map[string]string{}
+		if resp.StatusCode != http.StatusNoContent {
+			respBody, err = testutil.ReadJSONBody[map[string]string](resp.Body)
+			require.NoError(t, err)
+		}
+		assert.NoError(t, resp.Body.Close())
+		return resp, respBody
+	}
+
+	login := func(t *testing.T, server *testutil.TestServer, user *TestUser) map[string]string {
+		resp, body := post(t, server, "/login", map[string]any{"username": user.Email, "password": "secret"}, "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NotEmpty(t, body["token"])
+		require.NotEmpty(t, body["refresh_token"])
+		return body
+	}
+
+	getProtected := func(server *testutil.TestServer, accessToken string) int {
+		request := httptest.NewRequest(http.MethodGet, "/protected", nil)
+		request.Header.Set("Authorization", "Bearer "+accessToken)
+		resp := server.TestRequest(request)
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	t.Run("rotation", func(t *testing.T) {
+		_, user := prepareAuthenticatorTest(t)
+		server, store := prepareTest(t, &MockUserService[TestUser]{user: user})
+		tokens := login(t, server, user)
+		assert.Equal(t, 1, store.Len())
+
+		resp, refreshed := post(t, server, "/refresh", map[string]any{"refresh_token": tokens["refresh_token"]}, "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NotEmpty(t, refreshed["token"])
+		assert.NotEqual(t, tokens["token"], refreshed["token"])
+		assert.NotEqual(t, tokens["refresh_token"], refreshed["refresh_token"])
+		assert.Equal(t, http.StatusNoContent, getProtected(server, refreshed["token"]))
+
+		token, err := store.UseRefreshToken(context.Background(), HashRefreshToken(refreshed["refresh_token"]))
+		require.NoError(t, err)
+		previous, err := store.UseRefreshToken(context.Background(), HashRefreshToken(tokens["refresh_token"]))
+		require.NoError(t, err)
+		assert.Equal(t, previous.Family, token.Family)
+		assert.Equal(t, user.Email, token.Subject)
+	})
+
+	t.Run("reuse_detection", func(t *testing.T) {
+		_, user := prepareAuthenticatorTest(t)
+		server, store := prepareTest(t, &MockUserService[TestUser]{user: user})
+		buf := &bytes.Buffer{}
+		server.Logger = slog.New(slog.NewHandler(false, buf))
+		tokens := login(t, server, user)
+		other := login(t, server, user)
+
+		resp, refreshed := post(t, server, "/refresh", map[string]any{"refresh_token": tokens["refresh_token"]}, "")
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, body := post(t, server, "/refresh", map[string]any{"refresh_token": tokens["refresh_token"]}, "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, map[string]string{"error": server.Lang.GetDefault().Get("auth.invalid-refresh-token")}, body)
+		assert.Contains(t, buf.String(), "refresh token reuse detected")
+
+		// The whole family is revoked, including the token obtained by rotation
+		resp, _ = post(t, server, "/refresh", map[string]any{"refresh_token": refreshed["refresh_token"]}, "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		// Other logins are not affected
+		resp, _ = post(t, server, "/refresh", map[string]any{"refresh_token": other["refresh_token"]}, "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, 2, store.Len())
+	})
+
+	t.Run("invalid_refresh_token", func(t *testing.T) {
+		_, user := prepareAuthenticatorTest(t)
+		server, _ := prepareTest(t, &MockUserService[TestUser]{user: user})
+		resp, body := post(t, server, "/refresh", map[string]any{"refresh_token": "invalid"}, "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, map[string]string{"error": server.Lang.GetDefault().Get("auth.invalid-refresh-token")}, body)
+
+		request := httptest.NewRequest(http.MethodPost, "/refresh", bytes.NewReader([]byte("{}")))
+		request.Header.Set("Content-Type", "application/json")
+		resp = server.TestRequest(request)
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+		validationBody, err := testutil.ReadJSONBody[map[string]*validation.ErrorResponse](resp.Body)
+		assert.NoError(t, resp.Body.Close())
+		require.NoError(t, err)
+		if assert.Contains(t, validationBody, "error") && assert.NotNil(t, validationBody["error"]) {
+			assert.Contains(t, validationBody["error"].Body.Fields, "refresh_token")
+		}
+	})
+
+	t.Run("expired_refresh_token", func(t *testing.T) {
+		_, user := prepareAuthenticatorTest(t)
+		server, store := prepareTest(t, &MockUserService[TestUser]{user: user})
+		tokens := login(t, server, user)
+		store.now = func() time.Time { return time.Now().Add(8 * 24 * time.Hour) }
+		resp, _ := post(t, server, "/refresh", map[string]any{"refresh_token": tokens["refresh_token"]}, "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("user_not_found", func(t *testing.T) {
+		_, user := prepareAuthenticatorTest(t)
+		userService := &MockUserService[TestUser]{user: user}
+		server, _ := prepareTest(t, userService)
+		tokens := login(t, server, user)
+
+		userService.user = nil
+		userService.err = gorm.ErrRecordNotFound
+		resp, body := post(t, server, "/refresh", map[string]any{"refresh_token": tokens["refresh_token"]}, "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, map[string]string{"error": server.Lang.GetDefault().Get("auth.invalid-refresh-token")}, body)
+	})
+
+	t.Run("logout", func(t *testing.T) {
+		_, user := prepareAuthenticatorTest(t)
+		server, _ := prepareTest(t, &MockUserService[TestUser]{user: user})
+		tokens := login(t, server, user)
+		assert.Equal(t, http.StatusNoContent, getProtected(server, tokens["token"]))
+
+		resp, _ := post(t, server, "/logout", map[string]any{"refresh_token": tokens["refresh_token"]}, tokens["token"])
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		assert.Equal(t, http.StatusUnauthorized, getProtected(server, tokens["token"]))
+		resp, _ = post(t, server, "/refresh", map[string]any{"refresh_token": tokens["refresh_token"]}, "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		// The revoked access token is rejected by the logout route itself
+		resp, body := post(t, server, "/logout", map[string]any{"refresh_token": tokens["refresh_token"]}, tokens["token"])
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, map[string]string{"error": server.Lang.GetDefault().Get("auth.jwt-revoked")}, body)
+
+		// Logging out with an unknown refresh token and without access token succeeds
+		resp, _ = post(t, server, "/logout", map[string]any{"refresh_token": "invalid"}, "")
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	})
+
+	t.Run("no_token_store", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		server.Config().Set("auth.jwt.secret", "secret")
+		controller := NewJWTController(&MockUserService[TestUser]{user: user}, "Password")
+		server.RegisterRoutes(func(_ *goyave.Server, router *goyave.Router) {
+			router.Controller(controller)
+		})
+		resp, body := post(t, server, "/login", map[string]any{"username": user.Email, "password": "secret"}, "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NotContains(t, body, "refresh_token")
+
+		request := httptest.NewRequest(http.MethodPost, "/refresh", nil)
+		resp = server.TestRequest(request)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+}
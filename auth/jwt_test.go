@@ -2,13 +2,19 @@ package auth
 
 import (
 	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"path"
 	"testing"
 	"time"
 
-	"github.com/golang-jwt/jwt"
+	"github.com/golang-jwt/jwt/v5"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gorm.io/gorm"
@@ -45,7 +51,6 @@ func TestJWTService(t *testing.T) {
 		assert.True(t, parsedToken.Valid)
 		assert.Equal(t, jwt.SigningMethodHS256, parsedToken.Method)
 
-		require.NoError(t, parsedToken.Claims.Valid())
 		claims, ok := parsedToken.Claims.(jwt.MapClaims)
 		if assert.True(t, ok) {
 			assert.Equal(t, "johndoe", claims["sub"])
@@ -77,7 +82,6 @@ func TestJWTService(t *testing.T) {
 		assert.True(t, parsedToken.Valid)
 		assert.Equal(t, jwt.SigningMethodHS256, parsedToken.Method)
 
-		require.NoError(t, parsedToken.Claims.Valid())
 		claims, ok := parsedToken.Claims.(jwt.MapClaims)
 		if assert.True(t, ok) {
 			assert.Equal(t, "johndoe", claims["sub"])
@@ -112,7 +116,6 @@ func TestJWTService(t *testing.T) {
 		assert.True(t, parsedToken.Valid)
 		assert.Equal(t, jwt.SigningMethodRS256, parsedToken.Method)
 
-		require.NoError(t, parsedToken.Claims.Valid())
 		claims, ok := parsedToken.Claims.(jwt.MapClaims)
 		if assert.True(t, ok) {
 			assert.Equal(t, "johndoe", claims["sub"])
@@ -147,7 +150,6 @@ func TestJWTService(t *testing.T) {
 		assert.True(t, parsedToken.Valid)
 		assert.Equal(t, jwt.SigningMethodES256, parsedToken.Method)
 
-		require.NoError(t, parsedToken.Claims.Valid())
 		claims, ok := parsedToken.Claims.(jwt.MapClaims)
 		if assert.True(t, ok) {
 			assert.Equal(t, "johndoe", claims["sub"])
@@ -158,6 +160,50 @@ func TestJWTService(t *testing.T) {
 		}
 	})
 
+	t.Run("GenerateTokenWithClaims_EdDSA", func(t *testing.T) {
+		rootDir := testutil.FindRootDirectory()
+		server, service := prepareJWTServiceTest(t)
+		server.Config().Set("auth.jwt.eddsa.public", path.Join(rootDir, "resources/eddsa/public.pem"))
+		server.Config().Set("auth.jwt.eddsa.private", path.Join(rootDir, "resources/eddsa/private.pem"))
+
+		tokenString, err := service.GenerateTokenWithClaims(jwt.MapClaims{"sub": "johndoe"}, jwt.SigningMethodEdDSA)
+		require.NoError(t, err)
+		parsedToken, err := jwt.Parse(tokenString, func(_ *jwt.Token) (any, error) {
+			return service.GetKey("auth.jwt.eddsa.public")
+		})
+
+		require.NoError(t, err)
+		assert.True(t, parsedToken.Valid)
+		assert.Equal(t, jwt.SigningMethodEdDSA, parsedToken.Method)
+		claims, ok := parsedToken.Claims.(jwt.MapClaims)
+		if assert.True(t, ok) {
+			assert.Equal(t, "johndoe", claims["sub"])
+		}
+	})
+
+	t.Run("GenerateTokenWithClaims_issuer_audience", func(t *testing.T) {
+		server, service := prepareJWTServiceTest(t)
+		server.Config().Set("auth.jwt.secret", "secret")
+		server.Config().Set("auth.jwt.issuer", "goyave")
+		server.Config().Set("auth.jwt.audience", "api")
+
+		tokenString, err := service.GenerateTokenWithClaims(jwt.MapClaims{"sub": "johndoe"}, jwt.SigningMethodHS256)
+		require.NoError(t, err)
+		parsedToken, err := jwt.Parse(tokenString, func(_ *jwt.Token) (any, error) {
+			return []byte("secret"), nil
+		}, jwt.WithIssuer("goyave"), jwt.WithAudience("api"))
+		require.NoError(t, err)
+		claims := parsedToken.Claims.(jwt.MapClaims)
+		assert.Equal(t, "goyave", claims["iss"])
+		assert.Equal(t, "api", claims["aud"])
+
+		tokenString, err = service.GenerateTokenWithClaims(jwt.MapClaims{"sub": "johndoe", "iss": "other"}, jwt.SigningMethodHS256)
+		require.NoError(t, err)
+		parsedToken, _, err = jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{})
+		require.NoError(t, err)
+		assert.Equal(t, "other", parsedToken.Claims.(jwt.MapClaims)["iss"])
+	})
+
 	t.Run("GenerateTokenWithClaims_Unsupported", func(t *testing.T) {
 		server, service := prepareJWTServiceTest(t)
 		server.Config().Set("auth.jwt.expiry", 20)
@@ -194,6 +240,43 @@ func TestJWTAuthenticator(t *testing.T) {
 		assert.NoError(t, resp.Body.Close())
 	})
 
+	t.Run("revoked", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		server.Config().Set("auth.jwt.secret", "secret")
+		mockUserService := &MockUserService[TestUser]{user: user}
+		store := NewMemoryTokenStore()
+		a := NewJWTAuthenticator(mockUserService)
+		a.TokenStore = store
+		authenticator := Middleware(a)
+
+		service := NewJWTService(server.Config(), &osfs.FS{})
+		token, err := service.GenerateTokenWithClaims(jwt.MapClaims{"sub": user.Email, "jti": "token-id"}, jwt.SigningMethodHS256)
+		require.NoError(t, err)
+
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Request().Header.Set("Authorization", "Bearer "+token)
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, _ *goyave.Request) {
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+
+		require.NoError(t, store.Revoke(context.Background(), "token-id", time.Now().Add(time.Minute)))
+		request = server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Request().Header.Set("Authorization", "Bearer "+token)
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp = server.TestMiddleware(authenticator, request, func(response *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware passed despite revoked token")
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		body, err := testutil.ReadJSONBody[map[string]string](resp.Body)
+		assert.NoError(t, resp.Body.Close())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"error": server.Lang.GetDefault().Get("auth.jwt-revoked")}, body)
+	})
+
 	t.Run("success_rsa", func(t *testing.T) {
 		rootDir := testutil.FindRootDirectory()
 		server, user := prepareAuthenticatorTest(t)
@@ -336,6 +419,107 @@ func TestJWTAuthenticator(t *testing.T) {
 		assert.Equal(t, map[string]string{"error": server.Lang.GetDefault().Get("auth.jwt-expired")}, body)
 	})
 
+	t.Run("success_eddsa", func(t *testing.T) {
+		rootDir := testutil.FindRootDirectory()
+		server, user := prepareAuthenticatorTest(t)
+		server.Config().Set("auth.jwt.eddsa.public", path.Join(rootDir, "resources/eddsa/public.pem"))
+		server.Config().Set("auth.jwt.eddsa.private", path.Join(rootDir, "resources/eddsa/private.pem"))
+
+		mockUserService := &MockUserService[TestUser]{user: user}
+		a := NewJWTAuthenticator(mockUserService)
+		a.SigningMethod = jwt.SigningMethodEdDSA
+		authenticator := Middleware(a)
+
+		service := NewJWTService(server.Config(), &osfs.FS{})
+		token, err := service.GenerateTokenWithClaims(jwt.MapClaims{"sub": user.Email}, jwt.SigningMethodEdDSA)
+		require.NoError(t, err)
+
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Request().Header.Set("Authorization", "Bearer "+token)
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, request *goyave.Request) {
+			assert.Equal(t, user.Email, request.User.(*TestUser).Email)
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+
+		// HMAC token rejected
+		server.Config().Set("auth.jwt.secret", "secret")
+		token, err = service.GenerateTokenWithClaims(jwt.MapClaims{"sub": user.Email}, jwt.SigningMethodHS256)
+		require.NoError(t, err)
+		request = server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Request().Header.Set("Authorization", "Bearer "+token)
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp = server.TestMiddleware(authenticator, request, func(response *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware passed despite failed authentication")
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("issuer_audience", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		server.Config().Set("auth.jwt.secret", "secret")
+		server.Config().Set("auth.jwt.issuer", "goyave")
+		server.Config().Set("auth.jwt.audience", "api")
+		mockUserService := &MockUserService[TestUser]{user: user}
+		authenticator := Middleware(NewJWTAuthenticator(mockUserService))
+		service := NewJWTService(server.Config(), &osfs.FS{})
+
+		cases := []struct {
+			claims jwt.MapClaims
+			want   int
+		}{
+			{claims: jwt.MapClaims{"sub": user.Email}, want: http.StatusOK},
+			{claims: jwt.MapClaims{"sub": user.Email, "aud": []string{"other", "api"}}, want: http.StatusOK},
+			{claims: jwt.MapClaims{"sub": user.Email, "iss": "other"}, want: http.StatusUnauthorized},
+			{claims: jwt.MapClaims{"sub": user.Email, "aud": "other"}, want: http.StatusUnauthorized},
+		}
+		for _, c := range cases {
+			token, err := service.GenerateTokenWithClaims(c.claims, jwt.SigningMethodHS256)
+			require.NoError(t, err)
+			request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+			request.Request().Header.Set("Authorization", "Bearer "+token)
+			request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+			resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, _ *goyave.Request) {
+				response.Status(http.StatusOK)
+			})
+			assert.Equal(t, c.want, resp.StatusCode, c.claims)
+			if resp.StatusCode == http.StatusUnauthorized {
+				body, err := testutil.ReadJSONBody[map[string]string](resp.Body)
+				require.NoError(t, err)
+				assert.Equal(t, map[string]string{"error": server.Lang.GetDefault().Get("auth.jwt-invalid")}, body)
+			}
+			assert.NoError(t, resp.Body.Close())
+		}
+	})
+
+	t.Run("leeway", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		server.Config().Set("auth.jwt.secret", "secret")
+		server.Config().Set("auth.jwt.leeway", 60)
+		mockUserService := &MockUserService[TestUser]{user: user}
+		authenticator := Middleware(NewJWTAuthenticator(mockUserService))
+		service := NewJWTService(server.Config(), &osfs.FS{})
+
+		token, err := service.GenerateTokenWithClaims(jwt.MapClaims{
+			"sub": user.Email,
+			"exp": time.Now().Add(-30 * time.Second).Unix(),
+		}, jwt.SigningMethodHS256)
+		require.NoError(t, err)
+
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Request().Header.Set("Authorization", "Bearer "+token)
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, _ *goyave.Request) {
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
 	t.Run("unknown_user", func(t *testing.T) {
 		server, _ := prepareAuthenticatorTest(t)
 		server.Config().Set("auth.jwt.secret", "secret")
@@ -585,3 +769,161 @@ func TestJWTAuthenticator(t *testing.T) {
 		assert.NoError(t, resp.Body.Close())
 	})
 }
+
+func TestJWTServiceSigningKeys(t *testing.T) {
+	t.Run("rotation", func(t *testing.T) {
+		_, service := prepareJWTServiceTest(t)
+		oldKey := &SigningKey{ID: "old", Method: jwt.SigningMethodES256, Key: generateTestECDSAKey(t)}
+		newKey := &SigningKey{ID: "new", Method: jwt.SigningMethodES256, Key: generateTestECDSAKey(t)}
+		require.NoError(t, service.AddSigningKey(oldKey))
+
+		tokenString, err := service.GenerateTokenWithClaims(jwt.MapClaims{"sub": "johndoe"}, jwt.SigningMethodES256)
+		require.NoError(t, err)
+		token, _, err := jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{})
+		require.NoError(t, err)
+		assert.Equal(t, "old", token.Header["kid"])
+
+		require.NoError(t, service.AddSigningKey(newKey))
+		tokenString, err = service.GenerateTokenWithClaims(jwt.MapClaims{"sub": "johndoe"}, jwt.SigningMethodES256)
+		require.NoError(t, err)
+		token, _, err = jwt.NewParser().ParseUnverified(tokenString, jwt.MapClaims{})
+		require.NoError(t, err)
+		assert.Equal(t, "new", token.Header["kid"])
+
+		k, ok := service.LookupSigningKey("old")
+		assert.True(t, ok)
+		assert.Equal(t, oldKey, k)
+		service.RemoveSigningKey("old")
+		_, ok = service.LookupSigningKey("old")
+		assert.False(t, ok)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, service := prepareJWTServiceTest(t)
+		require.Error(t, service.AddSigningKey(&SigningKey{Method: jwt.SigningMethodHS256, Key: []byte("secret")}))
+		require.Error(t, service.AddSigningKey(&SigningKey{ID: "kid", Key: []byte("secret")}))
+		require.Error(t, service.AddSigningKey(&SigningKey{ID: "kid", Method: jwt.SigningMethodRS256, Key: []byte("secret")}))
+		require.NoError(t, service.AddSigningKey(&SigningKey{ID: "kid", Method: jwt.SigningMethodHS256, Key: []byte("secret")}))
+		require.Error(t, service.AddSigningKey(&SigningKey{ID: "kid", Method: jwt.SigningMethodHS256, Key: []byte("secret")}))
+	})
+
+	t.Run("ServeJWKS", func(t *testing.T) {
+		server, service := prepareJWTServiceTest(t)
+		rsaKey := loadTestRSAKey(t)
+		require.NoError(t, service.AddSigningKey(&SigningKey{ID: "rsa", Method: jwt.SigningMethodRS256, Key: rsaKey}))
+		require.NoError(t, service.AddSigningKey(&SigningKey{ID: "hmac", Method: jwt.SigningMethodHS256, Key: []byte("secret")}))
+
+		request := server.NewTestRequest(http.MethodGet, "/.well-known/jwks.json", nil)
+		response, recorder := server.NewTestResponse(request)
+		service.ServeJWKS(response, request)
+		resp := recorder.Result()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "public, max-age=300", resp.Header.Get("Cache-Control"))
+		set, err := testutil.ReadJSONBody[*JWKSet](resp.Body)
+		assert.NoError(t, resp.Body.Close())
+		require.NoError(t, err)
+		require.Len(t, set.Keys, 1)
+		assert.Equal(t, "rsa", set.Keys[0].Kid)
+		assert.Equal(t, "RS256", set.Keys[0].Alg)
+		publicKey, err := set.Keys[0].PublicKey()
+		require.NoError(t, err)
+		assert.True(t, rsaKey.PublicKey.Equal(publicKey))
+	})
+}
+
+func TestJWTAuthenticatorKeyID(t *testing.T) {
+	authenticate := func(server *testutil.TestServer, authenticator goyave.Middleware, token string) int {
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Request().Header.Set("Authorization", "Bearer "+token)
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, _ *goyave.Request) {
+			response.Status(http.StatusOK)
+		})
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	t.Run("signing_keys", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		service := NewJWTService(server.Config(), &osfs.FS{})
+		server.RegisterService(service)
+		authenticator := Middleware(NewJWTAuthenticator(&MockUserService[TestUser]{user: user}))
+
+		require.NoError(t, service.AddSigningKey(&SigningKey{ID: "old", Method: jwt.SigningMethodES256, Key: generateTestECDSAKey(t)}))
+		oldToken, err := service.GenerateTokenWithClaims(jwt.MapClaims{"sub": user.Email}, jwt.SigningMethodES256)
+		require.NoError(t, err)
+		require.NoError(t, service.AddSigningKey(&SigningKey{ID: "new", Method: jwt.SigningMethodES256, Key: generateTestECDSAKey(t)}))
+		newToken, err := service.GenerateTokenWithClaims(jwt.MapClaims{"sub": user.Email}, jwt.SigningMethodES256)
+		require.NoError(t, err)
+		require.NoError(t, service.AddSigningKey(&SigningKey{ID: "hmac", Method: jwt.SigningMethodHS256, Key: []byte("secret")}))
+		hmacToken, err := service.GenerateTokenWithClaims(jwt.MapClaims{"sub": user.Email}, jwt.SigningMethodHS256)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, authenticate(server, authenticator, oldToken))
+		assert.Equal(t, http.StatusOK, authenticate(server, authenticator, newToken))
+		assert.Equal(t, http.StatusOK, authenticate(server, authenticator, hmacToken))
+
+		service.RemoveSigningKey("old")
+		assert.Equal(t, http.StatusUnauthorized, authenticate(server, authenticator, oldToken))
+		assert.Equal(t, http.StatusOK, authenticate(server, authenticator, newToken))
+
+		// Algorithm doesn't match the key
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": user.Email})
+		token.Header["kid"] = "new"
+		tokenString, err := token.SignedString([]byte("secret"))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, authenticate(server, authenticator, tokenString))
+	})
+
+	t.Run("remote_key_set", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		key := generateTestECDSAKey(t)
+		jwk, err := NewJWK("remote", "ES256", key)
+		require.NoError(t, err)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			_ = json.NewEncoder(w).Encode(&JWKSet{Keys: []*JWK{jwk}})
+		}))
+		t.Cleanup(srv.Close)
+
+		a := NewJWTAuthenticator(&MockUserService[TestUser]{user: user})
+		a.KeySet = NewRemoteJWKSet(srv.URL)
+		authenticator := Middleware(a)
+
+		sign := func(method jwt.SigningMethod, kid string, key any) string {
+			token := jwt.NewWithClaims(method, jwt.MapClaims{"sub": user.Email})
+			token.Header["kid"] = kid
+			tokenString, err := token.SignedString(key)
+			require.NoError(t, err)
+			return tokenString
+		}
+
+		assert.Equal(t, http.StatusOK, authenticate(server, authenticator, sign(jwt.SigningMethodES256, "remote", key)))
+		assert.Equal(t, http.StatusUnauthorized, authenticate(server, authenticator, sign(jwt.SigningMethodES256, "unknown", key)))
+		assert.Equal(t, http.StatusUnauthorized, authenticate(server, authenticator, sign(jwt.SigningMethodES256, "remote", generateTestECDSAKey(t))))
+		p384Key, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, authenticate(server, authenticator, sign(jwt.SigningMethodES384, "remote", p384Key)))
+
+		jwk.Alg = ""
+		assert.Equal(t, http.StatusUnauthorized, authenticate(server, authenticator, sign(jwt.SigningMethodHS256, "remote", []byte("secret"))))
+	})
+
+	t.Run("key_set_error", func(t *testing.T) {
+		server, user := prepareAuthenticatorTest(t)
+		server.Logger = slog.New(slog.NewHandler(false, &bytes.Buffer{}))
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		t.Cleanup(srv.Close)
+
+		a := NewJWTAuthenticator(&MockUserService[TestUser]{user: user})
+		a.KeySet = NewRemoteJWKSet(srv.URL)
+		authenticator := Middleware(a)
+
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"sub": user.Email})
+		token.Header["kid"] = "remote"
+		tokenString, err := token.SignedString(generateTestECDSAKey(t))
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusInternalServerError, authenticate(server, authenticator, tokenString))
+	})
+}
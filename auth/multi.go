@@ -0,0 +1,195 @@
+package auth
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+
+	"goyave.dev/goyave/v5"
+	errorutil "goyave.dev/goyave/v5/util/errors"
+)
+
+// MetaAuthenticators the route meta key used to restrict the authenticators of a
+// `MultiAuthenticator` that apply to a route or a router. The meta value is expected
+// to be a `[]string` containing the names of the authenticators.
+//
+//	router.Get("/legacy", handler).SetMeta(auth.MetaAuthenticators, []string{"basic"})
+const MetaAuthenticators = "goyave.authenticators"
+
+// ExtraAuthenticator when using the `MultiAuthenticator`, this key can be used to
+// retrieve the name of the authenticator that succeeded in the request's `Extra`.
+type ExtraAuthenticator struct{}
+
+// CredentialsDetector can be implemented by Authenticators to tell if a request contains
+// credentials they can check, without checking them. It is used by the `MultiAuthenticator`
+// to select the authenticator to use.
+type CredentialsDetector interface {
+	HasCredentials(request *goyave.Request) bool
+}
+
+type namedAuthenticator[T any] struct {
+	Authenticator[T]
+	name string
+}
+
+// multiAuthError the error returned by the `MultiAuthenticator`, keeping track of the
+// authenticator that failed so it can handle the error if it is an `Unauthorizer`.
+type multiAuthError struct {
+	err           error
+	authenticator any
+}
+
+func (e *multiAuthError) Error() string {
+	return e.err.Error()
+}
+
+func (e *multiAuthError) Unwrap() error {
+	return e.err
+}
+
+// MultiAuthenticator implementation of Authenticator trying multiple authenticators in order,
+// allowing a route to accept several types of credentials (e.g. a JWT, an API key or basic auth).
+//
+// The first authenticator finding credentials in the request is used, and the others are not
+// tried: if the credentials are invalid, the authentication fails. Authenticators implementing
+// `CredentialsDetector` (all the built-in authenticators do) are skipped if the request doesn't
+// contain their credentials. The other authenticators are always tried, and the next one is
+// tried if they fail.
+//
+// The authenticators that apply to a route can be restricted using the `MetaAuthenticators`
+// meta. On success, the name of the authenticator that succeeded is added to `request.Extra`
+// with the key `ExtraAuthenticator{}`.
+//
+// If an authenticator implementing `Unauthorizer` fails, its `OnUnauthorized` method handles
+// the response. Otherwise, a single `401 Unauthorized` error is returned.
+//
+// The T parameter represents the user DTO and should not be a pointer.
+//
+// **Example:**
+//
+//	authenticator := auth.NewMultiAuthenticator[dto.User]().
+//		Add("jwt", auth.NewJWTAuthenticator(userService)).
+//		Add("api-key", auth.NewAPIKeyAuthenticator(keyService)).
+//		Add("basic", auth.NewBasicAuthenticator(userService, "Password"))
+//	router.GlobalMiddleware(auth.Middleware(authenticator))
+type MultiAuthenticator[T any] struct {
+	goyave.Component
+
+	authenticators []namedAuthenticator[T]
+
+	// Optional defines if the authenticator allows requests that
+	// don't provide credentials. Handlers should therefore check
+	// if `request.User` is not `nil` before accessing it.
+	Optional bool
+}
+
+// NewMultiAuthenticator create a new authenticator trying multiple authenticators in order.
+// Use `Add()` to register the authenticators.
+//
+// The T parameter represents the user DTO and should not be a pointer.
+func NewMultiAuthenticator[T any]() *MultiAuthenticator[T] {
+	return &MultiAuthenticator[T]{}
+}
+
+// Add registers an authenticator identified by the given name. The authenticators
+// are tried in the order in which they were added. If the `MultiAuthenticator` is
+// already initialized, the given authenticator is initialized too.
+func (a *MultiAuthenticator[T]) Add(name string, authenticator Authenticator[T]) *MultiAuthenticator[T] {
+	if server := a.Server(); server != nil {
+		authenticator.Init(server)
+	}
+	a.authenticators = append(a.authenticators, namedAuthenticator[T]{Authenticator: authenticator, name: name})
+	return a
+}
+
+// Init the authenticator and all its sub-authenticators.
+func (a *MultiAuthenticator[T]) Init(server *goyave.Server) {
+	a.Component.Init(server)
+	for _, authenticator := range a.authenticators {
+		authenticator.Init(server)
+	}
+}
+
+// HasCredentials returns true if one of the authenticators that apply to the matched
+// route finds credentials in the given request.
+func (a *MultiAuthenticator[T]) HasCredentials(request *goyave.Request) bool {
+	allowed := a.getAllowedAuthenticators(request)
+	for _, authenticator := range a.authenticators {
+		if allowed != nil && !slices.Contains(allowed, authenticator.name) {
+			continue
+		}
+		if detector, ok := authenticator.Authenticator.(CredentialsDetector); !ok || detector.HasCredentials(request) {
+			return true
+		}
+	}
+	return false
+}
+
+// Authenticate fetch the user using the first authenticator finding credentials in
+// the given request and returns it. If no user can be authenticated, returns an error.
+func (a *MultiAuthenticator[T]) Authenticate(request *goyave.Request) (*T, error) {
+	allowed := a.getAllowedAuthenticators(request)
+	var firstErr error
+	for _, authenticator := range a.authenticators {
+		if allowed != nil && !slices.Contains(allowed, authenticator.name) {
+			continue
+		}
+
+		detector, isDetector := authenticator.Authenticator.(CredentialsDetector)
+		if isDetector && !detector.HasCredentials(request) {
+			continue
+		}
+
+		user, err := authenticator.Authenticate(request)
+		if err != nil {
+			err = &multiAuthError{err: err, authenticator: authenticator.Authenticator}
+			if isDetector {
+				return nil, err
+			}
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if user == nil {
+			continue
+		}
+		request.Extra[ExtraAuthenticator{}] = authenticator.name
+		return user, nil
+	}
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if a.Optional {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("%s", request.Lang.Get("auth.no-credentials-provided"))
+}
+
+// OnUnauthorized delegates the response to the authenticator that failed if it
+// implements `Unauthorizer`. Otherwise, returns a `401 Unauthorized` error.
+func (a *MultiAuthenticator[T]) OnUnauthorized(response *goyave.Response, request *goyave.Request, err error) {
+	if e, ok := err.(*multiAuthError); ok {
+		if unauthorizer, ok := e.authenticator.(Unauthorizer); ok {
+			unauthorizer.OnUnauthorized(response, request, e.err)
+			return
+		}
+	}
+	response.JSON(http.StatusUnauthorized, map[string]string{"error": err.Error()})
+}
+
+func (a *MultiAuthenticator[T]) getAllowedAuthenticators(request *goyave.Request) []string {
+	if request.Route == nil {
+		return nil
+	}
+	meta, ok := request.Route.LookupMeta(MetaAuthenticators)
+	if !ok || meta == nil {
+		return nil
+	}
+	names, ok := meta.([]string)
+	if !ok {
+		panic(errorutil.NewSkip(fmt.Errorf("auth: route meta %q is not a []string", MetaAuthenticators), 3))
+	}
+	return names
+}
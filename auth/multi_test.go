@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/slog"
+	"goyave.dev/goyave/v5/util/testutil"
+)
+
+// testHeaderAuthenticator an authenticator not implementing `CredentialsDetector`.
+type testHeaderAuthenticator struct {
+	goyave.Component
+	user *TestUser
+}
+
+func (a *testHeaderAuthenticator) Authenticate(request *goyave.Request) (*TestUser, error) {
+	if request.Header().Get("X-Test-Auth") != "valid" {
+		return nil, fmt.Errorf("%s", request.Lang.Get("auth.invalid-credentials"))
+	}
+	return a.user, nil
+}
+
+func TestMultiAuthenticator(t *testing.T) {
+	prepare := func(t *testing.T) (*testutil.TestServer, *TestUser, *GeneratedAPIKey, *MultiAuthenticator[TestUser]) {
+		server, user := prepareAuthenticatorTest(t)
+		generated, err := GenerateAPIKey()
+		require.NoError(t, err)
+		keyService := &MockKeyService[TestUser]{
+			key: &APIKey[TestUser]{
+				User:   user,
+				Prefix: generated.Prefix,
+				Hash:   generated.Hash,
+				Scopes: []string{"read"},
+			},
+		}
+		authenticator := NewMultiAuthenticator[TestUser]().
+			Add("api-key", NewAPIKeyAuthenticator(keyService)).
+			Add("basic", NewBasicAuthenticator(&MockUserService[TestUser]{user: user}, "Password"))
+		return server, user, generated, authenticator
+	}
+
+	assertError := func(t *testing.T, server *testutil.TestServer, resp *http.Response, status int, message string) {
+		assert.Equal(t, status, resp.StatusCode)
+		body, err := testutil.ReadJSONBody[map[string]string](resp.Body)
+		assert.NoError(t, resp.Body.Close())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"error": server.Lang.GetDefault().Get(message)}, body)
+	}
+
+	t.Run("init", func(t *testing.T) {
+		server, user, _, authenticator := prepare(t)
+		authenticator.Init(server.Server)
+		for _, a := range authenticator.authenticators {
+			assert.Equal(t, server.Server, a.Authenticator.(interface{ Server() *goyave.Server }).Server())
+		}
+
+		other := &testHeaderAuthenticator{user: user}
+		authenticator.Add("header", other)
+		assert.Equal(t, server.Server, other.Server())
+	})
+
+	t.Run("first_authenticator", func(t *testing.T) {
+		server, user, generated, authenticator := prepare(t)
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Header().Set(DefaultAPIKeyHeader, generated.Key)
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(Middleware(authenticator), request, func(response *goyave.Response, request *goyave.Request) {
+			assert.Equal(t, user.Email, request.User.(*TestUser).Email)
+			assert.Equal(t, "api-key", request.Extra[ExtraAuthenticator{}])
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("second_authenticator", func(t *testing.T) {
+		server, user, _, authenticator := prepare(t)
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Request().SetBasicAuth(user.Email, "secret")
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(Middleware(authenticator), request, func(response *goyave.Response, request *goyave.Request) {
+			assert.Equal(t, user.Email, request.User.(*TestUser).Email)
+			assert.Equal(t, "basic", request.Extra[ExtraAuthenticator{}])
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("stops_at_first_credentials", func(t *testing.T) {
+		server, user, generated, authenticator := prepare(t)
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Header().Set(DefaultAPIKeyHeader, generated.Prefix+"_wrong")
+		request.Request().SetBasicAuth(user.Email, "secret")
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(Middleware(authenticator), request, func(response *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware passed despite failed authentication")
+			response.Status(http.StatusOK)
+		})
+		assertError(t, server, resp, http.StatusUnauthorized, "auth.invalid-credentials")
+	})
+
+	t.Run("no_credentials", func(t *testing.T) {
+		server, _, _, authenticator := prepare(t)
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(Middleware(authenticator), request, func(response *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware passed despite failed authentication")
+			response.Status(http.StatusOK)
+		})
+		assertError(t, server, resp, http.StatusUnauthorized, "auth.no-credentials-provided")
+	})
+
+	t.Run("optional_no_credentials", func(t *testing.T) {
+		server, _, _, authenticator := prepare(t)
+		authenticator.Optional = true
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(Middleware(authenticator), request, func(response *goyave.Response, request *goyave.Request) {
+			assert.Nil(t, request.User)
+			assert.NotContains(t, request.Extra, ExtraAuthenticator{})
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("delegate_unauthorizer", func(t *testing.T) {
+		server, _, generated, authenticator := prepare(t)
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Header().Set(DefaultAPIKeyHeader, generated.Key)
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true, MetaAPIKeyScopes: []string{"write"}}}
+		resp := server.TestMiddleware(Middleware(authenticator), request, func(response *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware passed despite failed authentication")
+			response.Status(http.StatusOK)
+		})
+		assertError(t, server, resp, http.StatusForbidden, "auth.insufficient-scope")
+	})
+
+	t.Run("restricted_by_meta", func(t *testing.T) {
+		server, user, generated, authenticator := prepare(t)
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Header().Set(DefaultAPIKeyHeader, generated.Key)
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true, MetaAuthenticators: []string{"basic"}}}
+		resp := server.TestMiddleware(Middleware(authenticator), request, func(response *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware passed despite disallowed authenticator")
+			response.Status(http.StatusOK)
+		})
+		assertError(t, server, resp, http.StatusUnauthorized, "auth.no-credentials-provided")
+		assert.False(t, authenticator.HasCredentials(request))
+
+		request = server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Request().SetBasicAuth(user.Email, "secret")
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true, MetaAuthenticators: []string{"basic"}}}
+		assert.True(t, authenticator.HasCredentials(request))
+		resp = server.TestMiddleware(Middleware(authenticator), request, func(response *goyave.Response, request *goyave.Request) {
+			assert.Equal(t, "basic", request.Extra[ExtraAuthenticator{}])
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("invalid_meta", func(t *testing.T) {
+		server, _, generated, authenticator := prepare(t)
+		buf := &bytes.Buffer{}
+		server.Logger = slog.New(slog.NewHandler(false, buf))
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Header().Set(DefaultAPIKeyHeader, generated.Key)
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true, MetaAuthenticators: "basic"}}
+		resp := server.TestMiddleware(Middleware(authenticator), request, func(response *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware passed despite invalid meta")
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("non_detector_fallthrough", func(t *testing.T) {
+		server, user, _, _ := prepare(t)
+		authenticator := NewMultiAuthenticator[TestUser]().
+			Add("header", &testHeaderAuthenticator{user: user}).
+			Add("basic", NewBasicAuthenticator(&MockUserService[TestUser]{user: user}, "Password"))
+
+		// The header authenticator fails but doesn't detect credentials: basic auth is tried
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Request().SetBasicAuth(user.Email, "secret")
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(Middleware(authenticator), request, func(response *goyave.Response, request *goyave.Request) {
+			assert.Equal(t, "basic", request.Extra[ExtraAuthenticator{}])
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+
+		// No authenticator succeeds: the error of the header authenticator is returned
+		request = server.NewTestRequest(http.MethodGet, "/protected", nil)
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp = server.TestMiddleware(Middleware(authenticator), request, func(response *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware passed despite failed authentication")
+			response.Status(http.StatusOK)
+		})
+		assertError(t, server, resp, http.StatusUnauthorized, "auth.invalid-credentials")
+	})
+}
+
+func TestHasCredentials(t *testing.T) {
+	server, user := prepareAuthenticatorTest(t)
+	userService := &MockUserService[TestUser]{user: user}
+	detectors := map[string]CredentialsDetector{
+		"jwt":     NewJWTAuthenticator(userService),
+		"oidc":    NewOIDCAuthenticator(NewOIDCProvider("https://example.org"), userService),
+		"session": NewSessionAuthenticator(userService),
+	}
+
+	request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+	for name, detector := range detectors {
+		assert.False(t, detector.HasCredentials(request), name)
+	}
+
+	request.Header().Set("Authorization", "Bearer token")
+	assert.True(t, detectors["jwt"].HasCredentials(request))
+	assert.True(t, detectors["oidc"].HasCredentials(request))
+	assert.False(t, detectors["session"].HasCredentials(request))
+}
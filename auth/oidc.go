@@ -0,0 +1,308 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+	"goyave.dev/goyave/v5"
+
+	errorutil "goyave.dev/goyave/v5/util/errors"
+)
+
+// OIDCProviderMetadata the OpenID Connect discovery document of an identity provider,
+// served at "{issuer}/.well-known/openid-configuration".
+type OIDCProviderMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	UserinfoEndpoint      string `json:"userinfo_endpoint,omitempty"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// OIDCProvider an external OpenID Connect identity provider. The provider metadata
+// is discovered from the issuer URL on first use, then cached for `CacheDuration`. The keys
+// used to verify the tokens are fetched from the provider's JWKS endpoint and cached by a
+// `RemoteJWKSet`.
+//
+// If the discovery fails, it is not attempted again before `MinRefreshInterval`: in the
+// meantime, the previously discovered metadata is used if any, otherwise the error of
+// the last attempt is returned.
+//
+// An `OIDCProvider` is safe for concurrent use and should be shared by the authenticators
+// and controllers using the same identity provider.
+type OIDCProvider struct {
+	metadata    *OIDCProviderMetadata
+	keySet      KeySet
+	discovery   *remoteCall[*OIDCProviderMetadata]
+	fetchedAt   time.Time
+	lastAttempt time.Time
+	lastErr     error
+	now         func() time.Time
+	mu          sync.Mutex
+
+	// Client the HTTP client used to communicate with the provider.
+	// Defaults to a client with a 10 seconds timeout.
+	Client *http.Client
+
+	// Issuer the issuer identifier of the provider. It must exactly match the "issuer"
+	// of the discovery document and the "iss" claim of the tokens.
+	Issuer string
+
+	// CacheDuration the duration during which the discovered metadata is used without
+	// discovering it again. Defaults to one hour.
+	CacheDuration time.Duration
+
+	// MinRefreshInterval the minimum duration between two discovery attempts, so
+	// a failing provider is not queried on every request. Defaults to one minute.
+	MinRefreshInterval time.Duration
+}
+
+// NewOIDCProvider create a new `OIDCProvider` for the given issuer URL.
+func NewOIDCProvider(issuer string) *OIDCProvider {
+	return &OIDCProvider{
+		Issuer:             issuer,
+		CacheDuration:      time.Hour,
+		MinRefreshInterval: time.Minute,
+	}
+}
+
+// Metadata returns the provider metadata, discovering it if it was not already or if
+// it expired. Concurrent calls share a single discovery request.
+func (p *OIDCProvider) Metadata(ctx context.Context) (*OIDCProviderMetadata, error) {
+	p.mu.Lock()
+	now := time.Now()
+	if p.now != nil {
+		now = p.now()
+	}
+	call := p.discovery
+	if call == nil {
+		fresh := p.metadata != nil && now.Sub(p.fetchedAt) < p.CacheDuration
+		throttled := !p.lastAttempt.IsZero() && now.Sub(p.lastAttempt) < p.MinRefreshInterval
+		if fresh || throttled {
+			metadata, err := p.metadata, p.lastErr
+			p.mu.Unlock()
+			if metadata != nil {
+				return metadata, nil
+			}
+			return nil, err
+		}
+		call = newRemoteCall[*OIDCProviderMetadata]()
+		p.discovery = call
+		p.lastAttempt = now
+		go p.discover(context.WithoutCancel(ctx), call, now)
+	}
+	p.mu.Unlock()
+	return call.wait(ctx)
+}
+
+// discover fetches the provider metadata. If the discovery fails but the metadata was
+// previously discovered, the previous metadata is kept and returned to the callers.
+func (p *OIDCProvider) discover(ctx context.Context, call *remoteCall[*OIDCProviderMetadata], now time.Time) {
+	metadata, err := p.fetchMetadata(ctx)
+	p.mu.Lock()
+	p.lastErr = err
+	if err == nil {
+		if p.metadata == nil || p.metadata.JWKSURI != metadata.JWKSURI {
+			keySet := NewRemoteJWKSet(metadata.JWKSURI)
+			keySet.Client = p.Client
+			p.keySet = keySet
+		}
+		p.metadata = metadata
+		p.fetchedAt = now
+	} else if p.metadata != nil {
+		metadata, err = p.metadata, nil
+	}
+	p.discovery = nil
+	p.mu.Unlock()
+	call.complete(metadata, err)
+}
+
+func (p *OIDCProvider) fetchMetadata(ctx context.Context) (*OIDCProviderMetadata, error) {
+	discoveryURL := strings.TrimSuffix(p.Issuer, "/") + "/.well-known/openid-configuration"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, discoveryURL, nil)
+	if err != nil {
+		return nil, errorutil.New(err)
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client().Do(req)
+	if err != nil {
+		return nil, errorutil.New(err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode != http.StatusOK {
+		return nil, errorutil.Errorf("auth: OIDC discovery of %q failed: unexpected status %d", p.Issuer, resp.StatusCode)
+	}
+	data, err := readRemoteDocument(resp, discoveryURL)
+	if err != nil {
+		return nil, err
+	}
+	metadata := &OIDCProviderMetadata{}
+	if err := json.Unmarshal(data, metadata); err != nil {
+		return nil, errorutil.Errorf("auth: invalid OIDC discovery document for %q: %w", p.Issuer, err)
+	}
+	if metadata.Issuer != p.Issuer {
+		return nil, errorutil.Errorf("auth: OIDC issuer mismatch: expected %q, discovered %q", p.Issuer, metadata.Issuer)
+	}
+	if metadata.JWKSURI == "" {
+		return nil, errorutil.Errorf("auth: OIDC discovery document for %q has no \"jwks_uri\"", p.Issuer)
+	}
+	return metadata, nil
+}
+
+// VerifyToken parses the given token and checks its signature using the provider's keys,
+// its issuer and its expiry. Additional validation options (such as `jwt.WithAudience`)
+// can be given. Returns the claims of the token if it is valid.
+//
+// If the keys cannot be retrieved from the provider, this method panics.
+func (p *OIDCProvider) VerifyToken(ctx context.Context, tokenString string, options ...jwt.ParserOption) (jwt.MapClaims, error) {
+	metadata, err := p.Metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	options = append([]jwt.ParserOption{jwt.WithIssuer(metadata.Issuer), jwt.WithExpirationRequired()}, options...)
+
+	claims := jwt.MapClaims{}
+	_, err = jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (any, error) {
+		return p.keyFunc(ctx, token)
+	}, options...)
+	if err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (p *OIDCProvider) keyFunc(ctx context.Context, token *jwt.Token) (any, error) {
+	kid, ok := token.Header["kid"].(string)
+	if !ok {
+		return nil, fmt.Errorf("Missing key ID")
+	}
+	p.mu.Lock()
+	keySet := p.keySet
+	p.mu.Unlock()
+
+	jwk, err := keySet.LookupKey(ctx, kid)
+	if err != nil {
+		if errors.Is(err, ErrKeyNotFound) {
+			return nil, fmt.Errorf("Unknown key ID: %q", kid)
+		}
+		panic(errorutil.New(err))
+	}
+	if jwk.Alg != "" && jwk.Alg != token.Method.Alg() {
+		return nil, fmt.Errorf("Unexpected signing method: %v", token.Header["alg"])
+	}
+	key, err := jwk.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkKeyMethod(token.Method, key); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+func (p *OIDCProvider) client() *http.Client {
+	if p.Client == nil {
+		return defaultHTTPClient
+	}
+	return p.Client
+}
+
+// OIDCAuthenticator implementation of Authenticator validating the tokens issued
+// by an external OpenID Connect identity provider and sent as bearer tokens.
+//
+// The tokens must be JWTs signed with one of the keys of the provider's JWK set, and
+// issued by the provider. The user is then retrieved from the `UserService` using the
+// value of the claim identified by `ClaimName`.
+//
+// The T parameter represents the user DTO and should not be a pointer.
+type OIDCAuthenticator[T any] struct {
+	goyave.Component
+
+	Provider *OIDCProvider
+
+	UserService UserService[T]
+
+	// Audience if not empty, the tokens are rejected if their "aud" claim
+	// doesn't contain this value. This is usually the client ID of the application.
+	Audience string
+
+	// ClaimName the name of the claim used to retrieve the user.
+	// Defaults to "sub".
+	ClaimName string
+
+	// Leeway the tolerated clock skew when validating the time-based claims.
+	Leeway time.Duration
+
+	// Optional defines if the authenticator allows requests that
+	// don't provide credentials. Handlers should therefore check
+	// if `request.User` is not `nil` before accessing it.
+	Optional bool
+}
+
+// NewOIDCAuthenticator create a new authenticator validating the tokens issued by the given provider.
+//
+// The T parameter represents the user DTO and should not be a pointer.
+func NewOIDCAuthenticator[T any](provider *OIDCProvider, userService UserService[T]) *OIDCAuthenticator[T] {
+	return &OIDCAuthenticator[T]{
+		Provider:    provider,
+		UserService: userService,
+	}
+}
+
+// HasCredentials returns true if the request has a bearer token.
+func (a *OIDCAuthenticator[T]) HasCredentials(request *goyave.Request) bool {
+	token, ok := request.BearerToken()
+	return ok && token != ""
+}
+
+// Authenticate fetch the user corresponding to the token
+// found in the given request and returns it.
+// If no user can be authenticated, returns an error.
+//
+// If the token is valid, its claims are added to `request.Extra` with the key `ExtraJWTClaims{}`.
+func (a *OIDCAuthenticator[T]) Authenticate(request *goyave.Request) (*T, error) {
+	tokenString, ok := request.BearerToken()
+	if tokenString == "" || !ok {
+		if a.Optional {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s", request.Lang.Get("auth.no-credentials-provided"))
+	}
+
+	if _, err := a.Provider.Metadata(request.Context()); err != nil {
+		panic(err)
+	}
+
+	options := []jwt.ParserOption{jwt.WithLeeway(a.Leeway)}
+	if a.Audience != "" {
+		options = append(options, jwt.WithAudience(a.Audience))
+	}
+	claims, err := a.Provider.VerifyToken(request.Context(), tokenString, options...)
+	if err != nil {
+		return nil, makeJWTError(request.Lang, err)
+	}
+	request.Extra[ExtraJWTClaims{}] = claims
+
+	claimName := a.ClaimName
+	if claimName == "" {
+		claimName = "sub"
+	}
+	user, err := a.UserService.FindByUsername(request.Context(), claims[claimName])
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%s", request.Lang.Get("auth.invalid-credentials"))
+		}
+		panic(errorutil.New(err))
+	}
+	return user, nil
+}
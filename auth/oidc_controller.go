@@ -0,0 +1,329 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"gorm.io/gorm"
+	"goyave.dev/goyave/v5"
+
+	errorutil "goyave.dev/goyave/v5/util/errors"
+)
+
+// DefaultOIDCCookieName the default prefix of the names of the cookies storing
+// the state of the OpenID Connect login flow.
+const DefaultOIDCCookieName = "goyave_oidc"
+
+// OIDCTokens the tokens returned by the identity provider at the end of
+// a successful login flow.
+type OIDCTokens struct {
+	// Claims the claims of the verified ID token.
+	Claims jwt.MapClaims `json:"-"`
+
+	AccessToken  string `json:"access_token"`
+	IDToken      string `json:"id_token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in,omitempty"`
+}
+
+// OIDCLoginFunc the function called by the `OIDCController` when a user successfully logs in.
+// It is responsible for writing the response, for example by storing the user ID in
+// the session and redirecting to the application.
+type OIDCLoginFunc[T any] func(response *goyave.Response, request *goyave.Request, user *T, tokens *OIDCTokens)
+
+// OIDCController controller implementing the OpenID Connect authorization code flow
+// with PKCE, to let users log in with an external identity provider.
+//
+// The "/login" route redirects the user to the provider's authorization endpoint. The
+// state, the nonce and the PKCE code verifier are stored in short-lived "HttpOnly"
+// cookies. The provider then redirects the user to the "/callback" route, which checks
+// the state, exchanges the authorization code for tokens, verifies the ID token and its
+// nonce, and retrieves the user from the `UserService` before calling `OnLogin`.
+//
+// The T parameter represents the user DTO and should not be a pointer.
+type OIDCController[T any] struct {
+	goyave.Component
+
+	Provider *OIDCProvider
+
+	UserService UserService[T]
+
+	// OnLogin called on successful login. If `nil`, the tokens
+	// are returned as a JSON response.
+	OnLogin OIDCLoginFunc[T]
+
+	// ClientID the client identifier of the application registered at the provider.
+	ClientID string
+
+	// ClientSecret the client secret of the application, sent to the token endpoint
+	// using HTTP basic authentication. Leave empty for public clients.
+	ClientSecret string
+
+	// RedirectURL the absolute URL of the callback route, as registered at the provider.
+	RedirectURL string
+
+	// Scopes the requested scopes. Defaults to "openid", "profile" and "email".
+	// The "openid" scope is always requested.
+	Scopes []string
+
+	// ClaimName the name of the ID token claim used to retrieve the user.
+	// Defaults to "sub".
+	ClaimName string
+
+	// CookieName the prefix of the names of the cookies storing the state of
+	// the login flow. Defaults to `DefaultOIDCCookieName`.
+	CookieName string
+
+	// CookiePath the path of the cookies. Defaults to "/".
+	CookiePath string
+
+	// StateTTL the maximum duration of the login flow. Defaults to 10 minutes.
+	StateTTL time.Duration
+}
+
+// NewOIDCController create a new `OIDCController` for the given provider and client.
+func NewOIDCController[T any](provider *OIDCProvider, userService UserService[T], clientID, clientSecret, redirectURL string) *OIDCController[T] {
+	return &OIDCController[T]{
+		Provider:     provider,
+		UserService:  userService,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		RedirectURL:  redirectURL,
+	}
+}
+
+// RegisterRoutes register the "/login" and "/callback" routes on the given router.
+func (c *OIDCController[T]) RegisterRoutes(router *goyave.Router) {
+	router.Get("/login", c.Login)
+	router.Get("/callback", c.Callback)
+}
+
+// Login GET handler starting the login flow: redirects the user to the
+// authorization endpoint of the provider.
+func (c *OIDCController[T]) Login(response *goyave.Response, request *goyave.Request) {
+	metadata, err := c.Provider.Metadata(request.Context())
+	if err != nil {
+		response.Error(err)
+		return
+	}
+
+	values := [3]string{}
+	for i := range values {
+		values[i], err = generateTokenID()
+		if err != nil {
+			response.Error(err)
+			return
+		}
+	}
+	state, nonce, verifier := values[0], values[1], values[2]
+	challenge := sha256.Sum256([]byte(verifier))
+
+	authURL, err := url.Parse(metadata.AuthorizationEndpoint)
+	if err != nil {
+		response.Error(errorutil.New(err))
+		return
+	}
+	query := authURL.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", c.ClientID)
+	query.Set("redirect_uri", c.RedirectURL)
+	query.Set("scope", strings.Join(c.scopes(), " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", base64.RawURLEncoding.EncodeToString(challenge[:]))
+	query.Set("code_challenge_method", "S256")
+	authURL.RawQuery = query.Encode()
+
+	maxAge := int(c.stateTTL().Seconds())
+	c.setCookie(response, request, "state", state, maxAge)
+	c.setCookie(response, request, "nonce", nonce, maxAge)
+	c.setCookie(response, request, "verifier", verifier, maxAge)
+	http.Redirect(response, request.Request(), authURL.String(), http.StatusFound)
+}
+
+// Callback GET handler completing the login flow. The state returned by the provider
+// must match the state cookie. The authorization code is then exchanged for tokens
+// and the ID token is verified before calling `OnLogin`.
+func (c *OIDCController[T]) Callback(response *goyave.Response, request *goyave.Request) {
+	query := request.URL().Query()
+	state := c.cookie(request, "state")
+	nonce := c.cookie(request, "nonce")
+	verifier := c.cookie(request, "verifier")
+	c.setCookie(response, request, "state", "", -1)
+	c.setCookie(response, request, "nonce", "", -1)
+	c.setCookie(response, request, "verifier", "", -1)
+
+	if providerError := query.Get("error"); providerError != "" {
+		c.Logger().WarnContext(request.Context(), "OIDC login rejected by the provider", "error", providerError, "description", query.Get("error_description"))
+		c.loginFailed(response, request)
+		return
+	}
+	if state == "" || nonce == "" || verifier == "" || query.Get("code") == "" ||
+		subtle.ConstantTimeCompare([]byte(state), []byte(query.Get("state"))) != 1 {
+		c.loginFailed(response, request)
+		return
+	}
+
+	tokens, err := c.exchangeCode(request, query.Get("code"), verifier)
+	if err != nil {
+		response.Error(err)
+		return
+	}
+	if tokens == nil {
+		c.loginFailed(response, request)
+		return
+	}
+
+	claims, err := c.Provider.VerifyToken(request.Context(), tokens.IDToken, jwt.WithAudience(c.ClientID))
+	if err != nil {
+		c.Logger().WarnContext(request.Context(), "OIDC login failed: invalid ID token", "error", err.Error())
+		c.loginFailed(response, request)
+		return
+	}
+	if claimNonce, _ := claims["nonce"].(string); subtle.ConstantTimeCompare([]byte(claimNonce), []byte(nonce)) != 1 {
+		c.Logger().WarnContext(request.Context(), "OIDC login failed: invalid ID token nonce")
+		c.loginFailed(response, request)
+		return
+	}
+	tokens.Claims = claims
+
+	claimName := c.ClaimName
+	if claimName == "" {
+		claimName = "sub"
+	}
+	user, err := c.UserService.FindByUsername(request.Context(), claims[claimName])
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			response.JSON(http.StatusUnauthorized, map[string]string{"error": request.Lang.Get("auth.invalid-credentials")})
+			return
+		}
+		response.Error(errorutil.New(err))
+		return
+	}
+
+	if c.OnLogin != nil {
+		c.OnLogin(response, request, user, tokens)
+		return
+	}
+	response.JSON(http.StatusOK, tokens)
+}
+
+// exchangeCode exchanges the authorization code for tokens at the token endpoint of the provider.
+// Returns `nil` without error if the provider rejected the code.
+func (c *OIDCController[T]) exchangeCode(request *goyave.Request, code, verifier string) (*OIDCTokens, error) {
+	metadata, err := c.Provider.Metadata(request.Context())
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{}
+	form.Set("grant_type", "authorization_code")
+	form.Set("code", code)
+	form.Set("redirect_uri", c.RedirectURL)
+	form.Set("code_verifier", verifier)
+	if c.ClientSecret == "" {
+		form.Set("client_id", c.ClientID)
+	}
+	req, err := http.NewRequestWithContext(request.Context(), http.MethodPost, metadata.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, errorutil.New(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.ClientSecret != "" {
+		req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+	}
+
+	resp, err := c.Provider.client().Do(req)
+	if err != nil {
+		return nil, errorutil.New(err)
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	data, err := readRemoteDocument(resp, metadata.TokenEndpoint)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		c.Logger().WarnContext(request.Context(), "OIDC login failed: token request rejected", "status", resp.StatusCode, "body", string(data))
+		return nil, nil
+	}
+
+	tokens := &OIDCTokens{}
+	if err := json.Unmarshal(data, tokens); err != nil {
+		return nil, errorutil.Errorf("auth: invalid OIDC token response: %w", err)
+	}
+	if tokens.IDToken == "" {
+		c.Logger().WarnContext(request.Context(), "OIDC login failed: token response has no ID token")
+		return nil, nil
+	}
+	return tokens, nil
+}
+
+func (c *OIDCController[T]) loginFailed(response *goyave.Response, request *goyave.Request) {
+	response.JSON(http.StatusUnauthorized, map[string]string{"error": request.Lang.Get("auth.oidc-login-failed")})
+}
+
+func (c *OIDCController[T]) scopes() []string {
+	scopes := c.Scopes
+	if len(scopes) == 0 {
+		scopes = []string{"openid", "profile", "email"}
+	}
+	for _, s := range scopes {
+		if s == "openid" {
+			return scopes
+		}
+	}
+	return append([]string{"openid"}, scopes...)
+}
+
+func (c *OIDCController[T]) stateTTL() time.Duration {
+	if c.StateTTL <= 0 {
+		return 10 * time.Minute
+	}
+	return c.StateTTL
+}
+
+func (c *OIDCController[T]) cookieName(name string) string {
+	prefix := c.CookieName
+	if prefix == "" {
+		prefix = DefaultOIDCCookieName
+	}
+	return prefix + "_" + name
+}
+
+func (c *OIDCController[T]) cookie(request *goyave.Request, name string) string {
+	cookieName := c.cookieName(name)
+	for _, cookie := range request.Cookies() {
+		if cookie.Name == cookieName {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+func (c *OIDCController[T]) setCookie(response *goyave.Response, request *goyave.Request, name, value string, maxAge int) {
+	path := c.CookiePath
+	if path == "" {
+		path = "/"
+	}
+	response.Cookie(&http.Cookie{
+		Name:     c.cookieName(name),
+		Value:    value,
+		Path:     path,
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   request.Scheme() == "https",
+		SameSite: http.SameSiteLaxMode,
+	})
+}
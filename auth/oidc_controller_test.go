@@ -0,0 +1,201 @@
+package auth
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/slog"
+	"goyave.dev/goyave/v5/util/testutil"
+)
+
+func TestOIDCController(t *testing.T) {
+	prepareTest := func(t *testing.T, userService UserService[TestUser]) (*testutil.TestServer, *testOIDCProvider, *OIDCController[TestUser], *bytes.Buffer) {
+		p := newTestOIDCProvider(t)
+		server, _ := prepareAuthenticatorTest(t)
+		logs := &bytes.Buffer{}
+		server.Logger = slog.New(slog.NewHandler(false, logs))
+		controller := NewOIDCController(p.newProvider(), userService, "client", "secret", "http://app.example.org/auth/callback")
+		server.RegisterRoutes(func(_ *goyave.Server, router *goyave.Router) {
+			router.Subrouter("/auth").Controller(controller)
+		})
+		return server, p, controller, logs
+	}
+
+	login := func(t *testing.T, server *testutil.TestServer) (url.Values, []*http.Cookie) {
+		resp := server.TestRequest(httptest.NewRequest(http.MethodGet, "/auth/login", nil))
+		assert.NoError(t, resp.Body.Close())
+		require.Equal(t, http.StatusFound, resp.StatusCode)
+		location, err := url.Parse(resp.Header.Get("Location"))
+		require.NoError(t, err)
+		return location.Query(), resp.Cookies()
+	}
+
+	callback := func(server *testutil.TestServer, query url.Values, cookies []*http.Cookie) (*http.Response, map[string]any) {
+		request := httptest.NewRequest(http.MethodGet, "/auth/callback?"+query.Encode(), nil)
+		for _, c := range cookies {
+			request.AddCookie(&http.Cookie{Name: c.Name, Value: c.Value})
+		}
+		resp := server.TestRequest(request)
+		body, err := testutil.ReadJSONBody[map[string]any](resp.Body)
+		if err != nil {
+			body = nil
+		}
+		_ = resp.Body.Close()
+		return resp, body
+	}
+
+	t.Run("login", func(t *testing.T) {
+		server, _, controller, _ := prepareTest(t, &MockUserService[TestUser]{})
+		controller.Scopes = []string{"email"}
+		query, cookies := login(t, server)
+
+		assert.Equal(t, "login", query.Get("prompt"))
+		assert.Equal(t, "code", query.Get("response_type"))
+		assert.Equal(t, "client", query.Get("client_id"))
+		assert.Equal(t, "http://app.example.org/auth/callback", query.Get("redirect_uri"))
+		assert.Equal(t, "openid email", query.Get("scope"))
+		assert.Equal(t, "S256", query.Get("code_challenge_method"))
+		assert.NotEmpty(t, query.Get("code_challenge"))
+		assert.NotEmpty(t, query.Get("state"))
+		assert.NotEmpty(t, query.Get("nonce"))
+
+		values := map[string]string{}
+		for _, c := range cookies {
+			values[c.Name] = c.Value
+			assert.True(t, c.HttpOnly)
+			assert.Equal(t, "/", c.Path)
+			assert.Equal(t, 600, c.MaxAge)
+			assert.Equal(t, http.SameSiteLaxMode, c.SameSite)
+		}
+		assert.Equal(t, query.Get("state"), values["goyave_oidc_state"])
+		assert.Equal(t, query.Get("nonce"), values["goyave_oidc_nonce"])
+		assert.NotEmpty(t, values["goyave_oidc_verifier"])
+	})
+
+	t.Run("callback", func(t *testing.T) {
+		_, user := prepareAuthenticatorTest(t)
+		server, p, _, _ := prepareTest(t, &MockUserService[TestUser]{user: user})
+		query, cookies := login(t, server)
+		p.authorize("code", testOIDCCode{challenge: query.Get("code_challenge"), nonce: query.Get("nonce"), subject: "provider-id"})
+
+		resp, body := callback(server, url.Values{"code": {"code"}, "state": {query.Get("state")}}, cookies)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "access-token", body["access_token"])
+		assert.NotEmpty(t, body["id_token"])
+		assert.Equal(t, "Bearer", body["token_type"])
+		assert.Equal(t, 3600.0, body["expires_in"])
+		for _, c := range resp.Cookies() {
+			assert.Equal(t, -1, c.MaxAge, c.Name)
+		}
+	})
+
+	t.Run("on_login", func(t *testing.T) {
+		_, user := prepareAuthenticatorTest(t)
+		server, p, controller, _ := prepareTest(t, &MockUserService[TestUser]{user: user})
+		controller.CookieName = "sso"
+		controller.OnLogin = func(response *goyave.Response, _ *goyave.Request, u *TestUser, tokens *OIDCTokens) {
+			assert.Equal(t, user, u)
+			assert.Equal(t, "provider-id", tokens.Claims["sub"])
+			response.Status(http.StatusNoContent)
+		}
+		query, cookies := login(t, server)
+		require.Len(t, cookies, 3)
+		assert.Equal(t, "sso_state", cookies[0].Name)
+		p.authorize("code", testOIDCCode{challenge: query.Get("code_challenge"), nonce: query.Get("nonce"), subject: "provider-id"})
+
+		resp, _ := callback(server, url.Values{"code": {"code"}, "state": {query.Get("state")}}, cookies)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	})
+
+	t.Run("invalid_state", func(t *testing.T) {
+		_, user := prepareAuthenticatorTest(t)
+		server, p, _, _ := prepareTest(t, &MockUserService[TestUser]{user: user})
+		query, cookies := login(t, server)
+		p.authorize("code", testOIDCCode{challenge: query.Get("code_challenge"), nonce: query.Get("nonce"), subject: "provider-id"})
+
+		resp, body := callback(server, url.Values{"code": {"code"}, "state": {"wrong"}}, cookies)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, map[string]any{"error": server.Lang.GetDefault().Get("auth.oidc-login-failed")}, body)
+
+		resp, _ = callback(server, url.Values{"code": {"code"}, "state": {query.Get("state")}}, nil)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		resp, _ = callback(server, url.Values{"state": {query.Get("state")}}, cookies)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("provider_error", func(t *testing.T) {
+		server, _, _, logs := prepareTest(t, &MockUserService[TestUser]{})
+		query, cookies := login(t, server)
+		resp, _ := callback(server, url.Values{"error": {"access_denied"}, "state": {query.Get("state")}}, cookies)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Contains(t, logs.String(), `"msg":"OIDC login rejected by the provider","error":"access_denied"`)
+	})
+
+	t.Run("invalid_code", func(t *testing.T) {
+		server, _, _, logs := prepareTest(t, &MockUserService[TestUser]{})
+		query, cookies := login(t, server)
+		resp, _ := callback(server, url.Values{"code": {"unknown"}, "state": {query.Get("state")}}, cookies)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Contains(t, logs.String(), "OIDC login failed: token request rejected")
+	})
+
+	t.Run("invalid_code_verifier", func(t *testing.T) {
+		server, p, _, _ := prepareTest(t, &MockUserService[TestUser]{})
+		query, cookies := login(t, server)
+		p.authorize("code", testOIDCCode{challenge: query.Get("code_challenge"), nonce: query.Get("nonce"), subject: "provider-id"})
+		for _, c := range cookies {
+			if c.Name == "goyave_oidc_verifier" {
+				c.Value = "wrong"
+			}
+		}
+		resp, _ := callback(server, url.Values{"code": {"code"}, "state": {query.Get("state")}}, cookies)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("invalid_client_secret", func(t *testing.T) {
+		server, p, controller, _ := prepareTest(t, &MockUserService[TestUser]{})
+		controller.ClientSecret = "wrong"
+		query, cookies := login(t, server)
+		p.authorize("code", testOIDCCode{challenge: query.Get("code_challenge"), nonce: query.Get("nonce"), subject: "provider-id"})
+		resp, _ := callback(server, url.Values{"code": {"code"}, "state": {query.Get("state")}}, cookies)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("invalid_nonce", func(t *testing.T) {
+		server, p, _, logs := prepareTest(t, &MockUserService[TestUser]{})
+		query, cookies := login(t, server)
+		p.authorize("code", testOIDCCode{challenge: query.Get("code_challenge"), nonce: "other", subject: "provider-id"})
+		resp, _ := callback(server, url.Values{"code": {"code"}, "state": {query.Get("state")}}, cookies)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Contains(t, logs.String(), "OIDC login failed: invalid ID token nonce")
+	})
+
+	t.Run("user_not_found", func(t *testing.T) {
+		server, p, _, _ := prepareTest(t, &MockUserService[TestUser]{err: gorm.ErrRecordNotFound})
+		query, cookies := login(t, server)
+		p.authorize("code", testOIDCCode{challenge: query.Get("code_challenge"), nonce: query.Get("nonce"), subject: "provider-id"})
+		resp, body := callback(server, url.Values{"code": {"code"}, "state": {query.Get("state")}}, cookies)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, map[string]any{"error": server.Lang.GetDefault().Get("auth.invalid-credentials")}, body)
+	})
+
+	t.Run("state_ttl", func(t *testing.T) {
+		server, _, controller, _ := prepareTest(t, &MockUserService[TestUser]{})
+		controller.StateTTL = time.Minute
+		controller.CookiePath = "/auth"
+		_, cookies := login(t, server)
+		for _, c := range cookies {
+			assert.Equal(t, 60, c.MaxAge)
+			assert.Equal(t, "/auth", c.Path)
+		}
+	})
+}
@@ -0,0 +1,395 @@
+package auth
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/util/testutil"
+)
+
+type testOIDCCode struct {
+	challenge string
+	nonce     string
+	subject   string
+}
+
+// testOIDCProvider a minimal OpenID Connect identity provider.
+type testOIDCProvider struct {
+	server      *httptest.Server
+	key         *ecdsa.PrivateKey
+	codes       map[string]testOIDCCode
+	metadata    map[string]any
+	discoveries atomic.Int32
+	mu          sync.Mutex
+}
+
+func newTestOIDCProvider(t *testing.T) *testOIDCProvider {
+	p := &testOIDCProvider{
+		key:   generateTestECDSAKey(t),
+		codes: map[string]testOIDCCode{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		p.discoveries.Add(1)
+		p.mu.Lock()
+		metadata := p.metadata
+		p.mu.Unlock()
+		if metadata == nil {
+			metadata = map[string]any{
+				"issuer":                 p.server.URL,
+				"authorization_endpoint": p.server.URL + "/authorize?prompt=login",
+				"token_endpoint":         p.server.URL + "/token",
+				"jwks_uri":               p.server.URL + "/jwks",
+			}
+		}
+		_ = json.NewEncoder(w).Encode(metadata)
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		jwk, err := NewJWK("test-key", "ES256", p.key)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(&JWKSet{Keys: []*JWK{jwk}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		clientID, clientSecret, ok := r.BasicAuth()
+		if !ok || clientID != "client" || clientSecret != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		}
+		p.mu.Lock()
+		code, ok := p.codes[r.PostFormValue("code")]
+		delete(p.codes, r.PostFormValue("code"))
+		p.mu.Unlock()
+		challenge := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+		if !ok || r.PostFormValue("grant_type") != "authorization_code" ||
+			r.PostFormValue("redirect_uri") != "http://app.example.org/auth/callback" ||
+			base64.RawURLEncoding.EncodeToString(challenge[:]) != code.challenge {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		idToken := p.sign(jwt.MapClaims{"sub": code.subject, "aud": "client", "nonce": code.nonce})
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "access-token",
+			"id_token":     idToken,
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		})
+	})
+	p.server = httptest.NewServer(mux)
+	t.Cleanup(p.server.Close)
+	return p
+}
+
+// authorize simulates the approval of the authorization request by the user.
+func (p *testOIDCProvider) authorize(code string, c testOIDCCode) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.codes[code] = c
+}
+
+func (p *testOIDCProvider) sign(claims jwt.MapClaims) string {
+	c := jwt.MapClaims{
+		"iss": p.server.URL,
+		"exp": time.Now().Add(time.Hour).Unix(),
+		"iat": time.Now().Unix(),
+	}
+	for k, v := range claims {
+		c[k] = v
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, c)
+	token.Header["kid"] = "test-key"
+	tokenString, err := token.SignedString(p.key)
+	if err != nil {
+		panic(err)
+	}
+	return tokenString
+}
+
+func (p *testOIDCProvider) newProvider() *OIDCProvider {
+	provider := NewOIDCProvider(p.server.URL)
+	provider.Client = p.server.Client()
+	return provider
+}
+
+func TestOIDCProvider(t *testing.T) {
+	t.Run("discovery", func(t *testing.T) {
+		p := newTestOIDCProvider(t)
+		provider := p.newProvider()
+
+		metadata, err := provider.Metadata(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, &OIDCProviderMetadata{
+			Issuer:                p.server.URL,
+			AuthorizationEndpoint: p.server.URL + "/authorize?prompt=login",
+			TokenEndpoint:         p.server.URL + "/token",
+			JWKSURI:               p.server.URL + "/jwks",
+		}, metadata)
+
+		_, err = provider.Metadata(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), p.discoveries.Load())
+	})
+
+	t.Run("concurrent_discovery", func(t *testing.T) {
+		p := newTestOIDCProvider(t)
+		provider := p.newProvider()
+		wg := sync.WaitGroup{}
+		for range 10 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := provider.Metadata(context.Background())
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), p.discoveries.Load())
+	})
+
+	t.Run("invalid_discovery", func(t *testing.T) {
+		p := newTestOIDCProvider(t)
+		cases := []map[string]any{
+			{"issuer": "https://other.example.org", "jwks_uri": p.server.URL + "/jwks"},
+			{"issuer": p.server.URL},
+			{"issuer": 1},
+		}
+		for _, c := range cases {
+			p.mu.Lock()
+			p.metadata = c
+			p.mu.Unlock()
+			_, err := p.newProvider().Metadata(context.Background())
+			require.Error(t, err, c)
+		}
+
+		provider := NewOIDCProvider(p.server.URL + "/notfound")
+		provider.Client = p.server.Client()
+		_, err := provider.Metadata(context.Background())
+		require.Error(t, err)
+	})
+
+	t.Run("discovery_error_backoff", func(t *testing.T) {
+		p := newTestOIDCProvider(t)
+		p.mu.Lock()
+		p.metadata = map[string]any{"issuer": p.server.URL}
+		p.mu.Unlock()
+		now := time.Now()
+		provider := p.newProvider()
+		provider.now = func() time.Time { return now }
+
+		_, err := provider.Metadata(context.Background())
+		require.Error(t, err)
+		_, err2 := provider.Metadata(context.Background())
+		require.Equal(t, err, err2)
+		assert.Equal(t, int32(1), p.discoveries.Load())
+
+		p.mu.Lock()
+		p.metadata = nil
+		p.mu.Unlock()
+		now = now.Add(time.Minute)
+		metadata, err := provider.Metadata(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, p.server.URL, metadata.Issuer)
+		assert.Equal(t, int32(2), p.discoveries.Load())
+	})
+
+	t.Run("metadata_expiry", func(t *testing.T) {
+		p := newTestOIDCProvider(t)
+		now := time.Now()
+		provider := p.newProvider()
+		provider.now = func() time.Time { return now }
+
+		metadata, err := provider.Metadata(context.Background())
+		require.NoError(t, err)
+		keySet := provider.keySet
+
+		now = now.Add(59 * time.Minute)
+		_, err = provider.Metadata(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int32(1), p.discoveries.Load())
+
+		now = now.Add(time.Minute)
+		refreshed, err := provider.Metadata(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int32(2), p.discoveries.Load())
+		assert.NotSame(t, metadata, refreshed)
+		assert.Same(t, keySet, provider.keySet, "the key set is kept if the JWKS URI didn't change")
+
+		// The refresh fails: the previous metadata is kept and the discovery is throttled
+		p.mu.Lock()
+		p.metadata = map[string]any{"issuer": "https://other.example.org", "jwks_uri": p.server.URL + "/jwks"}
+		p.mu.Unlock()
+		now = now.Add(time.Hour)
+		stale, err := provider.Metadata(context.Background())
+		require.NoError(t, err)
+		assert.Same(t, refreshed, stale)
+		_, err = provider.Metadata(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, int32(3), p.discoveries.Load())
+
+		// The JWKS URI changed: the key set is replaced
+		p.mu.Lock()
+		p.metadata = map[string]any{"issuer": p.server.URL, "jwks_uri": p.server.URL + "/jwks?v=2"}
+		p.mu.Unlock()
+		now = now.Add(time.Minute)
+		refreshed, err = provider.Metadata(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, p.server.URL+"/jwks?v=2", refreshed.JWKSURI)
+		assert.NotSame(t, keySet, provider.keySet)
+		assert.Equal(t, int32(4), p.discoveries.Load())
+	})
+
+	t.Run("verify_token", func(t *testing.T) {
+		p := newTestOIDCProvider(t)
+		provider := p.newProvider()
+
+		claims, err := provider.VerifyToken(context.Background(), p.sign(jwt.MapClaims{"sub": "johndoe"}))
+		require.NoError(t, err)
+		assert.Equal(t, "johndoe", claims["sub"])
+
+		_, err = provider.VerifyToken(context.Background(), p.sign(jwt.MapClaims{"sub": "johndoe", "iss": "other"}))
+		require.ErrorIs(t, err, jwt.ErrTokenInvalidIssuer)
+
+		_, err = provider.VerifyToken(context.Background(), p.sign(jwt.MapClaims{"sub": "johndoe", "exp": nil}))
+		require.Error(t, err)
+
+		token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{"iss": p.server.URL, "exp": time.Now().Add(time.Hour).Unix()})
+		tokenString, err := token.SignedString(p.key)
+		require.NoError(t, err)
+		_, err = provider.VerifyToken(context.Background(), tokenString)
+		require.ErrorIs(t, err, jwt.ErrTokenUnverifiable)
+
+		token.Header["kid"] = "unknown"
+		tokenString, err = token.SignedString(p.key)
+		require.NoError(t, err)
+		_, err = provider.VerifyToken(context.Background(), tokenString)
+		require.ErrorIs(t, err, jwt.ErrTokenUnverifiable)
+
+		token = jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"iss": p.server.URL, "exp": time.Now().Add(time.Hour).Unix()})
+		token.Header["kid"] = "test-key"
+		tokenString, err = token.SignedString([]byte("secret"))
+		require.NoError(t, err)
+		_, err = provider.VerifyToken(context.Background(), tokenString)
+		require.Error(t, err)
+	})
+}
+
+func TestOIDCAuthenticator(t *testing.T) {
+	authenticate := func(server *testutil.TestServer, authenticator goyave.Middleware, token string) (*http.Response, map[string]string) {
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		if token != "" {
+			request.Request().Header.Set("Authorization", "Bearer "+token)
+		}
+		request.Route = &goyave.Route{Meta: map[string]any{MetaAuth: true}}
+		resp := server.TestMiddleware(authenticator, request, func(response *goyave.Response, request *goyave.Request) {
+			if user, _ := request.User.(*TestUser); user != nil {
+				response.JSON(http.StatusOK, map[string]string{"email": user.Email, "sub": fmt.Sprint(request.Extra[ExtraJWTClaims{}].(jwt.MapClaims)["sub"])})
+				return
+			}
+			response.JSON(http.StatusOK, map[string]string{})
+		})
+		body, err := testutil.ReadJSONBody[map[string]string](resp.Body)
+		if err != nil {
+			body = nil
+		}
+		_ = resp.Body.Close()
+		return resp, body
+	}
+
+	t.Run("success", func(t *testing.T) {
+		p := newTestOIDCProvider(t)
+		server, user := prepareAuthenticatorTest(t)
+		authenticator := Middleware(NewOIDCAuthenticator(p.newProvider(), &MockUserService[TestUser]{user: user}))
+
+		resp, body := authenticate(server, authenticator, p.sign(jwt.MapClaims{"sub": "provider-id"}))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, map[string]string{"email": user.Email, "sub": "provider-id"}, body)
+	})
+
+	t.Run("audience", func(t *testing.T) {
+		p := newTestOIDCProvider(t)
+		server, user := prepareAuthenticatorTest(t)
+		a := NewOIDCAuthenticator(p.newProvider(), &MockUserService[TestUser]{user: user})
+		a.Audience = "client"
+		authenticator := Middleware(a)
+
+		resp, _ := authenticate(server, authenticator, p.sign(jwt.MapClaims{"sub": "provider-id", "aud": []string{"client", "other"}}))
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+		resp, body := authenticate(server, authenticator, p.sign(jwt.MapClaims{"sub": "provider-id", "aud": "other"}))
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, map[string]string{"error": server.Lang.GetDefault().Get("auth.jwt-invalid")}, body)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		p := newTestOIDCProvider(t)
+		server, user := prepareAuthenticatorTest(t)
+		a := NewOIDCAuthenticator(p.newProvider(), &MockUserService[TestUser]{user: user})
+		authenticator := Middleware(a)
+
+		token := p.sign(jwt.MapClaims{"sub": "provider-id", "exp": time.Now().Add(-10 * time.Second).Unix()})
+		resp, body := authenticate(server, authenticator, token)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, map[string]string{"error": server.Lang.GetDefault().Get("auth.jwt-expired")}, body)
+
+		a.Leeway = time.Minute
+		resp, _ = authenticate(server, authenticator, token)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("no_credentials", func(t *testing.T) {
+		p := newTestOIDCProvider(t)
+		server, user := prepareAuthenticatorTest(t)
+		a := NewOIDCAuthenticator(p.newProvider(), &MockUserService[TestUser]{user: user})
+		authenticator := Middleware(a)
+
+		resp, body := authenticate(server, authenticator, "")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, map[string]string{"error": server.Lang.GetDefault().Get("auth.no-credentials-provided")}, body)
+
+		a.Optional = true
+		resp, body = authenticate(server, authenticator, "")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Empty(t, body)
+		assert.Equal(t, int32(0), p.discoveries.Load())
+	})
+
+	t.Run("user_not_found", func(t *testing.T) {
+		p := newTestOIDCProvider(t)
+		server, _ := prepareAuthenticatorTest(t)
+		authenticator := Middleware(NewOIDCAuthenticator(p.newProvider(), &MockUserService[TestUser]{err: gorm.ErrRecordNotFound}))
+
+		resp, body := authenticate(server, authenticator, p.sign(jwt.MapClaims{"sub": "provider-id"}))
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.Equal(t, map[string]string{"error": server.Lang.GetDefault().Get("auth.invalid-credentials")}, body)
+	})
+
+	t.Run("discovery_error", func(t *testing.T) {
+		p := newTestOIDCProvider(t)
+		server, user := prepareAuthenticatorTest(t)
+		provider := NewOIDCProvider(p.server.URL + "/notfound")
+		provider.Client = p.server.Client()
+		authenticator := Middleware(NewOIDCAuthenticator(provider, &MockUserService[TestUser]{user: user}))
+
+		resp, _ := authenticate(server, authenticator, p.sign(jwt.MapClaims{"sub": "provider-id"}))
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	})
+}
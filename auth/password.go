@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"context"
+	"reflect"
+	"sync"
+
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/auth/hash"
+	errorutil "goyave.dev/goyave/v5/util/errors"
+)
+
+// RehashFunc the function called on successful login when the stored password hash of the
+// user was created with an outdated algorithm or outdated parameters. It should persist
+// the given new hash of the password for the user.
+//
+// If it returns an error, the error is logged but the login still succeeds.
+type RehashFunc[T any] func(ctx context.Context, user *T, hash string) error
+
+var defaultHasher = hash.Default()
+
+// dummyPassword the password of the dummy hashes compared when the user doesn't exist.
+const dummyPassword = "goyave-dummy-password"
+
+// dummyHashes caches a hash of `dummyPassword` for each hasher used by the login flows.
+var dummyHashes sync.Map
+
+// passwordVerifier checks the user passwords in the login flows using a `hash.Hasher`.
+type passwordVerifier[T any] struct {
+	hasher   hash.Hasher
+	onRehash RehashFunc[T]
+	field    string
+}
+
+// verify returns true if the given password matches the hash stored in the password field
+// of the given user. If the stored hash needs to be upgraded, `onRehash` is called.
+// Returns an error if the password field doesn't exist.
+func (v passwordVerifier[T]) verify(component *goyave.Component, request *goyave.Request, user *T, password string) (bool, error) {
+	t := reflect.Indirect(reflect.ValueOf(user))
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	pass := t.FieldByName(v.field)
+	if pass.Kind() == reflect.Invalid {
+		return false, errorutil.Errorf("could not find valid field/column %q in type %T", v.field, user)
+	}
+	stored := pass.String()
+
+	hasher := v.getHasher()
+	ok, err := hasher.Compare(stored, password)
+	if err != nil || !ok {
+		return false, nil
+	}
+
+	if v.onRehash != nil && hasher.NeedsRehash(stored) {
+		newHash, err := hasher.Hash(password)
+		if err == nil {
+			err = v.onRehash(request.Context(), user, newHash)
+		}
+		if err != nil {
+			component.Logger().ErrorCtx(request.Context(), errorutil.New(err))
+		}
+	}
+	return true, nil
+}
+
+// verifyDummy compares the given password against a dummy hash so the login flows take
+// about the same time whether the user exists or not, preventing user enumeration.
+//
+// The dummy hash is computed once per hasher, unless the hasher is not comparable.
+func (v passwordVerifier[T]) verifyDummy(password string) {
+	hasher := v.getHasher()
+	cacheable := reflect.TypeOf(hasher).Comparable()
+	if cacheable {
+		if stored, ok := dummyHashes.Load(hasher); ok {
+			_, _ = hasher.Compare(stored.(string), password)
+			return
+		}
+	}
+	stored, err := hasher.Hash(dummyPassword)
+	if err != nil {
+		return
+	}
+	if cacheable {
+		dummyHashes.Store(hasher, stored)
+	}
+	_, _ = hasher.Compare(stored, password)
+}
+
+func (v passwordVerifier[T]) getHasher() hash.Hasher {
+	if v.hasher == nil {
+		return defaultHasher
+	}
+	return v.hasher
+}
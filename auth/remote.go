@@ -0,0 +1,61 @@
+package auth
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	errorutil "goyave.dev/goyave/v5/util/errors"
+)
+
+// MaxRemoteDocumentSize the maximum size (in bytes) of the documents fetched from
+// identity providers: JWK sets, OpenID Connect discovery documents and token responses.
+const MaxRemoteDocumentSize = 1 << 20
+
+// defaultHTTPClient the client used to communicate with identity providers
+// when none is provided.
+var defaultHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// remoteCall a fetch of a remote document shared by all the concurrent callers
+// needing it, so only one request is sent and no lock is held during the request.
+type remoteCall[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+func newRemoteCall[T any]() *remoteCall[T] {
+	return &remoteCall[T]{done: make(chan struct{})}
+}
+
+// complete stores the result of the fetch and wakes up the waiting callers.
+func (c *remoteCall[T]) complete(value T, err error) {
+	c.value = value
+	c.err = err
+	close(c.done)
+}
+
+// wait for the fetch to complete, or for the given context to be canceled.
+func (c *remoteCall[T]) wait(ctx context.Context) (T, error) {
+	select {
+	case <-c.done:
+		return c.value, c.err
+	case <-ctx.Done():
+		var zero T
+		return zero, errorutil.New(ctx.Err())
+	}
+}
+
+// readRemoteDocument reads the body of the given response to a request sent to the
+// given URL, returning an error if it exceeds `MaxRemoteDocumentSize`.
+func readRemoteDocument(resp *http.Response, url string) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxRemoteDocumentSize+1))
+	if err != nil {
+		return nil, errorutil.New(err)
+	}
+	if len(data) > MaxRemoteDocumentSize {
+		return nil, errorutil.Errorf("auth: response from %q exceeds %d bytes", url, MaxRemoteDocumentSize)
+	}
+	return data, nil
+}
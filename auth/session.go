@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/httpsession"
+	errorutil "goyave.dev/goyave/v5/util/errors"
+)
+
+// DefaultSessionKey the default session key holding the identifier of the authenticated user.
+const DefaultSessionKey = "auth.user"
+
+// SessionAuthenticator implementation of Authenticator reading the identifier
+// of the authenticated user from the HTTP session (see `goyave.Router.Session()`).
+//
+// The identifier is passed to `UserService.FindByUsername()`. Because session values are
+// serialized as JSON, numeric identifiers are received as `float64`.
+//
+// The T parameter represents the user DTO and should not be a pointer.
+type SessionAuthenticator[T any] struct {
+	goyave.Component
+
+	UserService UserService[T]
+
+	// Key the session key holding the identifier of the authenticated user.
+	// Defaults to `DefaultSessionKey`.
+	Key string
+
+	// Optional defines if the authenticator allows requests that
+	// don't have an authenticated session. Handlers should therefore check
+	// if `request.User` is not `nil` before accessing it.
+	Optional bool
+}
+
+// NewSessionAuthenticator create a new authenticator reading the identifier
+// of the authenticated user from the HTTP session.
+//
+// The T parameter represents the user DTO and should not be a pointer.
+func NewSessionAuthenticator[T any](userService UserService[T]) *SessionAuthenticator[T] {
+	return &SessionAuthenticator[T]{
+		UserService: userService,
+	}
+}
+
+// HasCredentials returns true if the session of the request contains a user identifier.
+func (a *SessionAuthenticator[T]) HasCredentials(request *goyave.Request) bool {
+	session := request.Session()
+	if session == nil {
+		return false
+	}
+	_, ok := session.Get(a.getKey())
+	return ok
+}
+
+// Authenticate fetch the user corresponding to the identifier stored in
+// the session of the given request and returns it.
+// If no user can be authenticated, returns an error.
+// Panics if sessions are not enabled for the matched route.
+func (a *SessionAuthenticator[T]) Authenticate(request *goyave.Request) (*T, error) {
+	session := a.getSession(request)
+	id, ok := session.Get(a.getKey())
+	if !ok {
+		if a.Optional {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s", request.Lang.Get("auth.no-credentials-provided"))
+	}
+
+	user, err := a.UserService.FindByUsername(request.Context(), id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%s", request.Lang.Get("auth.invalid-credentials"))
+		}
+		panic(errorutil.New(err))
+	}
+	return user, nil
+}
+
+// Login stores the given user identifier in the session of the given request.
+// The session ID is regenerated to prevent session fixation attacks.
+// Panics if sessions are not enabled for the matched route.
+func (a *SessionAuthenticator[T]) Login(request *goyave.Request, id any) error {
+	session := a.getSession(request)
+	if err := session.Regenerate(); err != nil {
+		return errorutil.New(err)
+	}
+	session.Set(a.getKey(), id)
+	return nil
+}
+
+// Logout removes the user identifier from the session of the given request.
+// The session ID is regenerated to prevent session fixation attacks.
+// Panics if sessions are not enabled for the matched route.
+func (a *SessionAuthenticator[T]) Logout(request *goyave.Request) error {
+	session := a.getSession(request)
+	session.Delete(a.getKey())
+	return errorutil.New(session.Regenerate())
+}
+
+func (a *SessionAuthenticator[T]) getSession(request *goyave.Request) *httpsession.Session {
+	session := request.Session()
+	if session == nil {
+		panic(errorutil.NewSkip(fmt.Errorf("auth.SessionAuthenticator: sessions are not enabled for this route"), 4))
+	}
+	return session
+}
+
+func (a *SessionAuthenticator[T]) getKey() string {
+	if a.Key == "" {
+		return DefaultSessionKey
+	}
+	return a.Key
+}
@@ -0,0 +1,154 @@
+package auth
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/gorm"
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/httpsession"
+	"goyave.dev/goyave/v5/slog"
+	"goyave.dev/goyave/v5/util/testutil"
+)
+
+func prepareSessionAuthenticatorTest(t *testing.T, authenticator *SessionAuthenticator[TestUser]) (*testutil.TestServer, *goyave.Router) {
+	server, _ := prepareAuthenticatorTest(t)
+	router := goyave.NewRouter(server.Server)
+	router.Session(httpsession.Default())
+	router.Post("/login", func(response *goyave.Response, request *goyave.Request) {
+		require.NoError(t, authenticator.Login(request, 1))
+		response.Status(http.StatusNoContent)
+	})
+	router.Post("/logout", func(response *goyave.Response, request *goyave.Request) {
+		require.NoError(t, authenticator.Logout(request))
+		response.Status(http.StatusNoContent)
+	})
+	router.Get("/protected", func(response *goyave.Response, request *goyave.Request) {
+		user, _ := request.User.(*TestUser)
+		if user == nil {
+			response.String(http.StatusOK, "guest")
+			return
+		}
+		response.String(http.StatusOK, user.Name)
+	}).SetMeta(MetaAuth, true).Middleware(Middleware(authenticator))
+	return server, router
+}
+
+func serveSessionAuthenticatorTest(router *goyave.Router, method, path string, cookie *http.Cookie) (*http.Response, *http.Cookie) {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(method, path, nil)
+	if cookie != nil {
+		request.AddCookie(cookie)
+	}
+	router.ServeHTTP(recorder, request)
+	resp := recorder.Result()
+	for _, c := range resp.Cookies() {
+		if c.Name == httpsession.DefaultCookieName {
+			cookie = c
+		}
+	}
+	return resp, cookie
+}
+
+func TestSessionAuthenticator(t *testing.T) {
+	t.Run("login_logout", func(t *testing.T) {
+		user := &TestUser{Name: "johndoe"}
+		authenticator := NewSessionAuthenticator[TestUser](&MockUserService[TestUser]{user: user})
+		_, router := prepareSessionAuthenticatorTest(t, authenticator)
+
+		resp, cookie := serveSessionAuthenticatorTest(router, http.MethodPost, "/login", nil)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+		require.NotNil(t, cookie)
+
+		resp, cookie = serveSessionAuthenticatorTest(router, http.MethodGet, "/protected", cookie)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, resp.Body.Close())
+		require.NoError(t, err)
+		assert.Equal(t, "johndoe", string(body))
+
+		resp, cookie = serveSessionAuthenticatorTest(router, http.MethodPost, "/logout", cookie)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+
+		resp, _ = serveSessionAuthenticatorTest(router, http.MethodGet, "/protected", cookie)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("no_session_value", func(t *testing.T) {
+		authenticator := NewSessionAuthenticator[TestUser](&MockUserService[TestUser]{})
+		server, router := prepareSessionAuthenticatorTest(t, authenticator)
+
+		resp, _ := serveSessionAuthenticatorTest(router, http.MethodGet, "/protected", nil)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		body, err := testutil.ReadJSONBody[map[string]string](resp.Body)
+		assert.NoError(t, resp.Body.Close())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"error": server.Lang.GetDefault().Get("auth.no-credentials-provided")}, body)
+	})
+
+	t.Run("optional", func(t *testing.T) {
+		authenticator := NewSessionAuthenticator[TestUser](&MockUserService[TestUser]{})
+		authenticator.Optional = true
+		_, router := prepareSessionAuthenticatorTest(t, authenticator)
+
+		resp, _ := serveSessionAuthenticatorTest(router, http.MethodGet, "/protected", nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := io.ReadAll(resp.Body)
+		assert.NoError(t, resp.Body.Close())
+		require.NoError(t, err)
+		assert.Equal(t, "guest", string(body))
+	})
+
+	t.Run("user_not_found", func(t *testing.T) {
+		authenticator := NewSessionAuthenticator[TestUser](&MockUserService[TestUser]{err: gorm.ErrRecordNotFound})
+		authenticator.Key = "custom_key"
+		server, router := prepareSessionAuthenticatorTest(t, authenticator)
+
+		_, cookie := serveSessionAuthenticatorTest(router, http.MethodPost, "/login", nil)
+		resp, _ := serveSessionAuthenticatorTest(router, http.MethodGet, "/protected", cookie)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+		body, err := testutil.ReadJSONBody[map[string]string](resp.Body)
+		assert.NoError(t, resp.Body.Close())
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"error": server.Lang.GetDefault().Get("auth.invalid-credentials")}, body)
+	})
+
+	t.Run("service_error", func(t *testing.T) {
+		authenticator := NewSessionAuthenticator[TestUser](&MockUserService[TestUser]{err: fmt.Errorf("service_error")})
+		server, router := prepareSessionAuthenticatorTest(t, authenticator)
+		buf := &bytes.Buffer{}
+		server.Logger = slog.New(slog.NewHandler(false, buf))
+
+		_, cookie := serveSessionAuthenticatorTest(router, http.MethodPost, "/login", nil)
+		resp, _ := serveSessionAuthenticatorTest(router, http.MethodGet, "/protected", cookie)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+		assert.Contains(t, buf.String(), "service_error")
+	})
+
+	t.Run("sessions_disabled", func(t *testing.T) {
+		server, _ := prepareAuthenticatorTest(t)
+		authenticator := NewSessionAuthenticator[TestUser](&MockUserService[TestUser]{})
+		authenticator.Init(server.Server)
+
+		request := server.NewTestRequest(http.MethodGet, "/protected", nil)
+		assert.Panics(t, func() {
+			_, _ = authenticator.Authenticate(request)
+		})
+		assert.Panics(t, func() {
+			_ = authenticator.Login(request, 1)
+		})
+		assert.Panics(t, func() {
+			_ = authenticator.Logout(request)
+		})
+	})
+}
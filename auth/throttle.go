@@ -0,0 +1,367 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"goyave.dev/goyave/v5"
+	errorutil "goyave.dev/goyave/v5/util/errors"
+)
+
+// LoginAttempts the state of the failed login attempts for a throttling key.
+type LoginAttempts struct {
+	// LastFailure the time of the last failed attempt.
+	LastFailure time.Time
+
+	// LockedUntil the time until which all the attempts are rejected.
+	LockedUntil time.Time
+
+	// Failures the number of consecutive failed attempts since the last lockout.
+	Failures int
+}
+
+// LoginAttemptStore persists the failed login attempts used by the `LoginThrottler`.
+//
+// `Update` atomically retrieves the attempts identified by the given key, passes them to
+// the given function, then saves the modified attempts. If the key doesn't exist or has
+// expired, the function receives the zero value. The attempts expire after the given TTL
+// if they are not updated in the meantime.
+//
+// Implementations must be safe for concurrent use, and the read-modify-write cycle must be
+// atomic for a given key, even across multiple instances of the application if the store
+// is shared.
+type LoginAttemptStore interface {
+	// Get returns the attempts identified by the given key, or the
+	// zero value if the key doesn't exist or has expired.
+	Get(ctx context.Context, key string) (LoginAttempts, error)
+
+	Update(ctx context.Context, key string, ttl time.Duration, fn func(attempts *LoginAttempts)) error
+
+	// Delete removes the attempts identified by the given key.
+	Delete(ctx context.Context, key string) error
+}
+
+// LoginThrottler protects the login flows against brute-force attacks. The failed attempts
+// are counted per username and client IP (see `LoginThrottleKey`).
+//
+// After each failure, the next attempt is rejected until a progressively longer delay
+// has elapsed: `BaseDelay` after the first failure, doubled after each subsequent failure,
+// and capped to `MaxDelay`. After `MaxAttempts` consecutive failures, all the attempts are
+// rejected for `LockoutDuration`. The failures are forgotten after a successful login or
+// if no attempt failed during `Window`.
+//
+// Each attempt is reserved with `Reserve` before the credentials are verified: the attempt
+// is atomically checked and counted as a failure, so concurrent attempts cannot bypass the
+// limits. The reservation is released by `Succeed` if the credentials are valid.
+type LoginThrottler struct {
+	Store LoginAttemptStore
+
+	now func() time.Time
+
+	// MaxAttempts the number of consecutive failures triggering a lockout.
+	// Defaults to 5.
+	MaxAttempts int
+
+	// LockoutDuration the duration of a lockout. Defaults to 15 minutes.
+	LockoutDuration time.Duration
+
+	// BaseDelay the delay imposed after the first failure. If zero, no delay
+	// is imposed between attempts before the lockout.
+	BaseDelay time.Duration
+
+	// MaxDelay the maximum delay imposed between two attempts. Defaults to 30 seconds.
+	MaxDelay time.Duration
+
+	// Window the duration after which the failures are forgotten if no attempt
+	// failed in the meantime. Defaults to 15 minutes.
+	Window time.Duration
+}
+
+// NewLoginThrottler create a new `LoginThrottler` using the given store, locking out
+// clients for 15 minutes after 5 failures, with a delay of one second after the first
+// failure, doubled after each subsequent failure.
+func NewLoginThrottler(store LoginAttemptStore) *LoginThrottler {
+	return &LoginThrottler{
+		Store:           store,
+		MaxAttempts:     5,
+		LockoutDuration: 15 * time.Minute,
+		BaseDelay:       time.Second,
+		MaxDelay:        30 * time.Second,
+		Window:          15 * time.Minute,
+		now:             time.Now,
+	}
+}
+
+// LoginThrottleKey returns the throttling key for the given username and client IP.
+// The username is case-insensitive. The key is hashed so the store doesn't contain
+// the usernames in clear.
+func LoginThrottleKey(username, ip string) string {
+	sum := sha256.Sum256([]byte(strings.ToLower(username) + "\x00" + ip))
+	return hex.EncodeToString(sum[:])
+}
+
+// Allow returns the duration the client has to wait before attempting to log in again.
+// Returns zero if the attempt is allowed. The attempt is not reserved (see `Reserve`).
+func (t *LoginThrottler) Allow(ctx context.Context, key string) (time.Duration, error) {
+	attempts, err := t.Store.Get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	return t.retryAfter(attempts, t.getNow()), nil
+}
+
+// Reserve atomically checks if the client is allowed to attempt to log in and, if so,
+// records the attempt as a failure before the credentials are verified. If the credentials
+// are valid, the reservation must be released with `Succeed`.
+//
+// If the attempt is rejected, returns the duration the client has to wait before attempting
+// to log in again. Otherwise, returns the time until which the client is locked out if this
+// attempt triggered a lockout (effective unless the attempt succeeds), or the zero value.
+func (t *LoginThrottler) Reserve(ctx context.Context, key string) (retryAfter time.Duration, lockedUntil time.Time, err error) {
+	err = t.Store.Update(ctx, key, t.ttl(), func(attempts *LoginAttempts) {
+		now := t.getNow()
+		retryAfter = t.retryAfter(*attempts, now)
+		if retryAfter > 0 {
+			return
+		}
+		attempts.Failures++
+		attempts.LastFailure = now
+		if attempts.Failures >= t.maxAttempts() {
+			lockedUntil = now.Add(t.lockoutDuration())
+			attempts.LockedUntil = lockedUntil
+			attempts.Failures = 0
+		}
+	})
+	if err != nil {
+		return 0, time.Time{}, err
+	}
+	return retryAfter, lockedUntil, nil
+}
+
+// Succeed releases the reservation and forgets the failed attempts of the client
+// after a successful login.
+func (t *LoginThrottler) Succeed(ctx context.Context, key string) error {
+	return t.Store.Delete(ctx, key)
+}
+
+// retryAfter returns the duration the client has to wait before attempting
+// to log in again, or zero if the attempt is allowed.
+func (t *LoginThrottler) retryAfter(attempts LoginAttempts, now time.Time) time.Duration {
+	if now.Before(attempts.LockedUntil) {
+		return attempts.LockedUntil.Sub(now)
+	}
+	if attempts.Failures > 0 {
+		if next := attempts.LastFailure.Add(t.delay(attempts.Failures)); now.Before(next) {
+			return next.Sub(now)
+		}
+	}
+	return 0
+}
+
+func (t *LoginThrottler) delay(failures int) time.Duration {
+	if t.BaseDelay <= 0 {
+		return 0
+	}
+	maxDelay := t.MaxDelay
+	if maxDelay <= 0 {
+		maxDelay = 30 * time.Second
+	}
+	delay := t.BaseDelay
+	for i := 1; i < failures && delay < maxDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, maxDelay)
+}
+
+func (t *LoginThrottler) maxAttempts() int {
+	if t.MaxAttempts <= 0 {
+		return 5
+	}
+	return t.MaxAttempts
+}
+
+func (t *LoginThrottler) lockoutDuration() time.Duration {
+	if t.LockoutDuration <= 0 {
+		return 15 * time.Minute
+	}
+	return t.LockoutDuration
+}
+
+func (t *LoginThrottler) ttl() time.Duration {
+	window := t.Window
+	if window <= 0 {
+		window = 15 * time.Minute
+	}
+	return max(window, t.lockoutDuration())
+}
+
+func (t *LoginThrottler) getNow() time.Time {
+	if t.now == nil {
+		return time.Now()
+	}
+	return t.now()
+}
+
+// loginThrottledError returned by the login flows when the attempt is
+// rejected by the `LoginThrottler`.
+type loginThrottledError struct {
+	message    string
+	retryAfter time.Duration
+}
+
+func (e *loginThrottledError) Error() string {
+	return e.message
+}
+
+func (e *loginThrottledError) respond(response *goyave.Response) {
+	response.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(e.retryAfter.Seconds())), 10))
+	response.JSON(http.StatusTooManyRequests, map[string]string{"error": e.message})
+}
+
+// loginGuard applies the `LoginThrottler` (if any) to a login attempt.
+type loginGuard struct {
+	lockedUntil time.Time
+	throttler   *LoginThrottler
+	component   *goyave.Component
+	request     *goyave.Request
+	key         string
+	username    string
+}
+
+func newLoginGuard(throttler *LoginThrottler, component *goyave.Component, request *goyave.Request, username string) *loginGuard {
+	g := &loginGuard{throttler: throttler, component: component, request: request, username: username}
+	if throttler != nil {
+		g.key = LoginThrottleKey(username, request.ClientIP())
+	}
+	return g
+}
+
+// reserve reserves the attempt before the credentials are verified. Returns a
+// non-nil `*loginThrottledError` if the attempt is rejected.
+func (g *loginGuard) reserve() (*loginThrottledError, error) {
+	if g.throttler == nil {
+		return nil, nil
+	}
+	retryAfter, lockedUntil, err := g.throttler.Reserve(g.request.Context(), g.key)
+	if err != nil {
+		return nil, errorutil.New(err)
+	}
+	if retryAfter > 0 {
+		return &loginThrottledError{message: g.request.Lang.Get("auth.too-many-attempts"), retryAfter: retryAfter}, nil
+	}
+	g.lockedUntil = lockedUntil
+	return nil, nil
+}
+
+// fail logs a security event if the failed attempt locked the client out. The
+// failure itself was already recorded by the reservation.
+func (g *loginGuard) fail() {
+	if g.lockedUntil.IsZero() {
+		return
+	}
+	g.component.Logger().WarnContext(g.request.Context(), "login locked out after too many failed attempts",
+		"username", g.username,
+		"ip", g.request.ClientIP(),
+		"lockedUntil", g.lockedUntil,
+	)
+}
+
+func (g *loginGuard) succeed() error {
+	if g.throttler == nil {
+		return nil
+	}
+	return errorutil.New(g.throttler.Succeed(g.request.Context(), g.key))
+}
+
+type memoryLoginAttempts struct {
+	expiresAt time.Time
+	attempts  LoginAttempts
+}
+
+// MemoryLoginAttemptStore a `LoginAttemptStore` keeping the attempts in memory. The attempts
+// are not shared between multiple instances of the application.
+//
+// Expired entries are periodically removed when the store is updated.
+type MemoryLoginAttemptStore struct {
+	entries   map[string]*memoryLoginAttempts
+	nextSweep time.Time
+	now       func() time.Time
+	mu        sync.Mutex
+
+	// SweepInterval the minimum duration between two removals of the expired entries.
+	// Defaults to one minute.
+	SweepInterval time.Duration
+}
+
+// NewMemoryLoginAttemptStore create a new empty `MemoryLoginAttemptStore`.
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{
+		entries:       map[string]*memoryLoginAttempts{},
+		now:           time.Now,
+		SweepInterval: time.Minute,
+	}
+}
+
+// Get implementation of `LoginAttemptStore`.
+func (s *MemoryLoginAttemptStore) Get(_ context.Context, key string) (LoginAttempts, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry, ok := s.entries[key]
+	if !ok || !s.now().Before(entry.expiresAt) {
+		return LoginAttempts{}, nil
+	}
+	return entry.attempts, nil
+}
+
+// Update implementation of `LoginAttemptStore`.
+func (s *MemoryLoginAttemptStore) Update(_ context.Context, key string, ttl time.Duration, fn func(attempts *LoginAttempts)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	entry, ok := s.entries[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		entry = &memoryLoginAttempts{}
+		s.entries[key] = entry
+	}
+	fn(&entry.attempts)
+	entry.expiresAt = now.Add(ttl)
+	return nil
+}
+
+// Delete implementation of `LoginAttemptStore`.
+func (s *MemoryLoginAttemptStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.entries, key)
+	return nil
+}
+
+// Len returns the number of entries in the store, including the expired ones
+// that were not removed yet.
+func (s *MemoryLoginAttemptStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+func (s *MemoryLoginAttemptStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	for k, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+	s.nextSweep = now.Add(s.SweepInterval)
+}
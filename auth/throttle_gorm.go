@@ -0,0 +1,99 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	errorutil "goyave.dev/goyave/v5/util/errors"
+)
+
+// GORMLoginAttempts the model used by the `GORMLoginAttemptStore` to persist the failed
+// login attempts. The table must be created by the application, for example using auto-migration:
+//
+//	db.AutoMigrate(&auth.GORMLoginAttempts{})
+type GORMLoginAttempts struct {
+	LastFailure time.Time
+	LockedUntil time.Time
+	ExpiresAt   time.Time `gorm:"index"`
+	Key         string    `gorm:"primaryKey;size:64"`
+	Failures    int
+}
+
+// TableName returns the name of the table used by the `GORMLoginAttemptStore`.
+func (GORMLoginAttempts) TableName() string {
+	return "login_attempts"
+}
+
+// GORMLoginAttemptStore a `LoginAttemptStore` persisting the attempts in a database using GORM,
+// allowing multiple instances of the application to share the same counters.
+//
+// Each update is executed in a transaction, locking the row (`SELECT ... FOR UPDATE`)
+// if the database supports it.
+//
+// Expired rows are ignored but not removed automatically. Call `Cleanup()` periodically
+// to remove them.
+type GORMLoginAttemptStore struct {
+	DB  *gorm.DB
+	now func() time.Time
+}
+
+// NewGORMLoginAttemptStore create a new `GORMLoginAttemptStore` using the given database connection.
+func NewGORMLoginAttemptStore(db *gorm.DB) *GORMLoginAttemptStore {
+	return &GORMLoginAttemptStore{
+		DB:  db,
+		now: time.Now,
+	}
+}
+
+// Get implementation of `LoginAttemptStore`.
+func (s *GORMLoginAttemptStore) Get(ctx context.Context, key string) (LoginAttempts, error) {
+	rows := []*GORMLoginAttempts{}
+	if err := s.DB.WithContext(ctx).Where(&GORMLoginAttempts{Key: key}).Where("expires_at > ?", s.now()).Limit(1).Find(&rows).Error; err != nil {
+		return LoginAttempts{}, errorutil.New(err)
+	}
+	if len(rows) == 0 {
+		return LoginAttempts{}, nil
+	}
+	row := rows[0]
+	return LoginAttempts{LastFailure: row.LastFailure, LockedUntil: row.LockedUntil, Failures: row.Failures}, nil
+}
+
+// Update implementation of `LoginAttemptStore`.
+func (s *GORMLoginAttemptStore) Update(ctx context.Context, key string, ttl time.Duration, fn func(attempts *LoginAttempts)) error {
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := s.now()
+		row := &GORMLoginAttempts{Key: key}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(row).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(&GORMLoginAttempts{Key: key}).First(row).Error; err != nil {
+			return err
+		}
+
+		attempts := LoginAttempts{}
+		if now.Before(row.ExpiresAt) {
+			attempts = LoginAttempts{LastFailure: row.LastFailure, LockedUntil: row.LockedUntil, Failures: row.Failures}
+		}
+		fn(&attempts)
+
+		row.LastFailure = attempts.LastFailure
+		row.LockedUntil = attempts.LockedUntil
+		row.Failures = attempts.Failures
+		row.ExpiresAt = now.Add(ttl)
+		return tx.Model(row).Select("*").Updates(row).Error
+	})
+	return errorutil.New(err)
+}
+
+// Delete implementation of `LoginAttemptStore`.
+func (s *GORMLoginAttemptStore) Delete(ctx context.Context, key string) error {
+	return errorutil.New(s.DB.WithContext(ctx).Where(&GORMLoginAttempts{Key: key}).Delete(&GORMLoginAttempts{}).Error)
+}
+
+// Cleanup removes the expired rows from the database.
+func (s *GORMLoginAttemptStore) Cleanup(ctx context.Context) error {
+	return errorutil.New(s.DB.WithContext(ctx).Where("expires_at <= ?", s.now()).Delete(&GORMLoginAttempts{}).Error)
+}
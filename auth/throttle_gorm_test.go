@@ -0,0 +1,116 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupGORMLoginAttemptStore(t *testing.T) *GORMLoginAttemptStore {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+	require.NoError(t, db.AutoMigrate(&GORMLoginAttempts{}))
+	return NewGORMLoginAttemptStore(db)
+}
+
+func TestGORMLoginAttemptStore(t *testing.T) {
+	store := setupGORMLoginAttemptStore(t)
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	attempts, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, LoginAttempts{}, attempts)
+
+	err = store.Update(ctx, "key", time.Minute, func(attempts *LoginAttempts) {
+		assert.Equal(t, LoginAttempts{}, *attempts)
+		attempts.Failures = 1
+		attempts.LastFailure = now
+	})
+	require.NoError(t, err)
+
+	err = store.Update(ctx, "key", time.Minute, func(attempts *LoginAttempts) {
+		assert.Equal(t, 1, attempts.Failures)
+		assert.True(t, now.Equal(attempts.LastFailure))
+		attempts.Failures = 0
+		attempts.LockedUntil = now.Add(time.Minute)
+	})
+	require.NoError(t, err)
+
+	attempts, err = store.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, 0, attempts.Failures)
+	assert.True(t, now.Add(time.Minute).Equal(attempts.LockedUntil))
+
+	rows := []*GORMLoginAttempts{}
+	require.NoError(t, store.DB.Find(&rows).Error)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "key", rows[0].Key)
+	assert.True(t, now.Add(time.Minute).Equal(rows[0].ExpiresAt))
+
+	t.Run("expired", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		attempts, err := store.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.Equal(t, LoginAttempts{}, attempts)
+		err = store.Update(ctx, "key", time.Minute, func(attempts *LoginAttempts) {
+			assert.Equal(t, LoginAttempts{}, *attempts)
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, store.Update(ctx, "other", time.Minute, func(attempts *LoginAttempts) {
+			attempts.Failures = 1
+		}))
+		require.NoError(t, store.Delete(ctx, "other"))
+		attempts, err := store.Get(ctx, "other")
+		require.NoError(t, err)
+		assert.Equal(t, LoginAttempts{}, attempts)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		require.NoError(t, store.Update(ctx, "other", time.Minute, func(_ *LoginAttempts) {}))
+		now = now.Add(30 * time.Second)
+		require.NoError(t, store.Cleanup(ctx))
+
+		keys := []string{}
+		require.NoError(t, store.DB.Model(&GORMLoginAttempts{}).Pluck("key", &keys).Error)
+		assert.Equal(t, []string{"other"}, keys)
+	})
+
+	t.Run("with_throttler", func(t *testing.T) {
+		throttler := NewLoginThrottler(store)
+		throttler.now = store.now
+		throttler.MaxAttempts = 2
+		throttler.BaseDelay = 0
+		failLogin(t, throttler, "throttled")
+		lockedUntil := failLogin(t, throttler, "throttled")
+		assert.True(t, now.Add(15*time.Minute).Equal(lockedUntil))
+		retryAfter, err := throttler.Allow(ctx, "throttled")
+		require.NoError(t, err)
+		assert.Equal(t, 15*time.Minute, retryAfter)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		require.NoError(t, store.DB.Migrator().DropTable(&GORMLoginAttempts{}))
+		_, err := store.Get(ctx, "key")
+		require.Error(t, err)
+		require.Error(t, store.Update(ctx, "key", time.Minute, func(_ *LoginAttempts) {}))
+		require.Error(t, store.Delete(ctx, "key"))
+		require.Error(t, store.Cleanup(ctx))
+	})
+}
@@ -0,0 +1,304 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5/auth/hash"
+)
+
+type errLoginAttemptStore struct {
+	err error
+}
+
+func (s errLoginAttemptStore) Get(_ context.Context, _ string) (LoginAttempts, error) {
+	return LoginAttempts{}, s.err
+}
+
+func (s errLoginAttemptStore) Update(_ context.Context, _ string, _ time.Duration, _ func(attempts *LoginAttempts)) error {
+	return s.err
+}
+
+func (s errLoginAttemptStore) Delete(_ context.Context, _ string) error {
+	return s.err
+}
+
+// countingHasher counts the password comparisons.
+type countingHasher struct {
+	hash.Hasher
+	compared *int
+}
+
+func (h countingHasher) Compare(stored, password string) (bool, error) {
+	*h.compared++
+	return h.Hasher.Compare(stored, password)
+}
+
+func TestLoginThrottleKey(t *testing.T) {
+	key := LoginThrottleKey("JohnDoe@example.org", "127.0.0.1")
+	assert.Len(t, key, 64)
+	assert.Equal(t, key, LoginThrottleKey("johndoe@example.org", "127.0.0.1"))
+	assert.NotEqual(t, key, LoginThrottleKey("johndoe@example.org", "127.0.0.2"))
+	assert.NotEqual(t, key, LoginThrottleKey("johndoe@example.org1", "27.0.0.1"))
+}
+
+// failLogin reserves an attempt that must be allowed and doesn't release it,
+// as if the credentials were invalid.
+func failLogin(t *testing.T, throttler *LoginThrottler, key string) time.Time {
+	t.Helper()
+	retryAfter, lockedUntil, err := throttler.Reserve(context.Background(), key)
+	require.NoError(t, err)
+	require.Zero(t, retryAfter)
+	return lockedUntil
+}
+
+func TestLoginThrottler(t *testing.T) {
+	prepare := func() (*LoginThrottler, *time.Time) {
+		now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+		store := NewMemoryLoginAttemptStore()
+		store.now = func() time.Time { return now }
+		throttler := NewLoginThrottler(store)
+		throttler.now = func() time.Time { return now }
+		return throttler, &now
+	}
+
+	t.Run("progressive_delay", func(t *testing.T) {
+		throttler, now := prepare()
+		ctx := context.Background()
+
+		retryAfter, err := throttler.Allow(ctx, "key")
+		require.NoError(t, err)
+		assert.Zero(t, retryAfter)
+
+		for i, expected := range []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second} {
+			lockedUntil := failLogin(t, throttler, "key")
+			assert.True(t, lockedUntil.IsZero())
+
+			retryAfter, err = throttler.Allow(ctx, "key")
+			require.NoError(t, err)
+			assert.Equal(t, expected, retryAfter, i)
+
+			*now = now.Add(expected)
+			retryAfter, err = throttler.Allow(ctx, "key")
+			require.NoError(t, err)
+			assert.Zero(t, retryAfter, i)
+		}
+
+		// Other keys are not affected
+		assert.NoError(t, throttler.Store.Update(ctx, "other", time.Minute, func(attempts *LoginAttempts) {
+			assert.Equal(t, LoginAttempts{}, *attempts)
+		}))
+	})
+
+	t.Run("max_delay", func(t *testing.T) {
+		throttler, now := prepare()
+		throttler.MaxAttempts = 20
+		throttler.MaxDelay = 5 * time.Second
+		ctx := context.Background()
+		for range 10 {
+			failLogin(t, throttler, "key")
+			*now = now.Add(5 * time.Second)
+		}
+		*now = now.Add(-5 * time.Second)
+		retryAfter, err := throttler.Allow(ctx, "key")
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Second, retryAfter)
+	})
+
+	t.Run("no_delay", func(t *testing.T) {
+		throttler, _ := prepare()
+		throttler.BaseDelay = 0
+		ctx := context.Background()
+		failLogin(t, throttler, "key")
+		retryAfter, err := throttler.Allow(ctx, "key")
+		require.NoError(t, err)
+		assert.Zero(t, retryAfter)
+	})
+
+	t.Run("lockout", func(t *testing.T) {
+		throttler, now := prepare()
+		throttler.BaseDelay = 0
+		ctx := context.Background()
+
+		for range 4 {
+			lockedUntil := failLogin(t, throttler, "key")
+			assert.True(t, lockedUntil.IsZero())
+		}
+		lockedUntil := failLogin(t, throttler, "key")
+		assert.Equal(t, now.Add(15*time.Minute), lockedUntil)
+
+		*now = now.Add(10 * time.Minute)
+		retryAfter, err := throttler.Allow(ctx, "key")
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Minute, retryAfter)
+		retryAfter, lockedUntil, err = throttler.Reserve(ctx, "key")
+		require.NoError(t, err)
+		assert.Equal(t, 5*time.Minute, retryAfter)
+		assert.True(t, lockedUntil.IsZero())
+
+		// The failures are reset after the lockout
+		*now = now.Add(5 * time.Minute)
+		retryAfter, err = throttler.Allow(ctx, "key")
+		require.NoError(t, err)
+		assert.Zero(t, retryAfter)
+		lockedUntil = failLogin(t, throttler, "key")
+		assert.True(t, lockedUntil.IsZero())
+	})
+
+	t.Run("window", func(t *testing.T) {
+		throttler, now := prepare()
+		throttler.BaseDelay = 0
+		ctx := context.Background()
+		for range 4 {
+			failLogin(t, throttler, "key")
+		}
+		*now = now.Add(15 * time.Minute)
+		lockedUntil := failLogin(t, throttler, "key")
+		assert.True(t, lockedUntil.IsZero())
+		assert.NoError(t, throttler.Store.Update(ctx, "key", time.Minute, func(attempts *LoginAttempts) {
+			assert.Equal(t, 1, attempts.Failures)
+		}))
+	})
+
+	t.Run("succeed", func(t *testing.T) {
+		throttler, _ := prepare()
+		ctx := context.Background()
+		failLogin(t, throttler, "key")
+		require.NoError(t, throttler.Succeed(ctx, "key"))
+		retryAfter, err := throttler.Allow(ctx, "key")
+		require.NoError(t, err)
+		assert.Zero(t, retryAfter)
+		assert.Equal(t, 0, throttler.Store.(*MemoryLoginAttemptStore).Len())
+	})
+
+	t.Run("rejected_not_counted", func(t *testing.T) {
+		throttler, _ := prepare()
+		ctx := context.Background()
+		failLogin(t, throttler, "key")
+		retryAfter, _, err := throttler.Reserve(ctx, "key")
+		require.NoError(t, err)
+		assert.Equal(t, time.Second, retryAfter)
+		attempts, err := throttler.Store.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.Equal(t, 1, attempts.Failures)
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		throttler, _ := prepare()
+		throttler.BaseDelay = 0
+		allowed := atomic.Int32{}
+		wg := sync.WaitGroup{}
+		for range 50 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				retryAfter, _, err := throttler.Reserve(context.Background(), "key")
+				assert.NoError(t, err)
+				if retryAfter == 0 {
+					allowed.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(5), allowed.Load())
+	})
+
+	t.Run("defaults", func(t *testing.T) {
+		throttler := &LoginThrottler{Store: NewMemoryLoginAttemptStore()}
+		assert.Equal(t, 5, throttler.maxAttempts())
+		assert.Equal(t, 15*time.Minute, throttler.lockoutDuration())
+		assert.Equal(t, 15*time.Minute, throttler.ttl())
+		assert.Zero(t, throttler.delay(3))
+		throttler.BaseDelay = time.Second
+		assert.Equal(t, 30*time.Second, throttler.delay(10))
+		throttler.LockoutDuration = time.Hour
+		assert.Equal(t, time.Hour, throttler.ttl())
+		assert.False(t, throttler.getNow().IsZero())
+	})
+
+	t.Run("store_error", func(t *testing.T) {
+		storeErr := errors.New("store error")
+		throttler := NewLoginThrottler(errLoginAttemptStore{err: storeErr})
+		ctx := context.Background()
+		_, err := throttler.Allow(ctx, "key")
+		require.ErrorIs(t, err, storeErr)
+		_, _, err = throttler.Reserve(ctx, "key")
+		require.ErrorIs(t, err, storeErr)
+		require.ErrorIs(t, throttler.Succeed(ctx, "key"), storeErr)
+	})
+}
+
+func TestMemoryLoginAttemptStore(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryLoginAttemptStore()
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	attempts, err := store.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, LoginAttempts{}, attempts)
+
+	err = store.Update(ctx, "key", time.Minute, func(attempts *LoginAttempts) {
+		assert.Equal(t, LoginAttempts{}, *attempts)
+		attempts.Failures = 1
+		attempts.LastFailure = now
+	})
+	require.NoError(t, err)
+
+	attempts, err = store.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, LoginAttempts{Failures: 1, LastFailure: now}, attempts)
+
+	now = now.Add(time.Minute)
+	attempts, err = store.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, LoginAttempts{}, attempts)
+
+	err = store.Update(ctx, "other", time.Minute, func(_ *LoginAttempts) {})
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.Len()) // Expired entry has been swept
+
+	require.NoError(t, store.Delete(ctx, "other"))
+	assert.Equal(t, 0, store.Len())
+
+	t.Run("concurrent", func(t *testing.T) {
+		store := NewMemoryLoginAttemptStore()
+		wg := sync.WaitGroup{}
+		for range 50 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = store.Update(context.Background(), "key", time.Minute, func(attempts *LoginAttempts) {
+					attempts.Failures++
+				})
+			}()
+		}
+		wg.Wait()
+		attempts, err := store.Get(context.Background(), "key")
+		require.NoError(t, err)
+		assert.Equal(t, 50, attempts.Failures)
+	})
+}
+
+func TestPasswordVerifierDummy(t *testing.T) {
+	compared := 0
+	bcryptHasher := hash.NewBcrypt()
+	bcryptHasher.Cost = 4
+	hasher := countingHasher{Hasher: bcryptHasher, compared: &compared}
+	verifier := passwordVerifier[TestUser]{hasher: hasher}
+
+	verifier.verifyDummy("secret")
+	assert.Equal(t, 1, compared)
+	stored, ok := dummyHashes.Load(hasher)
+	require.True(t, ok)
+	assert.True(t, bcryptHasher.Identify(stored.(string)))
+
+	verifier.verifyDummy("secret")
+	assert.Equal(t, 2, compared)
+}
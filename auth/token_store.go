@@ -0,0 +1,184 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	errorutil "goyave.dev/goyave/v5/util/errors"
+)
+
+// RefreshToken the persisted state of a refresh token issued by the `JWTController`.
+//
+// The raw token is never persisted: `ID` is the hex-encoded SHA-256 hash of the token,
+// so a leak of the store doesn't allow to use the tokens it contains.
+type RefreshToken struct {
+	// ExpiresAt the time after which the token cannot be used anymore.
+	ExpiresAt time.Time
+
+	// ID the hex-encoded SHA-256 hash of the token.
+	ID string
+
+	// Family identifies the chain of tokens obtained by successive rotations
+	// from the same login. All the tokens of a family are revoked together.
+	Family string
+
+	// Subject the username of the user the token was issued to.
+	Subject string
+
+	// Used true if the token has already been exchanged for a new one. Presenting
+	// a used token is considered as a reuse of a stolen token.
+	Used bool
+}
+
+// TokenStore persists the refresh tokens issued by the `JWTController` and the
+// denylist of revoked access tokens, identified by their "jti" claim.
+//
+// Implementations must be safe for concurrent use. `UseRefreshToken` must be atomic, even
+// across multiple instances of the application if the store is shared, so a refresh token
+// cannot be exchanged twice.
+type TokenStore interface {
+	// SaveRefreshToken persists a new refresh token.
+	SaveRefreshToken(ctx context.Context, token *RefreshToken) error
+
+	// UseRefreshToken atomically marks the refresh token identified by the given ID as used
+	// and returns its state as it was before the operation. Returns `nil` if the token
+	// doesn't exist, has expired or has been revoked.
+	UseRefreshToken(ctx context.Context, id string) (*RefreshToken, error)
+
+	// RevokeFamily revokes all the refresh tokens of the given family.
+	RevokeFamily(ctx context.Context, family string) error
+
+	// Revoke adds the access token identified by the given "jti" to the denylist.
+	// The entry can be forgotten after `expiresAt`, as the token is expired by then.
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+
+	// IsRevoked returns true if the access token identified by the given "jti"
+	// is in the denylist.
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+}
+
+// MemoryTokenStore a `TokenStore` keeping the tokens in memory. The tokens are not shared
+// between multiple instances of the application and are lost when the application stops.
+//
+// Expired entries are periodically removed when the store is updated.
+type MemoryTokenStore struct {
+	refreshTokens map[string]*RefreshToken
+	revoked       map[string]time.Time
+	nextSweep     time.Time
+	now           func() time.Time
+	mu            sync.Mutex
+
+	// SweepInterval the minimum duration between two removals of the expired entries.
+	// Defaults to one minute.
+	SweepInterval time.Duration
+}
+
+// NewMemoryTokenStore create a new empty `MemoryTokenStore`.
+func NewMemoryTokenStore() *MemoryTokenStore {
+	return &MemoryTokenStore{
+		refreshTokens: map[string]*RefreshToken{},
+		revoked:       map[string]time.Time{},
+		now:           time.Now,
+		SweepInterval: time.Minute,
+	}
+}
+
+// SaveRefreshToken implementation of `TokenStore`.
+func (s *MemoryTokenStore) SaveRefreshToken(_ context.Context, token *RefreshToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(s.now())
+	t := *token
+	s.refreshTokens[token.ID] = &t
+	return nil
+}
+
+// UseRefreshToken implementation of `TokenStore`.
+func (s *MemoryTokenStore) UseRefreshToken(_ context.Context, id string) (*RefreshToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.sweep(now)
+
+	token, ok := s.refreshTokens[id]
+	if !ok || !now.Before(token.ExpiresAt) {
+		return nil, nil
+	}
+	previous := *token
+	token.Used = true
+	return &previous, nil
+}
+
+// RevokeFamily implementation of `TokenStore`.
+func (s *MemoryTokenStore) RevokeFamily(_ context.Context, family string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, token := range s.refreshTokens {
+		if token.Family == family {
+			delete(s.refreshTokens, id)
+		}
+	}
+	return nil
+}
+
+// Revoke implementation of `TokenStore`.
+func (s *MemoryTokenStore) Revoke(_ context.Context, jti string, expiresAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(s.now())
+	s.revoked[jti] = expiresAt
+	return nil
+}
+
+// IsRevoked implementation of `TokenStore`.
+func (s *MemoryTokenStore) IsRevoked(_ context.Context, jti string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	expiresAt, ok := s.revoked[jti]
+	return ok && s.now().Before(expiresAt), nil
+}
+
+// Len returns the number of refresh tokens and revoked access tokens in the store,
+// including the expired ones that were not removed yet.
+func (s *MemoryTokenStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.refreshTokens) + len(s.revoked)
+}
+
+func (s *MemoryTokenStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	for id, token := range s.refreshTokens {
+		if !now.Before(token.ExpiresAt) {
+			delete(s.refreshTokens, id)
+		}
+	}
+	for jti, expiresAt := range s.revoked {
+		if !now.Before(expiresAt) {
+			delete(s.revoked, jti)
+		}
+	}
+	s.nextSweep = now.Add(s.SweepInterval)
+}
+
+// HashRefreshToken returns the hex-encoded SHA-256 hash of the given raw refresh
+// token, used as `RefreshToken.ID`.
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func generateTokenID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errorutil.New(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
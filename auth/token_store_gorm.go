@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	errorutil "goyave.dev/goyave/v5/util/errors"
+)
+
+// GORMRefreshToken the model used by the `GORMTokenStore` to persist the refresh tokens.
+// The tables must be created by the application, for example using auto-migration:
+//
+//	db.AutoMigrate(&auth.GORMRefreshToken{}, &auth.GORMRevokedToken{})
+type GORMRefreshToken struct {
+	ExpiresAt time.Time `gorm:"index"`
+	ID        string    `gorm:"primaryKey;size:64"`
+	Family    string    `gorm:"index;size:64"`
+	Subject   string
+	Used      bool
+}
+
+// TableName returns the name of the table used by the `GORMTokenStore` for refresh tokens.
+func (GORMRefreshToken) TableName() string {
+	return "refresh_tokens"
+}
+
+// GORMRevokedToken the model used by the `GORMTokenStore` to persist the denylist
+// of revoked access tokens.
+type GORMRevokedToken struct {
+	ExpiresAt time.Time `gorm:"index"`
+	JTI       string    `gorm:"primaryKey;size:255"`
+}
+
+// TableName returns the name of the table used by the `GORMTokenStore` for revoked access tokens.
+func (GORMRevokedToken) TableName() string {
+	return "revoked_tokens"
+}
+
+// GORMTokenStore a `TokenStore` persisting the tokens in a database using GORM, allowing
+// multiple instances of the application to share the same tokens.
+//
+// Expired rows are ignored but not removed automatically. Call `Cleanup()` periodically
+// to remove them.
+type GORMTokenStore struct {
+	DB  *gorm.DB
+	now func() time.Time
+}
+
+// NewGORMTokenStore create a new `GORMTokenStore` using the given database connection.
+func NewGORMTokenStore(db *gorm.DB) *GORMTokenStore {
+	return &GORMTokenStore{
+		DB:  db,
+		now: time.Now,
+	}
+}
+
+// SaveRefreshToken implementation of `TokenStore`.
+func (s *GORMTokenStore) SaveRefreshToken(ctx context.Context, token *RefreshToken) error {
+	row := &GORMRefreshToken{
+		ExpiresAt: token.ExpiresAt,
+		ID:        token.ID,
+		Family:    token.Family,
+		Subject:   token.Subject,
+		Used:      token.Used,
+	}
+	return errorutil.New(s.DB.WithContext(ctx).Create(row).Error)
+}
+
+// UseRefreshToken implementation of `TokenStore`.
+//
+// The token is marked as used with a single conditional update, so only one of
+// concurrent requests exchanging the same token can see it unused.
+func (s *GORMTokenStore) UseRefreshToken(ctx context.Context, id string) (*RefreshToken, error) {
+	db := s.DB.WithContext(ctx)
+	now := s.now()
+	rows := []*GORMRefreshToken{}
+	if err := db.Where("id = ? AND expires_at > ?", id, now).Limit(1).Find(&rows).Error; err != nil {
+		return nil, errorutil.New(err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	row := rows[0]
+	token := &RefreshToken{
+		ExpiresAt: row.ExpiresAt,
+		ID:        row.ID,
+		Family:    row.Family,
+		Subject:   row.Subject,
+		Used:      true,
+	}
+	if row.Used {
+		return token, nil
+	}
+
+	result := db.Model(&GORMRefreshToken{}).Where("id = ? AND used = ?", id, false).Update("used", true)
+	if result.Error != nil {
+		return nil, errorutil.New(result.Error)
+	}
+	token.Used = result.RowsAffected == 0
+	return token, nil
+}
+
+// RevokeFamily implementation of `TokenStore`.
+func (s *GORMTokenStore) RevokeFamily(ctx context.Context, family string) error {
+	return errorutil.New(s.DB.WithContext(ctx).Where("family = ?", family).Delete(&GORMRefreshToken{}).Error)
+}
+
+// Revoke implementation of `TokenStore`.
+func (s *GORMTokenStore) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	row := &GORMRevokedToken{JTI: jti, ExpiresAt: expiresAt}
+	return errorutil.New(s.DB.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(row).Error)
+}
+
+// IsRevoked implementation of `TokenStore`.
+func (s *GORMTokenStore) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	var count int64
+	err := s.DB.WithContext(ctx).Model(&GORMRevokedToken{}).Where("jti = ? AND expires_at > ?", jti, s.now()).Count(&count).Error
+	if err != nil {
+		return false, errorutil.New(err)
+	}
+	return count > 0, nil
+}
+
+// Cleanup removes the expired rows from the database.
+func (s *GORMTokenStore) Cleanup(ctx context.Context) error {
+	now := s.now()
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("expires_at <= ?", now).Delete(&GORMRefreshToken{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("expires_at <= ?", now).Delete(&GORMRevokedToken{}).Error
+	})
+	return errorutil.New(err)
+}
@@ -0,0 +1,106 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupGORMTokenStore(t *testing.T) *GORMTokenStore {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+	require.NoError(t, db.AutoMigrate(&GORMRefreshToken{}, &GORMRevokedToken{}))
+	return NewGORMTokenStore(db)
+}
+
+func TestGORMTokenStore(t *testing.T) {
+	t.Run("refresh_token", func(t *testing.T) {
+		store := setupGORMTokenStore(t)
+		now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+		store.now = func() time.Time { return now }
+		ctx := context.Background()
+
+		token, err := store.UseRefreshToken(ctx, "id")
+		require.NoError(t, err)
+		assert.Nil(t, token)
+
+		require.NoError(t, store.SaveRefreshToken(ctx, &RefreshToken{ID: "id", Family: "family", Subject: "johndoe", ExpiresAt: now.Add(time.Minute)}))
+		require.NoError(t, store.SaveRefreshToken(ctx, &RefreshToken{ID: "id2", Family: "family", Subject: "johndoe", ExpiresAt: now.Add(time.Minute)}))
+		require.NoError(t, store.SaveRefreshToken(ctx, &RefreshToken{ID: "other", Family: "other", Subject: "johndoe", ExpiresAt: now.Add(time.Minute)}))
+
+		token, err = store.UseRefreshToken(ctx, "id")
+		require.NoError(t, err)
+		require.NotNil(t, token)
+		assert.False(t, token.Used)
+		assert.Equal(t, "family", token.Family)
+		assert.Equal(t, "johndoe", token.Subject)
+		assert.True(t, now.Add(time.Minute).Equal(token.ExpiresAt))
+
+		token, err = store.UseRefreshToken(ctx, "id")
+		require.NoError(t, err)
+		require.NotNil(t, token)
+		assert.True(t, token.Used)
+
+		require.NoError(t, store.RevokeFamily(ctx, "family"))
+		token, err = store.UseRefreshToken(ctx, "id2")
+		require.NoError(t, err)
+		assert.Nil(t, token)
+
+		now = now.Add(time.Minute)
+		token, err = store.UseRefreshToken(ctx, "other")
+		require.NoError(t, err)
+		assert.Nil(t, token)
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		store := setupGORMTokenStore(t)
+		now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+		store.now = func() time.Time { return now }
+		ctx := context.Background()
+
+		revoked, err := store.IsRevoked(ctx, "jti")
+		require.NoError(t, err)
+		assert.False(t, revoked)
+
+		require.NoError(t, store.Revoke(ctx, "jti", now.Add(time.Minute)))
+		require.NoError(t, store.Revoke(ctx, "jti", now.Add(time.Hour)))
+		revoked, err = store.IsRevoked(ctx, "jti")
+		require.NoError(t, err)
+		assert.True(t, revoked)
+
+		now = now.Add(time.Hour)
+		revoked, err = store.IsRevoked(ctx, "jti")
+		require.NoError(t, err)
+		assert.False(t, revoked)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		store := setupGORMTokenStore(t)
+		now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+		store.now = func() time.Time { return now }
+		ctx := context.Background()
+
+		require.NoError(t, store.SaveRefreshToken(ctx, &RefreshToken{ID: "expired", Family: "family", ExpiresAt: now.Add(-time.Second)}))
+		require.NoError(t, store.SaveRefreshToken(ctx, &RefreshToken{ID: "valid", Family: "family", ExpiresAt: now.Add(time.Minute)}))
+		require.NoError(t, store.Revoke(ctx, "expired", now.Add(-time.Second)))
+		require.NoError(t, store.Revoke(ctx, "valid", now.Add(time.Minute)))
+
+		require.NoError(t, store.Cleanup(ctx))
+		var count int64
+		require.NoError(t, store.DB.Model(&GORMRefreshToken{}).Count(&count).Error)
+		assert.Equal(t, int64(1), count)
+		require.NoError(t, store.DB.Model(&GORMRevokedToken{}).Count(&count).Error)
+		assert.Equal(t, int64(1), count)
+	})
+}
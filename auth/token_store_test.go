@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryTokenStore(t *testing.T) {
+	t.Run("refresh_token", func(t *testing.T) {
+		store := NewMemoryTokenStore()
+		now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+		store.now = func() time.Time { return now }
+		ctx := context.Background()
+
+		token, err := store.UseRefreshToken(ctx, "id")
+		require.NoError(t, err)
+		assert.Nil(t, token)
+
+		require.NoError(t, store.SaveRefreshToken(ctx, &RefreshToken{ID: "id", Family: "family", Subject: "johndoe", ExpiresAt: now.Add(time.Minute)}))
+		require.NoError(t, store.SaveRefreshToken(ctx, &RefreshToken{ID: "id2", Family: "family", Subject: "johndoe", ExpiresAt: now.Add(time.Minute)}))
+		require.NoError(t, store.SaveRefreshToken(ctx, &RefreshToken{ID: "other", Family: "other", Subject: "johndoe", ExpiresAt: now.Add(time.Minute)}))
+
+		token, err = store.UseRefreshToken(ctx, "id")
+		require.NoError(t, err)
+		assert.Equal(t, &RefreshToken{ID: "id", Family: "family", Subject: "johndoe", ExpiresAt: now.Add(time.Minute)}, token)
+
+		token, err = store.UseRefreshToken(ctx, "id")
+		require.NoError(t, err)
+		require.NotNil(t, token)
+		assert.True(t, token.Used)
+
+		require.NoError(t, store.RevokeFamily(ctx, "family"))
+		token, err = store.UseRefreshToken(ctx, "id2")
+		require.NoError(t, err)
+		assert.Nil(t, token)
+
+		now = now.Add(time.Minute)
+		token, err = store.UseRefreshToken(ctx, "other")
+		require.NoError(t, err)
+		assert.Nil(t, token)
+		assert.Equal(t, 0, store.Len())
+	})
+
+	t.Run("revoke", func(t *testing.T) {
+		store := NewMemoryTokenStore()
+		now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+		store.now = func() time.Time { return now }
+		ctx := context.Background()
+
+		revoked, err := store.IsRevoked(ctx, "jti")
+		require.NoError(t, err)
+		assert.False(t, revoked)
+
+		require.NoError(t, store.Revoke(ctx, "jti", now.Add(time.Minute)))
+		revoked, err = store.IsRevoked(ctx, "jti")
+		require.NoError(t, err)
+		assert.True(t, revoked)
+
+		now = now.Add(time.Minute)
+		revoked, err = store.IsRevoked(ctx, "jti")
+		require.NoError(t, err)
+		assert.False(t, revoked)
+		assert.Equal(t, 1, store.Len())
+
+		require.NoError(t, store.Revoke(ctx, "jti2", now.Add(time.Minute)))
+		assert.Equal(t, 1, store.Len())
+	})
+
+	t.Run("sweep_interval", func(t *testing.T) {
+		store := NewMemoryTokenStore()
+		now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+		store.now = func() time.Time { return now }
+		ctx := context.Background()
+
+		require.NoError(t, store.Revoke(ctx, "jti", now.Add(time.Second)))
+		now = now.Add(2 * time.Second)
+		require.NoError(t, store.Revoke(ctx, "jti2", now.Add(time.Second)))
+		assert.Equal(t, 2, store.Len())
+
+		now = now.Add(time.Minute)
+		require.NoError(t, store.Revoke(ctx, "jti3", now.Add(time.Second)))
+		assert.Equal(t, 1, store.Len())
+	})
+}
+
+func TestHashRefreshToken(t *testing.T) {
+	assert.Equal(t, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824", HashRefreshToken("hello"))
+}
@@ -0,0 +1,229 @@
+package authz
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/util/errors"
+)
+
+// MetaPermissions the route meta key used to define the permissions required to access
+// a route or a router. The meta value is expected to be a `[]string` containing the names
+// of gates. The user must be allowed by all of them, otherwise the request is rejected
+// with "403 Forbidden".
+//
+//	router.Delete("/users/{userID}", handler).SetMeta(authz.MetaPermissions, []string{"manage-users"})
+const MetaPermissions = "goyave.permissions"
+
+// ExtraAuthorizer the key used in `request.Extra` to store the `Authorizer`
+// used by the middleware. Use `authz.Authorize()` to check permissions in handlers.
+type ExtraAuthorizer struct{}
+
+// Gate a function deciding if the user of the given request is allowed to perform an action.
+// The subject is the value given to `Authorize()`, and is `nil` when the gate is checked
+// for the `MetaPermissions` route meta.
+type Gate func(request *goyave.Request, subject any) bool
+
+var requestType = reflect.TypeFor[*goyave.Request]()
+
+// Authorizer holds the gates and policies used to decide if a user is allowed to perform
+// an action. An ability is first resolved using the policy registered for the type of the
+// subject, if any. If the policy doesn't define the ability, the gate registered with the
+// same name is used. Abilities that are not defined are denied.
+//
+// Requests without an authenticated user are always denied.
+//
+// Gates and policies should be registered before the server starts. An `Authorizer`
+// is then safe for concurrent use.
+type Authorizer struct {
+	gates    map[string]Gate
+	policies map[reflect.Type]map[string]reflect.Value
+	mu       sync.RWMutex
+}
+
+// New create a new `Authorizer` without any gate or policy.
+func New() *Authorizer {
+	return &Authorizer{
+		gates:    map[string]Gate{},
+		policies: map[reflect.Type]map[string]reflect.Value{},
+	}
+}
+
+// Define registers a gate identified by the given name, replacing the
+// existing one if any.
+func (a *Authorizer) Define(name string, gate Gate) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.gates[name] = gate
+}
+
+// RegisterPolicy registers the policy for the model type `M`. The abilities of the policy
+// are its exported methods prefixed by "Can" and having the following signature:
+//
+//	func (p *PostPolicy) CanUpdate(request *goyave.Request, post *model.Post) bool
+//
+// The name of the ability is derived from the method name: "CanUpdate" defines the "update"
+// ability and "CanViewAny" defines the "view-any" ability.
+//
+// Panics if a method prefixed by "Can" doesn't have the expected signature.
+func RegisterPolicy[M any](authorizer *Authorizer, policy any) {
+	modelType := reflect.TypeFor[M]()
+	modelPtrType := reflect.PointerTo(modelType)
+	value := reflect.ValueOf(policy)
+	t := value.Type()
+
+	abilities := map[string]reflect.Value{}
+	for i := range t.NumMethod() {
+		method := t.Method(i)
+		name, ok := strings.CutPrefix(method.Name, "Can")
+		if !ok || name == "" {
+			continue
+		}
+		f := value.Method(i)
+		ft := f.Type()
+		if ft.NumIn() != 2 || ft.In(0) != requestType || ft.In(1) != modelPtrType || ft.NumOut() != 1 || ft.Out(0).Kind() != reflect.Bool {
+			panic(errors.NewSkip(fmt.Errorf("authz.RegisterPolicy: method %s.%s should have the signature func(*goyave.Request, %s) bool", t, method.Name, modelPtrType), 3))
+		}
+		abilities[abilityName(name)] = f
+	}
+
+	authorizer.mu.Lock()
+	defer authorizer.mu.Unlock()
+	authorizer.policies[modelType] = abilities
+}
+
+// Allows returns true if the user of the given request is allowed to perform the given
+// ability on the given subject. The subject can be `nil`, a model or a pointer to a model.
+func (a *Authorizer) Allows(request *goyave.Request, ability string, subject any) bool {
+	if isNil(request.User) {
+		return false
+	}
+
+	a.mu.RLock()
+	policy := a.findPolicy(subject, ability)
+	gate := a.gates[ability]
+	a.mu.RUnlock()
+
+	if policy.IsValid() {
+		return policy.Call([]reflect.Value{reflect.ValueOf(request), toPointer(subject)})[0].Bool()
+	}
+	if gate != nil {
+		return gate(request, subject)
+	}
+	return false
+}
+
+func (a *Authorizer) findPolicy(subject any, ability string) reflect.Value {
+	if subject == nil {
+		return reflect.Value{}
+	}
+	t := reflect.TypeOf(subject)
+	if t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return a.policies[t][ability]
+}
+
+// Authorize returns true if the user of the given request is allowed to perform the given
+// ability on the given subject, using the `Authorizer` of the authorization middleware.
+// The middleware must be applied to the route of the given request, otherwise this
+// function panics.
+//
+//	router.Patch("/posts/{postID}", func(response *goyave.Response, request *goyave.Request) {
+//		//...
+//		if !authz.Authorize(request, "update", post) {
+//			response.Status(http.StatusForbidden)
+//			return
+//		}
+//	})
+func Authorize(request *goyave.Request, ability string, subject any) bool {
+	authorizer, ok := request.Extra[ExtraAuthorizer{}].(*Authorizer)
+	if !ok {
+		panic(errors.NewSkip(fmt.Errorf("authz.Authorize: the authorization middleware is not applied on this route"), 3))
+	}
+	return authorizer.Allows(request, ability, subject)
+}
+
+// Middleware checks the permissions defined by the `MetaPermissions` route meta. If the
+// user is not allowed by all the gates listed in the meta, the request is rejected with
+// "403 Forbidden", handled by the status handler.
+//
+// This middleware should be used as a global middleware and executed after the
+// authentication middleware so `request.User` is set.
+//
+// **Example:**
+//
+//	authorizer := authz.New()
+//	authorizer.Define("manage-users", func(request *goyave.Request, _ any) bool {
+//		return request.User.(*dto.User).Admin
+//	})
+//	authz.RegisterPolicy[model.Post](authorizer, &policy.Post{})
+//	router.GlobalMiddleware(auth.Middleware(authenticator), &authz.Middleware{Authorizer: authorizer})
+type Middleware struct {
+	goyave.Component
+
+	Authorizer *Authorizer
+}
+
+// Handle implementation of `goyave.Middleware`.
+func (m *Middleware) Handle(next goyave.Handler) goyave.Handler {
+	return func(response *goyave.Response, request *goyave.Request) {
+		request.Extra[ExtraAuthorizer{}] = m.Authorizer
+
+		meta, ok := request.Route.LookupMeta(MetaPermissions)
+		if !ok || meta == nil {
+			next(response, request)
+			return
+		}
+		permissions, ok := meta.([]string)
+		if !ok {
+			panic(errors.NewSkip(fmt.Errorf("authz: route meta %q is not a []string", MetaPermissions), 3))
+		}
+		for _, permission := range permissions {
+			if !m.Authorizer.Allows(request, permission, nil) {
+				response.Status(http.StatusForbidden)
+				return
+			}
+		}
+		next(response, request)
+	}
+}
+
+// abilityName converts a method name suffix in PascalCase to kebab-case.
+func abilityName(name string) string {
+	b := strings.Builder{}
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('-')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func isNil(value any) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
+
+// toPointer returns a pointer to the given model, or the model itself if it is already a pointer.
+func toPointer(model any) reflect.Value {
+	v := reflect.ValueOf(model)
+	if v.Kind() == reflect.Pointer {
+		return v
+	}
+	ptr := reflect.New(v.Type())
+	ptr.Elem().Set(v)
+	return ptr
+}
@@ -0,0 +1,177 @@
+package authz
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/config"
+	"goyave.dev/goyave/v5/slog"
+	"goyave.dev/goyave/v5/util/testutil"
+)
+
+type testUser struct {
+	ID    uint
+	Admin bool
+}
+
+type testPost struct {
+	AuthorID uint
+}
+
+type testPostPolicy struct{}
+
+func (testPostPolicy) CanView(_ *goyave.Request, _ *testPost) bool {
+	return true
+}
+
+func (testPostPolicy) CanUpdate(request *goyave.Request, post *testPost) bool {
+	return request.User.(*testUser).ID == post.AuthorID
+}
+
+func (testPostPolicy) CanViewAny(_ *goyave.Request, _ *testPost) bool {
+	return false
+}
+
+func (testPostPolicy) Helper() bool {
+	return true
+}
+
+type testInvalidPolicy struct{}
+
+func (testInvalidPolicy) CanView(_ *goyave.Request, _ testPost) bool {
+	return true
+}
+
+func newTestAuthorizer() *Authorizer {
+	authorizer := New()
+	authorizer.Define("admin", func(request *goyave.Request, _ any) bool {
+		return request.User.(*testUser).Admin
+	})
+	authorizer.Define("publish", func(_ *goyave.Request, subject any) bool {
+		_, ok := subject.(*testPost)
+		return ok
+	})
+	RegisterPolicy[testPost](authorizer, testPostPolicy{})
+	return authorizer
+}
+
+func TestAuthorizer(t *testing.T) {
+	authorizer := newTestAuthorizer()
+
+	newRequest := func(user any) *goyave.Request {
+		request := testutil.NewTestRequest(http.MethodGet, "/test", nil)
+		request.User = user
+		return request
+	}
+
+	t.Run("policy", func(t *testing.T) {
+		request := newRequest(&testUser{ID: 1})
+		assert.True(t, authorizer.Allows(request, "view", &testPost{AuthorID: 2}))
+		assert.True(t, authorizer.Allows(request, "update", &testPost{AuthorID: 1}))
+		assert.True(t, authorizer.Allows(request, "update", testPost{AuthorID: 1}))
+		assert.False(t, authorizer.Allows(request, "update", &testPost{AuthorID: 2}))
+		assert.False(t, authorizer.Allows(request, "view-any", &testPost{}))
+		assert.False(t, authorizer.Allows(request, "helper", &testPost{}))
+		assert.False(t, authorizer.Allows(request, "delete", &testPost{AuthorID: 1}))
+	})
+
+	t.Run("gate", func(t *testing.T) {
+		assert.True(t, authorizer.Allows(newRequest(&testUser{Admin: true}), "admin", nil))
+		assert.False(t, authorizer.Allows(newRequest(&testUser{}), "admin", nil))
+		assert.False(t, authorizer.Allows(newRequest(&testUser{}), "undefined", nil))
+
+		// Falls back to the gate if the policy doesn't define the ability
+		assert.True(t, authorizer.Allows(newRequest(&testUser{}), "publish", &testPost{}))
+		assert.False(t, authorizer.Allows(newRequest(&testUser{}), "publish", "not a post"))
+	})
+
+	t.Run("no_user", func(t *testing.T) {
+		assert.False(t, authorizer.Allows(newRequest(nil), "view", &testPost{}))
+		assert.False(t, authorizer.Allows(newRequest((*testUser)(nil)), "view", &testPost{}))
+	})
+
+	t.Run("invalid_policy", func(t *testing.T) {
+		assert.Panics(t, func() {
+			RegisterPolicy[testPost](New(), testInvalidPolicy{})
+		})
+	})
+}
+
+func TestAbilityName(t *testing.T) {
+	assert.Equal(t, "view", abilityName("View"))
+	assert.Equal(t, "view-any", abilityName("ViewAny"))
+	assert.Equal(t, "force-delete", abilityName("ForceDelete"))
+}
+
+func TestMiddleware(t *testing.T) {
+	server := testutil.NewTestServerWithOptions(t, goyave.Options{Config: config.LoadDefault()})
+	middleware := &Middleware{Authorizer: newTestAuthorizer()}
+
+	newRequest := func(user any, meta map[string]any) *goyave.Request {
+		request := testutil.NewTestRequest(http.MethodGet, "/test", nil)
+		request.User = user
+		request.Route = &goyave.Route{Meta: meta}
+		return request
+	}
+
+	t.Run("no_meta", func(t *testing.T) {
+		request := newRequest(&testUser{ID: 1}, map[string]any{})
+		resp := server.TestMiddleware(middleware, request, func(response *goyave.Response, request *goyave.Request) {
+			assert.True(t, Authorize(request, "update", &testPost{AuthorID: 1}))
+			assert.False(t, Authorize(request, "update", &testPost{AuthorID: 2}))
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("allowed", func(t *testing.T) {
+		request := newRequest(&testUser{Admin: true}, map[string]any{MetaPermissions: []string{"admin"}})
+		resp := server.TestMiddleware(middleware, request, func(response *goyave.Response, _ *goyave.Request) {
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("forbidden", func(t *testing.T) {
+		request := newRequest(&testUser{}, map[string]any{MetaPermissions: []string{"admin"}})
+		resp := server.TestMiddleware(middleware, request, func(response *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware passed despite missing permission")
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("no_user", func(t *testing.T) {
+		request := newRequest(nil, map[string]any{MetaPermissions: []string{"admin"}})
+		resp := server.TestMiddleware(middleware, request, func(response *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware passed without user")
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("invalid_meta", func(t *testing.T) {
+		server := testutil.NewTestServerWithOptions(t, goyave.Options{Config: config.LoadDefault(), Logger: slog.New(slog.NewHandler(false, &bytes.Buffer{}))})
+		request := newRequest(&testUser{}, map[string]any{MetaPermissions: "admin"})
+		resp := server.TestMiddleware(middleware, request, func(response *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware passed despite invalid meta")
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("authorize_without_middleware", func(t *testing.T) {
+		request := newRequest(&testUser{}, map[string]any{})
+		assert.Panics(t, func() {
+			Authorize(request, "update", &testPost{})
+		})
+	})
+}
@@ -0,0 +1,424 @@
+package rbac
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	errorutil "goyave.dev/goyave/v5/util/errors"
+)
+
+// DefaultCacheTTL the default duration for which the resolved roles and permissions are cached.
+const DefaultCacheTTL = time.Minute
+
+// roleGraph the roles, their hierarchy and the permissions granted to them.
+type roleGraph struct {
+	names       map[uint]string
+	ids         map[string]uint
+	parents     map[uint]uint
+	permissions map[uint][]string
+}
+
+// expand returns the given roles, their ancestors and all their permissions.
+// Unknown role names are ignored.
+func (g *roleGraph) expand(roleIDs []uint) *Resolved {
+	resolved := &Resolved{
+		roles:       map[string]struct{}{},
+		permissions: map[string]struct{}{},
+	}
+	visited := map[uint]struct{}{}
+	for _, id := range roleIDs {
+		for {
+			name, ok := g.names[id]
+			if !ok {
+				break
+			}
+			if _, ok := visited[id]; ok {
+				break
+			}
+			visited[id] = struct{}{}
+			resolved.roles[name] = struct{}{}
+			for _, p := range g.permissions[id] {
+				resolved.permissions[p] = struct{}{}
+			}
+			parent, ok := g.parents[id]
+			if !ok {
+				break
+			}
+			id = parent
+		}
+	}
+	return resolved
+}
+
+// Resolved the roles and permissions of a user, including the ones
+// inherited from the hierarchy of roles.
+type Resolved struct {
+	roles       map[string]struct{}
+	permissions map[string]struct{}
+}
+
+// HasRole returns true if the user has the given role, directly or through inheritance.
+func (r *Resolved) HasRole(role string) bool {
+	_, ok := r.roles[role]
+	return ok
+}
+
+// HasPermission returns true if the user has the given permission.
+func (r *Resolved) HasPermission(permission string) bool {
+	_, ok := r.permissions[permission]
+	return ok
+}
+
+// Roles returns the names of all the roles of the user, in no particular order.
+func (r *Resolved) Roles() []string {
+	return keys(r.roles)
+}
+
+// Permissions returns the names of all the permissions of the user, in no particular order.
+func (r *Resolved) Permissions() []string {
+	return keys(r.permissions)
+}
+
+// Manager manages the roles, permissions and user-role assignments persisted in the
+// database, and resolves the permissions of users.
+//
+// The hierarchy of roles and the resolved permissions of users are cached for `CacheTTL`.
+// The cache is invalidated when the roles or assignments are modified through the manager.
+// If multiple instances of the application share the same database, the changes made by
+// another instance are visible after at most `CacheTTL`.
+//
+// The tables must be created by the application using `Migrate()`.
+type Manager struct {
+	graph          *roleGraph
+	users          map[string]*Resolved
+	cacheExpiresAt time.Time
+	now            func() time.Time
+
+	// generation incremented by each invalidation so the results of
+	// queries started before an invalidation are not cached.
+	generation uint64
+
+	DB *gorm.DB
+
+	// CacheTTL the duration for which the roles and permissions are cached.
+	// If zero or negative, nothing is cached.
+	CacheTTL time.Duration
+
+	mu sync.Mutex
+}
+
+// NewManager create a new `Manager` using the given database connection
+// and the `DefaultCacheTTL`.
+func NewManager(db *gorm.DB) *Manager {
+	return &Manager{
+		DB:       db,
+		CacheTTL: DefaultCacheTTL,
+		users:    map[string]*Resolved{},
+		now:      time.Now,
+	}
+}
+
+// Invalidate clears the cache. The next resolutions will query the database.
+func (m *Manager) Invalidate() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.graph = nil
+	m.users = map[string]*Resolved{}
+	m.generation++
+}
+
+// Resolve returns the roles and permissions of the user identified by the given ID.
+// The ID is converted to a string using `fmt.Sprint`.
+func (m *Manager) Resolve(ctx context.Context, userID any) (*Resolved, error) {
+	id := fmt.Sprint(userID)
+	m.mu.Lock()
+	m.checkCacheExpiry()
+	resolved, ok := m.users[id]
+	generation := m.generation
+	m.mu.Unlock()
+	if ok {
+		return resolved, nil
+	}
+
+	graph, err := m.getGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+	roleIDs := []uint{}
+	if err := m.DB.WithContext(ctx).Model(&UserRole{}).Where("user_id = ?", id).Pluck("role_id", &roleIDs).Error; err != nil {
+		return nil, errorutil.New(err)
+	}
+	resolved = graph.expand(roleIDs)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.CacheTTL > 0 && m.generation == generation && m.graph == graph {
+		m.users[id] = resolved
+	}
+	return resolved, nil
+}
+
+// ResolveRoles returns the given roles, their ancestors and all their permissions.
+// Unknown roles are ignored. Only the hierarchy of roles is retrieved from the
+// database, and it is cached.
+func (m *Manager) ResolveRoles(ctx context.Context, roles []string) (*Resolved, error) {
+	graph, err := m.getGraph(ctx)
+	if err != nil {
+		return nil, err
+	}
+	roleIDs := make([]uint, 0, len(roles))
+	for _, role := range roles {
+		if id, ok := graph.ids[role]; ok {
+			roleIDs = append(roleIDs, id)
+		}
+	}
+	return graph.expand(roleIDs), nil
+}
+
+// HasRole returns true if the user identified by the given ID has the given role,
+// directly or through inheritance.
+func (m *Manager) HasRole(ctx context.Context, userID any, role string) (bool, error) {
+	resolved, err := m.Resolve(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return resolved.HasRole(role), nil
+}
+
+// HasPermission returns true if the user identified by the given ID has the given permission.
+func (m *Manager) HasPermission(ctx context.Context, userID any, permission string) (bool, error) {
+	resolved, err := m.Resolve(ctx, userID)
+	if err != nil {
+		return false, err
+	}
+	return resolved.HasPermission(permission), nil
+}
+
+// CreateRole creates a new role. If parent is not empty, the new role inherits the
+// permissions of the parent role. Returns `gorm.ErrRecordNotFound` if the parent role
+// doesn't exist.
+func (m *Manager) CreateRole(ctx context.Context, name string, parent string) (*Role, error) {
+	db := m.DB.WithContext(ctx)
+	role := &Role{Name: name}
+	if parent != "" {
+		parentRole, err := m.findRole(db, parent)
+		if err != nil {
+			return nil, err
+		}
+		role.ParentID = &parentRole.ID
+	}
+	if err := db.Create(role).Error; err != nil {
+		return nil, errorutil.New(err)
+	}
+	m.Invalidate()
+	return role, nil
+}
+
+// SetParent changes the parent of the given role. If parent is empty, the role
+// doesn't inherit from any role anymore. Returns an error if the change would
+// create a cycle in the hierarchy.
+func (m *Manager) SetParent(ctx context.Context, role string, parent string) error {
+	db := m.DB.WithContext(ctx)
+	r, err := m.findRole(db, role)
+	if err != nil {
+		return err
+	}
+	var parentID *uint
+	if parent != "" {
+		graph, err := m.loadGraph(db)
+		if err != nil {
+			return err
+		}
+		id, ok := graph.ids[parent]
+		if !ok {
+			return errorutil.New(gorm.ErrRecordNotFound)
+		}
+		if graph.expand([]uint{id}).HasRole(r.Name) {
+			return errorutil.Errorf("rbac: setting %q as parent of %q would create a cycle", parent, role)
+		}
+		parentID = &id
+	}
+	if err := db.Model(r).Update("parent_id", parentID).Error; err != nil {
+		return errorutil.New(err)
+	}
+	m.Invalidate()
+	return nil
+}
+
+// DeleteRole deletes the given role, its permission grants and its assignments.
+// The roles inheriting from it don't have a parent anymore.
+func (m *Manager) DeleteRole(ctx context.Context, name string) error {
+	err := m.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		role, err := m.findRole(tx, name)
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&Role{}).Where("parent_id = ?", role.ID).Update("parent_id", nil).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", role.ID).Delete(&RolePermission{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("role_id = ?", role.ID).Delete(&UserRole{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(role).Error
+	})
+	if err != nil {
+		return errorutil.New(err)
+	}
+	m.Invalidate()
+	return nil
+}
+
+// GrantPermission grants the given permission to the given role. The permission is
+// created if it doesn't exist. Returns `gorm.ErrRecordNotFound` if the role doesn't exist.
+func (m *Manager) GrantPermission(ctx context.Context, role string, permission string) error {
+	err := m.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		r, err := m.findRole(tx, role)
+		if err != nil {
+			return err
+		}
+		p := &Permission{}
+		if err := tx.Where(&Permission{Name: permission}).FirstOrCreate(p).Error; err != nil {
+			return err
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&RolePermission{RoleID: r.ID, PermissionID: p.ID}).Error
+	})
+	if err != nil {
+		return errorutil.New(err)
+	}
+	m.Invalidate()
+	return nil
+}
+
+// RevokePermission revokes the given permission from the given role.
+func (m *Manager) RevokePermission(ctx context.Context, role string, permission string) error {
+	db := m.DB.WithContext(ctx)
+	roleIDs := db.Model(&Role{}).Select("id").Where("name = ?", role)
+	permissionIDs := db.Model(&Permission{}).Select("id").Where("name = ?", permission)
+	if err := db.Where("role_id IN (?) AND permission_id IN (?)", roleIDs, permissionIDs).Delete(&RolePermission{}).Error; err != nil {
+		return errorutil.New(err)
+	}
+	m.Invalidate()
+	return nil
+}
+
+// AssignRole assigns the given role to the user identified by the given ID.
+// Returns `gorm.ErrRecordNotFound` if the role doesn't exist.
+func (m *Manager) AssignRole(ctx context.Context, userID any, role string) error {
+	db := m.DB.WithContext(ctx)
+	r, err := m.findRole(db, role)
+	if err != nil {
+		return err
+	}
+	if err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&UserRole{UserID: fmt.Sprint(userID), RoleID: r.ID}).Error; err != nil {
+		return errorutil.New(err)
+	}
+	m.Invalidate()
+	return nil
+}
+
+// RemoveRole removes the given role from the user identified by the given ID.
+func (m *Manager) RemoveRole(ctx context.Context, userID any, role string) error {
+	db := m.DB.WithContext(ctx)
+	roleIDs := db.Model(&Role{}).Select("id").Where("name = ?", role)
+	if err := db.Where("user_id = ? AND role_id IN (?)", fmt.Sprint(userID), roleIDs).Delete(&UserRole{}).Error; err != nil {
+		return errorutil.New(err)
+	}
+	m.Invalidate()
+	return nil
+}
+
+func (m *Manager) findRole(db *gorm.DB, name string) (*Role, error) {
+	role := &Role{}
+	if err := db.Where("name = ?", name).First(role).Error; err != nil {
+		return nil, errorutil.New(err)
+	}
+	return role, nil
+}
+
+// checkCacheExpiry clears the cache if it has expired. The mutex must be locked.
+func (m *Manager) checkCacheExpiry() {
+	if m.graph != nil && !m.now().Before(m.cacheExpiresAt) {
+		m.graph = nil
+		m.users = map[string]*Resolved{}
+	}
+}
+
+func (m *Manager) getGraph(ctx context.Context) (*roleGraph, error) {
+	m.mu.Lock()
+	m.checkCacheExpiry()
+	graph := m.graph
+	generation := m.generation
+	m.mu.Unlock()
+	if graph != nil {
+		return graph, nil
+	}
+
+	graph, err := m.loadGraph(m.DB.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	if m.CacheTTL > 0 {
+		m.mu.Lock()
+		if m.graph == nil && m.generation == generation {
+			m.graph = graph
+			m.cacheExpiresAt = m.now().Add(m.CacheTTL)
+		}
+		m.mu.Unlock()
+	}
+	return graph, nil
+}
+
+func (m *Manager) loadGraph(db *gorm.DB) (*roleGraph, error) {
+	roles := []*Role{}
+	if err := db.Find(&roles).Error; err != nil {
+		return nil, errorutil.New(err)
+	}
+	permissions := []*Permission{}
+	if err := db.Find(&permissions).Error; err != nil {
+		return nil, errorutil.New(err)
+	}
+	grants := []*RolePermission{}
+	if err := db.Find(&grants).Error; err != nil {
+		return nil, errorutil.New(err)
+	}
+
+	graph := &roleGraph{
+		names:       make(map[uint]string, len(roles)),
+		ids:         make(map[string]uint, len(roles)),
+		parents:     map[uint]uint{},
+		permissions: map[uint][]string{},
+	}
+	for _, r := range roles {
+		graph.names[r.ID] = r.Name
+		graph.ids[r.Name] = r.ID
+		if r.ParentID != nil {
+			graph.parents[r.ID] = *r.ParentID
+		}
+	}
+	permissionNames := make(map[uint]string, len(permissions))
+	for _, p := range permissions {
+		permissionNames[p.ID] = p.Name
+	}
+	for _, g := range grants {
+		if name, ok := permissionNames[g.PermissionID]; ok {
+			graph.permissions[g.RoleID] = append(graph.permissions[g.RoleID], name)
+		}
+	}
+	return graph, nil
+}
+
+func keys(m map[string]struct{}) []string {
+	result := make([]string, 0, len(m))
+	for k := range m {
+		result = append(result, k)
+	}
+	return result
+}
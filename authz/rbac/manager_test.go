@@ -0,0 +1,253 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupManager(t *testing.T) *Manager {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+	require.NoError(t, Migrate(db))
+	return NewManager(db)
+}
+
+// seedManager creates the "viewer" < "editor" < "admin" hierarchy.
+func seedManager(t *testing.T, m *Manager) {
+	ctx := context.Background()
+	_, err := m.CreateRole(ctx, "viewer", "")
+	require.NoError(t, err)
+	_, err = m.CreateRole(ctx, "editor", "viewer")
+	require.NoError(t, err)
+	_, err = m.CreateRole(ctx, "admin", "editor")
+	require.NoError(t, err)
+	require.NoError(t, m.GrantPermission(ctx, "viewer", "posts.view"))
+	require.NoError(t, m.GrantPermission(ctx, "editor", "posts.update"))
+	require.NoError(t, m.GrantPermission(ctx, "admin", "posts.delete"))
+}
+
+func sorted(s []string) []string {
+	slices.Sort(s)
+	return s
+}
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("resolve", func(t *testing.T) {
+		m := setupManager(t)
+		seedManager(t, m)
+		require.NoError(t, m.AssignRole(ctx, 1, "editor"))
+		require.NoError(t, m.AssignRole(ctx, 1, "editor")) // Idempotent
+
+		resolved, err := m.Resolve(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"editor", "viewer"}, sorted(resolved.Roles()))
+		assert.Equal(t, []string{"posts.update", "posts.view"}, sorted(resolved.Permissions()))
+
+		ok, err := m.HasRole(ctx, 1, "viewer")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = m.HasRole(ctx, 1, "admin")
+		require.NoError(t, err)
+		assert.False(t, ok)
+		ok, err = m.HasPermission(ctx, "1", "posts.update")
+		require.NoError(t, err)
+		assert.True(t, ok)
+		ok, err = m.HasPermission(ctx, 1, "posts.delete")
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		resolved, err = m.Resolve(ctx, 2)
+		require.NoError(t, err)
+		assert.Empty(t, resolved.Roles())
+		assert.Empty(t, resolved.Permissions())
+	})
+
+	t.Run("resolve_roles", func(t *testing.T) {
+		m := setupManager(t)
+		seedManager(t, m)
+
+		resolved, err := m.ResolveRoles(ctx, []string{"admin", "unknown"})
+		require.NoError(t, err)
+		assert.Equal(t, []string{"admin", "editor", "viewer"}, sorted(resolved.Roles()))
+		assert.Equal(t, []string{"posts.delete", "posts.update", "posts.view"}, sorted(resolved.Permissions()))
+	})
+
+	t.Run("cache_invalidation", func(t *testing.T) {
+		m := setupManager(t)
+		seedManager(t, m)
+		require.NoError(t, m.AssignRole(ctx, 1, "viewer"))
+
+		ok, err := m.HasPermission(ctx, 1, "posts.update")
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		require.NoError(t, m.GrantPermission(ctx, "viewer", "posts.update"))
+		ok, err = m.HasPermission(ctx, 1, "posts.update")
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		require.NoError(t, m.RevokePermission(ctx, "viewer", "posts.update"))
+		ok, err = m.HasPermission(ctx, 1, "posts.update")
+		require.NoError(t, err)
+		assert.False(t, ok)
+
+		require.NoError(t, m.RemoveRole(ctx, 1, "viewer"))
+		ok, err = m.HasRole(ctx, 1, "viewer")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("cache", func(t *testing.T) {
+		m := setupManager(t)
+		seedManager(t, m)
+		now := time.Now()
+		m.now = func() time.Time { return now }
+		require.NoError(t, m.AssignRole(ctx, 1, "viewer"))
+
+		_, err := m.Resolve(ctx, 1)
+		require.NoError(t, err)
+
+		// Changes made outside of the manager are not visible until the cache expires
+		require.NoError(t, m.DB.Where("user_id = ?", "1").Delete(&UserRole{}).Error)
+		ok, err := m.HasRole(ctx, 1, "viewer")
+		require.NoError(t, err)
+		assert.True(t, ok)
+
+		now = now.Add(DefaultCacheTTL)
+		ok, err = m.HasRole(ctx, 1, "viewer")
+		require.NoError(t, err)
+		assert.False(t, ok)
+	})
+
+	t.Run("invalidated_while_loading", func(t *testing.T) {
+		m := setupManager(t)
+		seedManager(t, m)
+		require.NoError(t, m.AssignRole(ctx, 1, "viewer"))
+
+		// Simulate a concurrent modification while the graph and user roles are being loaded
+		invalidate := false
+		require.NoError(t, m.DB.Callback().Query().After("gorm:query").Register("test:invalidate", func(_ *gorm.DB) {
+			if invalidate {
+				invalidate = false
+				m.Invalidate()
+			}
+		}))
+
+		invalidate = true
+		_, err := m.getGraph(ctx)
+		require.NoError(t, err)
+		assert.Nil(t, m.graph)
+
+		graph, err := m.getGraph(ctx)
+		require.NoError(t, err)
+		assert.Same(t, graph, m.graph)
+
+		invalidate = true
+		_, err = m.Resolve(ctx, 1)
+		require.NoError(t, err)
+		assert.Empty(t, m.users)
+
+		_, err = m.Resolve(ctx, 1)
+		require.NoError(t, err)
+		assert.Len(t, m.users, 1)
+	})
+
+	t.Run("no_cache", func(t *testing.T) {
+		m := setupManager(t)
+		m.CacheTTL = 0
+		seedManager(t, m)
+		require.NoError(t, m.AssignRole(ctx, 1, "viewer"))
+
+		_, err := m.Resolve(ctx, 1)
+		require.NoError(t, err)
+		assert.Nil(t, m.graph)
+		assert.Empty(t, m.users)
+	})
+
+	t.Run("set_parent", func(t *testing.T) {
+		m := setupManager(t)
+		seedManager(t, m)
+		require.NoError(t, m.AssignRole(ctx, 1, "admin"))
+
+		require.NoError(t, m.SetParent(ctx, "admin", ""))
+		resolved, err := m.Resolve(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"admin"}, resolved.Roles())
+
+		require.NoError(t, m.SetParent(ctx, "admin", "viewer"))
+		resolved, err = m.Resolve(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"admin", "viewer"}, sorted(resolved.Roles()))
+
+		err = m.SetParent(ctx, "viewer", "admin")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "cycle")
+		err = m.SetParent(ctx, "viewer", "viewer")
+		require.Error(t, err)
+
+		err = m.SetParent(ctx, "admin", "unknown")
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+		err = m.SetParent(ctx, "unknown", "admin")
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	})
+
+	t.Run("delete_role", func(t *testing.T) {
+		m := setupManager(t)
+		seedManager(t, m)
+		require.NoError(t, m.AssignRole(ctx, 1, "admin"))
+		require.NoError(t, m.AssignRole(ctx, 2, "editor"))
+
+		require.NoError(t, m.DeleteRole(ctx, "editor"))
+
+		resolved, err := m.Resolve(ctx, 1)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"admin"}, resolved.Roles())
+		assert.Equal(t, []string{"posts.delete"}, resolved.Permissions())
+
+		resolved, err = m.Resolve(ctx, 2)
+		require.NoError(t, err)
+		assert.Empty(t, resolved.Roles())
+
+		var count int64
+		require.NoError(t, m.DB.Model(&RolePermission{}).Count(&count).Error)
+		assert.Equal(t, int64(2), count)
+
+		err = m.DeleteRole(ctx, "editor")
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	})
+
+	t.Run("role_not_found", func(t *testing.T) {
+		m := setupManager(t)
+
+		_, err := m.CreateRole(ctx, "editor", "unknown")
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+		err = m.GrantPermission(ctx, "unknown", "posts.view")
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+		err = m.AssignRole(ctx, 1, "unknown")
+		assert.True(t, errors.Is(err, gorm.ErrRecordNotFound))
+	})
+
+	t.Run("duplicate_role", func(t *testing.T) {
+		m := setupManager(t)
+		_, err := m.CreateRole(ctx, "admin", "")
+		require.NoError(t, err)
+		_, err = m.CreateRole(ctx, "admin", "")
+		assert.Error(t, err)
+	})
+}
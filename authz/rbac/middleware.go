@@ -0,0 +1,153 @@
+package rbac
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/util/errors"
+)
+
+const (
+	// MetaRoles the route meta key used to define the roles allowed to access a route or
+	// a router. The meta value is expected to be a `[]string`. The user must have at least
+	// one of the roles. Use `RequireRole()` to set it.
+	MetaRoles = "goyave.rbac-roles"
+
+	// MetaPermissions the route meta key used to define the permissions required to access
+	// a route or a router. The meta value is expected to be a `[]string`. The user must have
+	// all the permissions. Use `RequirePermission()` to set it.
+	MetaPermissions = "goyave.rbac-permissions"
+)
+
+// ExtraResolved the key used in `request.Extra` to store the `*Resolved` roles and
+// permissions of the authenticated user, when the middleware had to resolve them.
+type ExtraResolved struct{}
+
+// RoleHolder can be implemented by user DTOs to expose the names of their roles,
+// so the middleware doesn't need to query the user-role assignments. The hierarchy of
+// roles is still applied.
+type RoleHolder interface {
+	GetRoles() []string
+}
+
+// RequireRole returns the meta key and value requiring the user to have at least
+// one of the given roles.
+//
+//	router.Get("/admin", handler).SetMeta(rbac.RequireRole("admin"))
+func RequireRole(roles ...string) (string, any) {
+	return MetaRoles, roles
+}
+
+// RequirePermission returns the meta key and value requiring the user to have
+// all the given permissions.
+//
+//	router.Delete("/posts/{postID}", handler).SetMeta(rbac.RequirePermission("posts.delete"))
+func RequirePermission(permissions ...string) (string, any) {
+	return MetaPermissions, permissions
+}
+
+// Middleware checks the roles and permissions defined by the `MetaRoles` and `MetaPermissions`
+// route meta. If the user doesn't have one of the required roles or one of the required
+// permissions, the request is rejected with "403 Forbidden", handled by the status handler.
+//
+// The roles of the user are taken from `RoleHolder.GetRoles()` if the user DTO implements it.
+// Otherwise, they are retrieved from the database using the ID returned by `UserID`.
+//
+// This middleware should be used as a global middleware and executed after the
+// authentication middleware so `request.User` is set.
+//
+// **Example:**
+//
+//	rbacMiddleware := &rbac.Middleware{
+//		Manager: rbac.NewManager(db),
+//		UserID: func(user any) any {
+//			return user.(*dto.User).ID
+//		},
+//	}
+//	router.GlobalMiddleware(auth.Middleware(authenticator), rbacMiddleware)
+type Middleware struct {
+	goyave.Component
+
+	Manager *Manager
+
+	// UserID returns the ID of the given authenticated user. Required if the
+	// user DTO doesn't implement `RoleHolder`.
+	UserID func(user any) any
+}
+
+// Handle implementation of `goyave.Middleware`.
+func (m *Middleware) Handle(next goyave.Handler) goyave.Handler {
+	return func(response *goyave.Response, request *goyave.Request) {
+		roles := m.lookupMeta(request, MetaRoles)
+		permissions := m.lookupMeta(request, MetaPermissions)
+		if roles == nil && permissions == nil {
+			next(response, request)
+			return
+		}
+
+		if isNil(request.User) {
+			response.Status(http.StatusForbidden)
+			return
+		}
+
+		resolved, err := m.resolve(request)
+		if err != nil {
+			response.Error(err)
+			return
+		}
+		request.Extra[ExtraResolved{}] = resolved
+
+		if roles != nil && !hasAnyRole(resolved, roles) {
+			response.Status(http.StatusForbidden)
+			return
+		}
+		for _, permission := range permissions {
+			if !resolved.HasPermission(permission) {
+				response.Status(http.StatusForbidden)
+				return
+			}
+		}
+		next(response, request)
+	}
+}
+
+func (m *Middleware) resolve(request *goyave.Request) (*Resolved, error) {
+	if holder, ok := request.User.(RoleHolder); ok {
+		return m.Manager.ResolveRoles(request.Context(), holder.GetRoles())
+	}
+	if m.UserID == nil {
+		panic(errors.NewSkip(fmt.Errorf("rbac: the user DTO %T doesn't implement rbac.RoleHolder and the middleware doesn't define UserID", request.User), 3))
+	}
+	return m.Manager.Resolve(request.Context(), m.UserID(request.User))
+}
+
+func (m *Middleware) lookupMeta(request *goyave.Request, key string) []string {
+	meta, ok := request.Route.LookupMeta(key)
+	if !ok || meta == nil {
+		return nil
+	}
+	values, ok := meta.([]string)
+	if !ok {
+		panic(errors.NewSkip(fmt.Errorf("rbac: route meta %q is not a []string", key), 4))
+	}
+	return values
+}
+
+func hasAnyRole(resolved *Resolved, roles []string) bool {
+	for _, role := range roles {
+		if resolved.HasRole(role) {
+			return true
+		}
+	}
+	return false
+}
+
+func isNil(value any) bool {
+	if value == nil {
+		return true
+	}
+	v := reflect.ValueOf(value)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
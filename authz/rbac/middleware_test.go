@@ -0,0 +1,121 @@
+package rbac
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/config"
+	"goyave.dev/goyave/v5/slog"
+	"goyave.dev/goyave/v5/util/testutil"
+)
+
+type testUser struct {
+	ID uint
+}
+
+type testRoleHolder struct {
+	Roles []string
+}
+
+func (u *testRoleHolder) GetRoles() []string {
+	return u.Roles
+}
+
+func TestRequireMeta(t *testing.T) {
+	route := &goyave.Route{Meta: map[string]any{}}
+	route.SetMeta(RequireRole("admin", "editor"))
+	route.SetMeta(RequirePermission("posts.delete"))
+	assert.Equal(t, []string{"admin", "editor"}, route.Meta[MetaRoles])
+	assert.Equal(t, []string{"posts.delete"}, route.Meta[MetaPermissions])
+}
+
+func TestMiddleware(t *testing.T) {
+	server := testutil.NewTestServerWithOptions(t, goyave.Options{Config: config.LoadDefault()})
+	manager := setupManager(t)
+	seedManager(t, manager)
+	require.NoError(t, manager.AssignRole(context.Background(), 1, "editor"))
+
+	middleware := &Middleware{
+		Manager: manager,
+		UserID: func(user any) any {
+			return user.(*testUser).ID
+		},
+	}
+
+	newRequest := func(user any, meta map[string]any) *goyave.Request {
+		request := testutil.NewTestRequest(http.MethodGet, "/test", nil)
+		request.User = user
+		request.Route = &goyave.Route{Meta: meta}
+		return request
+	}
+
+	cases := []struct {
+		user   any
+		meta   map[string]any
+		desc   string
+		status int
+	}{
+		{desc: "no_meta", user: nil, meta: map[string]any{}, status: http.StatusOK},
+		{desc: "role", user: &testUser{ID: 1}, meta: map[string]any{MetaRoles: []string{"editor"}}, status: http.StatusOK},
+		{desc: "inherited_role", user: &testUser{ID: 1}, meta: map[string]any{MetaRoles: []string{"viewer"}}, status: http.StatusOK},
+		{desc: "any_role", user: &testUser{ID: 1}, meta: map[string]any{MetaRoles: []string{"admin", "editor"}}, status: http.StatusOK},
+		{desc: "missing_role", user: &testUser{ID: 1}, meta: map[string]any{MetaRoles: []string{"admin"}}, status: http.StatusForbidden},
+		{desc: "permissions", user: &testUser{ID: 1}, meta: map[string]any{MetaPermissions: []string{"posts.view", "posts.update"}}, status: http.StatusOK},
+		{desc: "missing_permission", user: &testUser{ID: 1}, meta: map[string]any{MetaPermissions: []string{"posts.view", "posts.delete"}}, status: http.StatusForbidden},
+		{desc: "role_and_missing_permission", user: &testUser{ID: 1}, meta: map[string]any{MetaRoles: []string{"editor"}, MetaPermissions: []string{"posts.delete"}}, status: http.StatusForbidden},
+		{desc: "unassigned_user", user: &testUser{ID: 2}, meta: map[string]any{MetaRoles: []string{"viewer"}}, status: http.StatusForbidden},
+		{desc: "no_user", user: nil, meta: map[string]any{MetaRoles: []string{"viewer"}}, status: http.StatusForbidden},
+		{desc: "typed_nil_user", user: (*testUser)(nil), meta: map[string]any{MetaRoles: []string{"viewer"}}, status: http.StatusForbidden},
+		{desc: "role_holder", user: &testRoleHolder{Roles: []string{"admin"}}, meta: map[string]any{MetaPermissions: []string{"posts.delete", "posts.view"}}, status: http.StatusOK},
+		{desc: "role_holder_forbidden", user: &testRoleHolder{Roles: []string{"viewer"}}, meta: map[string]any{MetaPermissions: []string{"posts.delete"}}, status: http.StatusForbidden},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			resp := server.TestMiddleware(middleware, newRequest(c.user, c.meta), func(response *goyave.Response, _ *goyave.Request) {
+				response.Status(http.StatusOK)
+			})
+			assert.Equal(t, c.status, resp.StatusCode)
+			assert.NoError(t, resp.Body.Close())
+		})
+	}
+
+	t.Run("extra", func(t *testing.T) {
+		request := newRequest(&testUser{ID: 1}, map[string]any{MetaRoles: []string{"editor"}})
+		resp := server.TestMiddleware(middleware, request, func(response *goyave.Response, request *goyave.Request) {
+			resolved, ok := request.Extra[ExtraResolved{}].(*Resolved)
+			if assert.True(t, ok) {
+				assert.True(t, resolved.HasPermission("posts.update"))
+			}
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("invalid_meta", func(t *testing.T) {
+		server := testutil.NewTestServerWithOptions(t, goyave.Options{Config: config.LoadDefault(), Logger: slog.New(slog.NewHandler(false, &bytes.Buffer{}))})
+		resp := server.TestMiddleware(middleware, newRequest(&testUser{ID: 1}, map[string]any{MetaRoles: "editor"}), func(response *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware passed despite invalid meta")
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("missing_user_id", func(t *testing.T) {
+		server := testutil.NewTestServerWithOptions(t, goyave.Options{Config: config.LoadDefault(), Logger: slog.New(slog.NewHandler(false, &bytes.Buffer{}))})
+		middleware := &Middleware{Manager: manager}
+		resp := server.TestMiddleware(middleware, newRequest(&testUser{ID: 1}, map[string]any{MetaRoles: []string{"editor"}}), func(response *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware passed without user ID")
+			response.Status(http.StatusOK)
+		})
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+}
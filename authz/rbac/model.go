@@ -0,0 +1,60 @@
+package rbac
+
+import (
+	"gorm.io/gorm"
+
+	errorutil "goyave.dev/goyave/v5/util/errors"
+)
+
+// Role a named set of permissions assigned to users. A role inherits all the permissions
+// of its parent, recursively: if the "admin" role has the "editor" role as parent, the
+// users having the "admin" role also have the "editor" role and its permissions.
+type Role struct {
+	ParentID *uint
+	Name     string `gorm:"uniqueIndex;size:255"`
+	ID       uint   `gorm:"primaryKey"`
+}
+
+// TableName returns the name of the table used for roles.
+func (Role) TableName() string {
+	return "roles"
+}
+
+// Permission an action that can be granted to roles, such as "posts.delete".
+type Permission struct {
+	Name string `gorm:"uniqueIndex;size:255"`
+	ID   uint   `gorm:"primaryKey"`
+}
+
+// TableName returns the name of the table used for permissions.
+func (Permission) TableName() string {
+	return "permissions"
+}
+
+// RolePermission the association between a role and a permission granted to it.
+type RolePermission struct {
+	RoleID       uint `gorm:"primaryKey;autoIncrement:false"`
+	PermissionID uint `gorm:"primaryKey;autoIncrement:false"`
+}
+
+// TableName returns the name of the table used for the permissions granted to roles.
+func (RolePermission) TableName() string {
+	return "role_permissions"
+}
+
+// UserRole the assignment of a role to a user. The user ID is stored as a string
+// so any type of identifier can be used.
+type UserRole struct {
+	UserID string `gorm:"primaryKey;size:255"`
+	RoleID uint   `gorm:"primaryKey;autoIncrement:false;index"`
+}
+
+// TableName returns the name of the table used for the roles assigned to users.
+func (UserRole) TableName() string {
+	return "user_roles"
+}
+
+// Migrate creates or updates the tables used by the RBAC models.
+func Migrate(db *gorm.DB) error {
+	return errorutil.New(db.AutoMigrate(&Role{}, &Permission{}, &RolePermission{}, &UserRole{}))
+}
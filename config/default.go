@@ -19,6 +19,11 @@ var configDefaults = object{
 		"idleTimeout":           &Entry{20, []any{}, reflect.Int, false, true},
 		"websocketCloseTimeout": &Entry{10, []any{}, reflect.Int, false, true},
 		"maxUploadSize":         &Entry{10.0, []any{}, reflect.Float64, false, true},
+		"trustedProxies":        &Entry{[]string{}, []any{}, reflect.String, true, true},
+		"maintenance": object{
+			"file":         &Entry{"", []any{}, reflect.String, false, true},
+			"pollInterval": &Entry{5, []any{}, reflect.Int, false, true},
+		},
 		"proxy": object{
 			"protocol": &Entry{"http", []any{"http", "https"}, reflect.String, false, true},
 			"host":     &Entry{nil, []any{}, reflect.String, false, false},
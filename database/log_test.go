@@ -87,6 +87,19 @@ func TestLogger(t *testing.T) {
 		assert.Regexp(t, regexp.MustCompile(`{"time":"\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}\.\d{1,9}((\+\d{2}:\d{2})|Z)?","level":"ERROR","source":{"function":".+","file":".+","line":\d+},"msg":"message 1"}\n`), buf.String())
 	})
 
+	t.Run("Trace_context_attrs", func(t *testing.T) {
+		buf := bytes.NewBuffer(make([]byte, 0, 1024))
+		slogger := slog.New(stdslog.NewJSONHandler(buf, &stdslog.HandlerOptions{Level: stdslog.LevelDebug}))
+		l := NewLogger(func() *slog.Logger { return slogger })
+
+		ctx := slog.ContextWithAttrs(context.Background(), stdslog.String("requestId", "123"))
+		l.Trace(ctx, time.Now(), func() (sql string, rowsAffected int64) {
+			return "SELECT * FROM some_table", 4
+		}, fmt.Errorf("test error"))
+
+		assert.Regexp(t, regexp.MustCompile(`"level":"ERROR","msg":".+","requestId":"123"}\n`), buf.String())
+	})
+
 	t.Run("Trace", func(t *testing.T) {
 		t.Run("nil_slogger", func(t *testing.T) {
 			l := NewLogger(nil)
@@ -0,0 +1,116 @@
+package httpsession
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"goyave.dev/goyave/v5/util/errors"
+)
+
+// MaxCookieSize the maximum size of the value of a session cookie stored by the `CookieStore`.
+// Most browsers reject cookies larger than 4096 bytes.
+const MaxCookieSize = 4000
+
+// ErrCookieTooLarge returned by `CookieStore.Save()` if the encoded
+// session exceeds `MaxCookieSize`.
+var ErrCookieTooLarge = fmt.Errorf("httpsession: session data too large to be stored in a cookie")
+
+// CookieStore a `Store` keeping the sessions on the client side, in the session cookie.
+// The session data is encrypted and authenticated with AES-GCM, so the client can
+// neither read nor modify it. No server-side storage is needed, but the size of the
+// sessions is limited by `MaxCookieSize`.
+//
+// Because the data is held by the client, a session cannot be revoked server-side before
+// it expires: `Delete` only clears the cookie. Regenerating or destroying a session
+// doesn't prevent a previously copied cookie from being used until it expires.
+type CookieStore struct {
+	aeads []cipher.AEAD
+	now   func() time.Time
+}
+
+// NewCookieStore create a new `CookieStore` using the given keys. Each key must be
+// 16, 24 or 32 bytes long to select AES-128, AES-192 or AES-256. The first key is used to
+// encrypt the sessions, all keys are used to decrypt them, allowing key rotation.
+func NewCookieStore(keys ...[]byte) (*CookieStore, error) {
+	if len(keys) == 0 {
+		return nil, errors.New(fmt.Errorf("httpsession: at least one key is required"))
+	}
+	store := &CookieStore{
+		aeads: make([]cipher.AEAD, 0, len(keys)),
+		now:   time.Now,
+	}
+	for _, key := range keys {
+		block, err := aes.NewCipher(key)
+		if err != nil {
+			return nil, errors.New(err)
+		}
+		aead, err := cipher.NewGCM(block)
+		if err != nil {
+			return nil, errors.New(err)
+		}
+		store.aeads = append(store.aeads, aead)
+	}
+	return store, nil
+}
+
+type cookiePayload struct {
+	ExpiresAt time.Time `json:"expiresAt"`
+	Data      *Data     `json:"data"`
+}
+
+// Load implementation of `Store`. Invalid and tampered tokens are ignored.
+func (s *CookieStore) Load(_ context.Context, token string) (*Data, error) {
+	ciphertext, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, nil
+	}
+	for _, aead := range s.aeads {
+		if len(ciphertext) < aead.NonceSize() {
+			continue
+		}
+		nonce, encrypted := ciphertext[:aead.NonceSize()], ciphertext[aead.NonceSize():]
+		plaintext, err := aead.Open(nil, nonce, encrypted, nil)
+		if err != nil {
+			continue
+		}
+		payload := &cookiePayload{}
+		if err := json.Unmarshal(plaintext, payload); err != nil || payload.Data == nil {
+			return nil, nil
+		}
+		if !s.now().Before(payload.ExpiresAt) {
+			return nil, nil
+		}
+		return payload.Data, nil
+	}
+	return nil, nil
+}
+
+// Save implementation of `Store`.
+func (s *CookieStore) Save(_ context.Context, data *Data, ttl time.Duration) (string, error) {
+	plaintext, err := json.Marshal(&cookiePayload{Data: data, ExpiresAt: s.now().Add(ttl)})
+	if err != nil {
+		return "", errors.New(err)
+	}
+	aead := s.aeads[0]
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", errors.New(err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, plaintext, nil))
+	if len(token) > MaxCookieSize {
+		return "", errors.New(ErrCookieTooLarge)
+	}
+	return token, nil
+}
+
+// Delete implementation of `Store`. The session is held by the client,
+// so there is nothing to remove server-side.
+func (s *CookieStore) Delete(_ context.Context, _ string) error {
+	return nil
+}
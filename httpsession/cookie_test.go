@@ -0,0 +1,92 @@
+package httpsession
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCookieStore(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	t.Run("new", func(t *testing.T) {
+		_, err := NewCookieStore()
+		require.Error(t, err)
+		_, err = NewCookieStore([]byte("invalid"))
+		require.Error(t, err)
+	})
+
+	store, err := NewCookieStore(key)
+	require.NoError(t, err)
+	store.now = func() time.Time { return now }
+
+	token, err := store.Save(ctx, newTestData(now), time.Minute)
+	require.NoError(t, err)
+	assert.NotContains(t, token, "johndoe")
+
+	data, err := store.Load(ctx, token)
+	require.NoError(t, err)
+	require.NotNil(t, data)
+	assert.Equal(t, "id", data.ID)
+	assert.Equal(t, map[string]any{"user": 1.0, "name": "johndoe"}, data.Values)
+
+	other, err := store.Save(ctx, newTestData(now), time.Minute)
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other) // Random nonce
+
+	require.NoError(t, store.Delete(ctx, token))
+
+	t.Run("tampered", func(t *testing.T) {
+		raw, err := base64.RawURLEncoding.DecodeString(token)
+		require.NoError(t, err)
+		raw[len(raw)-1] ^= 1
+		data, err := store.Load(ctx, base64.RawURLEncoding.EncodeToString(raw))
+		require.NoError(t, err)
+		assert.Nil(t, data)
+
+		for _, invalid := range []string{"", "not base64!", "c2hvcnQ"} {
+			data, err = store.Load(ctx, invalid)
+			require.NoError(t, err)
+			assert.Nil(t, data)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		store, err := NewCookieStore(key)
+		require.NoError(t, err)
+		store.now = func() time.Time { return now.Add(time.Minute) }
+		data, err := store.Load(ctx, token)
+		require.NoError(t, err)
+		assert.Nil(t, data)
+	})
+
+	t.Run("key_rotation", func(t *testing.T) {
+		newKey := []byte("fedcba9876543210")
+		rotated, err := NewCookieStore(newKey, key)
+		require.NoError(t, err)
+		rotated.now = store.now
+		data, err := rotated.Load(ctx, token)
+		require.NoError(t, err)
+		require.NotNil(t, data)
+
+		newToken, err := rotated.Save(ctx, data, time.Minute)
+		require.NoError(t, err)
+		data, err = store.Load(ctx, newToken)
+		require.NoError(t, err)
+		assert.Nil(t, data) // Old store doesn't know the new key
+	})
+
+	t.Run("too_large", func(t *testing.T) {
+		data := newTestData(now)
+		data.Values["large"] = strings.Repeat("a", MaxCookieSize)
+		_, err := store.Save(ctx, data, time.Minute)
+		require.ErrorIs(t, err, ErrCookieTooLarge)
+	})
+}
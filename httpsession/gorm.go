@@ -0,0 +1,86 @@
+package httpsession
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	errorutil "goyave.dev/goyave/v5/util/errors"
+)
+
+// GORMSession the model used by the `GORMStore` to persist the sessions.
+// The table must be created by the application, for example using auto-migration:
+//
+//	db.AutoMigrate(&httpsession.GORMSession{})
+type GORMSession struct {
+	ExpiresAt time.Time `gorm:"index"`
+	ID        string    `gorm:"primaryKey;size:255"`
+	Data      string
+}
+
+// TableName returns the name of the table used by the `GORMStore`.
+func (GORMSession) TableName() string {
+	return "sessions"
+}
+
+// GORMStore a `Store` persisting the sessions in a database using GORM, allowing
+// multiple instances of the application to share the same sessions.
+//
+// Expired rows are ignored but not removed automatically. Call `Cleanup()` periodically
+// to remove them.
+type GORMStore struct {
+	DB  *gorm.DB
+	now func() time.Time
+}
+
+// NewGORMStore create a new `GORMStore` using the given database connection.
+func NewGORMStore(db *gorm.DB) *GORMStore {
+	return &GORMStore{
+		DB:  db,
+		now: time.Now,
+	}
+}
+
+// Load implementation of `Store`.
+func (s *GORMStore) Load(ctx context.Context, token string) (*Data, error) {
+	rows := []*GORMSession{}
+	err := s.DB.WithContext(ctx).Where("id = ? AND expires_at > ?", token, s.now()).Limit(1).Find(&rows).Error
+	if err != nil {
+		return nil, errorutil.New(err)
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return decodeData([]byte(rows[0].Data))
+}
+
+// Save implementation of `Store`.
+func (s *GORMStore) Save(ctx context.Context, data *Data, ttl time.Duration) (string, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return "", errorutil.New(err)
+	}
+	row := &GORMSession{
+		ID:        data.ID,
+		Data:      string(b),
+		ExpiresAt: s.now().Add(ttl),
+	}
+	err = s.DB.WithContext(ctx).Clauses(clause.OnConflict{UpdateAll: true}).Create(row).Error
+	if err != nil {
+		return "", errorutil.New(err)
+	}
+	return data.ID, nil
+}
+
+// Delete implementation of `Store`.
+func (s *GORMStore) Delete(ctx context.Context, token string) error {
+	return errorutil.New(s.DB.WithContext(ctx).Where("id = ?", token).Delete(&GORMSession{}).Error)
+}
+
+// Cleanup removes the expired rows from the database.
+func (s *GORMStore) Cleanup(ctx context.Context) error {
+	return errorutil.New(s.DB.WithContext(ctx).Where("expires_at <= ?", s.now()).Delete(&GORMSession{}).Error)
+}
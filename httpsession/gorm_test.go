@@ -0,0 +1,101 @@
+package httpsession
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupGORMStore(t *testing.T) *GORMStore {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+	require.NoError(t, db.AutoMigrate(&GORMSession{}))
+	return NewGORMStore(db)
+}
+
+func TestGORMStore(t *testing.T) {
+	store := setupGORMStore(t)
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	data, err := store.Load(ctx, "id")
+	require.NoError(t, err)
+	assert.Nil(t, data)
+
+	token, err := store.Save(ctx, newTestData(now), time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "id", token)
+
+	data, err = store.Load(ctx, "id")
+	require.NoError(t, err)
+	require.NotNil(t, data)
+	assert.Equal(t, map[string]any{"user": 1.0, "name": "johndoe"}, data.Values)
+	assert.True(t, now.Equal(data.CreatedAt))
+
+	// Update
+	updated := newTestData(now)
+	updated.Values = map[string]any{"user": 2}
+	_, err = store.Save(ctx, updated, time.Hour)
+	require.NoError(t, err)
+	data, err = store.Load(ctx, "id")
+	require.NoError(t, err)
+	require.NotNil(t, data)
+	assert.Equal(t, map[string]any{"user": 2.0}, data.Values)
+
+	data, err = store.Load(ctx, "")
+	require.NoError(t, err)
+	assert.Nil(t, data)
+
+	t.Run("expired", func(t *testing.T) {
+		_, err := store.Save(ctx, &Data{ID: "short"}, time.Minute)
+		require.NoError(t, err)
+		now = now.Add(time.Minute)
+		data, err := store.Load(ctx, "short")
+		require.NoError(t, err)
+		assert.Nil(t, data)
+
+		require.NoError(t, store.Cleanup(ctx))
+		rows := []*GORMSession{}
+		require.NoError(t, store.DB.Find(&rows).Error)
+		require.Len(t, rows, 1)
+		assert.Equal(t, "id", rows[0].ID)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, store.Delete(ctx, "id"))
+		require.NoError(t, store.Delete(ctx, "id"))
+		data, err := store.Load(ctx, "id")
+		require.NoError(t, err)
+		assert.Nil(t, data)
+	})
+
+	t.Run("invalid_data", func(t *testing.T) {
+		require.NoError(t, store.DB.Create(&GORMSession{ID: "invalid", Data: "{", ExpiresAt: now.Add(time.Hour)}).Error)
+		_, err := store.Load(ctx, "invalid")
+		require.Error(t, err)
+
+		_, err = store.Save(ctx, &Data{ID: "invalid", Values: map[string]any{"a": make(chan int)}}, time.Minute)
+		require.Error(t, err)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		store := setupGORMStore(t)
+		require.NoError(t, store.DB.Migrator().DropTable(&GORMSession{}))
+		_, err := store.Load(ctx, "id")
+		require.Error(t, err)
+		_, err = store.Save(ctx, &Data{ID: "id"}, time.Minute)
+		require.Error(t, err)
+	})
+}
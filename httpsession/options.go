@@ -0,0 +1,125 @@
+package httpsession
+
+import (
+	"net/http"
+	"time"
+)
+
+const (
+	// DefaultCookieName the default name of the session cookie.
+	DefaultCookieName = "goyave_session"
+
+	// DefaultIdleTimeout the default duration of inactivity after which a session expires.
+	DefaultIdleTimeout = 2 * time.Hour
+
+	// DefaultAbsoluteTimeout the default maximum lifetime of a session.
+	DefaultAbsoluteTimeout = 24 * time.Hour
+)
+
+// Options holds the HTTP sessions configuration for a router.
+type Options struct {
+	// Store persists the sessions.
+	Store Store
+
+	// CookieName the name of the session cookie.
+	// Default value is "goyave_session".
+	CookieName string
+
+	// CookiePath the path of the session cookie. If empty, "/" is used.
+	CookiePath string
+
+	// CookieDomain the domain of the session cookie. If empty, the cookie
+	// is only sent to the requested host.
+	CookieDomain string
+
+	// CookieSameSite the SameSite attribute of the session cookie.
+	// Default value is `http.SameSiteLaxMode`.
+	CookieSameSite http.SameSite
+
+	// IdleTimeout the duration of inactivity after which the session expires.
+	// If zero or negative, `DefaultIdleTimeout` is used.
+	// Default value is 2 hours.
+	IdleTimeout time.Duration
+
+	// AbsoluteTimeout the maximum lifetime of the session, regardless of its activity.
+	// If zero, sessions only expire after being idle.
+	// Default value is 24 hours.
+	AbsoluteTimeout time.Duration
+}
+
+// Default create new session options with default settings and a `MemoryStore`.
+// The returned value can be used as a starting point for
+// customized options.
+func Default() *Options {
+	return &Options{
+		Store:           NewMemoryStore(),
+		CookieName:      DefaultCookieName,
+		CookieSameSite:  http.SameSiteLaxMode,
+		IdleTimeout:     DefaultIdleTimeout,
+		AbsoluteTimeout: DefaultAbsoluteTimeout,
+	}
+}
+
+// IsExpired returns true if the session having the given data expired at the given time.
+func (o *Options) IsExpired(data *Data, now time.Time) bool {
+	if !now.Before(data.LastActivity.Add(o.getIdleTimeout())) {
+		return true
+	}
+	return o.AbsoluteTimeout > 0 && !now.Before(data.CreatedAt.Add(o.AbsoluteTimeout))
+}
+
+// TTL returns the duration after which the session having the given data expires
+// if it is not used again.
+func (o *Options) TTL(data *Data, now time.Time) time.Duration {
+	ttl := o.getIdleTimeout()
+	if o.AbsoluteTimeout > 0 {
+		ttl = min(ttl, data.CreatedAt.Add(o.AbsoluteTimeout).Sub(now))
+	}
+	return ttl
+}
+
+// Cookie returns the session cookie containing the given value.
+// If `maxAge` is zero, the cookie is deleted when the browser is closed. If negative,
+// the cookie is deleted immediately.
+func (o *Options) Cookie(value string, maxAge time.Duration, secure bool) *http.Cookie {
+	path := o.CookiePath
+	if path == "" {
+		path = "/"
+	}
+	sameSite := o.CookieSameSite
+	if sameSite == 0 {
+		sameSite = http.SameSiteLaxMode
+	}
+	cookie := &http.Cookie{
+		Name:     o.GetCookieName(),
+		Value:    value,
+		Path:     path,
+		Domain:   o.CookieDomain,
+		Secure:   secure,
+		HttpOnly: true,
+		SameSite: sameSite,
+	}
+	switch {
+	case maxAge < 0:
+		cookie.MaxAge = -1
+	case maxAge > 0:
+		cookie.MaxAge = int(maxAge.Seconds())
+	}
+	return cookie
+}
+
+// GetCookieName returns the name of the session cookie, or `DefaultCookieName`
+// if `CookieName` is empty.
+func (o *Options) GetCookieName() string {
+	if o.CookieName == "" {
+		return DefaultCookieName
+	}
+	return o.CookieName
+}
+
+func (o *Options) getIdleTimeout() time.Duration {
+	if o.IdleTimeout <= 0 {
+		return DefaultIdleTimeout
+	}
+	return o.IdleTimeout
+}
@@ -0,0 +1,238 @@
+package httpsession
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"maps"
+	"sync"
+	"time"
+
+	"goyave.dev/goyave/v5/util/errors"
+)
+
+// Data the serializable content of a session, persisted by the stores.
+type Data struct {
+	CreatedAt    time.Time      `json:"createdAt"`
+	LastActivity time.Time      `json:"lastActivity"`
+	Values       map[string]any `json:"values"`
+	Flash        map[string]any `json:"flash,omitempty"`
+	ID           string         `json:"id"`
+}
+
+// Session the HTTP session of a client. Sessions are retrieved in handlers using
+// `request.Session()`.
+//
+// Values are serialized as JSON by the stores, so they are decoded as JSON types when the
+// session is loaded: numbers are `float64`, objects are `map[string]any` and arrays are `[]any`.
+//
+// A session is safe for concurrent use.
+type Session struct {
+	data        *Data
+	oldFlash    map[string]any
+	previousID  string
+	mu          sync.RWMutex
+	isNew       bool
+	modified    bool
+	regenerated bool
+	destroyed   bool
+}
+
+// New create a new empty session with a random ID.
+func New(now time.Time) (*Session, error) {
+	id, err := generateID()
+	if err != nil {
+		return nil, err
+	}
+	return &Session{
+		data: &Data{
+			ID:           id,
+			CreatedAt:    now,
+			LastActivity: now,
+			Values:       map[string]any{},
+			Flash:        map[string]any{},
+		},
+		oldFlash: map[string]any{},
+		isNew:    true,
+	}, nil
+}
+
+// Load create a session from the data retrieved from a store. The flash values
+// of the data become readable for the current request and are then discarded.
+func Load(data *Data) *Session {
+	s := &Session{
+		data:     data,
+		oldFlash: data.Flash,
+	}
+	if s.data.Values == nil {
+		s.data.Values = map[string]any{}
+	}
+	if s.oldFlash == nil {
+		s.oldFlash = map[string]any{}
+	}
+	s.data.Flash = map[string]any{}
+	s.modified = len(s.oldFlash) > 0
+	return s
+}
+
+// ID returns the identifier of the session.
+func (s *Session) ID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.ID
+}
+
+// CreatedAt returns the time at which the session was created.
+func (s *Session) CreatedAt() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.CreatedAt
+}
+
+// LastActivity returns the time of the last request using this session.
+func (s *Session) LastActivity() time.Time {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.data.LastActivity
+}
+
+// Get returns the value identified by the given key. The values flashed by the
+// previous request are also looked up.
+func (s *Session) Get(key string) (any, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if v, ok := s.data.Values[key]; ok {
+		return v, true
+	}
+	v, ok := s.oldFlash[key]
+	return v, ok
+}
+
+// Has returns true if the session contains a value identified by the given key.
+func (s *Session) Has(key string) bool {
+	_, ok := s.Get(key)
+	return ok
+}
+
+// Set the value identified by the given key.
+func (s *Session) Set(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Values[key] = value
+	s.modified = true
+}
+
+// Delete the value identified by the given key.
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.data.Values, key)
+	delete(s.oldFlash, key)
+	s.modified = true
+}
+
+// Flash sets a value that will only be available in the next request
+// using this session (for example a success message after a redirection).
+func (s *Session) Flash(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Flash[key] = value
+	s.modified = true
+}
+
+// Reflash keeps the values flashed by the previous request for one more request.
+func (s *Session) Reflash() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, v := range s.oldFlash {
+		if _, ok := s.data.Flash[k]; !ok {
+			s.data.Flash[k] = v
+		}
+	}
+	s.modified = true
+}
+
+// Regenerate assigns a new ID to the session, keeping its values. The previous
+// ID is invalidated. The ID should be regenerated when the privileges of the client
+// change (login, logout) to prevent session fixation attacks.
+func (s *Session) Regenerate() error {
+	id, err := generateID()
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.regenerated {
+		s.previousID = s.data.ID
+	}
+	s.data.ID = id
+	s.regenerated = true
+	s.modified = true
+	return nil
+}
+
+// Destroy invalidates the session. It is removed from the store and the client's
+// cookie is deleted at the end of the request.
+func (s *Session) Destroy() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.Values = map[string]any{}
+	s.data.Flash = map[string]any{}
+	s.oldFlash = map[string]any{}
+	s.destroyed = true
+}
+
+// IsNew returns true if the session was created during the current request.
+func (s *Session) IsNew() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.isNew
+}
+
+// IsModified returns true if the session was modified during the current request.
+func (s *Session) IsModified() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.modified
+}
+
+// IsDestroyed returns true if `Destroy()` was called during the current request.
+func (s *Session) IsDestroyed() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.destroyed
+}
+
+// IsRegenerated returns true if `Regenerate()` was called during the current request.
+func (s *Session) IsRegenerated() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.regenerated
+}
+
+// PreviousID returns the ID the session had before being regenerated,
+// or an empty string if the session was not regenerated.
+func (s *Session) PreviousID() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.previousID
+}
+
+// Touch updates the last activity time and returns a copy of the session's data,
+// ready to be saved in a store.
+func (s *Session) Touch(now time.Time) *Data {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.data.LastActivity = now
+	data := *s.data
+	data.Values = maps.Clone(s.data.Values)
+	data.Flash = maps.Clone(s.data.Flash)
+	return &data
+}
+
+func generateID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.New(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
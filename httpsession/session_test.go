@@ -0,0 +1,174 @@
+package httpsession
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSession(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("new", func(t *testing.T) {
+		s, err := New(now)
+		require.NoError(t, err)
+		assert.Len(t, s.ID(), 43)
+		assert.True(t, s.IsNew())
+		assert.False(t, s.IsModified())
+		assert.Equal(t, now, s.CreatedAt())
+		assert.Equal(t, now, s.LastActivity())
+
+		other, err := New(now)
+		require.NoError(t, err)
+		assert.NotEqual(t, s.ID(), other.ID())
+	})
+
+	t.Run("values", func(t *testing.T) {
+		s, err := New(now)
+		require.NoError(t, err)
+		s.Set("key", "value")
+		assert.True(t, s.IsModified())
+		v, ok := s.Get("key")
+		assert.True(t, ok)
+		assert.Equal(t, "value", v)
+		assert.True(t, s.Has("key"))
+
+		s.Delete("key")
+		_, ok = s.Get("key")
+		assert.False(t, ok)
+		assert.False(t, s.Has("key"))
+	})
+
+	t.Run("flash", func(t *testing.T) {
+		s, err := New(now)
+		require.NoError(t, err)
+		s.Flash("message", "hello")
+		assert.False(t, s.Has("message")) // Only available in the next request
+
+		next := Load(s.Touch(now))
+		assert.False(t, next.IsNew())
+		assert.True(t, next.IsModified()) // Flash values must be cleared
+		v, ok := next.Get("message")
+		assert.True(t, ok)
+		assert.Equal(t, "hello", v)
+
+		third := Load(next.Touch(now))
+		assert.False(t, third.Has("message"))
+		assert.False(t, third.IsModified())
+
+		// Reflash
+		next = Load(s.Touch(now))
+		next.Reflash()
+		third = Load(next.Touch(now))
+		assert.True(t, third.Has("message"))
+
+		// Delete removes old flash
+		next = Load(s.Touch(now))
+		next.Delete("message")
+		assert.False(t, next.Has("message"))
+	})
+
+	t.Run("regenerate", func(t *testing.T) {
+		s, err := New(now)
+		require.NoError(t, err)
+		s.Set("key", "value")
+		id := s.ID()
+		require.NoError(t, s.Regenerate())
+		assert.NotEqual(t, id, s.ID())
+		assert.Equal(t, id, s.PreviousID())
+		assert.True(t, s.IsRegenerated())
+		assert.True(t, s.Has("key"))
+
+		require.NoError(t, s.Regenerate())
+		assert.Equal(t, id, s.PreviousID())
+	})
+
+	t.Run("destroy", func(t *testing.T) {
+		s, err := New(now)
+		require.NoError(t, err)
+		s.Set("key", "value")
+		s.Destroy()
+		assert.True(t, s.IsDestroyed())
+		assert.False(t, s.Has("key"))
+	})
+
+	t.Run("touch", func(t *testing.T) {
+		s, err := New(now)
+		require.NoError(t, err)
+		s.Set("key", "value")
+		data := s.Touch(now.Add(time.Minute))
+		assert.Equal(t, now.Add(time.Minute), s.LastActivity())
+		assert.Equal(t, now.Add(time.Minute), data.LastActivity)
+		assert.Equal(t, now, data.CreatedAt)
+		assert.Equal(t, s.ID(), data.ID)
+
+		// Copy
+		data.Values["key"] = "modified"
+		v, _ := s.Get("key")
+		assert.Equal(t, "value", v)
+	})
+
+	t.Run("load_nil_maps", func(t *testing.T) {
+		s := Load(&Data{ID: "id"})
+		s.Set("key", "value")
+		s.Flash("flash", "value")
+		assert.True(t, s.Has("key"))
+	})
+}
+
+func TestOptions(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("expiry", func(t *testing.T) {
+		options := &Options{IdleTimeout: time.Hour, AbsoluteTimeout: 3 * time.Hour}
+		data := &Data{CreatedAt: now, LastActivity: now.Add(2 * time.Hour)}
+		assert.False(t, options.IsExpired(data, now.Add(2*time.Hour+30*time.Minute)))
+		assert.True(t, options.IsExpired(data, now.Add(3*time.Hour+30*time.Minute))) // Idle
+		assert.Equal(t, time.Hour, options.TTL(data, now.Add(time.Hour)))
+		assert.Equal(t, 30*time.Minute, options.TTL(data, now.Add(2*time.Hour+30*time.Minute)))
+
+		data.LastActivity = now.Add(2*time.Hour + 50*time.Minute)
+		assert.True(t, options.IsExpired(data, now.Add(3*time.Hour))) // Absolute
+
+		options = &Options{}
+		data = &Data{CreatedAt: now, LastActivity: now}
+		assert.False(t, options.IsExpired(data, now.Add(DefaultIdleTimeout-time.Second)))
+		assert.True(t, options.IsExpired(data, now.Add(DefaultIdleTimeout)))
+		assert.Equal(t, DefaultIdleTimeout, options.TTL(data, now))
+	})
+
+	t.Run("cookie", func(t *testing.T) {
+		options := &Options{}
+		cookie := options.Cookie("value", 0, false)
+		assert.Equal(t, DefaultCookieName, cookie.Name)
+		assert.Equal(t, "value", cookie.Value)
+		assert.Equal(t, "/", cookie.Path)
+		assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+		assert.True(t, cookie.HttpOnly)
+		assert.False(t, cookie.Secure)
+		assert.Equal(t, 0, cookie.MaxAge)
+
+		options = &Options{CookieName: "session", CookiePath: "/app", CookieDomain: "example.com", CookieSameSite: http.SameSiteStrictMode}
+		cookie = options.Cookie("value", time.Hour, true)
+		assert.Equal(t, "session", cookie.Name)
+		assert.Equal(t, "/app", cookie.Path)
+		assert.Equal(t, "example.com", cookie.Domain)
+		assert.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+		assert.True(t, cookie.Secure)
+		assert.Equal(t, 3600, cookie.MaxAge)
+
+		cookie = options.Cookie("", -1, true)
+		assert.Equal(t, -1, cookie.MaxAge)
+	})
+
+	t.Run("default", func(t *testing.T) {
+		options := Default()
+		assert.IsType(t, &MemoryStore{}, options.Store)
+		assert.Equal(t, DefaultCookieName, options.CookieName)
+		assert.Equal(t, DefaultIdleTimeout, options.IdleTimeout)
+		assert.Equal(t, DefaultAbsoluteTimeout, options.AbsoluteTimeout)
+	})
+}
@@ -0,0 +1,119 @@
+package httpsession
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"goyave.dev/goyave/v5/util/errors"
+)
+
+// Store persists the sessions.
+//
+// `Load` returns the data identified by the given token (the value of the session cookie),
+// or `nil` if it doesn't exist, has expired or is invalid.
+//
+// `Save` persists the given data so it expires after the given TTL, and returns the token
+// identifying it. Server-side stores use the session ID as token. Client-side stores
+// (such as the `CookieStore`) use the encoded data as token.
+//
+// `Delete` removes the data identified by the given token. Deleting a token that
+// doesn't exist is not an error.
+//
+// Implementations must be safe for concurrent use.
+type Store interface {
+	Load(ctx context.Context, token string) (*Data, error)
+	Save(ctx context.Context, data *Data, ttl time.Duration) (string, error)
+	Delete(ctx context.Context, token string) error
+}
+
+type memorySession struct {
+	expiresAt time.Time
+	data      []byte
+}
+
+// MemoryStore a `Store` keeping the sessions in memory. The sessions are not shared
+// between multiple instances of the application and are lost when the application stops.
+//
+// Expired sessions are periodically removed when a session is saved.
+type MemoryStore struct {
+	sessions  map[string]memorySession
+	nextSweep time.Time
+	now       func() time.Time
+	mu        sync.Mutex
+
+	// SweepInterval the minimum duration between two removals of the expired sessions.
+	// Defaults to one minute.
+	SweepInterval time.Duration
+}
+
+// NewMemoryStore create a new empty `MemoryStore`.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		sessions:      map[string]memorySession{},
+		now:           time.Now,
+		SweepInterval: time.Minute,
+	}
+}
+
+// Load implementation of `Store`.
+func (s *MemoryStore) Load(_ context.Context, token string) (*Data, error) {
+	s.mu.Lock()
+	session, ok := s.sessions[token]
+	s.mu.Unlock()
+	if !ok || !s.now().Before(session.expiresAt) {
+		return nil, nil
+	}
+	return decodeData(session.data)
+}
+
+// Save implementation of `Store`.
+func (s *MemoryStore) Save(_ context.Context, data *Data, ttl time.Duration) (string, error) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return "", errors.New(err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.sweep(now)
+	s.sessions[data.ID] = memorySession{data: b, expiresAt: now.Add(ttl)}
+	return data.ID, nil
+}
+
+// Delete implementation of `Store`.
+func (s *MemoryStore) Delete(_ context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, token)
+	return nil
+}
+
+// Len returns the number of sessions in the store, including the expired ones
+// that were not removed yet.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.sessions)
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	for k, session := range s.sessions {
+		if !now.Before(session.expiresAt) {
+			delete(s.sessions, k)
+		}
+	}
+	s.nextSweep = now.Add(s.SweepInterval)
+}
+
+func decodeData(b []byte) (*Data, error) {
+	data := &Data{}
+	if err := json.Unmarshal(b, data); err != nil {
+		return nil, errors.New(err)
+	}
+	return data, nil
+}
@@ -0,0 +1,67 @@
+package httpsession
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestData(now time.Time) *Data {
+	return &Data{
+		ID:           "id",
+		CreatedAt:    now,
+		LastActivity: now,
+		Values:       map[string]any{"user": 1, "name": "johndoe"},
+		Flash:        map[string]any{"message": "hello"},
+	}
+}
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	data, err := store.Load(ctx, "id")
+	require.NoError(t, err)
+	assert.Nil(t, data)
+
+	token, err := store.Save(ctx, newTestData(now), time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, "id", token)
+
+	data, err = store.Load(ctx, "id")
+	require.NoError(t, err)
+	assert.Equal(t, &Data{
+		ID:           "id",
+		CreatedAt:    now,
+		LastActivity: now,
+		Values:       map[string]any{"user": 1.0, "name": "johndoe"},
+		Flash:        map[string]any{"message": "hello"},
+	}, data)
+
+	t.Run("expired", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		data, err := store.Load(ctx, "id")
+		require.NoError(t, err)
+		assert.Nil(t, data)
+
+		_, err = store.Save(ctx, &Data{ID: "other"}, time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, 1, store.Len()) // "id" removed
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, store.Delete(ctx, "other"))
+		require.NoError(t, store.Delete(ctx, "other"))
+		assert.Equal(t, 0, store.Len())
+	})
+
+	t.Run("invalid_data", func(t *testing.T) {
+		_, err := store.Save(ctx, &Data{ID: "invalid", Values: map[string]any{"a": make(chan int)}}, time.Minute)
+		require.Error(t, err)
+	})
+}
@@ -3,17 +3,31 @@ package lang
 var enUS = &Language{
 	name: "en-US",
 	lines: map[string]string{
-		"malformed-request":              "Malformed request",
-		"malformed-json":                 "Malformed JSON",
-		"auth.invalid-credentials":       "Invalid credentials.",
-		"auth.no-credentials-provided":   "Invalid or missing authentication header.",
-		"auth.jwt-invalid":               "Your authentication token is invalid.",
-		"auth.jwt-not-valid-yet":         "Your authentication token is not valid yet.",
-		"auth.jwt-expired":               "Your authentication token is expired.",
-		"parse.invalid-query":            "Failed to parse query string due to invalid syntax or unexpected input format.",
-		"parse.json-invalid-body":        "The request Content-Type indicates JSON, but the request body is empty or invalid.",
-		"parse.invalid-content-for-type": "The request content does not match its type. E.g. invalid multipart/form-data or a problem with the file upload.",
-		"parse.error-in-request-body":    "Failed to read request body due to connection issues, timeouts, size mismatches, or corrupted data.",
+		"malformed-request":               "Malformed request",
+		"malformed-json":                  "Malformed JSON",
+		"auth.invalid-credentials":        "Invalid credentials.",
+		"auth.no-credentials-provided":    "Invalid or missing authentication header.",
+		"auth.jwt-invalid":                "Your authentication token is invalid.",
+		"auth.jwt-not-valid-yet":          "Your authentication token is not valid yet.",
+		"auth.jwt-expired":                "Your authentication token is expired.",
+		"auth.jwt-revoked":                "Your authentication token has been revoked.",
+		"auth.invalid-refresh-token":      "Your refresh token is invalid or expired.",
+		"auth.oidc-login-failed":          "Authentication with the identity provider failed.",
+		"auth.api-key-expired":            "Your API key is expired.",
+		"auth.insufficient-scope":         "Your credentials do not grant access to this resource.",
+		"auth.too-many-attempts":          "Too many failed login attempts. Please try again later.",
+		"csrf.invalid-token":              "Invalid or missing CSRF token.",
+		"csrf.invalid-origin":             "Cross-site request forbidden.",
+		"parse.invalid-query":             "Failed to parse query string due to invalid syntax or unexpected input format.",
+		"parse.json-invalid-body":         "The request Content-Type indicates JSON, but the request body is empty or invalid.",
+		"parse.invalid-body":              "The request body is empty or invalid for its Content-Type.",
+		"parse.invalid-content-for-type":  "The request content does not match its type. E.g. invalid multipart/form-data or a problem with the file upload.",
+		"parse.error-in-request-body":     "Failed to read request body due to connection issues, timeouts, size mismatches, or corrupted data.",
+		"parse.body-too-large":            "The request body or one of its files is too large.",
+		"parse.headers-too-large":         "The request header fields are too large.",
+		"parse.body-too-deep":             "The request body exceeds the maximum nesting depth.",
+		"parse.too-many-files":            "The request contains too many files.",
+		"parse.too-many-query-parameters": "The request contains too many query parameters.",
 	},
 	validation: validationLines{
 		rules: map[string]string{
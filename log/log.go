@@ -76,9 +76,9 @@ func (w *Writer) Close() error {
 
 	if w.Config().GetBool("app.debug") {
 		// In dev mode, we omit the details to avoid clutter. The message itself is enough.
-		w.Logger().Info(message)
+		w.Logger().InfoContext(w.request.Context(), message)
 	} else {
-		w.Logger().Info(message, lo.Map(attrs, func(a slog.Attr, _ int) any { return a })...)
+		w.Logger().InfoContext(w.request.Context(), message, lo.Map(attrs, func(a slog.Attr, _ int) any { return a })...)
 	}
 
 	return errors.New(w.CommonWriter.Close())
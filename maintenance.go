@@ -0,0 +1,228 @@
+package goyave
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"os"
+	"strconv"
+	"time"
+
+	"goyave.dev/goyave/v5/util/errors"
+)
+
+// DefaultMaintenanceCookieName the default name of the cookie used to bypass the maintenance mode.
+const DefaultMaintenanceCookieName = "goyave_maintenance"
+
+// defaultMaintenancePollInterval the interval between two checks of the maintenance
+// flag file if the "server.maintenance.pollInterval" config entry is not strictly positive.
+const defaultMaintenancePollInterval = 5 * time.Second
+
+// MaintenanceOptions options of the maintenance mode. See `Server.SetMaintenance()`.
+type MaintenanceOptions struct {
+	// AllowedIPs IP addresses and CIDRs of the clients allowed to access
+	// the application during the maintenance. The client IP is resolved
+	// with `Request.ClientIP()`.
+	AllowedIPs []string
+
+	// Secret if not empty, requests carrying a cookie named `CookieName`
+	// with this value are allowed to access the application during the maintenance.
+	Secret string
+
+	// CookieName the name of the bypass cookie.
+	// Defaults to `DefaultMaintenanceCookieName`.
+	CookieName string
+
+	// RetryAfter if strictly positive, the value of the "Retry-After" header
+	// sent with the "503 Service Unavailable" responses. The duration is rounded
+	// up to the second.
+	RetryAfter time.Duration
+}
+
+// maintenanceFile the content of the maintenance flag file.
+// The retry duration is expressed in seconds.
+type maintenanceFile struct {
+	Secret     string   `json:"secret"`
+	CookieName string   `json:"cookieName"`
+	AllowedIPs []string `json:"allowedIPs"`
+	RetryAfter int      `json:"retryAfter"`
+}
+
+// maintenanceState the parsed maintenance options currently applied to the server.
+type maintenanceState struct {
+	options    *MaintenanceOptions
+	allowedIPs []netip.Prefix
+	fromFile   bool
+}
+
+func newMaintenanceState(opts *MaintenanceOptions, fromFile bool) (*maintenanceState, error) {
+	allowedIPs, err := parseIPPrefixes(opts.AllowedIPs, "maintenance allowed IP")
+	if err != nil {
+		return nil, errors.New(err)
+	}
+	return &maintenanceState{
+		options:    opts,
+		allowedIPs: allowedIPs,
+		fromFile:   fromFile,
+	}, nil
+}
+
+func (s *maintenanceState) allows(request *Request) bool {
+	if s.options.Secret != "" {
+		cookieName := s.options.CookieName
+		if cookieName == "" {
+			cookieName = DefaultMaintenanceCookieName
+		}
+		if cookie, err := request.Request().Cookie(cookieName); err == nil && subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(s.options.Secret)) == 1 {
+			return true
+		}
+	}
+
+	if len(s.allowedIPs) == 0 {
+		return false
+	}
+	addr, ok := parseNodeAddr(request.ClientIP())
+	if !ok {
+		return false
+	}
+	for _, p := range s.allowedIPs {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// SetMaintenance put the server in maintenance mode using the given options.
+// While in maintenance, all requests are answered with "503 Service Unavailable",
+// rendered by the status handler, except:
+//   - requests from one of the allowed IPs
+//   - requests carrying the secret bypass cookie
+//   - requests matching a route having the `MetaMaintenanceExempt` meta set to `true`
+//     (e.g. health checks)
+//
+// Giving `nil` options takes the server out of maintenance mode.
+// This method can be called at any time from any goroutine.
+// Returns an error if one of the allowed IPs cannot be parsed.
+//
+// The maintenance mode can also be toggled without code changes using a flag
+// file (see the "server.maintenance.file" config entry).
+func (s *Server) SetMaintenance(opts *MaintenanceOptions) error {
+	return s.setMaintenance(opts, false)
+}
+
+func (s *Server) setMaintenance(opts *MaintenanceOptions, fromFile bool) error {
+	if opts == nil {
+		s.maintenance.Store(nil)
+		return nil
+	}
+	state, err := newMaintenanceState(opts, fromFile)
+	if err != nil {
+		return err
+	}
+	s.maintenance.Store(state)
+	return nil
+}
+
+// IsInMaintenance returns true if the server is currently in maintenance mode.
+func (s *Server) IsInMaintenance() bool {
+	return s.maintenance.Load() != nil
+}
+
+// watchMaintenanceFile polls the maintenance flag file defined by the "server.maintenance.file"
+// config entry until the given context is canceled. The server enters maintenance mode when the
+// file is created and leaves it when the file is removed, unless the maintenance mode has been
+// set manually with `SetMaintenance()` in the meantime.
+func (s *Server) watchMaintenanceFile(ctx context.Context, path string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	var lastModTime time.Time
+	for {
+		lastModTime = s.checkMaintenanceFile(path, lastModTime)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// checkMaintenanceFile applies the maintenance flag file if it changed since the given
+// modification time. Returns the modification time of the file, or the zero value if
+// the file doesn't exist.
+func (s *Server) checkMaintenanceFile(path string, lastModTime time.Time) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		if state := s.maintenance.Load(); state != nil && state.fromFile {
+			s.maintenance.CompareAndSwap(state, nil)
+		}
+		return time.Time{}
+	}
+	if info.ModTime().Equal(lastModTime) {
+		return lastModTime
+	}
+
+	opts, err := readMaintenanceFile(path)
+	if err == nil {
+		err = s.setMaintenance(opts, true)
+	}
+	if err != nil {
+		s.Logger.Error(errors.New(err))
+	}
+	return info.ModTime()
+}
+
+// readMaintenanceFile reads the maintenance options from the given file.
+// An empty file results in default options.
+func readMaintenanceFile(path string) (*MaintenanceOptions, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	file := &maintenanceFile{}
+	if len(content) > 0 {
+		if err := json.Unmarshal(content, file); err != nil {
+			return nil, fmt.Errorf("invalid maintenance file %q: %w", path, err)
+		}
+	}
+	return &MaintenanceOptions{
+		AllowedIPs: file.AllowedIPs,
+		Secret:     file.Secret,
+		CookieName: file.CookieName,
+		RetryAfter: time.Duration(file.RetryAfter) * time.Second,
+	}, nil
+}
+
+// maintenanceMiddleware answers requests with "503 Service Unavailable" while
+// the server is in maintenance mode (see `Server.SetMaintenance()`).
+// This middleware is part of the core middleware.
+type maintenanceMiddleware struct {
+	Component
+}
+
+func (m *maintenanceMiddleware) Handle(next Handler) Handler {
+	return func(response *Response, request *Request) {
+		state := m.server.maintenance.Load()
+		if state == nil || m.isExempt(request) || state.allows(request) {
+			next(response, request)
+			return
+		}
+
+		if state.options.RetryAfter > 0 {
+			seconds := int64((state.options.RetryAfter + time.Second - 1) / time.Second)
+			response.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+		}
+		response.Status(http.StatusServiceUnavailable)
+	}
+}
+
+func (m *maintenanceMiddleware) isExempt(request *Request) bool {
+	if request.Route == nil {
+		return false
+	}
+	exempt, ok := request.Route.LookupMeta(MetaMaintenanceExempt)
+	return ok && exempt == true
+}
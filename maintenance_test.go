@@ -0,0 +1,249 @@
+package goyave
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5/config"
+	"goyave.dev/goyave/v5/slog"
+)
+
+func prepareMaintenanceTest(t *testing.T) (*Server, *Router, *testSyncBuffer) {
+	logBuffer := &testSyncBuffer{}
+	server, err := New(Options{Config: config.LoadDefault(), Logger: slog.New(slog.NewHandler(false, logBuffer))})
+	require.NoError(t, err)
+	router := NewRouter(server)
+	router.Get("/test", func(response *Response, _ *Request) {
+		response.String(http.StatusOK, "hello")
+	})
+	router.Get("/health", func(response *Response, _ *Request) {
+		response.Status(http.StatusNoContent)
+	}).SetMeta(MetaMaintenanceExempt, true)
+	return server, router, logBuffer
+}
+
+func serveMaintenanceTest(router *Router, path string, setup func(*http.Request)) *http.Response {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(http.MethodGet, path, nil)
+	if setup != nil {
+		setup(request)
+	}
+	router.ServeHTTP(recorder, request)
+	return recorder.Result()
+}
+
+func TestMaintenance(t *testing.T) {
+	t.Run("disabled", func(t *testing.T) {
+		server, router, _ := prepareMaintenanceTest(t)
+		assert.False(t, server.IsInMaintenance())
+		resp := serveMaintenanceTest(router, "/test", nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("enabled", func(t *testing.T) {
+		server, router, _ := prepareMaintenanceTest(t)
+		require.NoError(t, server.SetMaintenance(&MaintenanceOptions{RetryAfter: 90500 * time.Millisecond}))
+		assert.True(t, server.IsInMaintenance())
+
+		resp := serveMaintenanceTest(router, "/test", nil)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, "91", resp.Header.Get("Retry-After"))
+		body, err := readMaintenanceBody(resp)
+		require.NoError(t, err)
+		assert.Equal(t, "{\"error\":\"Service Unavailable\"}\n", body)
+
+		resp = serveMaintenanceTest(router, "/not-found", nil)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+
+		resp = serveMaintenanceTest(router, "/health", nil)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+
+		require.NoError(t, server.SetMaintenance(nil))
+		assert.False(t, server.IsInMaintenance())
+		resp = serveMaintenanceTest(router, "/test", nil)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("Retry-After"))
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("allowed_ips", func(t *testing.T) {
+		server, router, _ := prepareMaintenanceTest(t)
+		require.NoError(t, server.SetMaintenance(&MaintenanceOptions{AllowedIPs: []string{"10.0.0.0/8", "::1"}}))
+
+		cases := []struct {
+			remoteAddr string
+			want       int
+		}{
+			{remoteAddr: "10.1.2.3:1234", want: http.StatusOK},
+			{remoteAddr: "[::1]:1234", want: http.StatusOK},
+			{remoteAddr: "192.168.1.1:1234", want: http.StatusServiceUnavailable},
+			{remoteAddr: "invalid", want: http.StatusServiceUnavailable},
+		}
+		for _, c := range cases {
+			resp := serveMaintenanceTest(router, "/test", func(r *http.Request) {
+				r.RemoteAddr = c.remoteAddr
+			})
+			assert.Equal(t, c.want, resp.StatusCode, c.remoteAddr)
+			assert.NoError(t, resp.Body.Close())
+		}
+	})
+
+	t.Run("invalid_allowed_ips", func(t *testing.T) {
+		server, _, _ := prepareMaintenanceTest(t)
+		err := server.SetMaintenance(&MaintenanceOptions{AllowedIPs: []string{"not an IP"}})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `invalid maintenance allowed IP "not an IP"`)
+		assert.False(t, server.IsInMaintenance())
+	})
+
+	t.Run("bypass_cookie", func(t *testing.T) {
+		server, router, _ := prepareMaintenanceTest(t)
+		require.NoError(t, server.SetMaintenance(&MaintenanceOptions{Secret: "secret"}))
+
+		resp := serveMaintenanceTest(router, "/test", func(r *http.Request) {
+			r.AddCookie(&http.Cookie{Name: DefaultMaintenanceCookieName, Value: "secret"})
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+
+		resp = serveMaintenanceTest(router, "/test", func(r *http.Request) {
+			r.AddCookie(&http.Cookie{Name: DefaultMaintenanceCookieName, Value: "wrong"})
+		})
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+
+		require.NoError(t, server.SetMaintenance(&MaintenanceOptions{Secret: "secret", CookieName: "bypass"}))
+		resp = serveMaintenanceTest(router, "/test", func(r *http.Request) {
+			r.AddCookie(&http.Cookie{Name: "bypass", Value: "secret"})
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("exempt_subrouter", func(t *testing.T) {
+		server, router, _ := prepareMaintenanceTest(t)
+		subrouter := router.Subrouter("/status").SetMeta(MetaMaintenanceExempt, true)
+		subrouter.Get("/ready", func(response *Response, _ *Request) {
+			response.Status(http.StatusNoContent)
+		})
+		subrouter.Get("/disabled", func(response *Response, _ *Request) {
+			response.Status(http.StatusNoContent)
+		}).SetMeta(MetaMaintenanceExempt, false)
+		require.NoError(t, server.SetMaintenance(&MaintenanceOptions{}))
+
+		resp := serveMaintenanceTest(router, "/status/ready", nil)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+
+		resp = serveMaintenanceTest(router, "/status/disabled", nil)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+}
+
+func TestMaintenanceFile(t *testing.T) {
+	t.Run("toggle", func(t *testing.T) {
+		server, router, _ := prepareMaintenanceTest(t)
+		path := filepath.Join(t.TempDir(), "maintenance")
+
+		modTime := server.checkMaintenanceFile(path, time.Time{})
+		assert.True(t, modTime.IsZero())
+		assert.False(t, server.IsInMaintenance())
+
+		require.NoError(t, os.WriteFile(path, []byte(`{"retryAfter":60,"secret":"secret","allowedIPs":["10.0.0.1"]}`), 0o644))
+		modTime = server.checkMaintenanceFile(path, modTime)
+		assert.False(t, modTime.IsZero())
+		require.True(t, server.IsInMaintenance())
+
+		resp := serveMaintenanceTest(router, "/test", nil)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, "60", resp.Header.Get("Retry-After"))
+		assert.NoError(t, resp.Body.Close())
+
+		resp = serveMaintenanceTest(router, "/test", func(r *http.Request) {
+			r.RemoteAddr = "10.0.0.1:1234"
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+
+		// Unchanged file is not read again
+		require.NoError(t, server.SetMaintenance(nil))
+		assert.Equal(t, modTime, server.checkMaintenanceFile(path, modTime))
+		assert.False(t, server.IsInMaintenance())
+
+		require.NoError(t, server.setMaintenance(&MaintenanceOptions{}, true))
+		require.NoError(t, os.Remove(path))
+		modTime = server.checkMaintenanceFile(path, modTime)
+		assert.True(t, modTime.IsZero())
+		assert.False(t, server.IsInMaintenance())
+	})
+
+	t.Run("removed_file_keeps_manual_maintenance", func(t *testing.T) {
+		server, _, _ := prepareMaintenanceTest(t)
+		path := filepath.Join(t.TempDir(), "maintenance")
+		require.NoError(t, server.SetMaintenance(&MaintenanceOptions{}))
+		server.checkMaintenanceFile(path, time.Time{})
+		assert.True(t, server.IsInMaintenance())
+	})
+
+	t.Run("empty_file", func(t *testing.T) {
+		server, _, _ := prepareMaintenanceTest(t)
+		path := filepath.Join(t.TempDir(), "maintenance")
+		require.NoError(t, os.WriteFile(path, []byte{}, 0o644))
+		server.checkMaintenanceFile(path, time.Time{})
+		require.True(t, server.IsInMaintenance())
+		assert.Equal(t, &MaintenanceOptions{}, server.maintenance.Load().options)
+	})
+
+	t.Run("invalid_file", func(t *testing.T) {
+		server, _, logBuffer := prepareMaintenanceTest(t)
+		path := filepath.Join(t.TempDir(), "maintenance")
+		require.NoError(t, os.WriteFile(path, []byte(`{"allowedIPs":"invalid"}`), 0o644))
+		server.checkMaintenanceFile(path, time.Time{})
+		assert.False(t, server.IsInMaintenance())
+		assert.Contains(t, logBuffer.String(), "invalid maintenance file")
+
+		require.NoError(t, os.WriteFile(path, []byte(`{"allowedIPs":["invalid"]}`), 0o644))
+		server.checkMaintenanceFile(path, time.Time{})
+		assert.False(t, server.IsInMaintenance())
+		assert.Contains(t, logBuffer.String(), `invalid maintenance allowed IP`)
+	})
+
+	t.Run("watch", func(t *testing.T) {
+		server, _, _ := prepareMaintenanceTest(t)
+		path := filepath.Join(t.TempDir(), "maintenance")
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			server.watchMaintenanceFile(ctx, path, 5*time.Millisecond)
+			close(done)
+		}()
+
+		require.NoError(t, os.WriteFile(path, []byte{}, 0o644))
+		assert.Eventually(t, server.IsInMaintenance, time.Second, 5*time.Millisecond)
+		require.NoError(t, os.Remove(path))
+		assert.Eventually(t, func() bool { return !server.IsInMaintenance() }, time.Second, 5*time.Millisecond)
+
+		cancel()
+		<-done
+	})
+}
+
+func readMaintenanceBody(resp *http.Response) (string, error) {
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
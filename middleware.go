@@ -81,7 +81,7 @@ func (m *recoveryMiddleware) Handle(next Handler) Handler {
 		defer func() {
 			if err := recover(); err != nil || panicked {
 				e := errors.NewSkip(err, 4).(*errors.Error) // Skipped: runtime.Callers, NewSkip, this func, runtime.panic
-				m.Logger().Error(e)
+				m.Logger().ErrorCtx(request.Context(), e)
 				response.err = e
 				response.status = http.StatusInternalServerError // Force status override
 			}
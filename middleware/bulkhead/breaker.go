@@ -0,0 +1,241 @@
+package bulkhead
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultFailureRatio     = 0.5
+	defaultMinRequests      = 10
+	defaultBreakerWindow    = 10 * time.Second
+	defaultOpenDuration     = 30 * time.Second
+	defaultHalfOpenRequests = 1
+)
+
+// BreakerState the state of a circuit `Breaker`.
+type BreakerState int
+
+const (
+	// StateClosed the requests are executed and their outcome is observed.
+	StateClosed BreakerState = iota
+
+	// StateOpen the requests are rejected without being executed.
+	StateOpen
+
+	// StateHalfOpen a limited number of trial requests are executed to
+	// check if the protected resource recovered.
+	StateHalfOpen
+)
+
+// String returns the name of the state.
+func (s BreakerState) String() string {
+	switch s {
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+// outcome the result of a request executed through a `Breaker`.
+type outcome int
+
+const (
+	outcomeIgnored outcome = iota
+	outcomeSuccess
+	outcomeFailure
+)
+
+// Breaker a circuit breaker stopping the execution of requests to a failing route or
+// group of routes, giving the resources it depends on (such as a database) time to recover
+// instead of piling up requests doomed to fail.
+//
+// The breaker starts closed: the requests are executed and their outcome is counted over a
+// `Window`. When at least `MinRequests` were observed in the window and the ratio of failures
+// reaches `FailureRatio`, the breaker opens: all the requests are rejected during `OpenDuration`.
+// The breaker then becomes half-open and lets `HalfOpenRequests` trial requests through. If
+// they all succeed, the breaker closes again. If one of them fails, it opens again.
+//
+// A request fails if its response status is a server error (5xx), if it panics, or if it is
+// slower than `SlowThreshold`.
+//
+// A `Breaker` holds its own state: routes sharing the same `*Breaker` share the same circuit.
+// A `Breaker` must not be copied after first use.
+type Breaker struct {
+	// IsFailure returns true if the given response status is a failure.
+	// Defaults to server errors (5xx).
+	IsFailure func(status int) bool
+
+	// Name identifies the breaker in the logs. If empty, the route URI is used.
+	Name string
+
+	// FailureRatio the ratio (between 0 and 1) of failed requests in the window opening
+	// the breaker. Defaults to 0.5.
+	FailureRatio float64
+
+	// MinRequests the minimum number of requests observed in the window before the
+	// failure ratio is evaluated. Defaults to 10.
+	MinRequests int
+
+	// Window the duration over which the outcomes of the requests are counted.
+	// Defaults to 10 seconds.
+	Window time.Duration
+
+	// SlowThreshold if strictly positive, requests taking longer are counted as failures.
+	SlowThreshold time.Duration
+
+	// OpenDuration the duration during which the requests are rejected once the breaker
+	// opened. Defaults to 30 seconds.
+	OpenDuration time.Duration
+
+	// HalfOpenRequests the number of trial requests that must succeed to close the
+	// breaker. Defaults to 1.
+	HalfOpenRequests int
+
+	// RetryAfter if strictly positive, the value of the "Retry-After" header sent with
+	// the "503 Service Unavailable" responses. Defaults to the remaining open duration.
+	RetryAfter time.Duration
+
+	windowStart time.Time
+	openedAt    time.Time
+	state       BreakerState
+	requests    int
+	failures    int
+	trials      int
+	successes   int
+	mu          sync.Mutex
+}
+
+// State returns the current state of the breaker.
+func (b *Breaker) State() BreakerState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// allow returns true if the request can be executed. If so, the request's outcome must be
+// recorded with `record()`. `trial` is true if the request is a half-open trial request.
+// If the request is rejected, `retryAfter` is the duration after which the breaker
+// becomes half-open. `changed` is true if the breaker became half-open.
+func (b *Breaker) allow(now time.Time) (allowed, trial, changed bool, retryAfter time.Duration) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == StateOpen {
+		reopenAt := b.openedAt.Add(b.openDuration())
+		if now.Before(reopenAt) {
+			return false, false, false, reopenAt.Sub(now)
+		}
+		b.state = StateHalfOpen
+		b.trials = 0
+		b.successes = 0
+		changed = true
+	}
+	if b.state == StateHalfOpen {
+		if b.trials >= b.halfOpenRequests() {
+			return false, false, changed, 0
+		}
+		b.trials++
+		return true, true, changed, 0
+	}
+	return true, false, changed, 0
+}
+
+// record the outcome of a request allowed by `allow()`. Returns the previous state
+// and the new state of the breaker.
+func (b *Breaker) record(trial bool, result outcome, now time.Time) (BreakerState, BreakerState) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	previous := b.state
+	switch {
+	case trial && b.state == StateHalfOpen:
+		switch result {
+		case outcomeFailure:
+			b.open(now)
+		case outcomeSuccess:
+			b.successes++
+			if b.successes >= b.halfOpenRequests() {
+				b.state = StateClosed
+				b.resetWindow(now)
+			}
+		default:
+			b.trials-- // The trial slot is given back.
+		}
+	case !trial && b.state == StateClosed && result != outcomeIgnored:
+		if now.Sub(b.windowStart) >= b.window() {
+			b.resetWindow(now)
+		}
+		b.requests++
+		if result == outcomeFailure {
+			b.failures++
+		}
+		if b.requests >= b.minRequests() && float64(b.failures) >= b.failureRatio()*float64(b.requests) {
+			b.open(now)
+		}
+	}
+	return previous, b.state
+}
+
+// outcome returns the outcome of a request that completed with the given status and latency.
+func (b *Breaker) outcome(status int, latency time.Duration) outcome {
+	if b.SlowThreshold > 0 && latency > b.SlowThreshold {
+		return outcomeFailure
+	}
+	isFailure := b.IsFailure
+	if isFailure == nil {
+		isFailure = func(status int) bool { return status >= http.StatusInternalServerError }
+	}
+	if isFailure(status) {
+		return outcomeFailure
+	}
+	return outcomeSuccess
+}
+
+func (b *Breaker) open(now time.Time) {
+	b.state = StateOpen
+	b.openedAt = now
+}
+
+func (b *Breaker) resetWindow(now time.Time) {
+	b.windowStart = now
+	b.requests = 0
+	b.failures = 0
+}
+
+func (b *Breaker) failureRatio() float64 {
+	if b.FailureRatio <= 0 || b.FailureRatio > 1 {
+		return defaultFailureRatio
+	}
+	return b.FailureRatio
+}
+
+func (b *Breaker) minRequests() int {
+	if b.MinRequests <= 0 {
+		return defaultMinRequests
+	}
+	return b.MinRequests
+}
+
+func (b *Breaker) window() time.Duration {
+	if b.Window <= 0 {
+		return defaultBreakerWindow
+	}
+	return b.Window
+}
+
+func (b *Breaker) openDuration() time.Duration {
+	if b.OpenDuration <= 0 {
+		return defaultOpenDuration
+	}
+	return b.OpenDuration
+}
+
+func (b *Breaker) halfOpenRequests() int {
+	if b.HalfOpenRequests <= 0 {
+		return defaultHalfOpenRequests
+	}
+	return b.HalfOpenRequests
+}
@@ -0,0 +1,145 @@
+package bulkhead
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBreaker(t *testing.T) {
+	t.Run("opens_on_failure_ratio", func(t *testing.T) {
+		b := &Breaker{MinRequests: 4, FailureRatio: 0.5}
+		now := time.Now()
+		for i := 0; i < 3; i++ {
+			allowed, trial, _, _ := b.allow(now)
+			assert.True(t, allowed)
+			assert.False(t, trial)
+			result := outcomeSuccess
+			if i > 0 {
+				result = outcomeFailure
+			}
+			b.record(trial, result, now)
+		}
+		assert.Equal(t, StateClosed, b.State(), "min requests not reached")
+
+		previous, state := b.record(false, outcomeSuccess, now)
+		assert.Equal(t, StateClosed, previous)
+		assert.Equal(t, StateOpen, state)
+
+		allowed, _, _, retryAfter := b.allow(now.Add(10 * time.Second))
+		assert.False(t, allowed)
+		assert.Equal(t, 20*time.Second, retryAfter)
+	})
+
+	t.Run("ignored_outcomes", func(t *testing.T) {
+		b := &Breaker{MinRequests: 1}
+		now := time.Now()
+		b.record(false, outcomeIgnored, now)
+		assert.Equal(t, StateClosed, b.State())
+		assert.Equal(t, 0, b.requests)
+	})
+
+	t.Run("window_reset", func(t *testing.T) {
+		b := &Breaker{MinRequests: 2, Window: time.Second}
+		now := time.Now()
+		b.record(false, outcomeFailure, now)
+		b.record(false, outcomeFailure, now.Add(2*time.Second))
+		assert.Equal(t, StateClosed, b.State())
+		assert.Equal(t, 1, b.requests)
+		assert.Equal(t, 1, b.failures)
+	})
+
+	t.Run("half_open_success", func(t *testing.T) {
+		b := &Breaker{MinRequests: 1, OpenDuration: time.Second, HalfOpenRequests: 2}
+		now := time.Now()
+		b.record(false, outcomeFailure, now)
+		assert.Equal(t, StateOpen, b.State())
+
+		now = now.Add(time.Second)
+		allowed, trial, changed, _ := b.allow(now)
+		assert.True(t, allowed)
+		assert.True(t, trial)
+		assert.True(t, changed)
+		assert.Equal(t, StateHalfOpen, b.State())
+
+		allowed, trial, changed, _ = b.allow(now)
+		assert.True(t, allowed)
+		assert.True(t, trial)
+		assert.False(t, changed)
+
+		allowed, _, _, _ = b.allow(now)
+		assert.False(t, allowed, "all trial slots are taken")
+
+		_, state := b.record(true, outcomeSuccess, now)
+		assert.Equal(t, StateHalfOpen, state)
+		previous, state := b.record(true, outcomeSuccess, now)
+		assert.Equal(t, StateHalfOpen, previous)
+		assert.Equal(t, StateClosed, state)
+		assert.Equal(t, 0, b.requests)
+	})
+
+	t.Run("half_open_failure", func(t *testing.T) {
+		b := &Breaker{MinRequests: 1, OpenDuration: time.Second}
+		now := time.Now()
+		b.record(false, outcomeFailure, now)
+
+		now = now.Add(time.Second)
+		_, trial, _, _ := b.allow(now)
+		previous, state := b.record(trial, outcomeFailure, now)
+		assert.Equal(t, StateHalfOpen, previous)
+		assert.Equal(t, StateOpen, state)
+
+		allowed, _, _, retryAfter := b.allow(now)
+		assert.False(t, allowed)
+		assert.Equal(t, time.Second, retryAfter)
+	})
+
+	t.Run("half_open_ignored", func(t *testing.T) {
+		b := &Breaker{MinRequests: 1, OpenDuration: time.Second}
+		now := time.Now()
+		b.record(false, outcomeFailure, now)
+
+		now = now.Add(time.Second)
+		_, trial, _, _ := b.allow(now)
+		b.record(trial, outcomeIgnored, now)
+		assert.Equal(t, StateHalfOpen, b.State())
+
+		allowed, trial, _, _ := b.allow(now)
+		assert.True(t, allowed, "the trial slot is given back")
+		assert.True(t, trial)
+	})
+
+	t.Run("late_outcome_ignored", func(t *testing.T) {
+		// A request allowed while closed completing after the breaker opened is not counted.
+		b := &Breaker{MinRequests: 1}
+		now := time.Now()
+		b.record(false, outcomeFailure, now)
+		previous, state := b.record(false, outcomeSuccess, now)
+		assert.Equal(t, StateOpen, previous)
+		assert.Equal(t, StateOpen, state)
+	})
+
+	t.Run("outcome", func(t *testing.T) {
+		b := &Breaker{}
+		assert.Equal(t, outcomeSuccess, b.outcome(0, time.Second))
+		assert.Equal(t, outcomeSuccess, b.outcome(http.StatusNotFound, time.Second))
+		assert.Equal(t, outcomeFailure, b.outcome(http.StatusInternalServerError, time.Second))
+		assert.Equal(t, outcomeFailure, b.outcome(http.StatusServiceUnavailable, time.Second))
+
+		b = &Breaker{
+			SlowThreshold: time.Second,
+			IsFailure:     func(status int) bool { return status == http.StatusTooManyRequests },
+		}
+		assert.Equal(t, outcomeSuccess, b.outcome(http.StatusInternalServerError, time.Second))
+		assert.Equal(t, outcomeFailure, b.outcome(http.StatusTooManyRequests, time.Millisecond))
+		assert.Equal(t, outcomeFailure, b.outcome(http.StatusOK, 2*time.Second))
+	})
+
+	t.Run("state_string", func(t *testing.T) {
+		assert.Equal(t, "closed", StateClosed.String())
+		assert.Equal(t, "open", StateOpen.String())
+		assert.Equal(t, "half-open", StateHalfOpen.String())
+	})
+}
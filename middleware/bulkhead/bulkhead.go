@@ -0,0 +1,233 @@
+package bulkhead
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrQueueFull returned when a request cannot be admitted because all
+	// concurrency slots are taken and the queue is full.
+	ErrQueueFull = errors.New("bulkhead: queue is full")
+
+	// ErrQueueTimeout returned when a request waited in the queue for longer
+	// than the queue timeout without being admitted.
+	ErrQueueTimeout = errors.New("bulkhead: queue timeout exceeded")
+)
+
+const (
+	defaultSmoothing      = 0.2
+	defaultDecreaseFactor = 0.75
+)
+
+// Adaptive settings of the adaptive load shedding mode. In this mode, the concurrency limit of
+// the bulkhead is adjusted depending on the observed latency of the requests. The limit is
+// decreased when the moving average of the latency exceeds the target, and increased by one
+// slot per fast request until it reaches `Bulkhead.MaxConcurrent` again.
+type Adaptive struct {
+	// TargetLatency the average latency above which the concurrency limit is decreased.
+	// The limit is decreased at most once per `TargetLatency` period.
+	TargetLatency time.Duration
+
+	// MinConcurrent the lower bound of the concurrency limit. Defaults to 1.
+	MinConcurrent int
+
+	// Smoothing the weight (between 0 and 1) of the latest observation in
+	// the exponential moving average of the latency. Defaults to 0.2.
+	Smoothing float64
+
+	// DecreaseFactor the factor (between 0 and 1) applied to the concurrency limit
+	// when the average latency exceeds the target. Defaults to 0.75.
+	DecreaseFactor float64
+}
+
+// Stats a snapshot of the state of a bulkhead.
+type Stats struct {
+	// InFlight the number of requests currently being executed.
+	InFlight int
+
+	// Queued the number of requests waiting to be admitted.
+	Queued int
+
+	// Limit the current concurrency limit. It is lower than `MaxConcurrent`
+	// if the adaptive load shedding reduced it.
+	Limit int
+
+	// Latency the moving average of the latency of the requests. Only
+	// computed in adaptive mode.
+	Latency time.Duration
+}
+
+// Bulkhead limits the number of requests executed concurrently. Requests exceeding the limit
+// wait in a queue until a slot is released. A `Bulkhead` holds its own state: routes sharing
+// the same `*Bulkhead` share the same limit, so a bulkhead set as meta on a router is shared
+// by all its routes.
+//
+// A `Bulkhead` must not be copied after first use.
+type Bulkhead struct {
+	// Adaptive enables the adaptive load shedding if not `nil`.
+	Adaptive *Adaptive
+
+	// Name identifies the bulkhead in the logs. If empty, the route URI is used.
+	Name string
+
+	// MaxConcurrent the maximum number of requests executed concurrently.
+	MaxConcurrent int
+
+	// MaxQueue the maximum number of requests waiting for a slot. If 0,
+	// requests are rejected immediately when all slots are taken.
+	MaxQueue int
+
+	// QueueTimeout the maximum duration a request can wait in the queue.
+	// If 0, requests wait until they are admitted or until their context is canceled.
+	QueueTimeout time.Duration
+
+	// RetryAfter if strictly positive, the value of the "Retry-After" header
+	// sent with the "503 Service Unavailable" responses.
+	RetryAfter time.Duration
+
+	waiters      list.List
+	lastDecrease time.Time
+	latency      float64
+	inFlight     int
+	limit        int
+	mu           sync.Mutex
+	saturated    atomic.Bool
+	initialized  bool
+}
+
+// Stats returns a snapshot of the current state of the bulkhead.
+func (b *Bulkhead) Stats() Stats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.init()
+	return Stats{
+		InFlight: b.inFlight,
+		Queued:   b.waiters.Len(),
+		Limit:    b.limit,
+		Latency:  time.Duration(b.latency),
+	}
+}
+
+func (b *Bulkhead) init() {
+	if !b.initialized {
+		b.limit = b.MaxConcurrent
+		b.initialized = true
+	}
+}
+
+// acquire takes a slot, waiting in the queue if necessary. The slot must be
+// released with `release()` if no error is returned.
+func (b *Bulkhead) acquire(ctx context.Context) error {
+	b.mu.Lock()
+	b.init()
+	if b.inFlight < b.limit && b.waiters.Len() == 0 {
+		b.inFlight++
+		b.mu.Unlock()
+		return nil
+	}
+	if b.waiters.Len() >= b.MaxQueue {
+		b.mu.Unlock()
+		return ErrQueueFull
+	}
+	ready := make(chan struct{})
+	elem := b.waiters.PushBack(ready)
+	b.mu.Unlock()
+
+	var timeout <-chan time.Time
+	if b.QueueTimeout > 0 {
+		timer := time.NewTimer(b.QueueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	var err error
+	select {
+	case <-ready:
+		return nil
+	case <-timeout:
+		err = ErrQueueTimeout
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	b.mu.Lock()
+	select {
+	case <-ready:
+		// The slot was granted concurrently, give it back.
+		b.inFlight--
+		b.admit()
+	default:
+		b.waiters.Remove(elem)
+	}
+	b.mu.Unlock()
+	return err
+}
+
+// release gives back a slot taken by `acquire()`. The given latency is the execution
+// time of the request and is used to adjust the limit in adaptive mode.
+// Returns the previous limit and the new limit.
+func (b *Bulkhead) release(latency time.Duration, now time.Time) (int, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	previousLimit := b.limit
+	if b.Adaptive != nil {
+		b.observe(latency, now)
+	}
+	b.inFlight--
+	b.admit()
+	return previousLimit, b.limit
+}
+
+// admit grants slots to the queued requests while the limit allows it.
+func (b *Bulkhead) admit() {
+	for b.inFlight < b.limit && b.waiters.Len() > 0 {
+		elem := b.waiters.Front()
+		b.waiters.Remove(elem)
+		b.inFlight++
+		close(elem.Value.(chan struct{}))
+	}
+}
+
+// observe updates the moving average of the latency and adjusts the limit.
+func (b *Bulkhead) observe(latency time.Duration, now time.Time) {
+	a := b.Adaptive
+	smoothing := a.Smoothing
+	if smoothing <= 0 || smoothing > 1 {
+		smoothing = defaultSmoothing
+	}
+	if b.latency == 0 {
+		b.latency = float64(latency)
+	} else {
+		b.latency = (1-smoothing)*b.latency + smoothing*float64(latency)
+	}
+
+	if time.Duration(b.latency) <= a.TargetLatency {
+		if b.limit < b.MaxConcurrent {
+			b.limit++
+		}
+		return
+	}
+
+	if now.Sub(b.lastDecrease) < a.TargetLatency {
+		return
+	}
+	factor := a.DecreaseFactor
+	if factor <= 0 || factor >= 1 {
+		factor = defaultDecreaseFactor
+	}
+	minConcurrent := max(a.MinConcurrent, 1)
+	b.limit = max(int(math.Floor(float64(b.limit)*factor)), minConcurrent)
+	b.lastDecrease = now
+}
+
+// setSaturated updates the saturation state of the bulkhead.
+// Returns true if the state changed.
+func (b *Bulkhead) setSaturated(saturated bool) bool {
+	return b.saturated.Swap(saturated) != saturated
+}
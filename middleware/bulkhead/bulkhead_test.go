@@ -0,0 +1,139 @@
+package bulkhead
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBulkhead(t *testing.T) {
+	t.Run("acquire_release", func(t *testing.T) {
+		b := &Bulkhead{MaxConcurrent: 2}
+		require.NoError(t, b.acquire(context.Background()))
+		require.NoError(t, b.acquire(context.Background()))
+		assert.Equal(t, Stats{InFlight: 2, Limit: 2}, b.Stats())
+
+		require.ErrorIs(t, b.acquire(context.Background()), ErrQueueFull)
+
+		b.release(time.Millisecond, time.Now())
+		assert.Equal(t, Stats{InFlight: 1, Limit: 2}, b.Stats())
+		require.NoError(t, b.acquire(context.Background()))
+	})
+
+	t.Run("queue", func(t *testing.T) {
+		b := &Bulkhead{MaxConcurrent: 1, MaxQueue: 1}
+		require.NoError(t, b.acquire(context.Background()))
+
+		admitted := make(chan error, 1)
+		go func() {
+			admitted <- b.acquire(context.Background())
+		}()
+		assert.Eventually(t, func() bool { return b.Stats().Queued == 1 }, time.Second, time.Millisecond)
+
+		require.ErrorIs(t, b.acquire(context.Background()), ErrQueueFull)
+
+		b.release(time.Millisecond, time.Now())
+		require.NoError(t, <-admitted)
+		assert.Equal(t, Stats{InFlight: 1, Limit: 1}, b.Stats())
+	})
+
+	t.Run("queue_timeout", func(t *testing.T) {
+		b := &Bulkhead{MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: 10 * time.Millisecond}
+		require.NoError(t, b.acquire(context.Background()))
+		require.ErrorIs(t, b.acquire(context.Background()), ErrQueueTimeout)
+		assert.Equal(t, Stats{InFlight: 1, Limit: 1}, b.Stats())
+	})
+
+	t.Run("context_canceled", func(t *testing.T) {
+		b := &Bulkhead{MaxConcurrent: 1, MaxQueue: 1}
+		require.NoError(t, b.acquire(context.Background()))
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		require.ErrorIs(t, b.acquire(ctx), context.Canceled)
+		assert.Equal(t, Stats{InFlight: 1, Limit: 1}, b.Stats())
+	})
+
+	t.Run("fifo", func(t *testing.T) {
+		b := &Bulkhead{MaxConcurrent: 1, MaxQueue: 2}
+		require.NoError(t, b.acquire(context.Background()))
+
+		order := make(chan int, 2)
+		for i := 1; i <= 2; i++ {
+			go func() {
+				assert.NoError(t, b.acquire(context.Background()))
+				order <- i
+			}()
+			assert.Eventually(t, func() bool { return b.Stats().Queued == i }, time.Second, time.Millisecond)
+		}
+
+		b.release(time.Millisecond, time.Now())
+		assert.Equal(t, 1, <-order)
+		b.release(time.Millisecond, time.Now())
+		assert.Equal(t, 2, <-order)
+	})
+
+	t.Run("adaptive", func(t *testing.T) {
+		b := &Bulkhead{
+			MaxConcurrent: 10,
+			Adaptive:      &Adaptive{TargetLatency: 100 * time.Millisecond, MinConcurrent: 3, Smoothing: 0.5},
+		}
+		now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+		acquire := func() {
+			require.NoError(t, b.acquire(context.Background()))
+		}
+
+		acquire()
+		previous, limit := b.release(300*time.Millisecond, now)
+		assert.Equal(t, 10, previous)
+		assert.Equal(t, 7, limit) // 10 * 0.75
+		assert.Equal(t, 300*time.Millisecond, b.Stats().Latency)
+
+		// At most one decrease per target latency period
+		acquire()
+		_, limit = b.release(300*time.Millisecond, now.Add(50*time.Millisecond))
+		assert.Equal(t, 7, limit)
+
+		acquire()
+		_, limit = b.release(300*time.Millisecond, now.Add(100*time.Millisecond))
+		assert.Equal(t, 5, limit)
+
+		acquire()
+		_, limit = b.release(300*time.Millisecond, now.Add(200*time.Millisecond))
+		assert.Equal(t, 3, limit) // MinConcurrent
+
+		acquire()
+		_, limit = b.release(300*time.Millisecond, now.Add(300*time.Millisecond))
+		assert.Equal(t, 3, limit)
+
+		// Latency goes back to normal: the limit increases by one per fast request
+		acquire()
+		_, limit = b.release(0, now.Add(time.Second)) // Average still above target
+		assert.Equal(t, 3, limit)
+		acquire()
+		_, limit = b.release(0, now.Add(time.Second))
+		assert.LessOrEqual(t, b.Stats().Latency, 100*time.Millisecond)
+		assert.Equal(t, 4, limit)
+		for range 10 {
+			acquire()
+			b.release(0, now.Add(time.Second))
+		}
+		assert.Equal(t, 10, b.Stats().Limit)
+	})
+
+	t.Run("adaptive_decrease_admits_less", func(t *testing.T) {
+		b := &Bulkhead{
+			MaxConcurrent: 4,
+			Adaptive:      &Adaptive{TargetLatency: time.Millisecond, DecreaseFactor: 0.5},
+		}
+		for range 4 {
+			require.NoError(t, b.acquire(context.Background()))
+		}
+		_, limit := b.release(time.Second, time.Now())
+		assert.Equal(t, 2, limit)
+		assert.Equal(t, 3, b.Stats().InFlight)
+		require.ErrorIs(t, b.acquire(context.Background()), ErrQueueFull)
+	})
+}
@@ -0,0 +1,216 @@
+package bulkhead
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"goyave.dev/goyave/v5"
+	errorutil "goyave.dev/goyave/v5/util/errors"
+)
+
+// MetaBulkhead the route meta key used to define the bulkhead protecting a route or
+// a router. The meta value is expected to be a `*bulkhead.Bulkhead`. A `nil` value
+// disables the bulkhead for the route.
+//
+// Routes sharing the same `*Bulkhead` share the same concurrency limit:
+//
+//	router.Subrouter("/reports").SetMeta(bulkhead.MetaBulkhead, &bulkhead.Bulkhead{
+//		MaxConcurrent: 10,
+//		MaxQueue:      50,
+//		QueueTimeout:  2 * time.Second,
+//	})
+const MetaBulkhead = "goyave.bulkhead"
+
+// MetaBreaker the route meta key used to define the circuit breaker protecting a route
+// or a router. The meta value is expected to be a `*bulkhead.Breaker`. A `nil` value
+// disables the circuit breaker for the route.
+//
+// Routes sharing the same `*Breaker` share the same circuit:
+//
+//	router.Subrouter("/reports").SetMeta(bulkhead.MetaBreaker, &bulkhead.Breaker{
+//		FailureRatio: 0.5,
+//		MinRequests:  20,
+//		OpenDuration: 30 * time.Second,
+//	})
+const MetaBreaker = "goyave.circuit-breaker"
+
+// Middleware protecting the server from overload by limiting the number of requests
+// executed concurrently for a route or a group of routes, so one slow endpoint cannot
+// exhaust the resources of the whole server, and by stopping the execution of requests
+// to failing routes with a circuit breaker.
+//
+// The bulkhead applied to a route is taken from the `MetaBulkhead` route meta and the
+// circuit breaker from the `MetaBreaker` route meta. If the route doesn't have these metas,
+// the middleware's default `Bulkhead` and `Breaker` are used. If there is neither a bulkhead
+// nor a circuit breaker for the route, the middleware immediately passes.
+//
+// When all the slots of the bulkhead are taken, requests wait in its queue. If the queue is
+// full or if the queue timeout is exceeded, the middleware stops the request with the
+// "503 Service Unavailable" status, which is rendered by the status handler. Requests are
+// stopped the same way while the circuit breaker is open. The requests rejected by the
+// bulkhead are not counted by the circuit breaker.
+//
+// A warning is logged when a bulkhead starts rejecting requests, when the adaptive load
+// shedding decreases the concurrency limit and when a circuit breaker opens. An info message
+// is logged when they admit requests again.
+type Middleware struct {
+	goyave.Component
+
+	// Bulkhead the default bulkhead applied to routes not having the `MetaBulkhead` meta.
+	// If `nil`, only the routes having the meta are protected.
+	Bulkhead *Bulkhead
+
+	// Breaker the default circuit breaker applied to routes not having the `MetaBreaker` meta.
+	// If `nil`, only the routes having the meta are protected.
+	Breaker *Breaker
+}
+
+// Handle implementation of `goyave.Middleware`.
+func (m *Middleware) Handle(next goyave.Handler) goyave.Handler {
+	return func(response *goyave.Response, request *goyave.Request) {
+		b := m.getBulkhead(request)
+		breaker := m.getBreaker(request)
+		if b == nil && breaker == nil {
+			next(response, request)
+			return
+		}
+		if b != nil && b.MaxConcurrent <= 0 {
+			panic(errorutil.NewSkip(fmt.Errorf("bulkhead: invalid max concurrent %d", b.MaxConcurrent), 3))
+		}
+
+		trial := false
+		if breaker != nil {
+			allowed, isTrial, changed, retryAfter := breaker.allow(time.Now())
+			if changed {
+				m.Logger().InfoContext(request.Context(), "circuit breaker half-open, admitting trial requests", "breaker", m.breakerName(breaker, request))
+			}
+			if !allowed {
+				if breaker.RetryAfter > 0 {
+					retryAfter = breaker.RetryAfter
+				}
+				reject(response, retryAfter)
+				return
+			}
+			trial = isTrial
+		}
+
+		if b != nil {
+			if err := b.acquire(request.Context()); err != nil {
+				if breaker != nil {
+					m.record(breaker, request, trial, outcomeIgnored)
+				}
+				if b.setSaturated(true) {
+					stats := b.Stats()
+					m.Logger().WarnContext(request.Context(), "bulkhead saturated, rejecting requests",
+						"bulkhead", m.name(b, request), "reason", err.Error(),
+						"inFlight", stats.InFlight, "queued", stats.Queued, "limit", stats.Limit,
+					)
+				}
+				reject(response, b.RetryAfter)
+				return
+			}
+			if b.setSaturated(false) {
+				m.Logger().InfoContext(request.Context(), "bulkhead recovered, admitting requests", "bulkhead", m.name(b, request))
+			}
+		}
+
+		start := time.Now()
+		completed := false
+		defer func() {
+			end := time.Now()
+			latency := end.Sub(start)
+			if breaker != nil {
+				result := outcomeFailure // The handler panicked
+				if completed {
+					result = breaker.outcome(response.GetStatus(), latency)
+				}
+				m.record(breaker, request, trial, result)
+			}
+			if b == nil {
+				return
+			}
+			previousLimit, limit := b.release(latency, end)
+			if limit < previousLimit {
+				m.Logger().WarnContext(request.Context(), "bulkhead concurrency limit decreased",
+					"bulkhead", m.name(b, request), "limit", limit, "previousLimit", previousLimit,
+					"latency", b.Stats().Latency.String(),
+				)
+			}
+		}()
+		next(response, request)
+		completed = true
+	}
+}
+
+// record the outcome of a request in the given breaker and logs its state changes.
+func (m *Middleware) record(breaker *Breaker, request *goyave.Request, trial bool, result outcome) {
+	previous, state := breaker.record(trial, result, time.Now())
+	if previous == state {
+		return
+	}
+	switch state {
+	case StateOpen:
+		m.Logger().WarnContext(request.Context(), "circuit breaker opened, rejecting requests",
+			"breaker", m.breakerName(breaker, request), "previousState", previous.String(),
+		)
+	case StateClosed:
+		m.Logger().InfoContext(request.Context(), "circuit breaker closed, admitting requests", "breaker", m.breakerName(breaker, request))
+	}
+}
+
+// reject stops the request with the "503 Service Unavailable" status.
+func reject(response *goyave.Response, retryAfter time.Duration) {
+	if retryAfter > 0 {
+		response.Header().Set("Retry-After", strconv.FormatInt(int64(math.Ceil(retryAfter.Seconds())), 10))
+	}
+	response.Status(http.StatusServiceUnavailable)
+}
+
+func (m *Middleware) getBulkhead(request *goyave.Request) *Bulkhead {
+	if request.Route != nil {
+		if meta, ok := request.Route.LookupMeta(MetaBulkhead); ok {
+			if meta == nil {
+				return nil
+			}
+			b, ok := meta.(*Bulkhead)
+			if !ok {
+				panic(errorutil.NewSkip(fmt.Errorf("bulkhead: route meta %q is not a *bulkhead.Bulkhead", MetaBulkhead), 3))
+			}
+			return b
+		}
+	}
+	return m.Bulkhead
+}
+
+func (m *Middleware) getBreaker(request *goyave.Request) *Breaker {
+	if request.Route != nil {
+		if meta, ok := request.Route.LookupMeta(MetaBreaker); ok {
+			if meta == nil {
+				return nil
+			}
+			b, ok := meta.(*Breaker)
+			if !ok {
+				panic(errorutil.NewSkip(fmt.Errorf("bulkhead: route meta %q is not a *bulkhead.Breaker", MetaBreaker), 3))
+			}
+			return b
+		}
+	}
+	return m.Breaker
+}
+
+func (m *Middleware) breakerName(b *Breaker, request *goyave.Request) string {
+	if b.Name != "" || request.Route == nil {
+		return b.Name
+	}
+	return request.Route.GetFullURI()
+}
+
+func (m *Middleware) name(b *Bulkhead, request *goyave.Request) string {
+	if b.Name != "" || request.Route == nil {
+		return b.Name
+	}
+	return request.Route.GetFullURI()
+}
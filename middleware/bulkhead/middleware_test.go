@@ -0,0 +1,269 @@
+package bulkhead
+
+import (
+	"bytes"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/config"
+	"goyave.dev/goyave/v5/slog"
+	"goyave.dev/goyave/v5/util/testutil"
+)
+
+// syncBuffer a buffer safe for concurrent use, as logs are written by concurrent requests.
+type syncBuffer struct {
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestMiddleware(t *testing.T) {
+	prepareTest := func(t *testing.T) (*testutil.TestServer, *syncBuffer) {
+		server := testutil.NewTestServerWithOptions(t, goyave.Options{Config: config.LoadDefault()})
+		logs := &syncBuffer{}
+		server.Logger = slog.New(slog.NewHandler(false, logs))
+		return server, logs
+	}
+	newRequest := func(server *testutil.TestServer, route *goyave.Route) *goyave.Request {
+		request := server.NewTestRequest(http.MethodGet, "/test", nil)
+		request.Route = route
+		return request
+	}
+	handler := func(response *goyave.Response, _ *goyave.Request) {
+		response.Status(http.StatusNoContent)
+	}
+
+	t.Run("no_bulkhead", func(t *testing.T) {
+		server, _ := prepareTest(t)
+		resp := server.TestMiddleware(&Middleware{}, newRequest(server, &goyave.Route{Meta: map[string]any{}}), handler)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+
+		resp = server.TestMiddleware(&Middleware{Bulkhead: &Bulkhead{MaxConcurrent: 1}}, newRequest(server, &goyave.Route{Meta: map[string]any{MetaBulkhead: nil}}), handler)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("saturated", func(t *testing.T) {
+		server, logs := prepareTest(t)
+		b := &Bulkhead{Name: "reports", MaxConcurrent: 1, RetryAfter: 1500 * time.Millisecond}
+		route := &goyave.Route{Meta: map[string]any{MetaBulkhead: b}}
+		middleware := &Middleware{}
+
+		started := make(chan struct{})
+		unblock := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			resp := server.TestMiddleware(middleware, newRequest(server, route), func(response *goyave.Response, _ *goyave.Request) {
+				close(started)
+				<-unblock
+				response.Status(http.StatusNoContent)
+			})
+			assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+			assert.NoError(t, resp.Body.Close())
+		}()
+		<-started
+
+		resp := server.TestMiddleware(middleware, newRequest(server, route), func(_ *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware should not pass")
+		})
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, "2", resp.Header.Get("Retry-After"))
+		body, err := testutil.ReadJSONBody[map[string]string](resp.Body)
+		assert.NoError(t, resp.Body.Close())
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]string{"error": http.StatusText(http.StatusServiceUnavailable)}, body)
+		assert.Contains(t, logs.String(), `"msg":"bulkhead saturated, rejecting requests","bulkhead":"reports","reason":"bulkhead: queue is full","inFlight":1,"queued":0,"limit":1`)
+
+		close(unblock)
+		<-done
+		assert.Equal(t, Stats{InFlight: 0, Limit: 1}, b.Stats())
+
+		resp = server.TestMiddleware(middleware, newRequest(server, route), handler)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+		assert.Contains(t, logs.String(), `"msg":"bulkhead recovered, admitting requests","bulkhead":"reports"`)
+	})
+
+	t.Run("queue", func(t *testing.T) {
+		server, _ := prepareTest(t)
+		b := &Bulkhead{MaxConcurrent: 1, MaxQueue: 1, QueueTimeout: time.Second}
+		middleware := &Middleware{Bulkhead: b}
+
+		unblock := make(chan struct{})
+		wg := sync.WaitGroup{}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp := server.TestMiddleware(middleware, newRequest(server, &goyave.Route{Meta: map[string]any{}}), func(response *goyave.Response, _ *goyave.Request) {
+				<-unblock
+				response.Status(http.StatusNoContent)
+			})
+			assert.NoError(t, resp.Body.Close())
+		}()
+		assert.Eventually(t, func() bool { return b.Stats().InFlight == 1 }, time.Second, time.Millisecond)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp := server.TestMiddleware(middleware, newRequest(server, &goyave.Route{Meta: map[string]any{}}), handler)
+			assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+			assert.NoError(t, resp.Body.Close())
+		}()
+		assert.Eventually(t, func() bool { return b.Stats().Queued == 1 }, time.Second, time.Millisecond)
+
+		close(unblock)
+		wg.Wait()
+		assert.Equal(t, Stats{InFlight: 0, Limit: 1}, b.Stats())
+	})
+
+	t.Run("panic_releases_slot", func(t *testing.T) {
+		server, _ := prepareTest(t)
+		server.Logger = slog.New(slog.NewHandler(false, &bytes.Buffer{}))
+		b := &Bulkhead{MaxConcurrent: 1}
+		resp := server.TestMiddleware(&Middleware{Bulkhead: b}, newRequest(server, &goyave.Route{Meta: map[string]any{}}), func(_ *goyave.Response, _ *goyave.Request) {
+			panic("test panic")
+		})
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+		assert.Equal(t, Stats{InFlight: 0, Limit: 1}, b.Stats())
+	})
+
+	t.Run("adaptive_log", func(t *testing.T) {
+		server, logs := prepareTest(t)
+		b := &Bulkhead{MaxConcurrent: 4, Adaptive: &Adaptive{TargetLatency: time.Nanosecond}}
+		route := &goyave.Route{Meta: map[string]any{MetaBulkhead: b}}
+		resp := server.TestMiddleware(&Middleware{}, newRequest(server, route), func(response *goyave.Response, _ *goyave.Request) {
+			time.Sleep(time.Millisecond)
+			response.Status(http.StatusNoContent)
+		})
+		assert.NoError(t, resp.Body.Close())
+		assert.Equal(t, 3, b.Stats().Limit)
+		assert.Contains(t, logs.String(), `"msg":"bulkhead concurrency limit decreased","bulkhead":"","limit":3,"previousLimit":4`)
+	})
+
+	t.Run("breaker", func(t *testing.T) {
+		server, logs := prepareTest(t)
+		breaker := &Breaker{Name: "reports", MinRequests: 2, OpenDuration: time.Hour}
+		route := &goyave.Route{Meta: map[string]any{MetaBreaker: breaker}}
+		middleware := &Middleware{}
+		failing := func(response *goyave.Response, _ *goyave.Request) {
+			response.Status(http.StatusInternalServerError)
+		}
+
+		for i := 0; i < 2; i++ {
+			resp := server.TestMiddleware(middleware, newRequest(server, route), failing)
+			assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+			assert.NoError(t, resp.Body.Close())
+		}
+		assert.Equal(t, StateOpen, breaker.State())
+		assert.Contains(t, logs.String(), `"msg":"circuit breaker opened, rejecting requests","breaker":"reports","previousState":"closed"`)
+
+		resp := server.TestMiddleware(middleware, newRequest(server, route), func(_ *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "middleware should not pass")
+		})
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, "3600", resp.Header.Get("Retry-After"))
+		assert.NoError(t, resp.Body.Close())
+
+		breaker.RetryAfter = 5 * time.Second
+		resp = server.TestMiddleware(middleware, newRequest(server, route), handler)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, "5", resp.Header.Get("Retry-After"))
+		assert.NoError(t, resp.Body.Close())
+
+		breaker.mu.Lock()
+		breaker.openedAt = time.Now().Add(-time.Hour)
+		breaker.mu.Unlock()
+
+		resp = server.TestMiddleware(middleware, newRequest(server, route), handler)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+		assert.Equal(t, StateClosed, breaker.State())
+		assert.Contains(t, logs.String(), `"msg":"circuit breaker half-open, admitting trial requests","breaker":"reports"`)
+		assert.Contains(t, logs.String(), `"msg":"circuit breaker closed, admitting requests","breaker":"reports"`)
+	})
+
+	t.Run("breaker_panic", func(t *testing.T) {
+		server, _ := prepareTest(t)
+		breaker := &Breaker{MinRequests: 1}
+		resp := server.TestMiddleware(&Middleware{Breaker: breaker}, newRequest(server, &goyave.Route{Meta: map[string]any{}}), func(_ *goyave.Response, _ *goyave.Request) {
+			panic("test panic")
+		})
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+		assert.Equal(t, StateOpen, breaker.State())
+
+		resp = server.TestMiddleware(&Middleware{Breaker: breaker}, newRequest(server, &goyave.Route{Meta: map[string]any{MetaBreaker: nil}}), handler)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+	})
+
+	t.Run("breaker_ignores_bulkhead_rejections", func(t *testing.T) {
+		server, _ := prepareTest(t)
+		b := &Bulkhead{MaxConcurrent: 1}
+		breaker := &Breaker{MinRequests: 1}
+		route := &goyave.Route{Meta: map[string]any{MetaBulkhead: b, MetaBreaker: breaker}}
+		middleware := &Middleware{}
+
+		started := make(chan struct{})
+		unblock := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			resp := server.TestMiddleware(middleware, newRequest(server, route), func(response *goyave.Response, _ *goyave.Request) {
+				close(started)
+				<-unblock
+				response.Status(http.StatusNoContent)
+			})
+			assert.NoError(t, resp.Body.Close())
+		}()
+		<-started
+
+		resp := server.TestMiddleware(middleware, newRequest(server, route), handler)
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.NoError(t, resp.Body.Close())
+		assert.Equal(t, StateClosed, breaker.State())
+
+		close(unblock)
+		<-done
+		assert.Equal(t, StateClosed, breaker.State())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		server, _ := prepareTest(t)
+		middleware := &Middleware{}
+		middleware.Init(server.Server)
+		request := newRequest(server, &goyave.Route{Meta: map[string]any{MetaBulkhead: "invalid"}})
+		response, _ := server.NewTestResponse(request)
+		assert.Panics(t, func() {
+			middleware.Handle(handler)(response, request)
+		})
+
+		request = newRequest(server, &goyave.Route{Meta: map[string]any{MetaBulkhead: &Bulkhead{}}})
+		assert.Panics(t, func() {
+			middleware.Handle(handler)(response, request)
+		})
+
+		request = newRequest(server, &goyave.Route{Meta: map[string]any{MetaBreaker: "invalid"}})
+		assert.Panics(t, func() {
+			middleware.Handle(handler)(response, request)
+		})
+	})
+}
@@ -0,0 +1,453 @@
+package cache
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/util/errors"
+)
+
+type (
+	// ExtraTags the key used in `request.Extra` to store the tags of the response.
+	// Use `cache.Tag()` to add tags.
+	ExtraTags struct{}
+
+	// ExtraStore the key used in `request.Extra` to store the cache store
+	// used by the middleware. Use `cache.Invalidate()` to invalidate tags.
+	ExtraStore struct{}
+)
+
+// DefaultMaxBodySize the default maximum size of a response body that can be cached (1 MiB).
+const DefaultMaxBodySize = 1 << 20
+
+// cacheableStatuses status codes of responses that can be stored.
+var cacheableStatuses = []int{
+	http.StatusOK,
+	http.StatusNonAuthoritativeInfo,
+	http.StatusNoContent,
+	http.StatusMultipleChoices,
+	http.StatusMovedPermanently,
+	http.StatusPermanentRedirect,
+	http.StatusNotFound,
+	http.StatusGone,
+}
+
+// excludedHeaders the response headers that are never stored.
+var excludedHeaders = []string{"Age", "Connection", "Keep-Alive", "Set-Cookie", "Trailer", "Transfer-Encoding", "Upgrade", "X-Cache"}
+
+// Tag adds the given tags to the response of the given request. If the response is
+// stored in the cache, it can later be removed by invalidating one of its tags.
+func Tag(request *goyave.Request, tags ...string) {
+	existing, _ := request.Extra[ExtraTags{}].([]string)
+	request.Extra[ExtraTags{}] = append(existing, tags...)
+}
+
+// Invalidate removes all the cached responses having at least one of the given tags
+// from the store used by the cache middleware. The cache middleware must be
+// applied to the route of the given request, otherwise this function panics.
+//
+//	router.Post("/articles", func(response *goyave.Response, request *goyave.Request) {
+//		//...
+//		if err := cache.Invalidate(request, "articles"); err != nil {
+//			response.Error(err)
+//			return
+//		}
+//	}).Middleware(cacheMiddleware)
+func Invalidate(request *goyave.Request, tags ...string) error {
+	store, ok := request.Extra[ExtraStore{}].(Store)
+	if !ok {
+		panic(errors.NewSkip(fmt.Errorf("cache.Invalidate: the cache middleware is not applied on this route"), 3))
+	}
+	return errors.New(store.InvalidateTags(request.Context(), tags...))
+}
+
+// Middleware caches full responses (status, headers and body) of "GET" requests.
+// "HEAD" requests are served from the cached "GET" responses.
+//
+// The cache key is built from the method, host and URL of the request. If the response
+// has a `Vary` header, the values of the listed request headers are added to the key.
+//
+// The `Cache-Control` directives are honored:
+//   - Request `no-store`: the cache is bypassed entirely.
+//   - Request `no-cache`: the cached response is not used, but the new response is stored.
+//   - Request `max-age`: cached responses older than the given age are not used.
+//   - Response `no-store`, `no-cache` and `private`: the response is not stored.
+//   - Response `s-maxage` and `max-age`: the response is stored for the given duration. If absent,
+//     the middleware's `TTL` is used. If the duration is zero, the response is not stored.
+//
+// Responses are only stored if their status is cacheable (200, 203, 204, 300, 301, 308, 404, 410),
+// if they don't set cookies, and if their body doesn't exceed `MaxBodySize`.
+//
+// Requests carrying credentials (the `Authorization` header, cookies or an existing HTTP session)
+// are only served from the cache, and their responses are only stored, if the response explicitly
+// allows it with the `public` or `s-maxage` directives. This prevents the responses personalized
+// for a user from being served to other users.
+//
+// Conditional requests (`If-None-Match`, `If-Modified-Since`) served from the cache
+// result in "304 Not Modified" responses. An `ETag` is generated from the body if the
+// response doesn't have one.
+//
+// Cached responses can be tagged with `cache.Tag()` and invalidated with `cache.Invalidate()`
+// or the store's `InvalidateTags()` method.
+//
+// The `X-Cache` header is set to "HIT" or "MISS", and the `Age` header is set on cache hits.
+//
+// Because the response is captured as it is written, this middleware should be executed
+// after encoding middleware (such as `compress`) so the stored body is not encoded.
+//
+// **Example:**
+//
+//	cacheMiddleware := &cache.Middleware{
+//		Store: cache.NewMemoryStore(1000),
+//		TTL:   time.Minute,
+//	}
+//	router.Get("/articles", articlesHandler).Middleware(cacheMiddleware)
+type Middleware struct {
+	goyave.Component
+
+	// Store persists the cached responses. Defaults to a `MemoryStore` with a
+	// capacity of 1000 entries.
+	Store Store
+
+	// TTL the duration for which responses that don't define `max-age` or `s-maxage`
+	// are stored. If zero, only these responses are stored.
+	TTL time.Duration
+
+	// MaxBodySize the maximum size in bytes of a response body that can be stored.
+	// Defaults to `DefaultMaxBodySize` (1 MiB).
+	MaxBodySize int
+
+	storeOnce sync.Once
+}
+
+// Handle implementation of `goyave.Middleware`.
+func (m *Middleware) Handle(next goyave.Handler) goyave.Handler {
+	return func(response *goyave.Response, request *goyave.Request) {
+		request.Extra[ExtraStore{}] = m.getStore()
+		method := request.Method()
+		if method != http.MethodGet && method != http.MethodHead {
+			next(response, request)
+			return
+		}
+
+		requestDirectives := parseCacheControl(request.Header().Values("Cache-Control"))
+		if requestDirectives.has("no-store") {
+			next(response, request)
+			return
+		}
+
+		key := m.key(request)
+		if !requestDirectives.has("no-cache") {
+			entry, err := m.lookup(request, key)
+			if err != nil {
+				m.Logger().ErrorCtx(request.Context(), err)
+			}
+			if entry != nil && isAcceptable(entry, requestDirectives, request.Now) &&
+				(!hasCredentials(request) || isShared(parseCacheControl(entry.Header.Values("Cache-Control")))) {
+				m.serve(response, request, entry)
+				return
+			}
+		}
+
+		response.Header().Set("X-Cache", "MISS")
+		if method == http.MethodGet {
+			response.SetWriter(&writer{
+				CommonWriter: goyave.NewCommonWriter(response.Writer()),
+				middleware:   m,
+				request:      request,
+				response:     response,
+				key:          key,
+			})
+		}
+		next(response, request)
+	}
+}
+
+func (m *Middleware) getStore() Store {
+	m.storeOnce.Do(func() {
+		if m.Store == nil {
+			m.Store = NewMemoryStore(1000)
+		}
+	})
+	return m.Store
+}
+
+func (m *Middleware) getMaxBodySize() int {
+	if m.MaxBodySize <= 0 {
+		return DefaultMaxBodySize
+	}
+	return m.MaxBodySize
+}
+
+func (m *Middleware) key(request *goyave.Request) string {
+	return http.MethodGet + " " + request.Scheme() + "://" + request.Host() + request.URL().RequestURI()
+}
+
+// lookup the entry matching the request, following the `Vary` indirection if needed.
+func (m *Middleware) lookup(request *goyave.Request, key string) (*Entry, error) {
+	store := m.getStore()
+	entry, err := store.Get(request.Context(), key)
+	if err != nil || entry == nil {
+		return nil, errors.New(err)
+	}
+	if entry.Status == 0 && len(entry.Vary) > 0 {
+		entry, err = store.Get(request.Context(), variantKey(key, entry.Vary, request.Header()))
+		if err != nil {
+			return nil, errors.New(err)
+		}
+	}
+	return entry, nil
+}
+
+func (m *Middleware) serve(response *goyave.Response, request *goyave.Request, entry *Entry) {
+	header := response.Header()
+	for k, v := range entry.Header {
+		header[k] = slices.Clone(v)
+	}
+	header.Set("X-Cache", "HIT")
+	header.Set("Age", strconv.FormatInt(int64(request.Now.Sub(entry.StoredAt)/time.Second), 10))
+
+	if isNotModified(request.Header(), entry) {
+		header.Del("Content-Length")
+		response.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	response.Status(entry.Status)
+	if len(entry.Body) == 0 {
+		response.WriteHeader(entry.Status)
+		return
+	}
+	if _, err := response.Write(entry.Body); err != nil {
+		panic(errors.New(err))
+	}
+}
+
+// store the response captured by the given writer if it is cacheable.
+func (m *Middleware) store(w *writer) error {
+	request := w.request
+	status := w.response.GetStatus()
+	if w.overflow || w.response.Hijacked() || !slices.Contains(cacheableStatuses, status) {
+		return nil
+	}
+
+	header := w.response.Header()
+	if header.Get("Set-Cookie") != "" {
+		return nil
+	}
+	vary := parseVary(header.Values("Vary"))
+	if slices.Contains(vary, "*") {
+		return nil
+	}
+	directives := parseCacheControl(header.Values("Cache-Control"))
+	if directives.has("no-store") || directives.has("no-cache") || directives.has("private") {
+		return nil
+	}
+	if hasCredentials(request) && !isShared(directives) {
+		return nil
+	}
+	ttl := m.TTL
+	if maxAge, ok := directives.duration("s-maxage"); ok {
+		ttl = maxAge
+	} else if maxAge, ok := directives.duration("max-age"); ok {
+		ttl = maxAge
+	}
+	if ttl <= 0 {
+		return nil
+	}
+
+	storedHeader := header.Clone()
+	for _, h := range excludedHeaders {
+		storedHeader.Del(h)
+	}
+	body := w.buf.Bytes()
+	etag := storedHeader.Get("ETag")
+	if etag == "" {
+		hash := sha256.Sum256(body)
+		etag = `"` + hex.EncodeToString(hash[:16]) + `"`
+		storedHeader.Set("ETag", etag)
+	}
+
+	tags, _ := request.Extra[ExtraTags{}].([]string)
+	now := request.Now
+	entry := &Entry{
+		Key:       w.key,
+		Status:    status,
+		Header:    storedHeader,
+		Body:      slices.Clone(body),
+		ETag:      etag,
+		Tags:      tags,
+		StoredAt:  now,
+		ExpiresAt: now.Add(ttl),
+	}
+
+	store := m.getStore()
+	ctx := request.Context()
+	if len(vary) > 0 {
+		marker := &Entry{
+			Key:       w.key,
+			Vary:      vary,
+			StoredAt:  now,
+			ExpiresAt: entry.ExpiresAt,
+		}
+		if err := store.Set(ctx, marker); err != nil {
+			return errors.New(err)
+		}
+		entry.Key = variantKey(w.key, vary, request.Header())
+		entry.Vary = vary
+	}
+	return errors.New(store.Set(ctx, entry))
+}
+
+// writer captures the response body so it can be stored when the response is closed.
+type writer struct {
+	goyave.CommonWriter
+	middleware *Middleware
+	request    *goyave.Request
+	response   *goyave.Response
+	key        string
+	buf        bytes.Buffer
+	overflow   bool
+}
+
+// Write writes the data to the child writer and keeps a copy of it
+// if the maximum body size is not exceeded.
+func (w *writer) Write(b []byte) (int, error) {
+	if !w.overflow {
+		if w.buf.Len()+len(b) > w.middleware.getMaxBodySize() {
+			w.overflow = true
+			w.buf = bytes.Buffer{}
+		} else {
+			w.buf.Write(b)
+		}
+	}
+	n, err := w.CommonWriter.Write(b)
+	return n, errors.New(err)
+}
+
+// Close stores the response if it is cacheable, then closes the child writer.
+func (w *writer) Close() error {
+	if err := w.middleware.store(w); err != nil {
+		w.middleware.Logger().ErrorCtx(w.request.Context(), err)
+	}
+	return errors.New(w.CommonWriter.Close())
+}
+
+// hasCredentials returns true if the given request carries credentials: the `Authorization`
+// header, cookies, or an existing HTTP session.
+func hasCredentials(request *goyave.Request) bool {
+	if request.Header().Get("Authorization") != "" || request.Header().Get("Cookie") != "" {
+		return true
+	}
+	session := request.Session()
+	return session != nil && !session.IsNew()
+}
+
+// isShared returns true if the given response directives explicitly allow a shared cache to
+// store the response to a request carrying credentials.
+func isShared(directives cacheControl) bool {
+	return directives.has("public") || directives.has("s-maxage")
+}
+
+func isAcceptable(entry *Entry, requestDirectives cacheControl, now time.Time) bool {
+	if entry.Expired(now) {
+		return false
+	}
+	if maxAge, ok := requestDirectives.duration("max-age"); ok && now.Sub(entry.StoredAt) > maxAge {
+		return false
+	}
+	return true
+}
+
+// isNotModified evaluates the conditional request headers against the given entry.
+func isNotModified(header http.Header, entry *Entry) bool {
+	if inm := header.Get("If-None-Match"); inm != "" {
+		for _, tag := range strings.Split(inm, ",") {
+			tag = strings.TrimSpace(tag)
+			if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(entry.ETag, "W/") {
+				return true
+			}
+		}
+		return false
+	}
+	if ims := header.Get("If-Modified-Since"); ims != "" {
+		lastModified, err := http.ParseTime(entry.Header.Get("Last-Modified"))
+		if err != nil {
+			return false
+		}
+		since, err := http.ParseTime(ims)
+		return err == nil && !lastModified.After(since)
+	}
+	return false
+}
+
+func variantKey(key string, vary []string, header http.Header) string {
+	var builder strings.Builder
+	builder.WriteString(key)
+	for _, h := range vary {
+		builder.WriteByte('\n')
+		builder.WriteString(h)
+		builder.WriteByte(':')
+		builder.WriteString(strings.Join(header.Values(h), ","))
+	}
+	return builder.String()
+}
+
+// parseVary returns the sorted and deduplicated canonical header names
+// listed in the given `Vary` header values.
+func parseVary(values []string) []string {
+	vary := []string{}
+	for _, v := range values {
+		for _, h := range strings.Split(v, ",") {
+			h = strings.TrimSpace(h)
+			if h != "" {
+				vary = append(vary, http.CanonicalHeaderKey(h))
+			}
+		}
+	}
+	slices.Sort(vary)
+	return slices.Compact(vary)
+}
+
+// cacheControl the parsed directives of a `Cache-Control` header.
+type cacheControl map[string]string
+
+func parseCacheControl(values []string) cacheControl {
+	directives := cacheControl{}
+	for _, v := range values {
+		for _, d := range strings.Split(v, ",") {
+			name, value, _ := strings.Cut(strings.TrimSpace(d), "=")
+			if name == "" {
+				continue
+			}
+			directives[strings.ToLower(name)] = strings.Trim(value, `"`)
+		}
+	}
+	return directives
+}
+
+func (c cacheControl) has(directive string) bool {
+	_, ok := c[directive]
+	return ok
+}
+
+func (c cacheControl) duration(directive string) (time.Duration, bool) {
+	value, ok := c[directive]
+	if !ok {
+		return 0, false
+	}
+	seconds, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || seconds < 0 {
+		return 0, false
+	}
+	return time.Duration(seconds) * time.Second, true
+}
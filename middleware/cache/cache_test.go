@@ -0,0 +1,404 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/config"
+	"goyave.dev/goyave/v5/util/testutil"
+)
+
+type testErrorStore struct{}
+
+func (testErrorStore) Get(_ context.Context, _ string) (*Entry, error) {
+	return nil, fmt.Errorf("get error")
+}
+
+func (testErrorStore) Set(_ context.Context, _ *Entry) error {
+	return fmt.Errorf("set error")
+}
+
+func (testErrorStore) InvalidateTags(_ context.Context, _ ...string) error {
+	return fmt.Errorf("invalidate error")
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	return string(body)
+}
+
+func TestMiddleware(t *testing.T) {
+	server := testutil.NewTestServerWithOptions(t, goyave.Options{Config: config.LoadDefault()})
+
+	newCountingHandler := func(count *int, f func(response *goyave.Response, request *goyave.Request)) goyave.Handler {
+		return func(response *goyave.Response, request *goyave.Request) {
+			*count++
+			f(response, request)
+		}
+	}
+
+	t.Run("hit_and_miss", func(t *testing.T) {
+		middleware := &Middleware{TTL: time.Minute}
+		count := 0
+		handler := newCountingHandler(&count, func(response *goyave.Response, request *goyave.Request) {
+			response.Header().Set("Content-Type", "text/plain")
+			response.Header().Set("Set-Cookie-Like", "value")
+			response.String(http.StatusOK, "hello "+request.Query["name"].(string))
+		})
+		newRequest := func(query string) *goyave.Request {
+			request := testutil.NewTestRequest(http.MethodGet, "/test?"+query, nil)
+			request.Query = map[string]any{"name": strings.TrimPrefix(query, "name=")}
+			return request
+		}
+
+		resp := server.TestMiddleware(middleware, newRequest("name=a"), handler)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "MISS", resp.Header.Get("X-Cache"))
+		assert.Equal(t, "hello a", readBody(t, resp))
+
+		resp = server.TestMiddleware(middleware, newRequest("name=a"), handler)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "HIT", resp.Header.Get("X-Cache"))
+		assert.Equal(t, "0", resp.Header.Get("Age"))
+		assert.Equal(t, "text/plain", resp.Header.Get("Content-Type"))
+		assert.NotEmpty(t, resp.Header.Get("ETag"))
+		assert.Equal(t, "hello a", readBody(t, resp))
+		assert.Equal(t, 1, count)
+
+		// Different URL
+		resp = server.TestMiddleware(middleware, newRequest("name=b"), handler)
+		assert.Equal(t, "MISS", resp.Header.Get("X-Cache"))
+		assert.Equal(t, "hello b", readBody(t, resp))
+		assert.Equal(t, 2, count)
+
+		// HEAD served from GET
+		request := newRequest("name=a")
+		request.Request().Method = http.MethodHead
+		resp = server.TestMiddleware(middleware, request, handler)
+		assert.Equal(t, "HIT", resp.Header.Get("X-Cache"))
+		_ = resp.Body.Close()
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("not_get", func(t *testing.T) {
+		middleware := &Middleware{TTL: time.Minute}
+		count := 0
+		handler := newCountingHandler(&count, func(response *goyave.Response, request *goyave.Request) {
+			assert.Equal(t, middleware.Store, request.Extra[ExtraStore{}])
+			response.String(http.StatusOK, "hello")
+		})
+		for i := 0; i < 2; i++ {
+			resp := server.TestMiddleware(middleware, testutil.NewTestRequest(http.MethodPost, "/test", nil), handler)
+			assert.Empty(t, resp.Header.Get("X-Cache"))
+			_ = resp.Body.Close()
+		}
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("request_cache_control", func(t *testing.T) {
+		middleware := &Middleware{TTL: time.Minute}
+		count := 0
+		handler := newCountingHandler(&count, func(response *goyave.Response, _ *goyave.Request) {
+			response.String(http.StatusOK, fmt.Sprintf("hello %d", count))
+		})
+		newRequest := func(cacheControl string) *goyave.Request {
+			request := testutil.NewTestRequest(http.MethodGet, "/test", nil)
+			request.Header().Set("Cache-Control", cacheControl)
+			return request
+		}
+
+		// no-store: not stored
+		resp := server.TestMiddleware(middleware, newRequest("no-store"), handler)
+		assert.Empty(t, resp.Header.Get("X-Cache"))
+		assert.Equal(t, "hello 1", readBody(t, resp))
+
+		resp = server.TestMiddleware(middleware, newRequest(""), handler)
+		assert.Equal(t, "MISS", resp.Header.Get("X-Cache"))
+		assert.Equal(t, "hello 2", readBody(t, resp))
+
+		// no-cache: revalidate and store new response
+		resp = server.TestMiddleware(middleware, newRequest("no-cache"), handler)
+		assert.Equal(t, "MISS", resp.Header.Get("X-Cache"))
+		assert.Equal(t, "hello 3", readBody(t, resp))
+
+		resp = server.TestMiddleware(middleware, newRequest("max-age=60"), handler)
+		assert.Equal(t, "HIT", resp.Header.Get("X-Cache"))
+		assert.Equal(t, "hello 3", readBody(t, resp))
+
+		// max-age: too old
+		request := newRequest("max-age=5")
+		request.Now = time.Now().Add(10 * time.Second)
+		resp = server.TestMiddleware(middleware, request, handler)
+		assert.Equal(t, "MISS", resp.Header.Get("X-Cache"))
+		assert.Equal(t, "hello 4", readBody(t, resp))
+	})
+
+	t.Run("response_cache_control", func(t *testing.T) {
+		cases := []struct {
+			header        http.Header
+			authorization bool
+			cookie        bool
+			stored        bool
+			status        int
+			ttl           time.Duration
+		}{
+			{header: http.Header{}, status: http.StatusOK, ttl: time.Minute, stored: true},
+			{header: http.Header{}, status: http.StatusOK, ttl: 0, stored: false},
+			{header: http.Header{"Cache-Control": {"max-age=60"}}, status: http.StatusOK, ttl: 0, stored: true},
+			{header: http.Header{"Cache-Control": {"max-age=0"}}, status: http.StatusOK, ttl: time.Minute, stored: false},
+			{header: http.Header{"Cache-Control": {"public, s-maxage=60, max-age=0"}}, status: http.StatusOK, ttl: 0, stored: true},
+			{header: http.Header{"Cache-Control": {"no-store"}}, status: http.StatusOK, ttl: time.Minute, stored: false},
+			{header: http.Header{"Cache-Control": {"no-cache"}}, status: http.StatusOK, ttl: time.Minute, stored: false},
+			{header: http.Header{"Cache-Control": {"private, max-age=60"}}, status: http.StatusOK, ttl: time.Minute, stored: false},
+			{header: http.Header{"Set-Cookie": {"a=b"}}, status: http.StatusOK, ttl: time.Minute, stored: false},
+			{header: http.Header{"Vary": {"*"}}, status: http.StatusOK, ttl: time.Minute, stored: false},
+			{header: http.Header{}, status: http.StatusCreated, ttl: time.Minute, stored: false},
+			{header: http.Header{}, status: http.StatusNotFound, ttl: time.Minute, stored: true},
+			{header: http.Header{}, status: http.StatusOK, ttl: time.Minute, authorization: true, stored: false},
+			{header: http.Header{"Cache-Control": {"public"}}, status: http.StatusOK, ttl: time.Minute, authorization: true, stored: true},
+			{header: http.Header{}, status: http.StatusOK, ttl: time.Minute, cookie: true, stored: false},
+			{header: http.Header{"Cache-Control": {"max-age=60"}}, status: http.StatusOK, ttl: time.Minute, cookie: true, stored: false},
+			{header: http.Header{"Cache-Control": {"s-maxage=60"}}, status: http.StatusOK, ttl: time.Minute, cookie: true, stored: true},
+		}
+
+		for i, c := range cases {
+			t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+				store := NewMemoryStore(10)
+				middleware := &Middleware{Store: store, TTL: c.ttl}
+				request := testutil.NewTestRequest(http.MethodGet, "/test", nil)
+				if c.authorization {
+					request.Header().Set("Authorization", "Bearer token")
+				}
+				if c.cookie {
+					request.Header().Set("Cookie", "session=abc")
+				}
+				resp := server.TestMiddleware(middleware, request, func(response *goyave.Response, _ *goyave.Request) {
+					for k, v := range c.header {
+						response.Header()[k] = v
+					}
+					response.String(c.status, "body")
+				})
+				_ = resp.Body.Close()
+				assert.Equal(t, c.stored, store.Len() > 0)
+			})
+		}
+	})
+
+	t.Run("credentials", func(t *testing.T) {
+		middleware := &Middleware{TTL: time.Minute}
+		count := 0
+		handler := newCountingHandler(&count, func(response *goyave.Response, request *goyave.Request) {
+			if request.Query["public"] == "true" {
+				response.Header().Set("Cache-Control", "public")
+			}
+			user := "anonymous"
+			if request.Header().Get("Cookie") != "" {
+				user = "johndoe"
+			}
+			response.String(http.StatusOK, "hello "+user)
+		})
+		newRequest := func(cookie, public bool) *goyave.Request {
+			request := testutil.NewTestRequest(http.MethodGet, fmt.Sprintf("/test?public=%t", public), nil)
+			request.Query = map[string]any{"public": fmt.Sprintf("%t", public)}
+			if cookie {
+				request.Header().Set("Cookie", "session=abc")
+			}
+			return request
+		}
+
+		// The cached anonymous response is not served to a logged in user.
+		resp := server.TestMiddleware(middleware, newRequest(false, false), handler)
+		assert.Equal(t, "hello anonymous", readBody(t, resp))
+		resp = server.TestMiddleware(middleware, newRequest(true, false), handler)
+		assert.Equal(t, "MISS", resp.Header.Get("X-Cache"))
+		assert.Equal(t, "hello johndoe", readBody(t, resp))
+		assert.Equal(t, 2, count)
+
+		// The response of the logged in user is not stored nor served to other users.
+		resp = server.TestMiddleware(middleware, newRequest(true, false), handler)
+		assert.Equal(t, "MISS", resp.Header.Get("X-Cache"))
+		assert.Equal(t, "hello johndoe", readBody(t, resp))
+		resp = server.TestMiddleware(middleware, newRequest(false, false), handler)
+		assert.Equal(t, "HIT", resp.Header.Get("X-Cache"))
+		assert.Equal(t, "hello anonymous", readBody(t, resp))
+		assert.Equal(t, 3, count)
+
+		// Explicitly public responses are shared.
+		resp = server.TestMiddleware(middleware, newRequest(false, true), handler)
+		assert.Equal(t, "hello anonymous", readBody(t, resp))
+		resp = server.TestMiddleware(middleware, newRequest(true, true), handler)
+		assert.Equal(t, "HIT", resp.Header.Get("X-Cache"))
+		assert.Equal(t, "hello anonymous", readBody(t, resp))
+		assert.Equal(t, 4, count)
+	})
+
+	t.Run("status_handler_body", func(t *testing.T) {
+		middleware := &Middleware{TTL: time.Minute}
+		count := 0
+		handler := newCountingHandler(&count, func(response *goyave.Response, _ *goyave.Request) {
+			response.Status(http.StatusNotFound)
+		})
+		resp := server.TestMiddleware(middleware, testutil.NewTestRequest(http.MethodGet, "/test", nil), handler)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		expected := readBody(t, resp)
+
+		resp = server.TestMiddleware(middleware, testutil.NewTestRequest(http.MethodGet, "/test", nil), handler)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Equal(t, "HIT", resp.Header.Get("X-Cache"))
+		assert.Equal(t, expected, readBody(t, resp))
+		assert.Equal(t, 1, count)
+	})
+
+	t.Run("no_content", func(t *testing.T) {
+		middleware := &Middleware{TTL: time.Minute}
+		handler := func(response *goyave.Response, _ *goyave.Request) {
+			response.Status(http.StatusNoContent)
+		}
+		resp := server.TestMiddleware(middleware, testutil.NewTestRequest(http.MethodGet, "/test", nil), handler)
+		_ = resp.Body.Close()
+		resp = server.TestMiddleware(middleware, testutil.NewTestRequest(http.MethodGet, "/test", nil), handler)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, "HIT", resp.Header.Get("X-Cache"))
+		assert.Empty(t, readBody(t, resp))
+	})
+
+	t.Run("vary", func(t *testing.T) {
+		middleware := &Middleware{TTL: time.Minute}
+		count := 0
+		handler := newCountingHandler(&count, func(response *goyave.Response, request *goyave.Request) {
+			response.Header().Add("Vary", "accept-language")
+			response.Header().Add("Vary", "Accept-Language, Origin")
+			response.String(http.StatusOK, "hello "+request.Header().Get("Accept-Language"))
+		})
+		newRequest := func(lang string) *goyave.Request {
+			request := testutil.NewTestRequest(http.MethodGet, "/test", nil)
+			request.Header().Set("Accept-Language", lang)
+			return request
+		}
+
+		resp := server.TestMiddleware(middleware, newRequest("en"), handler)
+		assert.Equal(t, "hello en", readBody(t, resp))
+		resp = server.TestMiddleware(middleware, newRequest("fr"), handler)
+		assert.Equal(t, "MISS", resp.Header.Get("X-Cache"))
+		assert.Equal(t, "hello fr", readBody(t, resp))
+		resp = server.TestMiddleware(middleware, newRequest("fr"), handler)
+		assert.Equal(t, "HIT", resp.Header.Get("X-Cache"))
+		assert.Equal(t, "hello fr", readBody(t, resp))
+		assert.Equal(t, 2, count)
+	})
+
+	t.Run("conditional", func(t *testing.T) {
+		middleware := &Middleware{TTL: time.Minute}
+		lastModified := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+		handler := func(response *goyave.Response, _ *goyave.Request) {
+			response.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+			response.String(http.StatusOK, "hello")
+		}
+		resp := server.TestMiddleware(middleware, testutil.NewTestRequest(http.MethodGet, "/test", nil), handler)
+		_ = resp.Body.Close()
+		resp = server.TestMiddleware(middleware, testutil.NewTestRequest(http.MethodGet, "/test", nil), handler)
+		etag := resp.Header.Get("ETag")
+		_ = resp.Body.Close()
+		require.NotEmpty(t, etag)
+
+		cases := []struct {
+			header http.Header
+			status int
+		}{
+			{header: http.Header{"If-None-Match": {etag}}, status: http.StatusNotModified},
+			{header: http.Header{"If-None-Match": {`"other", W/` + etag}}, status: http.StatusNotModified},
+			{header: http.Header{"If-None-Match": {"*"}}, status: http.StatusNotModified},
+			{header: http.Header{"If-None-Match": {`"other"`}}, status: http.StatusOK},
+			{header: http.Header{"If-None-Match": {`"other"`}, "If-Modified-Since": {lastModified.Format(http.TimeFormat)}}, status: http.StatusOK},
+			{header: http.Header{"If-Modified-Since": {lastModified.Format(http.TimeFormat)}}, status: http.StatusNotModified},
+			{header: http.Header{"If-Modified-Since": {lastModified.Add(-time.Hour).Format(http.TimeFormat)}}, status: http.StatusOK},
+			{header: http.Header{"If-Modified-Since": {"invalid"}}, status: http.StatusOK},
+		}
+		for i, c := range cases {
+			t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+				request := testutil.NewTestRequest(http.MethodGet, "/test", nil)
+				for k, v := range c.header {
+					request.Header()[k] = v
+				}
+				resp := server.TestMiddleware(middleware, request, handler)
+				assert.Equal(t, c.status, resp.StatusCode)
+				body := readBody(t, resp)
+				if c.status == http.StatusNotModified {
+					assert.Empty(t, body)
+					assert.Equal(t, etag, resp.Header.Get("ETag"))
+				} else {
+					assert.Equal(t, "hello", body)
+				}
+			})
+		}
+	})
+
+	t.Run("max_body_size", func(t *testing.T) {
+		store := NewMemoryStore(10)
+		middleware := &Middleware{Store: store, TTL: time.Minute, MaxBodySize: 8}
+		resp := server.TestMiddleware(middleware, testutil.NewTestRequest(http.MethodGet, "/test", nil), func(response *goyave.Response, _ *goyave.Request) {
+			response.String(http.StatusOK, "hello")
+			response.String(http.StatusOK, " world")
+		})
+		assert.Equal(t, "hello world", readBody(t, resp))
+		assert.Equal(t, 0, store.Len())
+	})
+
+	t.Run("tags", func(t *testing.T) {
+		store := NewMemoryStore(10)
+		middleware := &Middleware{Store: store, TTL: time.Minute}
+		resp := server.TestMiddleware(middleware, testutil.NewTestRequest(http.MethodGet, "/articles", nil), func(response *goyave.Response, request *goyave.Request) {
+			Tag(request, "articles")
+			Tag(request, "article:1", "article:2")
+			response.String(http.StatusOK, "articles")
+		})
+		_ = resp.Body.Close()
+		entry, err := store.Get(context.Background(), "GET http://example.com/articles")
+		require.NoError(t, err)
+		require.NotNil(t, entry)
+		assert.Equal(t, []string{"articles", "article:1", "article:2"}, entry.Tags)
+
+		resp = server.TestMiddleware(middleware, testutil.NewTestRequest(http.MethodPost, "/articles", nil), func(response *goyave.Response, request *goyave.Request) {
+			require.NoError(t, Invalidate(request, "article:2"))
+			response.Status(http.StatusCreated)
+		})
+		_ = resp.Body.Close()
+		assert.Equal(t, 0, store.Len())
+
+		request := testutil.NewTestRequest(http.MethodPost, "/articles", nil)
+		assert.Panics(t, func() {
+			_ = Invalidate(request, "article:2")
+		})
+	})
+
+	t.Run("store_error", func(t *testing.T) {
+		middleware := &Middleware{Store: testErrorStore{}, TTL: time.Minute}
+		resp := server.TestMiddleware(middleware, testutil.NewTestRequest(http.MethodGet, "/test", nil), func(response *goyave.Response, _ *goyave.Request) {
+			response.String(http.StatusOK, "hello")
+		})
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "hello", readBody(t, resp))
+	})
+}
+
+func TestParseCacheControl(t *testing.T) {
+	c := parseCacheControl([]string{`No-Cache, max-age="60"`, "private,, s-maxage=invalid"})
+	assert.Equal(t, cacheControl{"no-cache": "", "max-age": "60", "private": "", "s-maxage": "invalid"}, c)
+	d, ok := c.duration("max-age")
+	assert.True(t, ok)
+	assert.Equal(t, time.Minute, d)
+	_, ok = c.duration("s-maxage")
+	assert.False(t, ok)
+	_, ok = c.duration("min-fresh")
+	assert.False(t, ok)
+}
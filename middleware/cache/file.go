@@ -0,0 +1,162 @@
+package cache
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	errorutil "goyave.dev/goyave/v5/util/errors"
+	"goyave.dev/goyave/v5/util/fsutil"
+)
+
+const fileStoreExtension = ".json"
+
+// FileStoreFS the file system requirements of the `FileStore`.
+type FileStoreFS interface {
+	fsutil.FS
+	fsutil.WritableFS
+	fsutil.MkdirFS
+	fsutil.RemoveFS
+	fsutil.RenameFS
+}
+
+// FileStore a `Store` persisting the entries as JSON files in a directory.
+// The entries survive application restarts and can be shared between multiple
+// instances using a shared volume.
+//
+// Entries are written to a temporary file renamed over the entry's file, so readers
+// never see a partially written entry.
+//
+// Expired entries are removed when they are accessed or when tags are invalidated.
+// Tag invalidation reads all the entries of the directory.
+type FileStore struct {
+	FS  FileStoreFS
+	now func() time.Time
+	Dir string
+}
+
+// NewFileStore create a new `FileStore` using the given directory of the given file system.
+// The directory is created if it doesn't exist.
+func NewFileStore(fs FileStoreFS, dir string) (*FileStore, error) {
+	if err := fs.MkdirAll(dir, 0770); err != nil {
+		return nil, errorutil.New(err)
+	}
+	return &FileStore{
+		FS:  fs,
+		Dir: dir,
+		now: time.Now,
+	}, nil
+}
+
+// Get implementation of `Store`.
+func (s *FileStore) Get(_ context.Context, key string) (*Entry, error) {
+	name := s.fileName(key)
+	entry, err := s.read(name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, errorutil.New(err)
+	}
+	if entry == nil || entry.Key != key {
+		return nil, nil
+	}
+	if entry.Expired(s.now()) {
+		return nil, s.remove(name)
+	}
+	return entry, nil
+}
+
+// Set implementation of `Store`.
+func (s *FileStore) Set(_ context.Context, entry *Entry) error {
+	suffix := make([]byte, 8)
+	if _, err := rand.Read(suffix); err != nil {
+		return errorutil.New(err)
+	}
+	name := s.fileName(entry.Key)
+	tmpName := name + "." + hex.EncodeToString(suffix) + ".tmp"
+	file, err := s.FS.OpenFile(tmpName, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0660)
+	if err != nil {
+		return errorutil.New(err)
+	}
+	err = json.NewEncoder(file).Encode(entry)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = s.FS.Rename(tmpName, name)
+	}
+	if err != nil {
+		_ = s.FS.Remove(tmpName)
+		return errorutil.New(err)
+	}
+	return nil
+}
+
+// InvalidateTags implementation of `Store`.
+func (s *FileStore) InvalidateTags(_ context.Context, tags ...string) error {
+	dirEntries, err := s.FS.ReadDir(s.Dir)
+	if err != nil {
+		return errorutil.New(err)
+	}
+	now := s.now()
+	for _, dirEntry := range dirEntries {
+		if dirEntry.IsDir() || !strings.HasSuffix(dirEntry.Name(), fileStoreExtension) {
+			continue
+		}
+		name := path.Join(s.Dir, dirEntry.Name())
+		entry, err := s.read(name)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return errorutil.New(err)
+		}
+		if entry == nil || entry.Expired(now) || slices.ContainsFunc(entry.Tags, func(t string) bool { return slices.Contains(tags, t) }) {
+			if err := s.remove(name); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// read the entry in the given file. Returns `nil` and no error if the file is corrupted.
+func (s *FileStore) read(name string) (*Entry, error) {
+	file, err := s.FS.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+	entry := &Entry{}
+	if err := json.Unmarshal(data, entry); err != nil {
+		return nil, nil //nolint:nilerr
+	}
+	return entry, nil
+}
+
+func (s *FileStore) remove(name string) error {
+	if err := s.FS.Remove(name); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return errorutil.New(err)
+	}
+	return nil
+}
+
+func (s *FileStore) fileName(key string) string {
+	hash := sha256.Sum256([]byte(key))
+	return path.Join(s.Dir, hex.EncodeToString(hash[:])+fileStoreExtension)
+}
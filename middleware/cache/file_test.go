@@ -0,0 +1,146 @@
+package cache
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5/util/fsutil/osfs"
+)
+
+func TestFileStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	newStore := func(t *testing.T) *FileStore {
+		store, err := NewFileStore(osfs.New(t.TempDir()), "cache")
+		require.NoError(t, err)
+		store.now = func() time.Time { return now }
+		return store
+	}
+
+	t.Run("get_set", func(t *testing.T) {
+		store := newStore(t)
+
+		entry, err := store.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.Nil(t, entry)
+
+		e := &Entry{
+			Key:       "key",
+			Status:    200,
+			Header:    map[string][]string{"Content-Type": {"text/plain"}},
+			Body:      []byte("body"),
+			Tags:      []string{"tag"},
+			ETag:      `"etag"`,
+			StoredAt:  now,
+			ExpiresAt: now.Add(time.Minute),
+		}
+		require.NoError(t, store.Set(ctx, e))
+		entry, err = store.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.Equal(t, e, entry)
+
+		e.Body = []byte("new body")
+		require.NoError(t, store.Set(ctx, e))
+		entry, err = store.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.Equal(t, []byte("new body"), entry.Body)
+	})
+
+	t.Run("atomic_write", func(t *testing.T) {
+		store := newStore(t)
+		require.NoError(t, store.Set(ctx, &Entry{Key: "key", Body: []byte("a"), ExpiresAt: now.Add(time.Minute)}))
+
+		wg := sync.WaitGroup{}
+		for i := range 20 {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				assert.NoError(t, store.Set(ctx, &Entry{Key: "key", Body: bytes.Repeat([]byte("a"), i*1024), ExpiresAt: now.Add(time.Minute)}))
+			}()
+			go func() {
+				defer wg.Done()
+				entry, err := store.Get(ctx, "key")
+				assert.NoError(t, err)
+				assert.NotNil(t, entry)
+			}()
+		}
+		wg.Wait()
+
+		// No temporary file left
+		dirEntries, err := store.FS.ReadDir("cache")
+		require.NoError(t, err)
+		require.Len(t, dirEntries, 1)
+		assert.Equal(t, path.Base(store.fileName("key")), dirEntries[0].Name())
+	})
+
+	t.Run("set_error", func(t *testing.T) {
+		store := newStore(t)
+		require.NoError(t, store.FS.RemoveAll("cache"))
+		require.Error(t, store.Set(ctx, &Entry{Key: "key"}))
+
+		// The temporary file is removed if it cannot be renamed
+		require.NoError(t, store.FS.MkdirAll(store.fileName("key"), 0770))
+		require.Error(t, store.Set(ctx, &Entry{Key: "key"}))
+		dirEntries, err := store.FS.ReadDir("cache")
+		require.NoError(t, err)
+		assert.Len(t, dirEntries, 1)
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		store := newStore(t)
+		require.NoError(t, store.Set(ctx, &Entry{Key: "key", ExpiresAt: now}))
+		entry, err := store.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.Nil(t, entry)
+		dirEntries, err := store.FS.ReadDir("cache")
+		require.NoError(t, err)
+		assert.Empty(t, dirEntries)
+	})
+
+	t.Run("corrupted", func(t *testing.T) {
+		store := newStore(t)
+		file, err := store.FS.OpenFile(store.fileName("key"), os.O_WRONLY|os.O_CREATE, 0660)
+		require.NoError(t, err)
+		_, err = file.Write([]byte("{invalid"))
+		require.NoError(t, err)
+		require.NoError(t, file.Close())
+
+		entry, err := store.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.Nil(t, entry)
+
+		require.NoError(t, store.InvalidateTags(ctx, "tag"))
+		_, err = store.FS.Stat(store.fileName("key"))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+
+	t.Run("invalidate_tags", func(t *testing.T) {
+		store := newStore(t)
+		require.NoError(t, store.Set(ctx, &Entry{Key: "a", Tags: []string{"articles", "article:1"}, ExpiresAt: now.Add(time.Minute)}))
+		require.NoError(t, store.Set(ctx, &Entry{Key: "b", Tags: []string{"articles", "article:2"}, ExpiresAt: now.Add(time.Minute)}))
+		require.NoError(t, store.Set(ctx, &Entry{Key: "c", Tags: []string{"users"}, ExpiresAt: now.Add(time.Minute)}))
+		require.NoError(t, store.Set(ctx, &Entry{Key: "expired", ExpiresAt: now}))
+		require.NoError(t, store.FS.MkdirAll(path.Join("cache", "subdir"), 0770))
+
+		require.NoError(t, store.InvalidateTags(ctx, "article:1"))
+		dirEntries, err := store.FS.ReadDir("cache")
+		require.NoError(t, err)
+		assert.Len(t, dirEntries, 3) // b, c and subdir
+
+		require.NoError(t, store.InvalidateTags(ctx, "articles"))
+		entry, err := store.Get(ctx, "c")
+		require.NoError(t, err)
+		assert.NotNil(t, entry)
+		entry, err = store.Get(ctx, "b")
+		require.NoError(t, err)
+		assert.Nil(t, entry)
+	})
+}
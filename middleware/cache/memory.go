@@ -0,0 +1,106 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore a `Store` keeping the entries in memory. When the capacity is reached,
+// the least recently used entry is evicted.
+type MemoryStore struct {
+	ll       *list.List
+	entries  map[string]*list.Element
+	tags     map[string]map[string]struct{}
+	now      func() time.Time
+	capacity int
+	mu       sync.Mutex
+}
+
+// NewMemoryStore create a new empty `MemoryStore` holding at most `capacity` entries.
+// If `capacity` is lower than or equal to zero, the amount of entries is not limited.
+func NewMemoryStore(capacity int) *MemoryStore {
+	return &MemoryStore{
+		ll:       list.New(),
+		entries:  map[string]*list.Element{},
+		tags:     map[string]map[string]struct{}{},
+		now:      time.Now,
+		capacity: capacity,
+	}
+}
+
+// Get implementation of `Store`.
+func (s *MemoryStore) Get(_ context.Context, key string) (*Entry, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	elem, ok := s.entries[key]
+	if !ok {
+		return nil, nil
+	}
+	entry := elem.Value.(*Entry)
+	if entry.Expired(s.now()) {
+		s.remove(elem)
+		return nil, nil
+	}
+	s.ll.MoveToFront(elem)
+	return entry, nil
+}
+
+// Set implementation of `Store`.
+func (s *MemoryStore) Set(_ context.Context, entry *Entry) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if elem, ok := s.entries[entry.Key]; ok {
+		s.remove(elem)
+	}
+	s.entries[entry.Key] = s.ll.PushFront(entry)
+	for _, tag := range entry.Tags {
+		keys, ok := s.tags[tag]
+		if !ok {
+			keys = map[string]struct{}{}
+			s.tags[tag] = keys
+		}
+		keys[entry.Key] = struct{}{}
+	}
+	if s.capacity > 0 {
+		for s.ll.Len() > s.capacity {
+			s.remove(s.ll.Back())
+		}
+	}
+	return nil
+}
+
+// InvalidateTags implementation of `Store`.
+func (s *MemoryStore) InvalidateTags(_ context.Context, tags ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, tag := range tags {
+		for key := range s.tags[tag] {
+			if elem, ok := s.entries[key]; ok {
+				s.remove(elem)
+			}
+		}
+	}
+	return nil
+}
+
+// Len returns the number of entries in the store, including the expired ones
+// that were not removed yet.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.ll.Len()
+}
+
+func (s *MemoryStore) remove(elem *list.Element) {
+	entry := s.ll.Remove(elem).(*Entry)
+	delete(s.entries, entry.Key)
+	for _, tag := range entry.Tags {
+		keys := s.tags[tag]
+		delete(keys, entry.Key)
+		if len(keys) == 0 {
+			delete(s.tags, tag)
+		}
+	}
+}
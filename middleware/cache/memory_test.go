@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	t.Run("get_set", func(t *testing.T) {
+		store := NewMemoryStore(10)
+		store.now = func() time.Time { return now }
+
+		entry, err := store.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.Nil(t, entry)
+
+		e := &Entry{Key: "key", Status: 200, Body: []byte("body"), ExpiresAt: now.Add(time.Minute)}
+		require.NoError(t, store.Set(ctx, e))
+		entry, err = store.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.Same(t, e, entry)
+
+		// Replace
+		e2 := &Entry{Key: "key", Status: 404, ExpiresAt: now.Add(time.Minute)}
+		require.NoError(t, store.Set(ctx, e2))
+		entry, err = store.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.Same(t, e2, entry)
+		assert.Equal(t, 1, store.Len())
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		store := NewMemoryStore(10)
+		store.now = func() time.Time { return now }
+		require.NoError(t, store.Set(ctx, &Entry{Key: "key", Tags: []string{"tag"}, ExpiresAt: now}))
+		entry, err := store.Get(ctx, "key")
+		require.NoError(t, err)
+		assert.Nil(t, entry)
+		assert.Equal(t, 0, store.Len())
+		assert.Empty(t, store.tags)
+	})
+
+	t.Run("lru_eviction", func(t *testing.T) {
+		store := NewMemoryStore(2)
+		store.now = func() time.Time { return now }
+		for i := 0; i < 2; i++ {
+			require.NoError(t, store.Set(ctx, &Entry{Key: fmt.Sprintf("key%d", i), ExpiresAt: now.Add(time.Minute)}))
+		}
+		// Access key0 so key1 becomes the least recently used
+		_, err := store.Get(ctx, "key0")
+		require.NoError(t, err)
+		require.NoError(t, store.Set(ctx, &Entry{Key: "key2", ExpiresAt: now.Add(time.Minute)}))
+
+		assert.Equal(t, 2, store.Len())
+		entry, _ := store.Get(ctx, "key1")
+		assert.Nil(t, entry)
+		entry, _ = store.Get(ctx, "key0")
+		assert.NotNil(t, entry)
+		entry, _ = store.Get(ctx, "key2")
+		assert.NotNil(t, entry)
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		store := NewMemoryStore(0)
+		for i := 0; i < 100; i++ {
+			require.NoError(t, store.Set(ctx, &Entry{Key: fmt.Sprintf("key%d", i), ExpiresAt: time.Now().Add(time.Minute)}))
+		}
+		assert.Equal(t, 100, store.Len())
+	})
+
+	t.Run("invalidate_tags", func(t *testing.T) {
+		store := NewMemoryStore(10)
+		store.now = func() time.Time { return now }
+		require.NoError(t, store.Set(ctx, &Entry{Key: "a", Tags: []string{"articles", "article:1"}, ExpiresAt: now.Add(time.Minute)}))
+		require.NoError(t, store.Set(ctx, &Entry{Key: "b", Tags: []string{"articles", "article:2"}, ExpiresAt: now.Add(time.Minute)}))
+		require.NoError(t, store.Set(ctx, &Entry{Key: "c", Tags: []string{"users"}, ExpiresAt: now.Add(time.Minute)}))
+
+		require.NoError(t, store.InvalidateTags(ctx, "article:1"))
+		assert.Equal(t, 2, store.Len())
+		require.NoError(t, store.InvalidateTags(ctx, "articles", "unknown"))
+		assert.Equal(t, 1, store.Len())
+		entry, _ := store.Get(ctx, "c")
+		assert.NotNil(t, entry)
+		assert.Equal(t, map[string]map[string]struct{}{"users": {"c": {}}}, store.tags)
+	})
+}
@@ -0,0 +1,42 @@
+package cache
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Entry a cached response.
+//
+// If `Vary` is not empty and `Status` is zero, the entry doesn't contain a response
+// but indicates which request headers must be used to find the variant of the response
+// matching the request.
+type Entry struct {
+	StoredAt  time.Time   `json:"storedAt"`
+	ExpiresAt time.Time   `json:"expiresAt"`
+	Header    http.Header `json:"header,omitempty"`
+	Key       string      `json:"key"`
+	ETag      string      `json:"etag,omitempty"`
+	Body      []byte      `json:"body,omitempty"`
+	Tags      []string    `json:"tags,omitempty"`
+	Vary      []string    `json:"vary,omitempty"`
+	Status    int         `json:"status"`
+}
+
+// Expired returns true if the entry is expired at the given time.
+func (e *Entry) Expired(now time.Time) bool {
+	return !now.Before(e.ExpiresAt)
+}
+
+// Store persists cached responses.
+//
+// `Get` returns `nil` and no error if the key doesn't exist or if the entry is expired.
+// `Set` replaces the entry identified by `entry.Key`. The entry expires at `entry.ExpiresAt`.
+// `InvalidateTags` removes all entries having at least one of the given tags.
+//
+// Implementations must be safe for concurrent use.
+type Store interface {
+	Get(ctx context.Context, key string) (*Entry, error)
+	Set(ctx context.Context, entry *Entry) error
+	InvalidateTags(ctx context.Context, tags ...string) error
+}
@@ -0,0 +1,297 @@
+package csrf
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/util/errors"
+)
+
+const (
+	// MetaExempt the route meta key used to exempt a route or a router from the CSRF
+	// protection. The meta value is expected to be `true`.
+	//
+	//	router.Post("/webhooks/stripe", handler).SetMeta(csrf.MetaExempt, true)
+	MetaExempt = "goyave.csrf-exempt"
+
+	// DefaultCookieName the default name of the cookie containing the token in the
+	// double-submit cookie pattern.
+	DefaultCookieName = "csrf_token"
+
+	// DefaultHeaderName the default name of the request header containing the token.
+	DefaultHeaderName = "X-CSRF-Token"
+
+	// DefaultFieldName the default name of the request body field containing the token.
+	DefaultFieldName = "_csrf"
+
+	// DefaultTTL the default lifetime of a token.
+	DefaultTTL = 12 * time.Hour
+)
+
+// ExtraToken the key used in `request.Extra` to store the CSRF token of the current
+// client. Use `csrf.Token()` to retrieve it.
+type ExtraToken struct{}
+
+// safeMethods the methods that are not protected because they should not have side effects.
+var safeMethods = []string{http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace}
+
+// Token returns the CSRF token of the client of the given request. The token can be
+// rendered in templates (hidden form field) or returned in a JSON response so the client
+// can send it back in the header or body of the next unsafe requests.
+// Returns an empty string if the CSRF middleware is not applied to the route.
+func Token(request *goyave.Request) string {
+	token, _ := request.Extra[ExtraToken{}].(string)
+	return token
+}
+
+// Middleware protecting routes against Cross-Site Request Forgery. This protection
+// is only relevant for routes authenticated with cookies (such as sessions) and
+// used by browsers.
+//
+// Requests using unsafe methods (any method except GET, HEAD, OPTIONS and TRACE) are
+// rejected with "403 Forbidden" and a localized error message if:
+//   - the `Origin` header (or the `Referer` header if `Origin` is missing) doesn't match
+//     the requested host or one of the `TrustedOrigins`. Requests having none of these
+//     headers are not rejected by this check and only rely on the token.
+//   - the token sent in the request header (`HeaderName`) or the request body (`FieldName`)
+//     doesn't match the expected token.
+//
+// Two patterns are supported to validate the token:
+//   - Double-submit cookie (default): the token is stored in a cookie. The client must read
+//     the cookie and send its value back in the header or the body.
+//   - Synchronizer token: if a `Store` is defined, the token is kept server-side and associated
+//     with the client's session, identified by `SessionID`. No cookie is set.
+//
+// A token is generated for the client if it doesn't have one yet, on every route using
+// this middleware. It is available to handlers with `csrf.Token()`.
+//
+// Routes can be exempted from the protection with the `MetaExempt` route meta.
+//
+// For the body field to be read, the request must be parsed before this middleware is
+// executed (see the `parse` middleware).
+//
+// **Example:**
+//
+//	router.GlobalMiddleware(&csrf.Middleware{
+//		TrustedOrigins: []string{"https://app.example.com"},
+//	})
+type Middleware struct {
+	goyave.Component
+
+	// Store if not nil, the synchronizer token pattern is used and the tokens are persisted
+	// in this store. Otherwise, the double-submit cookie pattern is used.
+	Store Store
+
+	// SessionID returns the identifier of the session of the client of the given request.
+	// Required if `Store` is not nil. If the returned value is empty, no token is generated
+	// and unsafe requests are rejected.
+	SessionID func(request *goyave.Request) string
+
+	// TTL the lifetime of the tokens. Defaults to `DefaultTTL`.
+	TTL time.Duration
+
+	// CookieName the name of the cookie containing the token in the double-submit
+	// cookie pattern. Defaults to `DefaultCookieName`. Consider using the "__Host-" prefix
+	// so the cookie cannot be set by subdomains (requires HTTPS).
+	CookieName string
+
+	// CookiePath the path of the cookie. Defaults to "/".
+	CookiePath string
+
+	// CookieDomain the domain of the cookie. Defaults to the requested host.
+	CookieDomain string
+
+	// CookieSameSite the SameSite attribute of the cookie. Defaults to `http.SameSiteLaxMode`.
+	// The cookie is "Secure" if the request uses HTTPS. The cookie is not "HttpOnly" so it
+	// can be read by the client.
+	CookieSameSite http.SameSite
+
+	// HeaderName the name of the request header containing the token.
+	// Defaults to `DefaultHeaderName`.
+	HeaderName string
+
+	// FieldName the name of the request body field containing the token.
+	// Defaults to `DefaultFieldName`.
+	FieldName string
+
+	// TrustedOrigins the origins other than the requested host allowed to send
+	// unsafe requests (e.g. "https://app.example.com").
+	TrustedOrigins []string
+}
+
+// Handle implementation of `goyave.Middleware`.
+func (m *Middleware) Handle(next goyave.Handler) goyave.Handler {
+	if m.Store != nil && m.SessionID == nil {
+		panic(errors.New(fmt.Errorf("csrf: SessionID is required when using a Store")))
+	}
+	return func(response *goyave.Response, request *goyave.Request) {
+		if request.Route != nil {
+			if exempt, ok := request.Route.LookupMeta(MetaExempt); ok && exempt == true {
+				next(response, request)
+				return
+			}
+		}
+
+		expected, err := m.loadToken(request)
+		if err != nil {
+			response.Error(err)
+			return
+		}
+		token := expected
+		if token == "" {
+			token, err = m.newToken(response, request)
+			if err != nil {
+				response.Error(err)
+				return
+			}
+		}
+		if token != "" {
+			request.Extra[ExtraToken{}] = token
+		}
+
+		if slices.Contains(safeMethods, request.Method()) {
+			next(response, request)
+			return
+		}
+
+		if !m.checkOrigin(request) {
+			m.forbidden(response, request, "csrf.invalid-origin")
+			return
+		}
+		submitted := m.submittedToken(request)
+		if expected == "" || submitted == "" || subtle.ConstantTimeCompare([]byte(expected), []byte(submitted)) != 1 {
+			m.forbidden(response, request, "csrf.invalid-token")
+			return
+		}
+
+		next(response, request)
+	}
+}
+
+func (m *Middleware) forbidden(response *goyave.Response, request *goyave.Request, message string) {
+	response.JSON(http.StatusForbidden, map[string]string{"error": request.Lang.Get(message)})
+}
+
+// loadToken returns the current token of the client, or an empty string if it doesn't have one.
+func (m *Middleware) loadToken(request *goyave.Request) (string, error) {
+	if m.Store != nil {
+		sessionID := m.SessionID(request)
+		if sessionID == "" {
+			return "", nil
+		}
+		token, err := m.Store.Get(request.Context(), sessionID)
+		return token, errors.New(err)
+	}
+	for _, c := range request.Cookies() {
+		if c.Name == m.getCookieName() && c.Value != "" {
+			return c.Value, nil
+		}
+	}
+	return "", nil
+}
+
+// newToken generates a new token and saves it in the store or in a cookie.
+func (m *Middleware) newToken(response *goyave.Response, request *goyave.Request) (string, error) {
+	var sessionID string
+	if m.Store != nil {
+		sessionID = m.SessionID(request)
+		if sessionID == "" {
+			return "", nil
+		}
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.New(err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(b)
+
+	if m.Store != nil {
+		return token, errors.New(m.Store.Set(request.Context(), sessionID, token, m.getTTL()))
+	}
+
+	path := m.CookiePath
+	if path == "" {
+		path = "/"
+	}
+	sameSite := m.CookieSameSite
+	if sameSite == 0 {
+		sameSite = http.SameSiteLaxMode
+	}
+	response.Cookie(&http.Cookie{
+		Name:     m.getCookieName(),
+		Value:    token,
+		Path:     path,
+		Domain:   m.CookieDomain,
+		MaxAge:   int(m.getTTL().Seconds()),
+		Secure:   request.Scheme() == "https",
+		SameSite: sameSite,
+	})
+	return token, nil
+}
+
+// submittedToken returns the token sent by the client in the request header or body.
+func (m *Middleware) submittedToken(request *goyave.Request) string {
+	headerName := m.HeaderName
+	if headerName == "" {
+		headerName = DefaultHeaderName
+	}
+	if token := request.Header().Get(headerName); token != "" {
+		return token
+	}
+	fieldName := m.FieldName
+	if fieldName == "" {
+		fieldName = DefaultFieldName
+	}
+	if data, ok := request.Data.(map[string]any); ok {
+		token, _ := data[fieldName].(string)
+		return token
+	}
+	return ""
+}
+
+// checkOrigin returns false if the `Origin` or `Referer` headers don't match
+// the requested host or one of the trusted origins.
+func (m *Middleware) checkOrigin(request *goyave.Request) bool {
+	origin := request.Header().Get("Origin")
+	if origin == "" {
+		referer := request.Referrer()
+		if referer == "" {
+			return true
+		}
+		u, err := url.Parse(referer)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return false
+		}
+		origin = u.Scheme + "://" + u.Host
+	}
+
+	if strings.EqualFold(origin, request.Scheme()+"://"+request.Host()) {
+		return true
+	}
+	return slices.ContainsFunc(m.TrustedOrigins, func(o string) bool {
+		return strings.EqualFold(origin, o)
+	})
+}
+
+func (m *Middleware) getCookieName() string {
+	if m.CookieName == "" {
+		return DefaultCookieName
+	}
+	return m.CookieName
+}
+
+func (m *Middleware) getTTL() time.Duration {
+	if m.TTL <= 0 {
+		return DefaultTTL
+	}
+	return m.TTL
+}
@@ -0,0 +1,303 @@
+package csrf
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/config"
+	"goyave.dev/goyave/v5/util/testutil"
+)
+
+type testErrorStore struct{}
+
+func (testErrorStore) Get(_ context.Context, _ string) (string, error) {
+	return "", fmt.Errorf("get error")
+}
+
+func (testErrorStore) Set(_ context.Context, _, _ string, _ time.Duration) error {
+	return fmt.Errorf("set error")
+}
+
+func TestMiddleware(t *testing.T) {
+	server := testutil.NewTestServerWithOptions(t, goyave.Options{Config: config.LoadDefault()})
+	router := goyave.NewRouter(server.Server)
+	route := router.Route([]string{http.MethodGet, http.MethodPost}, "/test", nil)
+	exemptRoute := router.Post("/exempt", nil).SetMeta(MetaExempt, true)
+
+	lang := server.Lang.GetDefault()
+	newRequest := func(method string) *goyave.Request {
+		request := testutil.NewTestRequest(method, "/test", nil)
+		request.Route = route
+		request.Lang = lang
+		return request
+	}
+	handler := func(response *goyave.Response, _ *goyave.Request) {
+		response.Status(http.StatusNoContent)
+	}
+	findCookie := func(resp *http.Response) *http.Cookie {
+		for _, c := range resp.Cookies() {
+			if c.Name == DefaultCookieName {
+				return c
+			}
+		}
+		return nil
+	}
+	readError := func(t *testing.T, resp *http.Response) string {
+		body, err := testutil.ReadJSONBody[map[string]string](resp.Body)
+		require.NoError(t, err)
+		return body["error"]
+	}
+
+	t.Run("double_submit", func(t *testing.T) {
+		middleware := &Middleware{}
+		var token string
+		resp := server.TestMiddleware(middleware, newRequest(http.MethodGet), func(response *goyave.Response, request *goyave.Request) {
+			token = Token(request)
+			response.Status(http.StatusNoContent)
+		})
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		require.NotEmpty(t, token)
+		cookie := findCookie(resp)
+		require.NotNil(t, cookie)
+		assert.Equal(t, token, cookie.Value)
+		assert.Equal(t, "/", cookie.Path)
+		assert.Equal(t, http.SameSiteLaxMode, cookie.SameSite)
+		assert.Equal(t, int(DefaultTTL.Seconds()), cookie.MaxAge)
+		assert.False(t, cookie.Secure)
+		assert.False(t, cookie.HttpOnly)
+
+		// Existing cookie: token reused, no new cookie
+		request := newRequest(http.MethodGet)
+		request.Request().AddCookie(&http.Cookie{Name: DefaultCookieName, Value: token})
+		resp = server.TestMiddleware(middleware, request, func(response *goyave.Response, request *goyave.Request) {
+			assert.Equal(t, token, Token(request))
+			response.Status(http.StatusNoContent)
+		})
+		_ = resp.Body.Close()
+		assert.Nil(t, findCookie(resp))
+
+		// Valid header
+		request = newRequest(http.MethodPost)
+		request.Request().AddCookie(&http.Cookie{Name: DefaultCookieName, Value: token})
+		request.Header().Set(DefaultHeaderName, token)
+		resp = server.TestMiddleware(middleware, request, handler)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		// Valid body field
+		request = newRequest(http.MethodPost)
+		request.Request().AddCookie(&http.Cookie{Name: DefaultCookieName, Value: token})
+		request.Data = map[string]any{DefaultFieldName: token}
+		resp = server.TestMiddleware(middleware, request, handler)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		// Invalid token
+		request = newRequest(http.MethodPost)
+		request.Request().AddCookie(&http.Cookie{Name: DefaultCookieName, Value: token})
+		request.Header().Set(DefaultHeaderName, "invalid")
+		resp = server.TestMiddleware(middleware, request, handler)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Equal(t, lang.Get("csrf.invalid-token"), readError(t, resp))
+
+		// Missing token
+		request = newRequest(http.MethodPost)
+		request.Request().AddCookie(&http.Cookie{Name: DefaultCookieName, Value: token})
+		resp = server.TestMiddleware(middleware, request, handler)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Equal(t, lang.Get("csrf.invalid-token"), readError(t, resp))
+
+		// Missing cookie: new token issued but request rejected
+		request = newRequest(http.MethodPost)
+		request.Header().Set(DefaultHeaderName, token)
+		resp = server.TestMiddleware(middleware, request, handler)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		assert.Equal(t, lang.Get("csrf.invalid-token"), readError(t, resp))
+		assert.NotNil(t, findCookie(resp))
+	})
+
+	t.Run("custom_cookie", func(t *testing.T) {
+		middleware := &Middleware{
+			CookieName:     "__Host-csrf",
+			CookiePath:     "/app",
+			CookieDomain:   "example.com",
+			CookieSameSite: http.SameSiteStrictMode,
+			TTL:            time.Hour,
+			HeaderName:     "X-Token",
+			FieldName:      "token",
+		}
+		request := newRequest(http.MethodGet)
+		request.Request().TLS = &tls.ConnectionState{}
+		resp := server.TestMiddleware(middleware, request, handler)
+		_ = resp.Body.Close()
+		require.Len(t, resp.Cookies(), 1)
+		cookie := resp.Cookies()[0]
+		assert.Equal(t, "__Host-csrf", cookie.Name)
+		assert.Equal(t, "/app", cookie.Path)
+		assert.Equal(t, "example.com", cookie.Domain)
+		assert.Equal(t, http.SameSiteStrictMode, cookie.SameSite)
+		assert.Equal(t, 3600, cookie.MaxAge)
+		assert.True(t, cookie.Secure)
+
+		request = newRequest(http.MethodPost)
+		request.Request().AddCookie(&http.Cookie{Name: "__Host-csrf", Value: cookie.Value})
+		request.Header().Set("X-Token", cookie.Value)
+		resp = server.TestMiddleware(middleware, request, handler)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		request = newRequest(http.MethodPost)
+		request.Request().AddCookie(&http.Cookie{Name: "__Host-csrf", Value: cookie.Value})
+		request.Data = map[string]any{"token": cookie.Value}
+		resp = server.TestMiddleware(middleware, request, handler)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	})
+
+	t.Run("synchronizer", func(t *testing.T) {
+		store := NewMemoryStore()
+		middleware := &Middleware{
+			Store: store,
+			SessionID: func(request *goyave.Request) string {
+				return request.Header().Get("X-Session")
+			},
+		}
+		var token string
+		request := newRequest(http.MethodGet)
+		request.Header().Set("X-Session", "session")
+		resp := server.TestMiddleware(middleware, request, func(response *goyave.Response, request *goyave.Request) {
+			token = Token(request)
+			response.Status(http.StatusNoContent)
+		})
+		_ = resp.Body.Close()
+		require.NotEmpty(t, token)
+		assert.Empty(t, resp.Cookies())
+		stored, err := store.Get(context.Background(), "session")
+		require.NoError(t, err)
+		assert.Equal(t, token, stored)
+
+		request = newRequest(http.MethodPost)
+		request.Header().Set("X-Session", "session")
+		request.Header().Set(DefaultHeaderName, token)
+		resp = server.TestMiddleware(middleware, request, handler)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		// Other session
+		request = newRequest(http.MethodPost)
+		request.Header().Set("X-Session", "other")
+		request.Header().Set(DefaultHeaderName, token)
+		resp = server.TestMiddleware(middleware, request, handler)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		// No session
+		request = newRequest(http.MethodGet)
+		resp = server.TestMiddleware(middleware, request, func(response *goyave.Response, request *goyave.Request) {
+			assert.Empty(t, Token(request))
+			response.Status(http.StatusNoContent)
+		})
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+
+		request = newRequest(http.MethodPost)
+		request.Header().Set(DefaultHeaderName, token)
+		resp = server.TestMiddleware(middleware, request, handler)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+
+		assert.Panics(t, func() {
+			(&Middleware{Store: store}).Handle(handler)
+		})
+	})
+
+	t.Run("store_error", func(t *testing.T) {
+		middleware := &Middleware{
+			Store:     testErrorStore{},
+			SessionID: func(_ *goyave.Request) string { return "session" },
+		}
+		resp := server.TestMiddleware(middleware, newRequest(http.MethodGet), handler)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+	})
+
+	t.Run("origin", func(t *testing.T) {
+		middleware := &Middleware{TrustedOrigins: []string{"https://app.example.com"}}
+		cases := []struct {
+			origin  string
+			referer string
+			allowed bool
+		}{
+			{allowed: true},
+			{origin: "http://example.com", allowed: true},
+			{origin: "HTTP://EXAMPLE.COM", allowed: true},
+			{origin: "https://app.example.com", allowed: true},
+			{origin: "https://example.com", allowed: false},
+			{origin: "http://evil.com", allowed: false},
+			{origin: "null", allowed: false},
+			{referer: "http://example.com/page", allowed: true},
+			{referer: "https://app.example.com/page?a=b", allowed: true},
+			{referer: "http://evil.com/page", allowed: false},
+			{referer: "/relative", allowed: false},
+			{origin: "http://evil.com", referer: "http://example.com/page", allowed: false},
+		}
+		for i, c := range cases {
+			t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+				request := newRequest(http.MethodPost)
+				request.Request().AddCookie(&http.Cookie{Name: DefaultCookieName, Value: "token"})
+				request.Header().Set(DefaultHeaderName, "token")
+				if c.origin != "" {
+					request.Header().Set("Origin", c.origin)
+				}
+				if c.referer != "" {
+					request.Header().Set("Referer", c.referer)
+				}
+				resp := server.TestMiddleware(middleware, request, handler)
+				if c.allowed {
+					assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+					_ = resp.Body.Close()
+				} else {
+					assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+					assert.Equal(t, lang.Get("csrf.invalid-origin"), readError(t, resp))
+				}
+			})
+		}
+	})
+
+	t.Run("exempt", func(t *testing.T) {
+		middleware := &Middleware{}
+		request := testutil.NewTestRequest(http.MethodPost, "/exempt", nil)
+		request.Route = exemptRoute
+		resp := server.TestMiddleware(middleware, request, func(response *goyave.Response, request *goyave.Request) {
+			assert.Empty(t, Token(request))
+			response.Status(http.StatusNoContent)
+		})
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Empty(t, resp.Cookies())
+	})
+
+	t.Run("no_route", func(t *testing.T) {
+		middleware := &Middleware{}
+		request := newRequest(http.MethodPost)
+		request.Route = nil
+		resp := server.TestMiddleware(middleware, request, handler)
+		assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+		_ = resp.Body.Close()
+
+		request = newRequest(http.MethodGet)
+		request.Route = nil
+		resp = server.TestMiddleware(middleware, request, handler)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.NotNil(t, findCookie(resp))
+		_ = resp.Body.Close()
+	})
+}
@@ -0,0 +1,89 @@
+package csrf
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store persists the tokens used by the synchronizer token pattern.
+// Each token is associated with a session identifier.
+//
+// `Get` returns an empty string if there is no token for the given session
+// or if it has expired.
+//
+// Implementations must be safe for concurrent use.
+type Store interface {
+	Get(ctx context.Context, sessionID string) (string, error)
+	Set(ctx context.Context, sessionID, token string, ttl time.Duration) error
+}
+
+type memoryToken struct {
+	expiresAt time.Time
+	token     string
+}
+
+// MemoryStore a `Store` keeping the tokens in memory. The tokens are not shared
+// between multiple instances of the application.
+//
+// Expired tokens are periodically removed when a token is set.
+type MemoryStore struct {
+	tokens    map[string]memoryToken
+	nextSweep time.Time
+	now       func() time.Time
+	mu        sync.Mutex
+
+	// SweepInterval the minimum duration between two removals of the expired tokens.
+	// Defaults to one minute.
+	SweepInterval time.Duration
+}
+
+// NewMemoryStore create a new empty `MemoryStore`.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		tokens:        map[string]memoryToken{},
+		now:           time.Now,
+		SweepInterval: time.Minute,
+	}
+}
+
+// Get implementation of `Store`.
+func (s *MemoryStore) Get(_ context.Context, sessionID string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tokens[sessionID]
+	if !ok || !s.now().Before(t.expiresAt) {
+		return "", nil
+	}
+	return t.token, nil
+}
+
+// Set implementation of `Store`.
+func (s *MemoryStore) Set(_ context.Context, sessionID, token string, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.sweep(now)
+	s.tokens[sessionID] = memoryToken{token: token, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+// Len returns the number of tokens in the store, including the expired ones
+// that were not removed yet.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.tokens)
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	for k, t := range s.tokens {
+		if !now.Before(t.expiresAt) {
+			delete(s.tokens, k)
+		}
+	}
+	s.nextSweep = now.Add(s.SweepInterval)
+}
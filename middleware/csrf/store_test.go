@@ -0,0 +1,34 @@
+package csrf
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	token, err := store.Get(ctx, "session")
+	require.NoError(t, err)
+	assert.Empty(t, token)
+
+	require.NoError(t, store.Set(ctx, "session", "token", time.Minute))
+	token, err = store.Get(ctx, "session")
+	require.NoError(t, err)
+	assert.Equal(t, "token", token)
+
+	now = now.Add(time.Minute)
+	token, err = store.Get(ctx, "session")
+	require.NoError(t, err)
+	assert.Empty(t, token)
+
+	require.NoError(t, store.Set(ctx, "other", "token", time.Minute))
+	assert.Equal(t, 1, store.Len()) // "session" removed
+}
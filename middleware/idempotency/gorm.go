@@ -0,0 +1,134 @@
+package idempotency
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"goyave.dev/goyave/v5/util/errors"
+)
+
+// GORMRecord the model used by the `GORMStore` to persist the idempotency records.
+// The table must be created by the application, for example using auto-migration:
+//
+//	db.AutoMigrate(&idempotency.GORMRecord{})
+type GORMRecord struct {
+	ExpiresAt   time.Time `gorm:"index"`
+	Key         string    `gorm:"primaryKey;size:64"`
+	Fingerprint string    `gorm:"size:255"`
+	Header      string
+	Body        []byte
+	Status      int
+}
+
+// TableName returns the name of the table used by the `GORMStore`.
+func (GORMRecord) TableName() string {
+	return "idempotency_keys"
+}
+
+// GORMStore a `Store` persisting the records in a database using GORM, allowing
+// multiple instances of the application to share the same idempotency keys.
+//
+// Reservations are executed in a transaction, locking the row (`SELECT ... FOR UPDATE`)
+// if the database supports it.
+//
+// Expired rows are ignored but not removed automatically. Call `Cleanup()` periodically
+// to remove them.
+type GORMStore struct {
+	DB  *gorm.DB
+	now func() time.Time
+}
+
+// NewGORMStore create a new `GORMStore` using the given database connection.
+func NewGORMStore(db *gorm.DB) *GORMStore {
+	return &GORMStore{
+		DB:  db,
+		now: time.Now,
+	}
+}
+
+// Reserve implementation of `Store`.
+func (s *GORMStore) Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, error) {
+	var existing *Record
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := s.now()
+		row := &GORMRecord{
+			Key:         key,
+			Fingerprint: fingerprint,
+			ExpiresAt:   now.Add(ttl),
+		}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(row)
+		if result.Error != nil || result.RowsAffected > 0 {
+			return result.Error
+		}
+
+		row = &GORMRecord{}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(&GORMRecord{Key: key}).First(row).Error; err != nil {
+			return err
+		}
+		if now.Before(row.ExpiresAt) {
+			record, err := row.toRecord()
+			existing = record
+			return err
+		}
+
+		row.Fingerprint = fingerprint
+		row.Header = ""
+		row.Body = nil
+		row.Status = 0
+		row.ExpiresAt = now.Add(ttl)
+		return tx.Model(row).Select("*").Updates(row).Error
+	})
+	if err != nil {
+		return nil, errors.New(err)
+	}
+	return existing, nil
+}
+
+// Save implementation of `Store`.
+func (s *GORMStore) Save(ctx context.Context, record *Record, ttl time.Duration) error {
+	header, err := json.Marshal(record.Header)
+	if err != nil {
+		return errors.New(err)
+	}
+	row := &GORMRecord{
+		Key:         record.Key,
+		Fingerprint: record.Fingerprint,
+		Header:      string(header),
+		Body:        record.Body,
+		Status:      record.Status,
+		ExpiresAt:   s.now().Add(ttl),
+	}
+	return errors.New(s.DB.WithContext(ctx).Save(row).Error)
+}
+
+// Delete implementation of `Store`.
+func (s *GORMStore) Delete(ctx context.Context, key string) error {
+	return errors.New(s.DB.WithContext(ctx).Where(&GORMRecord{Key: key}).Delete(&GORMRecord{}).Error)
+}
+
+// Cleanup removes the expired rows from the database.
+func (s *GORMStore) Cleanup(ctx context.Context) error {
+	return errors.New(s.DB.WithContext(ctx).Where("expires_at <= ?", s.now()).Delete(&GORMRecord{}).Error)
+}
+
+func (r *GORMRecord) toRecord() (*Record, error) {
+	record := &Record{
+		Key:         r.Key,
+		Fingerprint: r.Fingerprint,
+		Body:        r.Body,
+		Status:      r.Status,
+		ExpiresAt:   r.ExpiresAt,
+	}
+	if r.Header != "" {
+		record.Header = http.Header{}
+		if err := json.Unmarshal([]byte(r.Header), &record.Header); err != nil {
+			return nil, err
+		}
+	}
+	return record, nil
+}
@@ -0,0 +1,113 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupGORMStore(t *testing.T) *GORMStore {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+	require.NoError(t, db.AutoMigrate(&GORMRecord{}))
+	return NewGORMStore(db)
+}
+
+func TestGORMStore(t *testing.T) {
+	store := setupGORMStore(t)
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	record, err := store.Reserve(ctx, "key", "fingerprint", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, record)
+
+	record, err = store.Reserve(ctx, "key", "other", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.True(t, record.InFlight())
+	assert.Equal(t, "fingerprint", record.Fingerprint)
+	assert.Nil(t, record.Header)
+
+	saved := &Record{
+		Key:         "key",
+		Fingerprint: "fingerprint",
+		Header:      http.Header{"Content-Type": {"text/plain"}},
+		Body:        []byte("hello"),
+		Status:      http.StatusCreated,
+	}
+	require.NoError(t, store.Save(ctx, saved, time.Hour))
+
+	record, err = store.Reserve(ctx, "key", "fingerprint", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.False(t, record.InFlight())
+	assert.Equal(t, http.Header{"Content-Type": {"text/plain"}}, record.Header)
+	assert.Equal(t, []byte("hello"), record.Body)
+	assert.Equal(t, http.StatusCreated, record.Status)
+	assert.True(t, now.Add(time.Hour).Equal(record.ExpiresAt))
+
+	t.Run("expired", func(t *testing.T) {
+		now = now.Add(time.Hour)
+		record, err := store.Reserve(ctx, "key", "new", time.Minute)
+		require.NoError(t, err)
+		assert.Nil(t, record)
+
+		record, err = store.Reserve(ctx, "key", "new", time.Minute)
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		assert.Equal(t, "new", record.Fingerprint)
+		assert.True(t, record.InFlight())
+		assert.Empty(t, record.Body)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, store.Delete(ctx, "key"))
+		require.NoError(t, store.Delete(ctx, "key"))
+		var count int64
+		require.NoError(t, store.DB.Model(&GORMRecord{}).Count(&count).Error)
+		assert.Equal(t, int64(0), count)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		_, err := store.Reserve(ctx, "a", "", time.Minute)
+		require.NoError(t, err)
+		_, err = store.Reserve(ctx, "b", "", time.Hour)
+		require.NoError(t, err)
+
+		now = now.Add(time.Minute)
+		require.NoError(t, store.Cleanup(ctx))
+		rows := []*GORMRecord{}
+		require.NoError(t, store.DB.Find(&rows).Error)
+		require.Len(t, rows, 1)
+		assert.Equal(t, "b", rows[0].Key)
+	})
+
+	t.Run("invalid_header", func(t *testing.T) {
+		require.NoError(t, store.DB.Model(&GORMRecord{}).Where("key = ?", "b").Update("header", "{").Error)
+		record, err := store.Reserve(ctx, "b", "", time.Minute)
+		require.Error(t, err)
+		assert.Nil(t, record)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		store := setupGORMStore(t)
+		require.NoError(t, store.DB.Migrator().DropTable(&GORMRecord{}))
+		_, err := store.Reserve(ctx, "key", "", time.Minute)
+		require.Error(t, err)
+		require.Error(t, store.Save(ctx, &Record{Key: "key"}, time.Minute))
+	})
+}
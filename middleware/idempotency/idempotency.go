@@ -0,0 +1,306 @@
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/util/errors"
+)
+
+const (
+	// Header the request header containing the idempotency key.
+	Header = "Idempotency-Key"
+
+	// ReplayedHeader the response header set to "true" when the response is replayed.
+	ReplayedHeader = "Idempotent-Replayed"
+
+	// MaxKeyLength the maximum length of an idempotency key.
+	MaxKeyLength = 255
+
+	// DefaultTTL the default duration for which responses are kept.
+	DefaultTTL = 24 * time.Hour
+
+	// DefaultLockTTL the default duration after which an in flight record
+	// is considered abandoned.
+	DefaultLockTTL = time.Minute
+)
+
+// excludedHeaders the response headers that are never replayed.
+var excludedHeaders = []string{"Connection", "Keep-Alive", "Set-Cookie", "Trailer", "Transfer-Encoding", "Upgrade"}
+
+// DefaultFingerprint computes the fingerprint of a request from its method, its URL
+// and its body. If the body was already parsed (see the `parse` middleware), the parsed
+// `request.Data` is used. Otherwise, the raw body is read and restored so it can still
+// be read by the next handlers.
+func DefaultFingerprint(request *goyave.Request) (string, error) {
+	hash := sha256.New()
+	hash.Write([]byte(request.Method() + " " + request.URL().RequestURI() + "\n"))
+	if request.Data != nil {
+		data, err := json.Marshal(request.Data)
+		if err != nil {
+			return "", errors.New(err)
+		}
+		hash.Write(data)
+	} else if body := request.Body(); body != nil {
+		raw, err := io.ReadAll(body)
+		if err != nil {
+			return "", errors.New(err)
+		}
+		request.Request().Body = io.NopCloser(bytes.NewReader(raw))
+		hash.Write(raw)
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Middleware making requests carrying the `Idempotency-Key` header safe to retry.
+//
+// The first response to a request having a given key is stored, and replayed to all
+// subsequent requests having the same key, without executing the handler again. Replayed
+// responses have the `Idempotent-Replayed: true` header. Keys are scoped by client
+// (see `Scope`) and by route, so two clients using the same key don't conflict. The stores
+// receive a SHA-256 hash of the scoped key.
+//
+// The request is rejected:
+//   - with "400 Bad Request" if the key is empty or longer than `MaxKeyLength`.
+//   - with "409 Conflict" if a request with the same key is still being processed.
+//   - with "422 Unprocessable Entity" if the request doesn't match the request that
+//     was first sent with the same key (see `Fingerprint`).
+//
+// Server errors (5xx), panics and hijacked connections are not stored: the key is
+// released so the client can retry. Requests without the header are not affected.
+//
+// The default store is a `MemoryStore`, which is local to the current instance. Use the
+// `GORMStore` if the application is deployed on multiple instances.
+//
+// **Example:**
+//
+//	idempotencyMiddleware := &idempotency.Middleware{
+//		Store: idempotency.NewGORMStore(db),
+//		Scope: ratelimit.ByUser(func(u *dto.User) string { return strconv.FormatUint(uint64(u.ID), 10) }),
+//	}
+//	router.Post("/payments", paymentsHandler).Middleware(idempotencyMiddleware)
+type Middleware struct {
+	goyave.Component
+
+	// Store persists the idempotency records. Defaults to a `MemoryStore`.
+	Store Store
+
+	// Scope returns the identifier of the client of the given request. By default, authenticated
+	// clients are identified by the `ID` field of the user DTO (`request.User`) and anonymous
+	// clients by their IP. The middleware panics if the user DTO has no `ID` field and `Scope`
+	// is not defined. The middleware must be executed after the authentication middleware.
+	Scope func(request *goyave.Request) string
+
+	// Fingerprint computes the fingerprint of the given request, used to detect a key being
+	// reused for a different request. Defaults to `DefaultFingerprint`.
+	Fingerprint func(request *goyave.Request) (string, error)
+
+	// TTL the duration for which responses are stored and replayed. Defaults to `DefaultTTL`.
+	TTL time.Duration
+
+	// LockTTL the duration after which a request that is still being processed is
+	// considered abandoned (for example if the instance crashed), and the key can be
+	// used again. Defaults to `DefaultLockTTL`.
+	LockTTL time.Duration
+
+	storeOnce sync.Once
+}
+
+// Handle implementation of `goyave.Middleware`.
+func (m *Middleware) Handle(next goyave.Handler) goyave.Handler {
+	return func(response *goyave.Response, request *goyave.Request) {
+		values := request.Header().Values(Header)
+		if len(values) == 0 {
+			next(response, request)
+			return
+		}
+		if len(values) > 1 || values[0] == "" || len(values[0]) > MaxKeyLength {
+			response.Status(http.StatusBadRequest)
+			return
+		}
+
+		fingerprint, err := m.getFingerprint(request)
+		if err != nil {
+			response.Error(errors.New(err))
+			return
+		}
+
+		key := m.key(request, values[0])
+		record, err := m.getStore().Reserve(request.Context(), key, fingerprint, m.getLockTTL())
+		if err != nil {
+			response.Error(errors.New(err))
+			return
+		}
+		if record != nil {
+			switch {
+			case record.Fingerprint != fingerprint:
+				response.Status(http.StatusUnprocessableEntity)
+			case record.InFlight():
+				response.Status(http.StatusConflict)
+			default:
+				m.replay(response, record)
+			}
+			return
+		}
+
+		response.SetWriter(&writer{
+			CommonWriter: goyave.NewCommonWriter(response.Writer()),
+			middleware:   m,
+			request:      request,
+			response:     response,
+			key:          key,
+			fingerprint:  fingerprint,
+		})
+		next(response, request)
+	}
+}
+
+func (m *Middleware) getStore() Store {
+	m.storeOnce.Do(func() {
+		if m.Store == nil {
+			m.Store = NewMemoryStore()
+		}
+	})
+	return m.Store
+}
+
+func (m *Middleware) getFingerprint(request *goyave.Request) (string, error) {
+	if m.Fingerprint != nil {
+		return m.Fingerprint(request)
+	}
+	return DefaultFingerprint(request)
+}
+
+func (m *Middleware) getTTL() time.Duration {
+	if m.TTL <= 0 {
+		return DefaultTTL
+	}
+	return m.TTL
+}
+
+func (m *Middleware) getLockTTL() time.Duration {
+	if m.LockTTL <= 0 {
+		return DefaultLockTTL
+	}
+	return m.LockTTL
+}
+
+func (m *Middleware) key(request *goyave.Request, idempotencyKey string) string {
+	var scope string
+	if m.Scope != nil {
+		scope = m.Scope(request)
+	} else {
+		var ok bool
+		scope, ok = defaultScope(request)
+		if !ok {
+			panic(errors.NewSkip(fmt.Errorf("idempotency: the user DTO %T doesn't have an ID field and the middleware doesn't define Scope", request.User), 3))
+		}
+	}
+	route := request.Method() + " "
+	if request.Route != nil {
+		route += request.Route.GetFullURI()
+	}
+	hash := sha256.Sum256([]byte(route + "|" + scope + "|" + idempotencyKey))
+	return hex.EncodeToString(hash[:])
+}
+
+// defaultScope identifies the client by the `ID` field of the authenticated user,
+// or by its IP if the request is anonymous.
+func defaultScope(request *goyave.Request) (string, bool) {
+	user := reflect.ValueOf(request.User)
+	for user.Kind() == reflect.Pointer || user.Kind() == reflect.Interface {
+		if user.IsNil() {
+			return "ip:" + request.ClientIP(), true
+		}
+		user = user.Elem()
+	}
+	if !user.IsValid() {
+		return "ip:" + request.ClientIP(), true
+	}
+	if user.Kind() != reflect.Struct {
+		return "", false
+	}
+	id := user.FieldByName("ID")
+	if !id.IsValid() || !id.CanInterface() {
+		return "", false
+	}
+	return fmt.Sprintf("user:%v", id.Interface()), true
+}
+
+func (m *Middleware) replay(response *goyave.Response, record *Record) {
+	header := response.Header()
+	for k, v := range record.Header {
+		header[k] = slices.Clone(v)
+	}
+	header.Set(ReplayedHeader, "true")
+
+	response.Status(record.Status)
+	if len(record.Body) == 0 {
+		response.WriteHeader(record.Status)
+		return
+	}
+	if _, err := response.Write(record.Body); err != nil {
+		panic(errors.New(err))
+	}
+}
+
+// save the response captured by the given writer, or release the key
+// if the response should not be replayed. The request's context cancellation is
+// ignored so the response is saved even if the client disconnected.
+func (m *Middleware) save(w *writer) error {
+	ctx := context.WithoutCancel(w.request.Context())
+	status := w.response.GetStatus()
+	if w.response.Hijacked() || status >= http.StatusInternalServerError {
+		return errors.New(m.getStore().Delete(ctx, w.key))
+	}
+
+	header := w.response.Header().Clone()
+	for _, h := range excludedHeaders {
+		header.Del(h)
+	}
+	record := &Record{
+		Key:         w.key,
+		Fingerprint: w.fingerprint,
+		Header:      header,
+		Body:        slices.Clone(w.buf.Bytes()),
+		Status:      status,
+	}
+	return errors.New(m.getStore().Save(ctx, record, m.getTTL()))
+}
+
+// writer captures the response body so it can be stored when the response is closed.
+type writer struct {
+	goyave.CommonWriter
+	middleware  *Middleware
+	request     *goyave.Request
+	response    *goyave.Response
+	key         string
+	fingerprint string
+	buf         bytes.Buffer
+}
+
+// Write writes the data to the child writer and keeps a copy of it.
+func (w *writer) Write(b []byte) (int, error) {
+	w.buf.Write(b)
+	n, err := w.CommonWriter.Write(b)
+	return n, errors.New(err)
+}
+
+// Close stores the response, then closes the child writer.
+func (w *writer) Close() error {
+	if err := w.middleware.save(w); err != nil {
+		w.middleware.Logger().ErrorCtx(w.request.Context(), err)
+	}
+	return errors.New(w.CommonWriter.Close())
+}
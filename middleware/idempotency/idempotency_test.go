@@ -0,0 +1,342 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/config"
+	"goyave.dev/goyave/v5/util/testutil"
+)
+
+type testErrorStore struct{}
+
+func (testErrorStore) Reserve(_ context.Context, _, _ string, _ time.Duration) (*Record, error) {
+	return nil, fmt.Errorf("reserve error")
+}
+
+func (testErrorStore) Save(_ context.Context, _ *Record, _ time.Duration) error {
+	return fmt.Errorf("save error")
+}
+
+func (testErrorStore) Delete(_ context.Context, _ string) error {
+	return fmt.Errorf("delete error")
+}
+
+func readBody(t *testing.T, resp *http.Response) string {
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	return string(body)
+}
+
+func newTestRequest(key, body string) *goyave.Request {
+	request := testutil.NewTestRequest(http.MethodPost, "/payments", strings.NewReader(body))
+	if key != "" {
+		request.Header().Set(Header, key)
+	}
+	return request
+}
+
+func TestDefaultFingerprint(t *testing.T) {
+	request := newTestRequest("", `{"amount":10}`)
+	fingerprint, err := DefaultFingerprint(request)
+	require.NoError(t, err)
+	assert.Len(t, fingerprint, 64)
+
+	// Body restored
+	body, err := io.ReadAll(request.Body())
+	require.NoError(t, err)
+	assert.Equal(t, `{"amount":10}`, string(body))
+
+	other, err := DefaultFingerprint(newTestRequest("", `{"amount":20}`))
+	require.NoError(t, err)
+	assert.NotEqual(t, fingerprint, other)
+
+	request = newTestRequest("", "")
+	request.Data = map[string]any{"b": 1, "a": 2}
+	parsed, err := DefaultFingerprint(request)
+	require.NoError(t, err)
+	request.Data = map[string]any{"a": 2, "b": 1}
+	parsed2, err := DefaultFingerprint(request)
+	require.NoError(t, err)
+	assert.Equal(t, parsed, parsed2)
+
+	request.Data = map[string]any{"a": make(chan int)}
+	_, err = DefaultFingerprint(request)
+	require.Error(t, err)
+}
+
+func TestMiddleware(t *testing.T) {
+	server := testutil.NewTestServerWithOptions(t, goyave.Options{Config: config.LoadDefault()})
+
+	t.Run("replay", func(t *testing.T) {
+		middleware := &Middleware{}
+		count := 0
+		handler := func(response *goyave.Response, request *goyave.Request) {
+			count++
+			body, err := io.ReadAll(request.Body())
+			require.NoError(t, err)
+			response.Header().Set("Set-Cookie", "a=b")
+			response.Header().Set("X-Count", fmt.Sprintf("%d", count))
+			response.String(http.StatusCreated, string(body))
+		}
+
+		resp := server.TestMiddleware(middleware, newTestRequest("key", "hello"), handler)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get(ReplayedHeader))
+		assert.Equal(t, "hello", readBody(t, resp))
+
+		resp = server.TestMiddleware(middleware, newTestRequest("key", "hello"), handler)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, "true", resp.Header.Get(ReplayedHeader))
+		assert.Equal(t, "1", resp.Header.Get("X-Count"))
+		assert.Empty(t, resp.Header.Get("Set-Cookie"))
+		assert.Equal(t, "hello", readBody(t, resp))
+		assert.Equal(t, 1, count)
+
+		// Different key
+		resp = server.TestMiddleware(middleware, newTestRequest("other", "hello"), handler)
+		assert.Empty(t, resp.Header.Get(ReplayedHeader))
+		_ = resp.Body.Close()
+		assert.Equal(t, 2, count)
+
+		// Different client
+		request := newTestRequest("key", "hello")
+		request.Request().RemoteAddr = "192.0.2.2:1234"
+		resp = server.TestMiddleware(middleware, request, handler)
+		assert.Empty(t, resp.Header.Get(ReplayedHeader))
+		_ = resp.Body.Close()
+		assert.Equal(t, 3, count)
+
+		// No key
+		resp = server.TestMiddleware(middleware, newTestRequest("", "hello"), handler)
+		assert.Empty(t, resp.Header.Get(ReplayedHeader))
+		_ = resp.Body.Close()
+		assert.Equal(t, 4, count)
+	})
+
+	t.Run("replay_status_only", func(t *testing.T) {
+		middleware := &Middleware{}
+		count := 0
+		handler := func(response *goyave.Response, _ *goyave.Request) {
+			count++
+			response.Status(http.StatusBadRequest)
+		}
+		resp := server.TestMiddleware(middleware, newTestRequest("key", ""), handler)
+		expected := readBody(t, resp)
+		resp = server.TestMiddleware(middleware, newTestRequest("key", ""), handler)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, expected, readBody(t, resp))
+		assert.Equal(t, 1, count)
+
+		middleware = &Middleware{}
+		handler = func(response *goyave.Response, _ *goyave.Request) {
+			response.WriteHeader(http.StatusNoContent)
+		}
+		resp = server.TestMiddleware(middleware, newTestRequest("key", ""), handler)
+		_ = resp.Body.Close()
+		resp = server.TestMiddleware(middleware, newTestRequest("key", ""), handler)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, "true", resp.Header.Get(ReplayedHeader))
+		assert.Empty(t, readBody(t, resp))
+	})
+
+	t.Run("invalid_key", func(t *testing.T) {
+		middleware := &Middleware{}
+		handler := func(response *goyave.Response, _ *goyave.Request) {
+			response.Status(http.StatusNoContent)
+		}
+		cases := [][]string{
+			{""},
+			{strings.Repeat("a", MaxKeyLength+1)},
+			{"a", "b"},
+		}
+		for _, c := range cases {
+			request := newTestRequest("", "")
+			request.Header()[Header] = c
+			resp := server.TestMiddleware(middleware, request, handler)
+			assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+			_ = resp.Body.Close()
+		}
+	})
+
+	t.Run("fingerprint_mismatch", func(t *testing.T) {
+		middleware := &Middleware{}
+		handler := func(response *goyave.Response, _ *goyave.Request) {
+			response.Status(http.StatusNoContent)
+		}
+		resp := server.TestMiddleware(middleware, newTestRequest("key", "a"), handler)
+		_ = resp.Body.Close()
+		resp = server.TestMiddleware(middleware, newTestRequest("key", "b"), handler)
+		assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+		_ = resp.Body.Close()
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		middleware := &Middleware{}
+		started := make(chan struct{})
+		release := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			resp := server.TestMiddleware(middleware, newTestRequest("key", ""), func(response *goyave.Response, _ *goyave.Request) {
+				close(started)
+				<-release
+				response.Status(http.StatusNoContent)
+			})
+			assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+			_ = resp.Body.Close()
+		}()
+
+		<-started
+		resp := server.TestMiddleware(middleware, newTestRequest("key", ""), func(_ *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "handler should not be executed")
+		})
+		assert.Equal(t, http.StatusConflict, resp.StatusCode)
+		_ = resp.Body.Close()
+		close(release)
+		<-done
+
+		resp = server.TestMiddleware(middleware, newTestRequest("key", ""), func(_ *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "handler should not be executed")
+		})
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, "true", resp.Header.Get(ReplayedHeader))
+		_ = resp.Body.Close()
+	})
+
+	t.Run("server_error_released", func(t *testing.T) {
+		store := NewMemoryStore()
+		middleware := &Middleware{Store: store}
+		resp := server.TestMiddleware(middleware, newTestRequest("key", ""), func(response *goyave.Response, _ *goyave.Request) {
+			response.Status(http.StatusServiceUnavailable)
+		})
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		_ = resp.Body.Close()
+		assert.Equal(t, 0, store.Len())
+
+		resp = server.TestMiddleware(middleware, newTestRequest("key", ""), func(_ *goyave.Response, _ *goyave.Request) {
+			panic("test panic")
+		})
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		_ = resp.Body.Close()
+		assert.Equal(t, 0, store.Len())
+	})
+
+	t.Run("custom_scope_and_fingerprint", func(t *testing.T) {
+		store := NewMemoryStore()
+		middleware := &Middleware{
+			Store: store,
+			Scope: func(_ *goyave.Request) string { return "user:1" },
+			Fingerprint: func(_ *goyave.Request) (string, error) {
+				return "fingerprint", nil
+			},
+			TTL:     time.Hour,
+			LockTTL: time.Second,
+		}
+		route := goyave.NewRouter(server.Server).Post("/payments", nil)
+		request := newTestRequest("key", "a")
+		request.Route = route
+		key := middleware.key(request, "key")
+		resp := server.TestMiddleware(middleware, request, func(response *goyave.Response, _ *goyave.Request) {
+			record, err := store.Reserve(context.Background(), key, "fingerprint", time.Minute)
+			require.NoError(t, err)
+			require.NotNil(t, record)
+			assert.WithinDuration(t, time.Now().Add(time.Second), record.ExpiresAt, 100*time.Millisecond)
+			response.Status(http.StatusNoContent)
+		})
+		_ = resp.Body.Close()
+
+		record, err := store.Reserve(context.Background(), key, "fingerprint", time.Minute)
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		assert.Equal(t, http.StatusNoContent, record.Status)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), record.ExpiresAt, 100*time.Millisecond)
+
+		request = newTestRequest("key", "b")
+		request.Route = route
+		resp = server.TestMiddleware(middleware, request, func(_ *goyave.Response, _ *goyave.Request) {})
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		_ = resp.Body.Close()
+	})
+
+	t.Run("default_scope", func(t *testing.T) {
+		type user struct {
+			Name string
+			ID   uint
+		}
+		middleware := &Middleware{}
+		count := 0
+		handler := func(response *goyave.Response, _ *goyave.Request) {
+			count++
+			response.String(http.StatusCreated, "hello")
+		}
+		newUserRequest := func(u any, remoteAddr string) *goyave.Request {
+			request := newTestRequest("key", "hello")
+			request.User = u
+			request.Request().RemoteAddr = remoteAddr
+			return request
+		}
+
+		resp := server.TestMiddleware(middleware, newUserRequest(&user{ID: 1}, "192.0.2.1:1234"), handler)
+		assert.Empty(t, resp.Header.Get(ReplayedHeader))
+		_ = resp.Body.Close()
+
+		// Same user from another IP
+		resp = server.TestMiddleware(middleware, newUserRequest(&user{ID: 1, Name: "johndoe"}, "192.0.2.2:1234"), handler)
+		assert.Equal(t, "true", resp.Header.Get(ReplayedHeader))
+		_ = resp.Body.Close()
+		assert.Equal(t, 1, count)
+
+		// Another user from the same IP
+		resp = server.TestMiddleware(middleware, newUserRequest(&user{ID: 2}, "192.0.2.1:1234"), handler)
+		assert.Empty(t, resp.Header.Get(ReplayedHeader))
+		_ = resp.Body.Close()
+		assert.Equal(t, 2, count)
+
+		// Anonymous client from the same IP
+		resp = server.TestMiddleware(middleware, newUserRequest((*user)(nil), "192.0.2.1:1234"), handler)
+		assert.Empty(t, resp.Header.Get(ReplayedHeader))
+		_ = resp.Body.Close()
+		assert.Equal(t, 3, count)
+
+		assert.Len(t, middleware.key(newUserRequest(nil, "192.0.2.1:1234"), strings.Repeat("a", MaxKeyLength)), 64)
+
+		assert.Panics(t, func() {
+			middleware.key(newUserRequest(map[string]any{"id": 1}, "192.0.2.1:1234"), "key")
+		})
+		assert.Panics(t, func() {
+			middleware.key(newUserRequest(&struct{ Name string }{}, "192.0.2.1:1234"), "key")
+		})
+	})
+
+	t.Run("fingerprint_error", func(t *testing.T) {
+		middleware := &Middleware{
+			Fingerprint: func(_ *goyave.Request) (string, error) {
+				return "", fmt.Errorf("fingerprint error")
+			},
+		}
+		resp := server.TestMiddleware(middleware, newTestRequest("key", ""), func(_ *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "handler should not be executed")
+		})
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		_ = resp.Body.Close()
+	})
+
+	t.Run("store_error", func(t *testing.T) {
+		middleware := &Middleware{Store: testErrorStore{}}
+		resp := server.TestMiddleware(middleware, newTestRequest("key", ""), func(_ *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "handler should not be executed")
+		})
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		_ = resp.Body.Close()
+	})
+}
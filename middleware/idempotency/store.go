@@ -0,0 +1,136 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Record the state of an idempotency key. While the first request is being processed,
+// the record is "in flight" and doesn't have a status. Once the response is sent,
+// the record holds the response so it can be replayed.
+type Record struct {
+	ExpiresAt   time.Time   `json:"expiresAt"`
+	Header      http.Header `json:"header"`
+	Key         string      `json:"key"`
+	Fingerprint string      `json:"fingerprint"`
+	Body        []byte      `json:"body"`
+	Status      int         `json:"status"`
+}
+
+// InFlight returns true if the request that created this record is still being processed.
+func (r *Record) InFlight() bool {
+	return r.Status == 0
+}
+
+// Store persists the idempotency records.
+//
+// `Reserve` atomically creates an in flight record for the given key if it doesn't exist
+// or if it has expired, and returns `nil`. Otherwise, the existing record is returned
+// and left untouched. The new record expires after the given TTL.
+//
+// `Save` replaces the record identified by `record.Key`, which now expires after the given TTL.
+//
+// `Delete` removes the record identified by the given key. Deleting a key that
+// doesn't exist is not an error.
+//
+// Implementations must be safe for concurrent use. If the store is shared
+// between multiple instances of the application, `Reserve` must be atomic across
+// all instances.
+type Store interface {
+	Reserve(ctx context.Context, key, fingerprint string, ttl time.Duration) (*Record, error)
+	Save(ctx context.Context, record *Record, ttl time.Duration) error
+	Delete(ctx context.Context, key string) error
+}
+
+// MemoryStore a `Store` keeping the records in memory. The records are not shared
+// between multiple instances of the application.
+//
+// Expired records are periodically removed when a key is reserved.
+type MemoryStore struct {
+	records   map[string]*Record
+	nextSweep time.Time
+	now       func() time.Time
+	mu        sync.Mutex
+
+	// SweepInterval the minimum duration between two removals of the expired records.
+	// Defaults to one minute.
+	SweepInterval time.Duration
+}
+
+// NewMemoryStore create a new empty `MemoryStore`.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		records:       map[string]*Record{},
+		now:           time.Now,
+		SweepInterval: time.Minute,
+	}
+}
+
+// Reserve implementation of `Store`.
+func (s *MemoryStore) Reserve(_ context.Context, key, fingerprint string, ttl time.Duration) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	if record, ok := s.records[key]; ok && now.Before(record.ExpiresAt) {
+		return copyRecord(record), nil
+	}
+	s.records[key] = &Record{
+		Key:         key,
+		Fingerprint: fingerprint,
+		ExpiresAt:   now.Add(ttl),
+	}
+	return nil, nil
+}
+
+// Save implementation of `Store`.
+func (s *MemoryStore) Save(_ context.Context, record *Record, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r := copyRecord(record)
+	r.ExpiresAt = s.now().Add(ttl)
+	s.records[record.Key] = r
+	return nil
+}
+
+// Delete implementation of `Store`.
+func (s *MemoryStore) Delete(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+// Len returns the number of records in the store, including the expired ones
+// that were not removed yet.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.records)
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	for k, r := range s.records {
+		if !now.Before(r.ExpiresAt) {
+			delete(s.records, k)
+		}
+	}
+	s.nextSweep = now.Add(s.SweepInterval)
+}
+
+// copyRecord returns a deep copy of the given record so it cannot be
+// modified outside of the store.
+func copyRecord(record *Record) *Record {
+	r := *record
+	r.Header = record.Header.Clone()
+	r.Body = slices.Clone(record.Body)
+	return &r
+}
@@ -0,0 +1,84 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	store := NewMemoryStore()
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	record, err := store.Reserve(ctx, "key", "fingerprint", time.Minute)
+	require.NoError(t, err)
+	assert.Nil(t, record)
+
+	record, err = store.Reserve(ctx, "key", "other", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.True(t, record.InFlight())
+	assert.Equal(t, "fingerprint", record.Fingerprint)
+	assert.Equal(t, now.Add(time.Minute), record.ExpiresAt)
+
+	saved := &Record{
+		Key:         "key",
+		Fingerprint: "fingerprint",
+		Header:      http.Header{"Content-Type": {"text/plain"}},
+		Body:        []byte("hello"),
+		Status:      http.StatusCreated,
+	}
+	require.NoError(t, store.Save(ctx, saved, time.Hour))
+	saved.Header.Set("Content-Type", "modified")
+	saved.Body[0] = 'j'
+
+	record, err = store.Reserve(ctx, "key", "fingerprint", time.Minute)
+	require.NoError(t, err)
+	require.NotNil(t, record)
+	assert.False(t, record.InFlight())
+	assert.Equal(t, &Record{
+		Key:         "key",
+		Fingerprint: "fingerprint",
+		Header:      http.Header{"Content-Type": {"text/plain"}},
+		Body:        []byte("hello"),
+		Status:      http.StatusCreated,
+		ExpiresAt:   now.Add(time.Hour),
+	}, record)
+
+	t.Run("expired", func(t *testing.T) {
+		now = now.Add(time.Hour)
+		record, err := store.Reserve(ctx, "key", "new", time.Minute)
+		require.NoError(t, err)
+		assert.Nil(t, record)
+		record, err = store.Reserve(ctx, "key", "new", time.Minute)
+		require.NoError(t, err)
+		require.NotNil(t, record)
+		assert.Equal(t, "new", record.Fingerprint)
+		assert.True(t, record.InFlight())
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		require.NoError(t, store.Delete(ctx, "key"))
+		require.NoError(t, store.Delete(ctx, "key"))
+		assert.Equal(t, 0, store.Len())
+	})
+
+	t.Run("sweep", func(t *testing.T) {
+		_, err := store.Reserve(ctx, "a", "", time.Minute)
+		require.NoError(t, err)
+		_, err = store.Reserve(ctx, "b", "", time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, 2, store.Len())
+
+		now = now.Add(2 * time.Minute)
+		_, err = store.Reserve(ctx, "c", "", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, 2, store.Len()) // "a" removed
+	})
+}
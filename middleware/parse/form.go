@@ -0,0 +1,228 @@
+package parse
+
+import (
+	"fmt"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"goyave.dev/goyave/v5/util/fsutil"
+)
+
+// formArray intermediate representation of an array built from bracket notation.
+// Because the order of the keys of `url.Values` is not preserved, indexed elements
+// are stored in a map and sorted when the array is converted to `[]any`.
+type formArray struct {
+	indexed  map[int]any
+	appended []any
+}
+
+// parseForm converts the given values and files into `dst`. Keys using the bracket notation
+// are converted to nested objects (`map[string]any`) and arrays (`[]any`):
+//
+//	user[address][city]=x  --> {"user": {"address": {"city": "x"}}}
+//	tags[]=a&tags[]=b      --> {"tags": ["a", "b"]}
+//	items[0][name]=a       --> {"items": [{"name": "a"}]}
+//	items[][name]=a&items[][name]=b --> {"items": [{"name": "a"}, {"name": "b"}]}
+//
+// Numeric indexes are only used to order the elements of the array, the resulting
+// arrays are never sparse. When an empty bracket pair is followed by other keys,
+// the n-th value is put in the n-th element of the array.
+//
+// Keys not using the bracket notation are flattened: single value arrays
+// are converted to non-array. Files are always a `[]fsutil.File`, so a trailing
+// empty bracket pair in a file key is ignored.
+//
+// Returns an error if the keys are conflicting (e.g. "a=1&a[b]=2").
+func parseForm(dst map[string]any, values url.Values, files map[string][]fsutil.File) error {
+	for key, value := range values {
+		name, segments := splitBracketKey(key)
+		if segments == nil {
+			if _, ok := dst[name]; ok {
+				return fmt.Errorf("conflicting values for key %q", key)
+			}
+			dst[name] = flattenValue(value)
+			continue
+		}
+
+		anyValues := make([]any, 0, len(value))
+		for _, v := range value {
+			anyValues = append(anyValues, v)
+		}
+		if err := setFormValue(dst, name, segments, anyValues); err != nil {
+			return fmt.Errorf("key %q: %w", key, err)
+		}
+	}
+
+	for key, f := range files {
+		name, segments := splitBracketKey(key)
+		if len(segments) > 0 && segments[len(segments)-1] == "" {
+			segments = segments[:len(segments)-1]
+		}
+		if err := setFormValue(dst, name, segments, []any{f}); err != nil {
+			return fmt.Errorf("key %q: %w", key, err)
+		}
+	}
+
+	finalizeFormObject(dst)
+	return nil
+}
+
+// splitBracketKey splits a key using the bracket notation into its name and segments.
+// For example "user[address][city]" returns "user" and ["address", "city"].
+// If the key doesn't use the bracket notation or is malformed, the segments are `nil`
+// and the key is returned as is.
+func splitBracketKey(key string) (string, []string) {
+	i := strings.IndexByte(key, '[')
+	if i <= 0 || !strings.HasSuffix(key, "]") {
+		return key, nil
+	}
+	name := key[:i]
+	rest := key[i:]
+	segments := []string{}
+	for rest != "" {
+		if rest[0] != '[' {
+			return key, nil
+		}
+		end := strings.IndexByte(rest, ']')
+		if end == -1 {
+			return key, nil
+		}
+		segment := rest[1:end]
+		if strings.ContainsRune(segment, '[') {
+			return key, nil
+		}
+		segments = append(segments, segment)
+		rest = rest[end+1:]
+	}
+	return name, segments
+}
+
+func setFormValue(object map[string]any, name string, segments []string, values []any) error {
+	if len(segments) == 0 {
+		if _, ok := object[name]; ok {
+			return fmt.Errorf("conflicting values for %q", name)
+		}
+		object[name] = flattenAny(values)
+		return nil
+	}
+	child, err := formContainer(object[name], segments[0])
+	if err != nil {
+		return err
+	}
+	object[name] = child
+	return setFormSegment(child, segments, values)
+}
+
+func setFormSegment(container any, segments []string, values []any) error {
+	segment := segments[0]
+	rest := segments[1:]
+	switch c := container.(type) {
+	case map[string]any:
+		if isArraySegment(segment) {
+			return fmt.Errorf("cannot use index %q on an object", segment)
+		}
+		return setFormValue(c, segment, rest, values)
+	case *formArray:
+		if segment == "" {
+			if len(rest) == 0 {
+				c.appended = append(c.appended, values...)
+				return nil
+			}
+			for i, v := range values {
+				if err := setFormIndex(c, i, rest, []any{v}); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		if !isArraySegment(segment) {
+			return fmt.Errorf("cannot use key %q on an array", segment)
+		}
+		index, _ := strconv.Atoi(segment)
+		return setFormIndex(c, index, rest, values)
+	}
+	return nil
+}
+
+func setFormIndex(array *formArray, index int, segments []string, values []any) error {
+	if len(segments) == 0 {
+		if _, ok := array.indexed[index]; ok {
+			return fmt.Errorf("conflicting values for index %d", index)
+		}
+		array.indexed[index] = flattenAny(values)
+		return nil
+	}
+	child, err := formContainer(array.indexed[index], segments[0])
+	if err != nil {
+		return err
+	}
+	array.indexed[index] = child
+	return setFormSegment(child, segments, values)
+}
+
+// formContainer returns the existing container if it is not nil, or creates a new
+// one. If the next segment is empty or numeric, the new container is an array.
+func formContainer(existing any, nextSegment string) (any, error) {
+	switch existing.(type) {
+	case nil:
+		if isArraySegment(nextSegment) {
+			return &formArray{indexed: map[int]any{}}, nil
+		}
+		return map[string]any{}, nil
+	case map[string]any, *formArray:
+		return existing, nil
+	default:
+		return nil, fmt.Errorf("conflicting values")
+	}
+}
+
+// isArraySegment returns true if the segment is empty or a positive integer.
+func isArraySegment(segment string) bool {
+	if segment == "" {
+		return true
+	}
+	i, err := strconv.Atoi(segment)
+	return err == nil && i >= 0
+}
+
+func finalizeFormObject(object map[string]any) {
+	for k, v := range object {
+		object[k] = finalizeFormValue(v)
+	}
+}
+
+func finalizeFormValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		finalizeFormObject(v)
+		return v
+	case *formArray:
+		indexes := make([]int, 0, len(v.indexed))
+		for i := range v.indexed {
+			indexes = append(indexes, i)
+		}
+		slices.Sort(indexes)
+		result := make([]any, 0, len(v.indexed)+len(v.appended))
+		for _, i := range indexes {
+			result = append(result, finalizeFormValue(v.indexed[i]))
+		}
+		return append(result, v.appended...)
+	}
+	return value
+}
+
+func flattenValue(value []string) any {
+	if len(value) > 1 {
+		return value
+	}
+	return value[0]
+}
+
+func flattenAny(values []any) any {
+	if len(values) > 1 {
+		return values
+	}
+	return values[0]
+}
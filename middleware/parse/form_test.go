@@ -0,0 +1,144 @@
+package parse
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5/util/fsutil"
+)
+
+func TestSplitBracketKey(t *testing.T) {
+	cases := []struct {
+		key          string
+		expectedName string
+		expected     []string
+	}{
+		{key: "field", expectedName: "field", expected: nil},
+		{key: "user[address][city]", expectedName: "user", expected: []string{"address", "city"}},
+		{key: "tags[]", expectedName: "tags", expected: []string{""}},
+		{key: "items[0][name]", expectedName: "items", expected: []string{"0", "name"}},
+		{key: "[a]", expectedName: "[a]", expected: nil},
+		{key: "a[b", expectedName: "a[b", expected: nil},
+		{key: "a[b]c]", expectedName: "a[b]c]", expected: nil},
+		{key: "a[b[c]]", expectedName: "a[b[c]]", expected: nil},
+	}
+
+	for _, c := range cases {
+		t.Run(c.key, func(t *testing.T) {
+			name, segments := splitBracketKey(c.key)
+			assert.Equal(t, c.expectedName, name)
+			assert.Equal(t, c.expected, segments)
+		})
+	}
+}
+
+func TestParseForm(t *testing.T) {
+	cases := []struct {
+		files    map[string][]fsutil.File
+		expected map[string]any
+		desc     string
+		query    string
+		wantErr  bool
+	}{
+		{
+			desc:     "flat",
+			query:    "a=b&c=d&array=1&array=2",
+			expected: map[string]any{"a": "b", "c": "d", "array": []string{"1", "2"}},
+		},
+		{
+			desc:  "nested_object",
+			query: "user[address][city]=Paris&user[address][zip]=75000&user[name]=John",
+			expected: map[string]any{
+				"user": map[string]any{
+					"name":    "John",
+					"address": map[string]any{"city": "Paris", "zip": "75000"},
+				},
+			},
+		},
+		{
+			desc:     "array",
+			query:    "tags[]=a&tags[]=b",
+			expected: map[string]any{"tags": []any{"a", "b"}},
+		},
+		{
+			desc:     "single_value_array",
+			query:    "tags[]=a",
+			expected: map[string]any{"tags": []any{"a"}},
+		},
+		{
+			desc:  "indexed_array",
+			query: "items[1][name]=b&items[0][name]=a&items[0][qty]=1&items[10][name]=c",
+			expected: map[string]any{
+				"items": []any{
+					map[string]any{"name": "a", "qty": "1"},
+					map[string]any{"name": "b"},
+					map[string]any{"name": "c"},
+				},
+			},
+		},
+		{
+			desc:  "array_of_objects",
+			query: "items[][name]=a&items[][name]=b&items[][qty]=1",
+			expected: map[string]any{
+				"items": []any{
+					map[string]any{"name": "a", "qty": "1"},
+					map[string]any{"name": "b"},
+				},
+			},
+		},
+		{
+			desc:     "n_dimensional_array",
+			query:    "matrix[0][]=1&matrix[0][]=2&matrix[1][]=3",
+			expected: map[string]any{"matrix": []any{[]any{"1", "2"}, []any{"3"}}},
+		},
+		{
+			desc:     "nested_repeated_value",
+			query:    "user[tags]=a&user[tags]=b",
+			expected: map[string]any{"user": map[string]any{"tags": []any{"a", "b"}}},
+		},
+		{
+			desc:  "files",
+			query: "user[name]=John",
+			files: map[string][]fsutil.File{
+				"user[avatar]": {{MIMEType: "image/png"}},
+				"documents[]":  {{MIMEType: "application/pdf"}, {MIMEType: "text/plain"}},
+				"flat":         {{MIMEType: "text/plain"}},
+			},
+			expected: map[string]any{
+				"user": map[string]any{
+					"name":   "John",
+					"avatar": []fsutil.File{{MIMEType: "image/png"}},
+				},
+				"documents": []fsutil.File{{MIMEType: "application/pdf"}, {MIMEType: "text/plain"}},
+				"flat":      []fsutil.File{{MIMEType: "text/plain"}},
+			},
+		},
+		{desc: "conflict_value_object", query: "a=1&a[b]=2", wantErr: true},
+		{desc: "conflict_object_array", query: "a[b]=1&a[]=2", wantErr: true},
+		{desc: "conflict_array_index_key", query: "a[0]=1&a[b]=2", wantErr: true},
+		{desc: "conflict_duplicate_index", query: "a[0]=1&a[0][b]=2", wantErr: true},
+		{
+			desc:    "conflict_file",
+			query:   "a=1",
+			files:   map[string][]fsutil.File{"a": {{}}},
+			wantErr: true,
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			values, err := url.ParseQuery(c.query)
+			require.NoError(t, err)
+			dst := map[string]any{}
+			err = parseForm(dst, values, c.files)
+			if c.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, dst)
+		})
+	}
+}
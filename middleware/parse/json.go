@@ -0,0 +1,29 @@
+package parse
+
+import (
+	"encoding/json"
+
+	"goyave.dev/goyave/v5"
+)
+
+// JSON decoder for the "application/json" media type using Go's standard
+// `encoding/json` package.
+type JSON struct{}
+
+// MediaTypes returns "application/json".
+func (d *JSON) MediaTypes() []string {
+	return []string{"application/json"}
+}
+
+// Decode unmarshals the given JSON body. If `maxDepth` is positive, the nesting depth
+// of objects and arrays is checked before the body is unmarshaled.
+func (d *JSON) Decode(body []byte, maxDepth int) (any, error) {
+	if maxDepth > 0 && exceedsJSONDepth(body, maxDepth) {
+		return nil, goyave.ErrBodyTooDeep
+	}
+	var data any
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,26 @@
+package parse
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5"
+)
+
+func TestJSONDecoder(t *testing.T) {
+	decoder := &JSON{}
+	assert.Equal(t, []string{"application/json"}, decoder.MediaTypes())
+
+	data, err := decoder.Decode([]byte(`{"a":"b","c":[1,2]}`), 0)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"a": "b", "c": []any{1.0, 2.0}}, data)
+
+	data, err = decoder.Decode([]byte(`{"unclosed"`), 0)
+	require.Error(t, err)
+	assert.Nil(t, data)
+
+	data, err = decoder.Decode([]byte(`{"a":"b","c":[1,2]}`), 1)
+	require.ErrorIs(t, err, goyave.ErrBodyTooDeep)
+	assert.Nil(t, data)
+}
@@ -0,0 +1,90 @@
+package parse
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+	"goyave.dev/goyave/v5"
+)
+
+// MessagePack decoder for the "application/msgpack", "application/x-msgpack" and
+// "application/vnd.msgpack" media types.
+//
+// Maps are decoded as `map[string]any`, arrays as `[]any`. Integers are decoded
+// as `int64` or `uint64` and floats as `float64`. Documents nested deeper than
+// 10000 maps and arrays are always rejected.
+type MessagePack struct{}
+
+// MediaTypes returns "application/msgpack", "application/x-msgpack" and "application/vnd.msgpack".
+func (d *MessagePack) MediaTypes() []string {
+	return []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"}
+}
+
+// Decode unmarshals the given MessagePack body. The body must contain a single value.
+func (d *MessagePack) Decode(body []byte, maxDepth int) (any, error) {
+	reader := bytes.NewReader(body)
+	decoder := msgpack.NewDecoder(reader)
+	decoder.UseLooseInterfaceDecoding(true)
+	data, err := decodeMessagePackValue(decoder, 1, depthLimit(maxDepth))
+	if err != nil {
+		return nil, err
+	}
+	if reader.Len() > 0 {
+		return nil, errors.New("invalid data after top-level MessagePack value")
+	}
+	return data, nil
+}
+
+// decodeMessagePackValue decodes the next value. Maps and arrays are decoded
+// recursively so their depth can be checked before the stack grows.
+func decodeMessagePackValue(decoder *msgpack.Decoder, depth, maxDepth int) (any, error) {
+	code, err := decoder.PeekCode()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case msgpcode.IsFixedMap(code) || code == msgpcode.Map16 || code == msgpcode.Map32:
+		if depth > maxDepth {
+			return nil, goyave.ErrBodyTooDeep
+		}
+		n, err := decoder.DecodeMapLen()
+		if err != nil || n == -1 {
+			return nil, err
+		}
+		object := make(map[string]any, min(n, 64))
+		for range n {
+			key, err := decoder.DecodeString()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeMessagePackValue(decoder, depth+1, maxDepth)
+			if err != nil {
+				return nil, err
+			}
+			object[key] = value
+		}
+		return object, nil
+	case msgpcode.IsFixedArray(code) || code == msgpcode.Array16 || code == msgpcode.Array32:
+		if depth > maxDepth {
+			return nil, goyave.ErrBodyTooDeep
+		}
+		n, err := decoder.DecodeArrayLen()
+		if err != nil || n == -1 {
+			return nil, err
+		}
+		array := make([]any, 0, min(n, 64))
+		for range n {
+			value, err := decodeMessagePackValue(decoder, depth+1, maxDepth)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		return array, nil
+	default:
+		return decoder.DecodeInterfaceLoose()
+	}
+}
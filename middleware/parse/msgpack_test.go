@@ -0,0 +1,92 @@
+package parse
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+	"goyave.dev/goyave/v5"
+)
+
+func TestMessagePackDecoder(t *testing.T) {
+	decoder := &MessagePack{}
+	assert.Equal(t, []string{"application/msgpack", "application/x-msgpack", "application/vnd.msgpack"}, decoder.MediaTypes())
+
+	body, err := msgpack.Marshal(map[string]any{
+		"name":  "John",
+		"age":   42,
+		"score": 12.5,
+		"tags":  []string{"a", "b"},
+		"address": map[string]any{
+			"city": "Paris",
+		},
+		"nil": nil,
+	})
+	require.NoError(t, err)
+
+	data, err := decoder.Decode(body, 0)
+	require.NoError(t, err)
+	expected := map[string]any{
+		"name":  "John",
+		"age":   int64(42),
+		"score": 12.5,
+		"tags":  []any{"a", "b"},
+		"address": map[string]any{
+			"city": "Paris",
+		},
+		"nil": nil,
+	}
+	assert.Equal(t, expected, data)
+
+	_, err = decoder.Decode([]byte{}, 0)
+	require.Error(t, err)
+
+	t.Run("nil_containers", func(t *testing.T) {
+		data, err := decoder.Decode([]byte{msgpcode.Nil}, 0)
+		require.NoError(t, err)
+		assert.Nil(t, data)
+
+		body, err := msgpack.Marshal(map[string]any{"list": []any{}, "object": map[string]any{}})
+		require.NoError(t, err)
+		data, err = decoder.Decode(body, 0)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"list": []any{}, "object": map[string]any{}}, data)
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		_, err := decoder.Decode([]byte{0x92, 0x01}, 0) // Array of two elements containing only one
+		require.Error(t, err)
+		_, err = decoder.Decode([]byte{0x81, 0x01, 0x01}, 0) // Map with a non-string key
+		require.Error(t, err)
+		_, err = decoder.Decode([]byte{0x81, 0xa1, 'a'}, 0) // Map with a missing value
+		require.Error(t, err)
+		data, err := decoder.Decode([]byte{0x91, 0x01, 0x02}, 0) // Trailing data
+		require.Error(t, err)
+		assert.Nil(t, data)
+	})
+
+	t.Run("max_depth", func(t *testing.T) {
+		body := append(bytes.Repeat([]byte{0x91}, maxDecodeDepth-1), 0x90)
+		_, err := decoder.Decode(body, 0)
+		require.NoError(t, err)
+
+		body = append(bytes.Repeat([]byte{0x91}, maxDecodeDepth), 0x90)
+		data, err := decoder.Decode(body, 0)
+		require.ErrorIs(t, err, goyave.ErrBodyTooDeep)
+		assert.Nil(t, data)
+
+		body = append(bytes.Repeat([]byte{0x81, 0xa1, 'a'}, maxDecodeDepth), 0x80)
+		_, err = decoder.Decode(body, 0)
+		require.ErrorIs(t, err, goyave.ErrBodyTooDeep)
+
+		body = []byte{0x81, 0xa1, 'a', 0x91, 0x01} // {"a": [1]}
+		data, err = decoder.Decode(body, 2)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"a": []any{int64(1)}}, data)
+		_, err = decoder.Decode(body, 1)
+		require.ErrorIs(t, err, goyave.ErrBodyTooDeep)
+	})
+}
@@ -2,42 +2,158 @@ package parse
 
 import (
 	"bytes"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
+	"mime/multipart"
 	"net/http"
 	"net/url"
+	"os"
+	"slices"
 	"strings"
 
+	"github.com/google/uuid"
 	"goyave.dev/goyave/v5"
+	errorutil "goyave.dev/goyave/v5/util/errors"
 	"goyave.dev/goyave/v5/util/fsutil"
+	"goyave.dev/goyave/v5/util/fsutil/osfs"
 )
 
+var errFileTooLarge = errors.New("parse middleware: file exceeds the maximum file size")
+
+// maxDecodeDepth the maximum nesting depth accepted by the XML and MessagePack decoders
+// regardless of the middleware's settings, preventing stack exhaustion. This is the same
+// limit as the one enforced internally by `encoding/json`.
+const maxDecodeDepth = 10000
+
+// MetaLimits the route meta key used to override the limits enforced by the parse
+// middleware for a route or a router. The value is expected to be a `*Limits`.
+const MetaLimits = "goyave.parse-limits"
+
+// Limits the request limits enforced by the parse middleware, overriding the
+// middleware's settings for a route or a router (see `MetaLimits`).
+//
+// Zero values inherit the middleware's settings. Except for `MaxBodySize`,
+// negative values disable the limit.
+type Limits struct {
+	// MaxBodySize the maximum size of the request body (in MiB).
+	// If exceeded, "413 Request Entity Too Large" is returned.
+	MaxBodySize float64
+
+	// MaxHeaderBytes the maximum size of the request header fields (names and values).
+	// If exceeded, "431 Request Header Fields Too Large" is returned.
+	// This limit cannot exceed the server-wide `goyave.Options.MaxHeaderBytes`.
+	MaxHeaderBytes int
+
+	// MaxBodyDepth the maximum nesting depth of the bodies decoded by the `Decoders`
+	// (e.g. JSON objects and arrays, XML elements). If exceeded, "400 Bad Request" is returned.
+	MaxBodyDepth int
+
+	// MaxFiles the maximum number of files in a multipart form.
+	// If exceeded, "413 Request Entity Too Large" is returned.
+	MaxFiles int
+
+	// MaxQueryParameters the maximum number of query values.
+	// If exceeded, "400 Bad Request" is returned.
+	MaxQueryParameters int
+}
+
+// Decoder is an interface that wraps the methods returning the information
+// necessary for the parse middleware to decode request bodies.
+//
+// `MediaTypes` returns the media types (e.g. "application/json") handled by the decoder.
+// The decoder is chosen by matching the request's "Content-Type" header (without parameters)
+// with these values. If no decoder matches and the media type has a structured syntax suffix
+// (e.g. "application/vnd.api+json"), the decoder handling "application/" + suffix is used.
+//
+// `Decode` decodes the given body into a generic structure (`map[string]any`, `[]any`, etc)
+// that can be validated. The returned error doesn't need to be wrapped. If `maxDepth` is
+// positive and the body is nested deeper, `Decode` must return an error wrapping
+// `goyave.ErrBodyTooDeep`. The nesting depth of a body containing a single scalar value is 0.
+type Decoder interface {
+	Decode(body []byte, maxDepth int) (any, error)
+	MediaTypes() []string
+}
+
+// DefaultDecoders the decoders used by the parse middleware if its `Decoders` field is `nil`.
+var DefaultDecoders = []Decoder{&JSON{}, &XML{}, &MessagePack{}}
+
 // Middleware reading the raw request query and body.
 //
 // First, the query is parsed using Go's standard `url.ParseQuery()`. After being flattened
 // (single value arrays converted to non-array), the result is put in the request's `Query`.
+// Keys using the bracket notation (e.g. `user[address][city]=x` or `tags[]=a`)
+// are converted to nested `map[string]any` and `[]any`.
 // If the parsing fails, returns "400 Bad request".
 //
 // The body is read only if the "Content-Type" header is set. If
 // the body exceeds the configured max upload size (in MiB), "413 Request Entity Too Large"
 // is returned.
-// If the content type is "application/json", the middleware will attempt
-// to unmarshal the body and put the result in the request's `Data`. If it fails, returns "400 Bad request".
+// If one of the `Decoders` handles the content type, the middleware will attempt
+// to decode the body and put the result in the request's `Data`. If it fails, returns "400 Bad request".
+// By default, JSON, XML and MessagePack are supported.
 // If the content-type has another value, Go's standard `ParseMultipartForm` is called. The result
-// is put inside the request's `Data` after being flattened.
+// is put inside the request's `Data` after being flattened, the bracket notation being
+// supported the same way as in the query.
 // If the form is not a multipart form, attempts `ParseForm`. If `ParseMultipartForm` or `ParseForm` return
 // an error, returns "400 Bad request".
 //
 // In `multipart/form-data`, all file parts are automatically converted to `[]fsutil.File`.
 // Inside `request.Data`, a field of type "file" will therefore always be of type `[]fsutil.File`.
 // It is a slice so it support multi-file uploads in a single field.
+//
+// If `StreamFiles` is enabled, `multipart/form-data` requests are not buffered in memory.
+// The parts are read one by one directly from the request body and file parts are written
+// to the `FileStorage` as they arrive. Their MIME type is detected and the `MaxFileSize` is
+// checked during the stream. The streamed files are removed at the end of the request if
+// the storage implements `fsutil.RemoveFS`.
+//
+// The size of the header fields, the nesting depth of decoded bodies, the number of files and
+// the number of query parameters can be limited. All limits can be overridden for a route
+// or a router using the `MetaLimits` meta. Violations are rejected with "413 Request Entity Too Large",
+// "431 Request Header Fields Too Large" or "400 Bad Request".
 type Middleware struct {
 	goyave.Component
 
+	// Decoders the decoders used to parse the request body depending on its content type.
+	// Defaults to `DefaultDecoders`.
+	Decoders []Decoder
+
+	// FileStorage the file system in which the files are written when `StreamFiles` is enabled.
+	// Defaults to the OS temporary directory.
+	FileStorage fsutil.WritableFS
+
 	// MaxUpoadSize the maximum size of the request (in MiB).
 	// Defaults to the value provided in the config "server.maxUploadSize".
 	MaxUploadSize float64
+
+	// MaxFileSize the maximum size of a single file (in MiB) when `StreamFiles` is enabled.
+	// If a file exceeds this size, "413 Request Entity Too Large" is returned.
+	// If 0, the size of the files is only limited by `MaxUploadSize`.
+	MaxFileSize float64
+
+	// MaxHeaderBytes the maximum size of the request header fields (names and values).
+	// If exceeded, "431 Request Header Fields Too Large" is returned.
+	// If 0, the size is only limited by the server-wide `goyave.Options.MaxHeaderBytes`.
+	MaxHeaderBytes int
+
+	// MaxBodyDepth the maximum nesting depth of the bodies decoded by the `Decoders`
+	// (e.g. JSON objects and arrays, XML elements). If exceeded, "400 Bad Request" is returned.
+	// If 0, the depth is only limited by the decoders themselves.
+	MaxBodyDepth int
+
+	// MaxFiles the maximum number of files in a multipart form.
+	// If exceeded, "413 Request Entity Too Large" is returned. If 0, the number of files is not limited.
+	MaxFiles int
+
+	// MaxQueryParameters the maximum number of query values.
+	// If exceeded, "400 Bad Request" is returned. If 0, the number of parameters is not limited.
+	MaxQueryParameters int
+
+	// StreamFiles if true, `multipart/form-data` requests are parsed while being read
+	// and files are written directly to the `FileStorage` instead of being held in memory.
+	StreamFiles bool
 }
 
 // Handle reads the request query and body and parses it if necessary.
@@ -45,9 +161,16 @@ type Middleware struct {
 // middleware immediately passes after parsing the query.
 func (m *Middleware) Handle(next goyave.Handler) goyave.Handler {
 	return func(response *goyave.Response, r *goyave.Request) {
-		if err := parseQuery(r); err != nil {
+		limits := m.getLimits(r)
+		if limits.MaxHeaderBytes > 0 && headerSize(r.Header()) > limits.MaxHeaderBytes {
+			response.Status(http.StatusRequestHeaderFieldsTooLarge)
+			r.Extra[goyave.ExtraParseError{}] = goyave.ErrHeadersTooLarge
+			return
+		}
+
+		if err := parseQuery(r, limits.MaxQueryParameters); err != nil {
 			response.Status(http.StatusBadRequest)
-			r.Extra[goyave.ExtraParseError{}] = fmt.Errorf("%w: %w", goyave.ErrInvalidQuery, err)
+			r.Extra[goyave.ExtraParseError{}] = err
 			return
 		}
 
@@ -58,8 +181,29 @@ func (m *Middleware) Handle(next goyave.Handler) goyave.Handler {
 
 		r.Data = nil
 		contentType := r.Header().Get("Content-Type")
-		if contentType != "" {
-			maxSize := int64(m.getMaxUploadSize() * 1024 * 1024)
+		if m.StreamFiles && strings.HasPrefix(contentType, "multipart/form-data") {
+			storage := m.getFileStorage()
+			data, files, err := m.parseMultipartStream(r, storage, limits)
+			defer removeFiles(storage, files)
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				switch {
+				case errors.As(err, &maxBytesErr) || errors.Is(err, errFileTooLarge):
+					response.Status(http.StatusRequestEntityTooLarge)
+					r.Extra[goyave.ExtraParseError{}] = fmt.Errorf("%w: %w", goyave.ErrBodyTooLarge, err)
+					return
+				case errors.Is(err, goyave.ErrTooManyFiles):
+					response.Status(http.StatusRequestEntityTooLarge)
+					r.Extra[goyave.ExtraParseError{}] = err
+					return
+				}
+				response.Status(http.StatusBadRequest)
+				r.Extra[goyave.ExtraParseError{}] = fmt.Errorf("%w: %w", goyave.ErrInvalidContentForType, err)
+				return
+			}
+			r.Data = data
+		} else if contentType != "" {
+			maxSize := int64(limits.MaxBodySize * 1024 * 1024)
 			maxValueBytes := maxSize
 			var bodyBuf bytes.Buffer
 			n, err := io.CopyN(&bodyBuf, r.Body(), maxValueBytes+1)
@@ -67,22 +211,37 @@ func (m *Middleware) Handle(next goyave.Handler) goyave.Handler {
 				maxValueBytes -= n
 				if maxValueBytes < 0 {
 					response.Status(http.StatusRequestEntityTooLarge)
+					r.Extra[goyave.ExtraParseError{}] = goyave.ErrBodyTooLarge
 					return
 				}
 
 				bodyBytes := bodyBuf.Bytes()
-				if strings.HasPrefix(contentType, "application/json") {
-					var body any
-					if err := json.Unmarshal(bodyBytes, &body); err != nil {
+				if decoder, mediaType := m.getDecoder(contentType); decoder != nil {
+					body, err := decoder.Decode(bodyBytes, limits.MaxBodyDepth)
+					if errors.Is(err, goyave.ErrBodyTooDeep) {
+						response.Status(http.StatusBadRequest)
+						r.Extra[goyave.ExtraParseError{}] = err
+						return
+					}
+					if err != nil {
+						parseErr := goyave.ErrInvalidBody
+						if mediaType == "application/json" {
+							parseErr = goyave.ErrInvalidJSONBody
+						}
 						response.Status(http.StatusBadRequest)
-						r.Extra[goyave.ExtraParseError{}] = fmt.Errorf("%w: %w", goyave.ErrInvalidJSONBody, err)
+						r.Extra[goyave.ExtraParseError{}] = fmt.Errorf("%w: %w", parseErr, err)
 					}
 					r.Data = body
 				} else {
 					req := r.Request()
 					req.Body = io.NopCloser(bytes.NewBuffer(bodyBytes))
-					r.Data, err = generateFlatMap(req, maxSize)
-					if err != nil {
+					r.Data, err = generateFlatMap(req, maxSize, limits.MaxFiles)
+					switch {
+					case errors.Is(err, goyave.ErrTooManyFiles):
+						response.Status(http.StatusRequestEntityTooLarge)
+						r.Extra[goyave.ExtraParseError{}] = err
+						return
+					case err != nil:
 						response.Status(http.StatusBadRequest)
 						r.Extra[goyave.ExtraParseError{}] = fmt.Errorf("%w: %w", goyave.ErrInvalidContentForType, err)
 					}
@@ -107,16 +266,295 @@ func (m *Middleware) getMaxUploadSize() float64 {
 	return m.MaxUploadSize
 }
 
-func parseQuery(request *goyave.Request) error {
+// getLimits returns the limits applicable to the given request: the middleware's
+// settings overridden by the `MetaLimits` meta of the matched route, if any.
+func (m *Middleware) getLimits(request *goyave.Request) Limits {
+	limits := Limits{
+		MaxBodySize:        m.getMaxUploadSize(),
+		MaxHeaderBytes:     m.MaxHeaderBytes,
+		MaxBodyDepth:       m.MaxBodyDepth,
+		MaxFiles:           m.MaxFiles,
+		MaxQueryParameters: m.MaxQueryParameters,
+	}
+	if request.Route == nil {
+		return limits
+	}
+	meta, ok := request.Route.LookupMeta(MetaLimits)
+	if !ok || meta == nil {
+		return limits
+	}
+	routeLimits, ok := meta.(*Limits)
+	if !ok {
+		panic(errorutil.NewSkip(fmt.Errorf("route meta %q is not a *parse.Limits", MetaLimits), 3))
+	}
+	if routeLimits == nil {
+		return limits
+	}
+	if routeLimits.MaxBodySize > 0 {
+		limits.MaxBodySize = routeLimits.MaxBodySize
+	}
+	limits.MaxHeaderBytes = overrideLimit(limits.MaxHeaderBytes, routeLimits.MaxHeaderBytes)
+	limits.MaxBodyDepth = overrideLimit(limits.MaxBodyDepth, routeLimits.MaxBodyDepth)
+	limits.MaxFiles = overrideLimit(limits.MaxFiles, routeLimits.MaxFiles)
+	limits.MaxQueryParameters = overrideLimit(limits.MaxQueryParameters, routeLimits.MaxQueryParameters)
+	return limits
+}
+
+func overrideLimit(limit, override int) int {
+	if override == 0 {
+		return limit
+	}
+	return override
+}
+
+// headerSize returns the size of the given header fields, counting the separator
+// and line ending of each field.
+func headerSize(header http.Header) int {
+	size := 0
+	for name, values := range header {
+		for _, v := range values {
+			size += len(name) + len(v) + 4 // ": " and CRLF
+		}
+	}
+	return size
+}
+
+// depthLimit returns the nesting depth enforced by the decoders for the given
+// `maxDepth` setting, never exceeding `maxDecodeDepth`.
+func depthLimit(maxDepth int) int {
+	if maxDepth <= 0 || maxDepth > maxDecodeDepth {
+		return maxDecodeDepth
+	}
+	return maxDepth
+}
+
+// exceedsJSONDepth returns true if the nesting depth of objects and arrays in the given
+// JSON document exceeds the given maximum. The document is not validated: this check
+// is meant to reject deeply nested documents before they are decoded.
+func exceedsJSONDepth(body []byte, maxDepth int) bool {
+	depth := 0
+	inString := false
+	escaped := false
+	for _, c := range body {
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			depth++
+			if depth > maxDepth {
+				return true
+			}
+		case '}', ']':
+			depth--
+		}
+	}
+	return false
+}
+
+// getDecoder returns the decoder matching the given content type and the media type it
+// has been matched with. Returns `nil` if no decoder can handle the content type.
+func (m *Middleware) getDecoder(contentType string) (Decoder, string) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, ""
+	}
+	decoders := m.Decoders
+	if decoders == nil {
+		decoders = DefaultDecoders
+	}
+
+	if decoder := findDecoder(decoders, mediaType); decoder != nil {
+		return decoder, mediaType
+	}
+	if i := strings.LastIndexByte(mediaType, '+'); i != -1 {
+		suffixType := "application/" + mediaType[i+1:]
+		if decoder := findDecoder(decoders, suffixType); decoder != nil {
+			return decoder, suffixType
+		}
+	}
+	return nil, ""
+}
+
+func findDecoder(decoders []Decoder, mediaType string) Decoder {
+	for _, d := range decoders {
+		if slices.Contains(d.MediaTypes(), mediaType) {
+			return d
+		}
+	}
+	return nil
+}
+
+func (m *Middleware) getFileStorage() fsutil.WritableFS {
+	if m.FileStorage == nil {
+		return osfs.New(os.TempDir())
+	}
+	return m.FileStorage
+}
+
+// parseMultipartStream reads the multipart form parts one by one and writes the files
+// to the given storage. The returned files must be removed by the caller, even if
+// an error is returned.
+func (m *Middleware) parseMultipartStream(r *goyave.Request, storage fsutil.WritableFS, limits Limits) (map[string]any, []fsutil.File, error) {
+	req := r.Request()
+	maxSize := int64(limits.MaxBodySize * 1024 * 1024)
+	req.Body = http.MaxBytesReader(nil, req.Body, maxSize)
+
+	reader, err := req.MultipartReader()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	maxFileSize := int64(m.MaxFileSize * 1024 * 1024)
+	values := url.Values{}
+	fileFields := map[string][]fsutil.File{}
+	allFiles := []fsutil.File{}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, allFiles, err
+		}
+
+		name := part.FormName()
+		if name == "" {
+			_ = part.Close()
+			continue
+		}
+
+		if part.FileName() == "" {
+			var value strings.Builder
+			_, err := io.Copy(&value, part)
+			_ = part.Close()
+			if err != nil {
+				return nil, allFiles, err
+			}
+			values.Add(name, value.String())
+			continue
+		}
+
+		if limits.MaxFiles > 0 && len(allFiles) >= limits.MaxFiles {
+			_ = part.Close()
+			return nil, allFiles, goyave.ErrTooManyFiles
+		}
+
+		file, err := streamFile(part, storage, maxFileSize)
+		_ = part.Close()
+		if file != nil {
+			allFiles = append(allFiles, *file)
+		}
+		if err != nil {
+			return nil, allFiles, err
+		}
+		fileFields[name] = append(fileFields[name], *file)
+	}
+
+	data := make(map[string]any, len(values)+len(fileFields))
+	if err := parseForm(data, values, fileFields); err != nil {
+		return nil, allFiles, err
+	}
+	return data, allFiles, nil
+}
+
+// streamFile writes the given file part to the storage. The MIME type is detected
+// using the first 512 bytes of the file, the same way `fsutil.ParseMultipartFiles()` does.
+// If the file could be created in the storage, it is returned even if an error occurred
+// so it can be cleaned up.
+func streamFile(part *multipart.Part, storage fsutil.WritableFS, maxFileSize int64) (*fsutil.File, error) {
+	var src io.Reader = part
+	if maxFileSize > 0 {
+		src = io.LimitReader(part, maxFileSize+1)
+	}
+
+	fileHeader := make([]byte, 512)
+	n, err := io.ReadFull(src, fileHeader)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, err
+	}
+
+	path := "goyave-upload-" + uuid.NewString()
+	writer, err := storage.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		return nil, err
+	}
+	file := &fsutil.File{
+		Header: &multipart.FileHeader{
+			Filename: part.FileName(),
+			Header:   part.Header,
+		},
+		MIMEType: http.DetectContentType(fileHeader),
+		Storage:  storage,
+		Path:     path,
+	}
+
+	size, err := writer.Write(fileHeader[:n])
+	file.Header.Size = int64(size)
+	if err == nil {
+		var copied int64
+		copied, err = io.Copy(writer, src)
+		file.Header.Size += copied
+	}
+	if closeErr := writer.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && maxFileSize > 0 && file.Header.Size > maxFileSize {
+		err = errFileTooLarge
+	}
+	return file, err
+}
+
+func removeFiles(storage fsutil.WritableFS, files []fsutil.File) {
+	removeFS, ok := storage.(fsutil.RemoveFS)
+	if !ok {
+		return
+	}
+	for _, f := range files {
+		_ = removeFS.Remove(f.Path)
+	}
+}
+
+// parseQuery parses the query of the given request and puts the result in its `Query`.
+// If the query contains more than the given maximum number of values, returns
+// `goyave.ErrTooManyQueryParameters`. The maximum is ignored if it is not strictly positive.
+func parseQuery(request *goyave.Request, maxParameters int) error {
 	queryParams, err := url.ParseQuery(request.URL().RawQuery)
 	if err == nil {
+		if maxParameters > 0 && countValues(queryParams) > maxParameters {
+			return goyave.ErrTooManyQueryParameters
+		}
 		request.Query = make(map[string]any, len(queryParams))
-		flatten(request.Query, queryParams)
+		err = parseForm(request.Query, queryParams, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %w", goyave.ErrInvalidQuery, err)
+	}
+	return nil
+}
+
+func countValues(values url.Values) int {
+	count := 0
+	for _, v := range values {
+		count += len(v)
 	}
-	return err
+	return count
 }
 
-func generateFlatMap(request *http.Request, maxSize int64) (map[string]any, error) {
+// generateFlatMap parses the form of the given request. If the multipart form
+// contains more than the given maximum number of files, returns `goyave.ErrTooManyFiles`.
+// The maximum is ignored if it is not strictly positive.
+func generateFlatMap(request *http.Request, maxSize int64, maxFiles int) (map[string]any, error) {
 	flatMap := make(map[string]any)
 	request.Form = url.Values{} // Prevent Form from being parsed because it would be redundant with our parsing
 	err := request.ParseMultipartForm(maxSize)
@@ -131,20 +569,26 @@ func generateFlatMap(request *http.Request, maxSize int64) (map[string]any, erro
 		}
 	}
 
-	if request.PostForm != nil {
-		flatten(flatMap, request.PostForm)
-	}
+	// PostForm also contains the values of the multipart form.
+	var files map[string][]fsutil.File
 	if request.MultipartForm != nil {
-		flatten(flatMap, request.MultipartForm.Value)
-
+		if maxFiles > 0 && countFiles(request.MultipartForm) > maxFiles {
+			_ = request.MultipartForm.RemoveAll()
+			request.MultipartForm = nil
+			return nil, goyave.ErrTooManyFiles
+		}
+		files = make(map[string][]fsutil.File, len(request.MultipartForm.File))
 		for field, headers := range request.MultipartForm.File {
-			files, err := fsutil.ParseMultipartFiles(headers)
+			f, err := fsutil.ParseMultipartFiles(headers)
 			if err != nil {
 				return nil, err
 			}
-			flatMap[field] = files
+			files[field] = f
 		}
 	}
+	if err := parseForm(flatMap, request.PostForm, files); err != nil {
+		return nil, err
+	}
 
 	// Source form is not needed anymore, clear it.
 	request.Form = nil
@@ -154,12 +598,10 @@ func generateFlatMap(request *http.Request, maxSize int64) (map[string]any, erro
 	return flatMap, nil
 }
 
-func flatten(dst map[string]any, values url.Values) {
-	for field, value := range values {
-		if len(value) > 1 {
-			dst[field] = value
-		} else {
-			dst[field] = value[0]
-		}
+func countFiles(form *multipart.Form) int {
+	count := 0
+	for _, headers := range form.File {
+		count += len(headers)
 	}
+	return count
 }
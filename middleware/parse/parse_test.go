@@ -2,14 +2,18 @@ package parse
 
 import (
 	"bytes"
+	"io"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/vmihailenco/msgpack/v5"
 	"goyave.dev/goyave/v5"
 	"goyave.dev/goyave/v5/config"
 	"goyave.dev/goyave/v5/util/fsutil"
@@ -67,6 +71,7 @@ func TestParseMiddleware(t *testing.T) {
 
 	t.Run("Entity Too Large", func(t *testing.T) {
 		request := testutil.NewTestRequest(http.MethodPost, "/parse", strings.NewReader(strings.Repeat("a", 1024*1024)))
+		request.Lang = server.Lang.GetDefault()
 		request.Header().Set("Content-Type", "application/octet-stream")
 
 		result := server.TestMiddleware(&Middleware{MaxUploadSize: 0.01}, request, func(_ *goyave.Response, _ *goyave.Request) {
@@ -146,6 +151,163 @@ func TestParseMiddleware(t *testing.T) {
 		}
 	})
 
+	t.Run("Decoders", func(t *testing.T) {
+		msgpackBody, err := msgpack.Marshal(map[string]any{"a": "b", "c": 1})
+		require.NoError(t, err)
+
+		cases := []struct {
+			expected    any
+			middleware  *Middleware
+			contentType string
+			body        []byte
+		}{
+			{
+				middleware:  &Middleware{},
+				contentType: "application/xml; charset=utf-8",
+				body:        []byte(`<root><a>b</a><c>d</c></root>`),
+				expected:    map[string]any{"a": "b", "c": "d"},
+			},
+			{
+				middleware:  &Middleware{},
+				contentType: "text/xml",
+				body:        []byte(`<root><a>b</a></root>`),
+				expected:    map[string]any{"a": "b"},
+			},
+			{
+				middleware:  &Middleware{},
+				contentType: "application/msgpack",
+				body:        msgpackBody,
+				expected:    map[string]any{"a": "b", "c": int64(1)},
+			},
+			{
+				middleware:  &Middleware{},
+				contentType: "application/vnd.api+json",
+				body:        []byte(`{"a":"b"}`),
+				expected:    map[string]any{"a": "b"},
+			},
+			{
+				middleware:  &Middleware{},
+				contentType: "application/atom+xml",
+				body:        []byte(`<feed><title>t</title></feed>`),
+				expected:    map[string]any{"title": "t"},
+			},
+			{
+				// Custom decoders replace the defaults
+				middleware:  &Middleware{Decoders: []Decoder{&XML{}}},
+				contentType: "application/json",
+				body:        []byte(`a=b`),
+				expected:    map[string]any{},
+			},
+		}
+
+		for _, c := range cases {
+			t.Run(c.contentType, func(t *testing.T) {
+				request := testutil.NewTestRequest(http.MethodPost, "/parse", bytes.NewReader(c.body))
+				request.Header().Set("Content-Type", c.contentType)
+
+				result := server.TestMiddleware(c.middleware, request, func(resp *goyave.Response, req *goyave.Request) {
+					assert.Equal(t, c.expected, req.Data)
+					resp.Status(http.StatusOK)
+				})
+				assert.NoError(t, result.Body.Close())
+				assert.Equal(t, http.StatusOK, result.StatusCode)
+			})
+		}
+	})
+
+	t.Run("Invalid XML", func(t *testing.T) {
+		request := testutil.NewTestRequest(http.MethodPost, "/parse", strings.NewReader("<unclosed>"))
+		request.Lang = server.Lang.GetDefault()
+		request.Header().Set("Content-Type", "application/xml")
+
+		result := server.TestMiddleware(&Middleware{}, request, func(_ *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "Middleware should not pass")
+		})
+
+		assert.NoError(t, result.Body.Close())
+		assert.Equal(t, http.StatusBadRequest, result.StatusCode)
+		assert.NotPanics(t, func() {
+			extraError, ok := request.Extra[goyave.ExtraParseError{}].(error)
+			require.True(t, ok)
+			assert.ErrorIs(t, extraError, goyave.ErrInvalidBody)
+		})
+	})
+
+	t.Run("Too deep MessagePack", func(t *testing.T) {
+		body := bytes.Repeat([]byte{0x91}, 1024*1024)
+		request := testutil.NewTestRequest(http.MethodPost, "/parse", bytes.NewReader(body))
+		request.Lang = server.Lang.GetDefault()
+		request.Header().Set("Content-Type", "application/msgpack")
+
+		result := server.TestMiddleware(&Middleware{}, request, func(_ *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "Middleware should not pass")
+		})
+
+		assert.NoError(t, result.Body.Close())
+		assert.Equal(t, http.StatusBadRequest, result.StatusCode)
+		extraError, ok := request.Extra[goyave.ExtraParseError{}].(error)
+		require.True(t, ok)
+		assert.ErrorIs(t, extraError, goyave.ErrBodyTooDeep)
+	})
+
+	t.Run("MessagePack trailing data", func(t *testing.T) {
+		request := testutil.NewTestRequest(http.MethodPost, "/parse", bytes.NewReader([]byte{0x91, 0x01, 0x02}))
+		request.Lang = server.Lang.GetDefault()
+		request.Header().Set("Content-Type", "application/msgpack")
+
+		result := server.TestMiddleware(&Middleware{}, request, func(_ *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "Middleware should not pass")
+		})
+
+		assert.NoError(t, result.Body.Close())
+		assert.Equal(t, http.StatusBadRequest, result.StatusCode)
+		extraError, ok := request.Extra[goyave.ExtraParseError{}].(error)
+		require.True(t, ok)
+		assert.ErrorIs(t, extraError, goyave.ErrInvalidBody)
+	})
+
+	t.Run("Bracket Notation", func(t *testing.T) {
+		data := "user[name]=John&user[tags][]=a&user[tags][]=b"
+
+		request := testutil.NewTestRequest(http.MethodPost, "/parse?filter[name]=John&sort[]=name&sort[]=id", strings.NewReader(data))
+		request.Header().Set("Content-Type", "application/x-www-form-urlencoded")
+
+		result := server.TestMiddleware(&Middleware{}, request, func(resp *goyave.Response, req *goyave.Request) {
+			expectedQuery := map[string]any{
+				"filter": map[string]any{"name": "John"},
+				"sort":   []any{"name", "id"},
+			}
+			assert.Equal(t, expectedQuery, req.Query)
+			expected := map[string]any{
+				"user": map[string]any{
+					"name": "John",
+					"tags": []any{"a", "b"},
+				},
+			}
+			assert.Equal(t, expected, req.Data)
+			resp.Status(http.StatusOK)
+		})
+
+		assert.NoError(t, result.Body.Close())
+		assert.Equal(t, http.StatusOK, result.StatusCode)
+	})
+
+	t.Run("Bracket Notation Conflict", func(t *testing.T) {
+		request := testutil.NewTestRequest(http.MethodGet, "/parse?a=1&a[b]=2", nil)
+		request.Lang = server.Lang.GetDefault()
+
+		result := server.TestMiddleware(&Middleware{}, request, func(_ *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "Middleware should not pass")
+		})
+		assert.NoError(t, result.Body.Close())
+		assert.Equal(t, http.StatusBadRequest, result.StatusCode)
+		assert.NotPanics(t, func() {
+			extraError, ok := request.Extra[goyave.ExtraParseError{}].(error)
+			require.True(t, ok)
+			assert.ErrorIs(t, extraError, goyave.ErrInvalidQuery)
+		})
+	})
+
 	t.Run("Multipart", func(t *testing.T) {
 		body := &bytes.Buffer{}
 		writer := multipart.NewWriter(body)
@@ -183,6 +345,137 @@ func TestParseMiddleware(t *testing.T) {
 		assert.Equal(t, http.StatusOK, result.StatusCode)
 	})
 
+	t.Run("Multipart Stream", func(t *testing.T) {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		require.NoError(t, testutil.WriteMultipartFile(writer, &osfs.FS{}, "../../resources/img/logo/goyave_16.png", "profile_picture", "goyave_16.png"))
+		require.NoError(t, testutil.WriteMultipartFile(writer, &osfs.FS{}, "../../resources/test_file.txt", "attachments", "test_file.txt"))
+		require.NoError(t, testutil.WriteMultipartFile(writer, &osfs.FS{}, "../../resources/img/logo/goyave_16.png", "attachments", "goyave_16.png"))
+		require.NoError(t, writer.WriteField("email", "johndoe@example.org"))
+		require.NoError(t, writer.WriteField("tags", "a"))
+		require.NoError(t, writer.WriteField("tags", "b"))
+		require.NoError(t, writer.Close())
+
+		request := testutil.NewTestRequest(http.MethodPost, "/parse", body)
+		request.Header().Set("Content-Type", writer.FormDataContentType())
+
+		dir := t.TempDir()
+		storage := osfs.New(dir)
+		var paths []string
+		result := server.TestMiddleware(&Middleware{StreamFiles: true, FileStorage: storage}, request, func(resp *goyave.Response, req *goyave.Request) {
+			data, ok := req.Data.(map[string]any)
+			if !assert.True(t, ok) {
+				return
+			}
+			assert.Equal(t, "johndoe@example.org", data["email"])
+			assert.Equal(t, []string{"a", "b"}, data["tags"])
+
+			picture, ok := data["profile_picture"].([]fsutil.File)
+			if !assert.True(t, ok) || !assert.Len(t, picture, 1) {
+				return
+			}
+			assert.Equal(t, "image/png", picture[0].MIMEType)
+			assert.Equal(t, "goyave_16.png", picture[0].Header.Filename)
+			assert.Equal(t, int64(630), picture[0].Header.Size)
+			assert.Equal(t, storage, picture[0].Storage)
+			assert.FileExists(t, filepath.Join(dir, picture[0].Path))
+
+			f, err := picture[0].Open()
+			require.NoError(t, err)
+			content, err := io.ReadAll(f)
+			require.NoError(t, err)
+			assert.NoError(t, f.Close())
+			expected, err := os.ReadFile("../../resources/img/logo/goyave_16.png")
+			require.NoError(t, err)
+			assert.Equal(t, expected, content)
+
+			attachments, ok := data["attachments"].([]fsutil.File)
+			if !assert.True(t, ok) || !assert.Len(t, attachments, 2) {
+				return
+			}
+			assert.Equal(t, "text/plain; charset=utf-8", attachments[0].MIMEType)
+			assert.Equal(t, "test_file.txt", attachments[0].Header.Filename)
+			assert.Equal(t, "image/png", attachments[1].MIMEType)
+
+			paths = []string{picture[0].Path, attachments[0].Path, attachments[1].Path}
+			resp.Status(http.StatusOK)
+		})
+
+		assert.NoError(t, result.Body.Close())
+		assert.Equal(t, http.StatusOK, result.StatusCode)
+
+		// Files are removed at the end of the request
+		require.Len(t, paths, 3)
+		for _, p := range paths {
+			assert.NoFileExists(t, filepath.Join(dir, p))
+		}
+	})
+
+	t.Run("Multipart Stream Default Storage", func(t *testing.T) {
+		m := &Middleware{StreamFiles: true}
+		m.Init(server.Server)
+		assert.Equal(t, osfs.New(os.TempDir()), m.getFileStorage())
+	})
+
+	t.Run("Multipart Stream File Too Large", func(t *testing.T) {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		require.NoError(t, testutil.WriteMultipartFile(writer, &osfs.FS{}, "../../resources/img/logo/goyave_16.png", "profile_picture", "goyave_16.png"))
+		require.NoError(t, writer.Close())
+
+		request := testutil.NewTestRequest(http.MethodPost, "/parse", body)
+		request.Lang = server.Lang.GetDefault()
+		request.Header().Set("Content-Type", writer.FormDataContentType())
+
+		dir := t.TempDir()
+		result := server.TestMiddleware(&Middleware{StreamFiles: true, FileStorage: osfs.New(dir), MaxFileSize: 0.0001}, request, func(_ *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "Middleware should not pass")
+		})
+		assert.NoError(t, result.Body.Close())
+		assert.Equal(t, http.StatusRequestEntityTooLarge, result.StatusCode)
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("Multipart Stream Entity Too Large", func(t *testing.T) {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		require.NoError(t, writer.WriteField("field", strings.Repeat("a", 1024*1024)))
+		require.NoError(t, writer.Close())
+
+		request := testutil.NewTestRequest(http.MethodPost, "/parse", body)
+		request.Lang = server.Lang.GetDefault()
+		request.Header().Set("Content-Type", writer.FormDataContentType())
+
+		result := server.TestMiddleware(&Middleware{StreamFiles: true, FileStorage: osfs.New(t.TempDir()), MaxUploadSize: 0.01}, request, func(_ *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "Middleware should not pass")
+		})
+		assert.NoError(t, result.Body.Close())
+		assert.Equal(t, http.StatusRequestEntityTooLarge, result.StatusCode)
+	})
+
+	t.Run("Invalid Multipart Stream", func(t *testing.T) {
+		writer := multipart.NewWriter(nil)
+
+		request := testutil.NewTestRequest(http.MethodPost, "/parse", strings.NewReader("invalid"))
+		request.Lang = server.Lang.GetDefault()
+		request.Header().Set("Content-Type", writer.FormDataContentType())
+
+		result := server.TestMiddleware(&Middleware{StreamFiles: true, FileStorage: osfs.New(t.TempDir())}, request, func(_ *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "Middleware should not pass")
+		})
+
+		assert.NoError(t, result.Body.Close())
+		assert.Equal(t, http.StatusBadRequest, result.StatusCode)
+		assert.NotPanics(t, func() {
+			extraError, ok := request.Extra[goyave.ExtraParseError{}].(error)
+			require.True(t, ok)
+			assert.ErrorIs(t, extraError, goyave.ErrInvalidContentForType)
+		})
+	})
+
 	t.Run("Invalid Multipart", func(t *testing.T) {
 		// Write empty body, which is not allowed for content multipart.
 		writer := multipart.NewWriter(nil)
@@ -293,3 +586,176 @@ func TestParseMiddleware(t *testing.T) {
 		assert.NoError(t, result.Body.Close())
 	})
 }
+
+func TestParseLimits(t *testing.T) {
+	server := testutil.NewTestServerWithOptions(t, goyave.Options{Config: config.LoadDefault()})
+	lang := server.Lang.GetDefault()
+
+	newRequest := func(method, uri string, body io.Reader, limits *Limits) *goyave.Request {
+		request := server.NewTestRequest(method, uri, body)
+		request.Route = &goyave.Route{Meta: map[string]any{MetaLimits: limits}}
+		return request
+	}
+
+	testError := func(t *testing.T, m *Middleware, request *goyave.Request, expectedStatus int, expectedMessage string) {
+		result := server.TestMiddleware(m, request, func(_ *goyave.Response, _ *goyave.Request) {
+			assert.Fail(t, "Middleware should not pass")
+		})
+		body, err := testutil.ReadJSONBody[map[string]string](result.Body)
+		assert.NoError(t, result.Body.Close())
+		require.NoError(t, err)
+		assert.Equal(t, expectedStatus, result.StatusCode)
+		assert.Equal(t, map[string]string{"error": lang.Get(expectedMessage)}, body)
+	}
+
+	testPass := func(t *testing.T, m *Middleware, request *goyave.Request) {
+		result := server.TestMiddleware(m, request, func(resp *goyave.Response, _ *goyave.Request) {
+			resp.Status(http.StatusNoContent)
+		})
+		assert.NoError(t, result.Body.Close())
+		assert.Equal(t, http.StatusNoContent, result.StatusCode)
+	}
+
+	multipartBody := func(t *testing.T, files int) (*bytes.Buffer, string) {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		for i := 0; i < files; i++ {
+			require.NoError(t, testutil.WriteMultipartFile(writer, &osfs.FS{}, "../../resources/test_file.txt", "attachments", "test_file.txt"))
+		}
+		require.NoError(t, writer.WriteField("email", "johndoe@example.org"))
+		require.NoError(t, writer.Close())
+		return body, writer.FormDataContentType()
+	}
+
+	t.Run("getLimits", func(t *testing.T) {
+		m := &Middleware{MaxHeaderBytes: 100, MaxBodyDepth: 10, MaxFiles: 5, MaxQueryParameters: 20}
+		m.Init(server.Server)
+
+		request := server.NewTestRequest(http.MethodGet, "/parse", nil)
+		assert.Equal(t, Limits{MaxBodySize: 10, MaxHeaderBytes: 100, MaxBodyDepth: 10, MaxFiles: 5, MaxQueryParameters: 20}, m.getLimits(request))
+
+		request.Route = &goyave.Route{Meta: map[string]any{}}
+		assert.Equal(t, Limits{MaxBodySize: 10, MaxHeaderBytes: 100, MaxBodyDepth: 10, MaxFiles: 5, MaxQueryParameters: 20}, m.getLimits(request))
+
+		request = newRequest(http.MethodGet, "/parse", nil, &Limits{MaxBodySize: 1, MaxBodyDepth: 3, MaxFiles: -1})
+		assert.Equal(t, Limits{MaxBodySize: 1, MaxHeaderBytes: 100, MaxBodyDepth: 3, MaxFiles: -1, MaxQueryParameters: 20}, m.getLimits(request))
+
+		request = newRequest(http.MethodGet, "/parse", nil, &Limits{MaxBodySize: -1})
+		assert.InEpsilon(t, 10.0, m.getLimits(request).MaxBodySize, 0)
+
+		request.Route.Meta[MetaLimits] = nil
+		assert.Equal(t, Limits{MaxBodySize: 10, MaxHeaderBytes: 100, MaxBodyDepth: 10, MaxFiles: 5, MaxQueryParameters: 20}, m.getLimits(request))
+
+		request.Route.Meta[MetaLimits] = Limits{}
+		assert.Panics(t, func() {
+			m.getLimits(request)
+		})
+	})
+
+	t.Run("headers_too_large", func(t *testing.T) {
+		request := newRequest(http.MethodGet, "/parse", nil, &Limits{MaxHeaderBytes: 50})
+		request.Header().Set("X-Large", strings.Repeat("a", 50))
+		testError(t, &Middleware{}, request, http.StatusRequestHeaderFieldsTooLarge, "parse.headers-too-large")
+
+		request = newRequest(http.MethodGet, "/parse", nil, &Limits{MaxHeaderBytes: 50})
+		request.Header().Set("X-Small", "a")
+		testPass(t, &Middleware{}, request)
+	})
+
+	t.Run("too_many_query_parameters", func(t *testing.T) {
+		request := newRequest(http.MethodGet, "/parse?a=1&a=2&b=3", nil, &Limits{MaxQueryParameters: 2})
+		testError(t, &Middleware{}, request, http.StatusBadRequest, "parse.too-many-query-parameters")
+
+		request = newRequest(http.MethodGet, "/parse?a=1&b=2", nil, &Limits{MaxQueryParameters: 2})
+		testPass(t, &Middleware{}, request)
+
+		request = newRequest(http.MethodGet, "/parse?a=1&a=2&b=3", nil, &Limits{MaxQueryParameters: -1})
+		testPass(t, &Middleware{MaxQueryParameters: 1}, request)
+	})
+
+	t.Run("body_too_deep", func(t *testing.T) {
+		request := newRequest(http.MethodPost, "/parse", strings.NewReader(`{"a":{"b":[{"c":1}]}}`), nil)
+		request.Header().Set("Content-Type", "application/json")
+		testError(t, &Middleware{MaxBodyDepth: 3}, request, http.StatusBadRequest, "parse.body-too-deep")
+
+		request = newRequest(http.MethodPost, "/parse", strings.NewReader(`{"a":{"b":"[[{{\"[{"}}`), nil)
+		request.Header().Set("Content-Type", "application/vnd.api+json")
+		testPass(t, &Middleware{MaxBodyDepth: 2}, request)
+
+		request = newRequest(http.MethodPost, "/parse", strings.NewReader(`[[[[1]]]]`), &Limits{MaxBodyDepth: -1})
+		request.Header().Set("Content-Type", "application/json")
+		testPass(t, &Middleware{MaxBodyDepth: 2}, request)
+
+		request = newRequest(http.MethodPost, "/parse", strings.NewReader(`<a><b><c><d>1</d></c></b></a>`), nil)
+		request.Header().Set("Content-Type", "application/xml")
+		testError(t, &Middleware{MaxBodyDepth: 2}, request, http.StatusBadRequest, "parse.body-too-deep")
+
+		request = newRequest(http.MethodPost, "/parse", bytes.NewReader([]byte{0x91, 0x91, 0x91, 0x01}), &Limits{MaxBodyDepth: 2})
+		request.Header().Set("Content-Type", "application/msgpack")
+		testError(t, &Middleware{}, request, http.StatusBadRequest, "parse.body-too-deep")
+
+		request = newRequest(http.MethodPost, "/parse", bytes.NewReader([]byte{0x91, 0x91, 0x01}), &Limits{MaxBodyDepth: 2})
+		request.Header().Set("Content-Type", "application/msgpack")
+		testPass(t, &Middleware{}, request)
+	})
+
+	t.Run("body_too_large", func(t *testing.T) {
+		request := newRequest(http.MethodPost, "/parse", strings.NewReader(strings.Repeat("a", 1024*1024)), &Limits{MaxBodySize: 0.01})
+		request.Header().Set("Content-Type", "application/octet-stream")
+		testError(t, &Middleware{}, request, http.StatusRequestEntityTooLarge, "parse.body-too-large")
+
+		body, contentType := multipartBody(t, 1)
+		request = newRequest(http.MethodPost, "/parse", body, &Limits{MaxBodySize: 0.0001})
+		request.Header().Set("Content-Type", contentType)
+		testError(t, &Middleware{StreamFiles: true, FileStorage: osfs.New(t.TempDir())}, request, http.StatusRequestEntityTooLarge, "parse.body-too-large")
+	})
+
+	t.Run("too_many_files", func(t *testing.T) {
+		body, contentType := multipartBody(t, 3)
+		request := newRequest(http.MethodPost, "/parse", body, &Limits{MaxFiles: 2})
+		request.Header().Set("Content-Type", contentType)
+		testError(t, &Middleware{}, request, http.StatusRequestEntityTooLarge, "parse.too-many-files")
+
+		body, contentType = multipartBody(t, 2)
+		request = newRequest(http.MethodPost, "/parse", body, &Limits{MaxFiles: 2})
+		request.Header().Set("Content-Type", contentType)
+		testPass(t, &Middleware{}, request)
+	})
+
+	t.Run("too_many_files_stream", func(t *testing.T) {
+		dir := t.TempDir()
+		body, contentType := multipartBody(t, 3)
+		request := newRequest(http.MethodPost, "/parse", body, nil)
+		request.Header().Set("Content-Type", contentType)
+		testError(t, &Middleware{StreamFiles: true, FileStorage: osfs.New(dir), MaxFiles: 2}, request, http.StatusRequestEntityTooLarge, "parse.too-many-files")
+
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+
+		body, contentType = multipartBody(t, 2)
+		request = newRequest(http.MethodPost, "/parse", body, nil)
+		request.Header().Set("Content-Type", contentType)
+		testPass(t, &Middleware{StreamFiles: true, FileStorage: osfs.New(dir), MaxFiles: 2}, request)
+	})
+}
+
+func TestExceedsJSONDepth(t *testing.T) {
+	cases := []struct {
+		body     string
+		maxDepth int
+		want     bool
+	}{
+		{body: `1`, maxDepth: 1, want: false},
+		{body: `{}`, maxDepth: 1, want: false},
+		{body: `{"a":[]}`, maxDepth: 1, want: true},
+		{body: `{"a":[]}`, maxDepth: 2, want: false},
+		{body: `[{},{},[]]`, maxDepth: 2, want: false},
+		{body: `{"a":"[[[[{{{{"}`, maxDepth: 1, want: false},
+		{body: `{"a":"\"[[[\\"}`, maxDepth: 1, want: false},
+		{body: `{"a\\":[[1]]}`, maxDepth: 2, want: true},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.want, exceedsJSONDepth([]byte(c.body), c.maxDepth), c.body)
+	}
+}
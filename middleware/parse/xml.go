@@ -0,0 +1,104 @@
+package parse
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+
+	"goyave.dev/goyave/v5"
+)
+
+// XML decoder for the "application/xml" and "text/xml" media types using Go's
+// standard `encoding/xml` package.
+//
+// The root element is decoded as the body itself, its name is discarded.
+// Elements containing other elements or attributes are decoded as `map[string]any`.
+// Attributes are stored in this map with their name prefixed with "@". If such
+// an element also contains text, the text is stored with the "#text" key.
+// Elements without children nor attributes are decoded as `string`. If an
+// element contains several children with the same name, they are grouped in a `[]any`.
+// The depth of a document is the nesting depth of its elements, the root element
+// excluded. Documents nested deeper than 10000 elements are always rejected.
+//
+// Example:
+//
+//	<user id="1"><name>John</name><tag>a</tag><tag>b</tag></user>
+//
+// is decoded as:
+//
+//	map[string]any{"@id": "1", "name": "John", "tag": []any{"a", "b"}}
+type XML struct{}
+
+// MediaTypes returns "application/xml" and "text/xml".
+func (d *XML) MediaTypes() []string {
+	return []string{"application/xml", "text/xml"}
+}
+
+// Decode parses the given XML body.
+func (d *XML) Decode(body []byte, maxDepth int) (any, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err == io.EOF {
+				return nil, errors.New("missing XML root element")
+			}
+			return nil, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return decodeXMLElement(decoder, start, 0, depthLimit(maxDepth))
+		}
+	}
+}
+
+func decodeXMLElement(decoder *xml.Decoder, start xml.StartElement, depth, maxDepth int) (any, error) {
+	if depth > maxDepth {
+		return nil, goyave.ErrBodyTooDeep
+	}
+	var object map[string]any
+	if len(start.Attr) > 0 {
+		object = make(map[string]any, len(start.Attr))
+		for _, attr := range start.Attr {
+			object["@"+attr.Name.Local] = attr.Value
+		}
+	}
+
+	var text strings.Builder
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			child, err := decodeXMLElement(decoder, t, depth+1, maxDepth)
+			if err != nil {
+				return nil, err
+			}
+			if object == nil {
+				object = map[string]any{}
+			}
+			name := t.Name.Local
+			switch existing := object[name].(type) {
+			case nil:
+				object[name] = child
+			case []any:
+				object[name] = append(existing, child)
+			default:
+				object[name] = []any{existing, child}
+			}
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if object == nil {
+				return text.String(), nil
+			}
+			if str := strings.TrimSpace(text.String()); str != "" {
+				object["#text"] = str
+			}
+			return object, nil
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package parse
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5"
+)
+
+func TestXMLDecoder(t *testing.T) {
+	decoder := &XML{}
+	assert.Equal(t, []string{"application/xml", "text/xml"}, decoder.MediaTypes())
+
+	cases := []struct {
+		expected any
+		desc     string
+		body     string
+		wantErr  bool
+	}{
+		{
+			desc: "object",
+			body: `<?xml version="1.0" encoding="UTF-8"?>
+<!-- comment -->
+<user id="1">
+	<name>John</name>
+	<address><city>Paris</city></address>
+	<tag>a</tag>
+	<tag>b</tag>
+	<tag>c</tag>
+	<empty/>
+</user>`,
+			expected: map[string]any{
+				"@id":     "1",
+				"name":    "John",
+				"address": map[string]any{"city": "Paris"},
+				"tag":     []any{"a", "b", "c"},
+				"empty":   "",
+			},
+		},
+		{
+			desc:     "text_and_attributes",
+			body:     `<root><price currency="EUR"> 12.5 </price></root>`,
+			expected: map[string]any{"price": map[string]any{"@currency": "EUR", "#text": "12.5"}},
+		},
+		{
+			desc:     "leaf_root",
+			body:     `<root> value </root>`,
+			expected: " value ",
+		},
+		{desc: "empty", body: ``, wantErr: true},
+		{desc: "unclosed", body: `<root><a>b</a>`, wantErr: true},
+		{desc: "invalid", body: `<root><a>b</c></root>`, wantErr: true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			data, err := decoder.Decode([]byte(c.body), 0)
+			if c.wantErr {
+				require.Error(t, err)
+				assert.Nil(t, data)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, c.expected, data)
+		})
+	}
+
+	t.Run("max_depth", func(t *testing.T) {
+		body := strings.Repeat("<a>", maxDecodeDepth+1) + strings.Repeat("</a>", maxDecodeDepth+1)
+		_, err := decoder.Decode([]byte(body), 0)
+		require.NoError(t, err)
+
+		body = strings.Repeat("<a>", maxDecodeDepth+2) + strings.Repeat("</a>", maxDecodeDepth+2)
+		data, err := decoder.Decode([]byte(body), 0)
+		require.ErrorIs(t, err, goyave.ErrBodyTooDeep)
+		assert.Nil(t, data)
+
+		body = `<user><address><city>Paris</city></address></user>`
+		data, err = decoder.Decode([]byte(body), 2)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]any{"address": map[string]any{"city": "Paris"}}, data)
+		_, err = decoder.Decode([]byte(body), 1)
+		require.ErrorIs(t, err, goyave.ErrBodyTooDeep)
+		_, err = decoder.Decode([]byte(body), maxDecodeDepth+1)
+		require.NoError(t, err)
+	})
+}
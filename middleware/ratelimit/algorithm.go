@@ -0,0 +1,139 @@
+package ratelimit
+
+import (
+	"math"
+	"time"
+)
+
+// State the persisted state of a rate limiter for a single key. The meaning of
+// the fields depends on the `Algorithm` using it.
+type State struct {
+	// Timestamp the time of the last refill for the token bucket, or the start
+	// of the current window for the sliding window.
+	Timestamp time.Time
+
+	// Value the amount of tokens left for the token bucket, or the amount of requests
+	// in the current window for the sliding window.
+	Value float64
+
+	// Previous the amount of requests in the previous window for the sliding window.
+	// Unused by the token bucket.
+	Previous float64
+}
+
+// Result the outcome of a rate limiter check.
+type Result struct {
+	// RetryAfter the minimum duration after which the client can retry the request.
+	// Zero if the request is allowed.
+	RetryAfter time.Duration
+
+	// Reset the duration after which the limit is completely reset.
+	Reset time.Duration
+
+	// Remaining the amount of requests the client can still make.
+	Remaining int
+
+	// Allowed true if the request is allowed.
+	Allowed bool
+}
+
+// Algorithm is an interface implemented by rate limiting algorithms.
+//
+// `Allow` consumes one request from the given state (if possible), updates it
+// and returns the result. The state is the zero value if the key is new.
+// The given state is persisted by the `Store` after this function returns.
+type Algorithm interface {
+	Allow(state *State, limit *Limit, now time.Time) Result
+}
+
+// TokenBucket rate limiting algorithm. The bucket holds at most `Limit.Requests` tokens
+// and is refilled at a constant rate of `Limit.Requests` per `Limit.Period`.
+// Each request consumes a token. This algorithm allows bursts up to the bucket capacity.
+type TokenBucket struct{}
+
+// Allow consumes a token from the bucket if there is one left.
+func (TokenBucket) Allow(state *State, limit *Limit, now time.Time) Result {
+	capacity := float64(limit.Requests)
+	rate := capacity / float64(limit.Period) // Tokens per nanosecond
+
+	if state.Timestamp.IsZero() {
+		state.Value = capacity
+	} else if elapsed := now.Sub(state.Timestamp); elapsed > 0 {
+		state.Value = math.Min(capacity, state.Value+float64(elapsed)*rate)
+	}
+	state.Timestamp = now
+
+	result := Result{}
+	if state.Value >= 1 {
+		state.Value--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration(math.Ceil((1 - state.Value) / rate))
+	}
+	result.Remaining = int(state.Value)
+	result.Reset = time.Duration(math.Ceil((capacity - state.Value) / rate))
+	return result
+}
+
+// SlidingWindow rate limiting algorithm using the sliding window counter approximation.
+// The amount of requests in the window is estimated by weighting the count of the
+// previous fixed window by the portion of the period it still overlaps with the sliding window.
+// This algorithm smoothes the traffic better than a fixed window and doesn't allow bursts
+// at the edges of the windows.
+type SlidingWindow struct{}
+
+// Allow counts the request in the current window if the estimated amount of requests
+// in the sliding window is below the limit.
+func (SlidingWindow) Allow(state *State, limit *Limit, now time.Time) Result {
+	period := limit.Period
+	windowStart := now.Truncate(period)
+	switch {
+	case state.Timestamp.Equal(windowStart):
+	case state.Timestamp.Add(period).Equal(windowStart):
+		state.Previous = state.Value
+		state.Value = 0
+	default:
+		state.Previous = 0
+		state.Value = 0
+	}
+	state.Timestamp = windowStart
+
+	elapsed := now.Sub(windowStart)
+	weight := float64(period-elapsed) / float64(period)
+	count := state.Previous*weight + state.Value
+	max := float64(limit.Requests)
+
+	result := Result{}
+	if count+1 <= max {
+		state.Value++
+		count++
+		result.Allowed = true
+	} else {
+		result.RetryAfter = slidingWindowRetryAfter(state, max, period, elapsed)
+	}
+	result.Remaining = int(math.Max(0, math.Floor(max-count)))
+	result.Reset = 2*period - elapsed
+	if state.Previous == 0 {
+		result.Reset = period - elapsed
+	}
+	return result
+}
+
+// slidingWindowRetryAfter computes the duration after which the estimated count
+// will allow one more request.
+func slidingWindowRetryAfter(state *State, max float64, period, elapsed time.Duration) time.Duration {
+	if state.Previous > 0 && state.Value+1 <= max {
+		// Wait until the weight of the previous window decreases enough:
+		// previous * (period - t) / period + value + 1 <= max
+		t := float64(period) - (max-state.Value-1)*float64(period)/state.Previous
+		return time.Duration(math.Ceil(t)) - elapsed
+	}
+	// The current window is full, wait for the next window.
+	nextRetry := period - elapsed
+	if state.Value > 0 && state.Value+1 > max {
+		// In the next window, the current window becomes the previous one.
+		t := float64(period) - (max-1)*float64(period)/state.Value
+		nextRetry += time.Duration(math.Ceil(math.Max(0, t)))
+	}
+	return nextRetry
+}
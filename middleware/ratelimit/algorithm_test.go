@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	limit := &Limit{Requests: 3, Period: 3 * time.Second}
+	algorithm := TokenBucket{}
+	state := &State{}
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 2; i >= 0; i-- {
+		result := algorithm.Allow(state, limit, now)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, i, result.Remaining)
+		assert.Zero(t, result.RetryAfter)
+	}
+
+	result := algorithm.Allow(state, limit, now)
+	assert.Equal(t, Result{Allowed: false, Remaining: 0, RetryAfter: time.Second, Reset: 3 * time.Second}, result)
+
+	// Half a token refilled
+	now = now.Add(500 * time.Millisecond)
+	result = algorithm.Allow(state, limit, now)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+
+	now = now.Add(500 * time.Millisecond)
+	result = algorithm.Allow(state, limit, now)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// Refill doesn't exceed capacity
+	now = now.Add(time.Hour)
+	result = algorithm.Allow(state, limit, now)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 2, result.Remaining)
+	assert.Equal(t, time.Second, result.Reset)
+	assert.Equal(t, now, state.Timestamp)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	limit := &Limit{Requests: 4, Period: 10 * time.Second}
+	algorithm := SlidingWindow{}
+	state := &State{}
+	start := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	now := start.Add(5 * time.Second)
+
+	for i := 3; i >= 0; i-- {
+		result := algorithm.Allow(state, limit, now)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, i, result.Remaining)
+		assert.Equal(t, 5*time.Second, result.Reset)
+	}
+	assert.Equal(t, State{Timestamp: start, Value: 4}, *state)
+
+	result := algorithm.Allow(state, limit, now)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	// Next window starts in 5s, then the previous window weighs 4*(10-t)/10 and must be <= 3.
+	assert.Equal(t, 5*time.Second+2500*time.Millisecond, result.RetryAfter)
+
+	t.Run("next_window", func(t *testing.T) {
+		state := &State{Timestamp: start, Value: 4}
+		now := start.Add(12500 * time.Millisecond)
+		result := algorithm.Allow(state, limit, now)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, State{Timestamp: start.Add(10 * time.Second), Value: 1, Previous: 4}, *state)
+		assert.Equal(t, 0, result.Remaining)
+		assert.Equal(t, 17500*time.Millisecond, result.Reset)
+
+		now = start.Add(13 * time.Second)
+		result = algorithm.Allow(state, limit, now)
+		assert.False(t, result.Allowed)
+		// 4*(10-t)/10 + 1 + 1 <= 4 --> t >= 5
+		assert.Equal(t, 2*time.Second, result.RetryAfter)
+
+		now = now.Add(result.RetryAfter)
+		result = algorithm.Allow(state, limit, now)
+		assert.True(t, result.Allowed)
+	})
+
+	t.Run("expired_windows", func(t *testing.T) {
+		state := &State{Timestamp: start, Value: 4, Previous: 4}
+		result := algorithm.Allow(state, limit, start.Add(time.Minute))
+		assert.True(t, result.Allowed)
+		assert.Equal(t, State{Timestamp: start.Add(time.Minute), Value: 1}, *state)
+		assert.Equal(t, 3, result.Remaining)
+	})
+}
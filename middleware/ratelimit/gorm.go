@@ -0,0 +1,81 @@
+package ratelimit
+
+import (
+	"context"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"goyave.dev/goyave/v5/util/errors"
+)
+
+// GORMState the model used by the `GORMStore` to persist the states of the rate limiters.
+// The table must be created by the application, for example using auto-migration:
+//
+//	db.AutoMigrate(&ratelimit.GORMState{})
+type GORMState struct {
+	Timestamp time.Time
+	ExpiresAt time.Time `gorm:"index"`
+	Key       string    `gorm:"primaryKey;size:255"`
+	Value     float64
+	Previous  float64
+}
+
+// TableName returns the name of the table used by the `GORMStore`.
+func (GORMState) TableName() string {
+	return "rate_limits"
+}
+
+// GORMStore a `Store` persisting the states in a database using GORM, allowing
+// multiple instances of the application to share the same limits.
+//
+// Each update is executed in a transaction, locking the row (`SELECT ... FOR UPDATE`)
+// if the database supports it.
+//
+// Expired rows are ignored but not removed automatically. Call `Cleanup()` periodically
+// to remove them.
+type GORMStore struct {
+	DB  *gorm.DB
+	now func() time.Time
+}
+
+// NewGORMStore create a new `GORMStore` using the given database connection.
+func NewGORMStore(db *gorm.DB) *GORMStore {
+	return &GORMStore{
+		DB:  db,
+		now: time.Now,
+	}
+}
+
+// Update implementation of `Store`.
+func (s *GORMStore) Update(ctx context.Context, key string, ttl time.Duration, fn func(state *State)) error {
+	err := s.DB.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := s.now()
+		row := &GORMState{Key: key}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(row).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where(&GORMState{Key: key}).First(row).Error; err != nil {
+			return err
+		}
+
+		state := State{}
+		if now.Before(row.ExpiresAt) {
+			state = State{Timestamp: row.Timestamp, Value: row.Value, Previous: row.Previous}
+		}
+		fn(&state)
+
+		row.Timestamp = state.Timestamp
+		row.Value = state.Value
+		row.Previous = state.Previous
+		row.ExpiresAt = now.Add(ttl)
+		return tx.Model(row).Select("*").Updates(row).Error
+	})
+	return errors.New(err)
+}
+
+// Cleanup removes the expired rows from the database.
+func (s *GORMStore) Cleanup(ctx context.Context) error {
+	return errors.New(s.DB.WithContext(ctx).Where("expires_at <= ?", s.now()).Delete(&GORMState{}).Error)
+}
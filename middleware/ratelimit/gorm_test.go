@@ -0,0 +1,84 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupGORMStore(t *testing.T) *GORMStore {
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{})
+	require.NoError(t, err)
+	sqlDB, err := db.DB()
+	require.NoError(t, err)
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+	require.NoError(t, db.AutoMigrate(&GORMState{}))
+	return NewGORMStore(db)
+}
+
+func TestGORMStore(t *testing.T) {
+	store := setupGORMStore(t)
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	store.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	err := store.Update(ctx, "key", time.Minute, func(state *State) {
+		assert.Equal(t, State{}, *state)
+		state.Timestamp = now
+		state.Value = 1.5
+		state.Previous = 3
+	})
+	require.NoError(t, err)
+
+	err = store.Update(ctx, "key", time.Minute, func(state *State) {
+		assert.True(t, now.Equal(state.Timestamp))
+		assert.InDelta(t, 1.5, state.Value, 0)
+		assert.InDelta(t, 3.0, state.Previous, 0)
+		state.Value = 2
+	})
+	require.NoError(t, err)
+
+	rows := []*GORMState{}
+	require.NoError(t, store.DB.Find(&rows).Error)
+	require.Len(t, rows, 1)
+	assert.Equal(t, "key", rows[0].Key)
+	assert.InDelta(t, 2.0, rows[0].Value, 0)
+	assert.True(t, now.Add(time.Minute).Equal(rows[0].ExpiresAt))
+
+	t.Run("expired", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		err := store.Update(ctx, "other", time.Minute, func(state *State) {
+			state.Value = 1
+		})
+		require.NoError(t, err)
+		err = store.Update(ctx, "key", time.Minute, func(state *State) {
+			assert.Equal(t, State{}, *state)
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("cleanup", func(t *testing.T) {
+		now = now.Add(time.Minute)
+		require.NoError(t, store.Update(ctx, "other", time.Minute, func(_ *State) {}))
+		now = now.Add(30 * time.Second)
+		require.NoError(t, store.Cleanup(ctx))
+
+		keys := []string{}
+		require.NoError(t, store.DB.Model(&GORMState{}).Pluck("key", &keys).Error)
+		assert.Equal(t, []string{"other"}, keys)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		require.NoError(t, store.DB.Migrator().DropTable(&GORMState{}))
+		err := store.Update(ctx, "key", time.Minute, func(_ *State) {})
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,207 @@
+package ratelimit
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/util/errors"
+)
+
+// MetaLimit the route meta key used to define a rate limit specific to a route or
+// a router. The meta value is expected to be a `*ratelimit.Limit`. A `nil` value
+// disables rate limiting for the route.
+//
+//	router.Get("/login", handler).SetMeta(ratelimit.MetaLimit, &ratelimit.Limit{
+//		Requests: 5,
+//		Period:   time.Minute,
+//	})
+const MetaLimit = "goyave.rate-limit"
+
+// KeyFunc returns the key identifying the client of the given request.
+// Requests having the same key share the same limit.
+type KeyFunc func(request *goyave.Request) string
+
+// ByIP identifies the client by its IP address. If the server is behind a proxy,
+// make sure the "server.trustedProxies" config entry is set so the real IP of the client is used.
+func ByIP(request *goyave.Request) string {
+	return request.ClientIP()
+}
+
+// ByUser returns a `KeyFunc` identifying the client by the authenticated user (`request.User`).
+// The given function returns a unique identifier for the user. If the request is not
+// authenticated or if the user is not of type `*T`, the client is identified by its IP address.
+//
+//	ratelimit.ByUser(func(u *dto.User) string { return strconv.FormatUint(uint64(u.ID), 10) })
+func ByUser[T any](id func(user *T) string) KeyFunc {
+	return func(request *goyave.Request) string {
+		if user, ok := request.User.(*T); ok && user != nil {
+			return "user:" + id(user)
+		}
+		return "ip:" + ByIP(request)
+	}
+}
+
+// Limit defines the amount of requests a client is allowed to make in a given period.
+type Limit struct {
+	// Algorithm overrides the middleware's algorithm for this limit if not nil.
+	Algorithm Algorithm
+
+	// Key overrides the middleware's key function for this limit if not nil.
+	Key KeyFunc
+
+	// Name identifies the limit in the store. Routes using limits with the same name
+	// share the same counters. If empty, each route has its own counters.
+	Name string
+
+	// Requests the maximum amount of requests allowed in the period.
+	Requests int
+
+	// Period the duration in which at most `Requests` requests are allowed.
+	Period time.Duration
+}
+
+// Middleware limiting the rate of requests per client.
+//
+// The limit applied to a route is taken from the `MetaLimit` route meta. If the route
+// doesn't have this meta, the middleware's default `Limit` is used. If there is no
+// limit for the route, the middleware immediately passes.
+//
+// The middleware sets the `RateLimit-Limit`, `RateLimit-Remaining` and `RateLimit-Reset`
+// response headers. If the client exceeded the limit, the `Retry-After` header is set and
+// the middleware stops the request with the "429 Too Many Requests" status, which
+// is rendered by the status handler.
+//
+// The default store is a `MemoryStore`, which is local to the current instance. Use the
+// `GORMStore` if the application is deployed on multiple instances.
+//
+// **Example:**
+//
+//	rateLimitMiddleware := &ratelimit.Middleware{
+//		Store:     ratelimit.NewGORMStore(db),
+//		Algorithm: ratelimit.SlidingWindow{},
+//		Limit:     &ratelimit.Limit{Requests: 100, Period: time.Minute},
+//	}
+//	router.GlobalMiddleware(rateLimitMiddleware)
+type Middleware struct {
+	goyave.Component
+
+	// Store persists the state of the limiters. Defaults to a `MemoryStore`.
+	Store Store
+
+	// Algorithm the rate limiting algorithm. Defaults to `TokenBucket`.
+	Algorithm Algorithm
+
+	// Key the function identifying the client. Defaults to `ByIP`.
+	Key KeyFunc
+
+	// Limit the default limit applied to routes not having the `MetaLimit` meta.
+	// If `nil`, only the routes having the meta are rate limited.
+	Limit *Limit
+
+	storeOnce sync.Once
+}
+
+// Handle implementation of `goyave.Middleware`.
+func (m *Middleware) Handle(next goyave.Handler) goyave.Handler {
+	return func(response *goyave.Response, request *goyave.Request) {
+		limit := m.getLimit(request)
+		if limit == nil {
+			next(response, request)
+			return
+		}
+		if limit.Requests <= 0 || limit.Period <= 0 {
+			panic(errors.NewSkip(fmt.Errorf("ratelimit: invalid limit %d requests per %s", limit.Requests, limit.Period), 3))
+		}
+
+		algorithm := m.getAlgorithm(limit)
+		var result Result
+		err := m.getStore().Update(request.Context(), m.key(limit, request), stateTTL(limit), func(state *State) {
+			result = algorithm.Allow(state, limit, request.Now)
+		})
+		if err != nil {
+			response.Error(errors.New(err))
+			return
+		}
+
+		header := response.Header()
+		header.Set("RateLimit-Limit", strconv.Itoa(limit.Requests))
+		header.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		header.Set("RateLimit-Reset", formatSeconds(result.Reset))
+		if !result.Allowed {
+			header.Set("Retry-After", formatSeconds(result.RetryAfter))
+			response.Status(http.StatusTooManyRequests)
+			return
+		}
+
+		next(response, request)
+	}
+}
+
+func (m *Middleware) getLimit(request *goyave.Request) *Limit {
+	if request.Route != nil {
+		if meta, ok := request.Route.LookupMeta(MetaLimit); ok {
+			if meta == nil {
+				return nil
+			}
+			limit, ok := meta.(*Limit)
+			if !ok {
+				panic(errors.NewSkip(fmt.Errorf("ratelimit: route meta %q is not a *ratelimit.Limit", MetaLimit), 3))
+			}
+			return limit
+		}
+	}
+	return m.Limit
+}
+
+func (m *Middleware) getStore() Store {
+	m.storeOnce.Do(func() {
+		if m.Store == nil {
+			m.Store = NewMemoryStore()
+		}
+	})
+	return m.Store
+}
+
+func (m *Middleware) getAlgorithm(limit *Limit) Algorithm {
+	switch {
+	case limit.Algorithm != nil:
+		return limit.Algorithm
+	case m.Algorithm != nil:
+		return m.Algorithm
+	default:
+		return TokenBucket{}
+	}
+}
+
+func (m *Middleware) key(limit *Limit, request *goyave.Request) string {
+	keyFunc := ByIP
+	switch {
+	case limit.Key != nil:
+		keyFunc = limit.Key
+	case m.Key != nil:
+		keyFunc = m.Key
+	}
+	name := limit.Name
+	if name == "" {
+		name = request.Method() + " "
+		if request.Route != nil {
+			name += request.Route.GetFullURI()
+		}
+	}
+	return name + "|" + keyFunc(request)
+}
+
+// stateTTL returns the duration after which an unused state can be safely discarded.
+// The sliding window needs to keep the previous window.
+func stateTTL(limit *Limit) time.Duration {
+	return 2 * limit.Period
+}
+
+func formatSeconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
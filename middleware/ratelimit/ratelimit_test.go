@@ -0,0 +1,208 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/config"
+	"goyave.dev/goyave/v5/util/testutil"
+)
+
+type testUser struct {
+	Name string
+}
+
+type testErrorStore struct{}
+
+func (testErrorStore) Update(_ context.Context, _ string, _ time.Duration, _ func(*State)) error {
+	return fmt.Errorf("test error")
+}
+
+func TestByIP(t *testing.T) {
+	request := testutil.NewTestRequest(http.MethodGet, "/", nil)
+	request.Request().RemoteAddr = "192.0.2.1:1234"
+	assert.Equal(t, "192.0.2.1", ByIP(request))
+
+	request.Request().RemoteAddr = "192.0.2.1"
+	assert.Equal(t, "192.0.2.1", ByIP(request))
+}
+
+func TestByUser(t *testing.T) {
+	key := ByUser(func(u *testUser) string { return u.Name })
+	request := testutil.NewTestRequest(http.MethodGet, "/", nil)
+	request.Request().RemoteAddr = "192.0.2.1:1234"
+	assert.Equal(t, "ip:192.0.2.1", key(request))
+
+	request.User = &testUser{Name: "johndoe"}
+	assert.Equal(t, "user:johndoe", key(request))
+
+	request.User = "not a user"
+	assert.Equal(t, "ip:192.0.2.1", key(request))
+}
+
+func TestMiddleware(t *testing.T) {
+	server := testutil.NewTestServerWithOptions(t, goyave.Options{Config: config.LoadDefault()})
+	handler := func(response *goyave.Response, _ *goyave.Request) {
+		response.Status(http.StatusNoContent)
+	}
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	newRequest := func(route *goyave.Route) *goyave.Request {
+		request := testutil.NewTestRequest(http.MethodGet, "/test", nil)
+		request.Now = now
+		request.Route = route
+		return request
+	}
+	router := goyave.NewRouter(server.Server)
+
+	t.Run("default_limit", func(t *testing.T) {
+		middleware := &Middleware{
+			Limit: &Limit{Requests: 2, Period: time.Minute},
+		}
+		route := router.Get("/test", handler)
+
+		resp := server.TestMiddleware(middleware, newRequest(route), handler)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, "2", resp.Header.Get("RateLimit-Limit"))
+		assert.Equal(t, "1", resp.Header.Get("RateLimit-Remaining"))
+		assert.Equal(t, "30", resp.Header.Get("RateLimit-Reset"))
+		assert.Empty(t, resp.Header.Get("Retry-After"))
+		_ = resp.Body.Close()
+
+		resp = server.TestMiddleware(middleware, newRequest(route), handler)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, "0", resp.Header.Get("RateLimit-Remaining"))
+		_ = resp.Body.Close()
+
+		resp = server.TestMiddleware(middleware, newRequest(route), handler)
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Equal(t, "0", resp.Header.Get("RateLimit-Remaining"))
+		assert.Equal(t, "60", resp.Header.Get("RateLimit-Reset"))
+		assert.Equal(t, "30", resp.Header.Get("Retry-After"))
+		body, err := testutil.ReadJSONBody[map[string]string](resp.Body)
+		_ = resp.Body.Close()
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"error": http.StatusText(http.StatusTooManyRequests)}, body)
+
+		// Other client is not limited
+		request := newRequest(route)
+		request.Request().RemoteAddr = "192.0.2.2:1234"
+		resp = server.TestMiddleware(middleware, request, handler)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		_ = resp.Body.Close()
+
+		// Other route is not limited
+		resp = server.TestMiddleware(middleware, newRequest(router.Get("/other", handler)), handler)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		_ = resp.Body.Close()
+	})
+
+	t.Run("no_limit", func(t *testing.T) {
+		middleware := &Middleware{}
+		resp := server.TestMiddleware(middleware, newRequest(router.Get("/test", handler)), handler)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("RateLimit-Limit"))
+		_ = resp.Body.Close()
+	})
+
+	t.Run("meta", func(t *testing.T) {
+		middleware := &Middleware{
+			Limit: &Limit{Requests: 10, Period: time.Minute},
+		}
+		subrouter := router.Subrouter("/meta")
+		subrouter.SetMeta(MetaLimit, &Limit{Requests: 1, Period: time.Minute, Name: "shared", Algorithm: SlidingWindow{}})
+		routeA := subrouter.Get("/a", handler)
+		routeB := subrouter.Get("/b", handler)
+		disabled := subrouter.Get("/disabled", handler).SetMeta(MetaLimit, nil)
+
+		resp := server.TestMiddleware(middleware, newRequest(routeA), handler)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Equal(t, "1", resp.Header.Get("RateLimit-Limit"))
+		_ = resp.Body.Close()
+
+		resp = server.TestMiddleware(middleware, newRequest(routeB), handler)
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		_ = resp.Body.Close()
+
+		resp = server.TestMiddleware(middleware, newRequest(disabled), handler)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Empty(t, resp.Header.Get("RateLimit-Limit"))
+		_ = resp.Body.Close()
+	})
+
+	t.Run("custom_key", func(t *testing.T) {
+		middleware := &Middleware{
+			Key:   ByUser(func(u *testUser) string { return u.Name }),
+			Limit: &Limit{Requests: 1, Period: time.Minute},
+		}
+		route := router.Get("/test", handler)
+
+		request := newRequest(route)
+		request.User = &testUser{Name: "a"}
+		resp := server.TestMiddleware(middleware, request, handler)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		_ = resp.Body.Close()
+
+		request = newRequest(route)
+		request.User = &testUser{Name: "b"}
+		resp = server.TestMiddleware(middleware, request, handler)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		_ = resp.Body.Close()
+
+		request = newRequest(route)
+		request.User = &testUser{Name: "a"}
+		resp = server.TestMiddleware(middleware, request, handler)
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		_ = resp.Body.Close()
+	})
+
+	t.Run("gorm_store", func(t *testing.T) {
+		middleware := &Middleware{
+			Store: setupGORMStore(t),
+			Limit: &Limit{Requests: 1, Period: time.Minute},
+		}
+		route := router.Get("/test", handler)
+
+		resp := server.TestMiddleware(middleware, newRequest(route), handler)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		_ = resp.Body.Close()
+
+		resp = server.TestMiddleware(middleware, newRequest(route), handler)
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		_ = resp.Body.Close()
+	})
+
+	t.Run("store_error", func(t *testing.T) {
+		middleware := &Middleware{
+			Store: testErrorStore{},
+			Limit: &Limit{Requests: 1, Period: time.Minute},
+		}
+		resp := server.TestMiddleware(middleware, newRequest(router.Get("/test", handler)), handler)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		_ = resp.Body.Close()
+	})
+
+	t.Run("invalid_meta", func(t *testing.T) {
+		middleware := &Middleware{}
+		route := router.Get("/test", handler).SetMeta(MetaLimit, "invalid")
+		request := newRequest(route)
+		response, _ := testutil.NewTestResponse(request)
+		assert.Panics(t, func() {
+			middleware.Handle(handler)(response, request)
+		})
+	})
+
+	t.Run("invalid_limit", func(t *testing.T) {
+		middleware := &Middleware{Limit: &Limit{}}
+		request := newRequest(router.Get("/test", handler))
+		response, _ := testutil.NewTestResponse(request)
+		assert.Panics(t, func() {
+			middleware.Handle(handler)(response, request)
+		})
+	})
+}
@@ -0,0 +1,88 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Store persists the state of the rate limiters.
+//
+// `Update` atomically retrieves the state identified by the given key, passes it to
+// the given function, then saves the modified state. If the key doesn't exist or has
+// expired, the function receives the zero value. The state expires after the given TTL
+// if it is not updated in the meantime.
+//
+// Implementations must be safe for concurrent use, and the read-modify-write cycle must be
+// atomic for a given key, even across multiple instances of the application if the store
+// is shared.
+type Store interface {
+	Update(ctx context.Context, key string, ttl time.Duration, fn func(state *State)) error
+}
+
+type memoryEntry struct {
+	expiresAt time.Time
+	state     State
+}
+
+// MemoryStore a `Store` keeping the states in memory. The states are not shared
+// between multiple instances of the application.
+//
+// Expired entries are periodically removed when the store is updated.
+type MemoryStore struct {
+	entries   map[string]*memoryEntry
+	nextSweep time.Time
+	now       func() time.Time
+	mu        sync.Mutex
+
+	// SweepInterval the minimum duration between two removals of the expired entries.
+	// Defaults to one minute.
+	SweepInterval time.Duration
+}
+
+// NewMemoryStore create a new empty `MemoryStore`.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries:       map[string]*memoryEntry{},
+		now:           time.Now,
+		SweepInterval: time.Minute,
+	}
+}
+
+// Update implementation of `Store`.
+func (s *MemoryStore) Update(_ context.Context, key string, ttl time.Duration, fn func(state *State)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	s.sweep(now)
+
+	entry, ok := s.entries[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		entry = &memoryEntry{}
+		s.entries[key] = entry
+	}
+	fn(&entry.state)
+	entry.expiresAt = now.Add(ttl)
+	return nil
+}
+
+// Len returns the number of entries in the store, including the expired ones
+// that were not removed yet.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.entries)
+}
+
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Before(s.nextSweep) {
+		return
+	}
+	for k, e := range s.entries {
+		if !now.Before(e.expiresAt) {
+			delete(s.entries, k)
+		}
+	}
+	s.nextSweep = now.Add(s.SweepInterval)
+}
@@ -0,0 +1,62 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStore(t *testing.T) {
+	now := time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	err := store.Update(context.Background(), "key", time.Minute, func(state *State) {
+		assert.Equal(t, State{}, *state)
+		state.Value = 1
+	})
+	require.NoError(t, err)
+
+	err = store.Update(context.Background(), "key", time.Minute, func(state *State) {
+		assert.Equal(t, State{Value: 1}, *state)
+		state.Value = 2
+	})
+	require.NoError(t, err)
+
+	now = now.Add(time.Minute)
+	err = store.Update(context.Background(), "other", time.Minute, func(_ *State) {})
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.Len()) // Expired entry has been swept
+
+	t.Run("expired_entry_not_swept", func(t *testing.T) {
+		store.SweepInterval = time.Hour
+		now = now.Add(time.Minute)
+		store.nextSweep = now.Add(time.Hour)
+		err = store.Update(context.Background(), "other", time.Minute, func(state *State) {
+			assert.Equal(t, State{}, *state)
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("concurrent", func(t *testing.T) {
+		store := NewMemoryStore()
+		wg := sync.WaitGroup{}
+		for i := 0; i < 50; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_ = store.Update(context.Background(), "key", time.Minute, func(state *State) {
+					state.Value++
+				})
+			}()
+		}
+		wg.Wait()
+		_ = store.Update(context.Background(), "key", time.Minute, func(state *State) {
+			assert.InDelta(t, 50.0, state.Value, 0)
+		})
+	})
+}
@@ -0,0 +1,148 @@
+package requestid
+
+import (
+	"context"
+	stdslog "log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/slog"
+)
+
+// DefaultHeader the default header from which the request ID is read and in which
+// it is echoed in the response.
+const DefaultHeader = "X-Request-ID"
+
+// LogAttr the name of the log attribute containing the request ID.
+const LogAttr = "requestId"
+
+// maxLength the maximum length of an incoming request ID. Longer IDs are replaced.
+const maxLength = 128
+
+type contextKey struct{}
+
+// FromContext returns the request ID stored in the given context by the middleware.
+// Returns an empty string if there is none.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// NewContext returns a copy of the given context carrying the given request ID.
+// The ID is also added to the context's log attributes so it is automatically
+// added to the records logged with this context.
+func NewContext(ctx context.Context, id string) context.Context {
+	ctx = context.WithValue(ctx, contextKey{}, id)
+	return slog.ContextWithAttrs(ctx, stdslog.String(LogAttr, id))
+}
+
+// Middleware tags every request with an ID, allowing to correlate log records.
+//
+// The ID is taken from the incoming request header (`X-Request-ID` by default) if it is
+// valid: not longer than 128 characters and only containing printable ASCII characters.
+// Otherwise, a new UUID is generated.
+//
+// The ID is echoed in the response header and stored in the request's context.
+// It can be retrieved using `requestid.FromContext(request.Context())`. Every record
+// logged with the request's context contains the `requestId` attribute. This includes
+// errors logged by the framework (panics, `response.Error()`), access logs and
+// GORM logs if the request context is used for the query:
+//
+//	db.WithContext(request.Context()).First(&user)
+//
+// This middleware should be added as a global middleware so the ID is available
+// as early as possible in the request's life-cycle.
+//
+//	router.GlobalMiddleware(&requestid.Middleware{})
+type Middleware struct {
+	goyave.Component
+
+	// Generator returns a new request ID. Defaults to a random UUID (v4).
+	Generator func() string
+
+	// Header the name of the request and response header containing the ID.
+	// Defaults to `X-Request-ID`.
+	Header string
+
+	// IgnoreIncoming if true, the request ID sent by the client is ignored
+	// and a new one is always generated.
+	IgnoreIncoming bool
+}
+
+// Handle implementation of `goyave.Middleware`.
+func (m *Middleware) Handle(next goyave.Handler) goyave.Handler {
+	return func(response *goyave.Response, request *goyave.Request) {
+		header := m.getHeader()
+		id := ""
+		if !m.IgnoreIncoming {
+			id = request.Header().Get(header)
+		}
+		if !isValid(id) {
+			id = m.generate()
+		}
+
+		response.Header().Set(header, id)
+		request.WithContext(NewContext(request.Context(), id))
+		next(response, request)
+	}
+}
+
+func (m *Middleware) getHeader() string {
+	if m.Header == "" {
+		return DefaultHeader
+	}
+	return m.Header
+}
+
+func (m *Middleware) generate() string {
+	if m.Generator == nil {
+		return uuid.NewString()
+	}
+	return m.Generator()
+}
+
+func isValid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// Transport an `http.RoundTripper` propagating the request ID stored in the context
+// of outgoing requests, allowing to correlate the logs of other services.
+//
+//	client := &http.Client{Transport: &requestid.Transport{}}
+//	req, _ := http.NewRequestWithContext(request.Context(), http.MethodGet, url, nil)
+//	resp, err := client.Do(req)
+type Transport struct {
+	// Base the underlying transport. Defaults to `http.DefaultTransport`.
+	Base http.RoundTripper
+
+	// Header the name of the header set on outgoing requests.
+	// Defaults to `X-Request-ID`.
+	Header string
+}
+
+// RoundTrip implementation of `http.RoundTripper`. If the request's context contains
+// a request ID and the header is not already set, the request is cloned and the header is set.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	header := t.Header
+	if header == "" {
+		header = DefaultHeader
+	}
+	if id := FromContext(req.Context()); id != "" && req.Header.Get(header) == "" {
+		req = req.Clone(req.Context())
+		req.Header.Set(header, id)
+	}
+	return base.RoundTrip(req)
+}
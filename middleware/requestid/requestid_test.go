@@ -0,0 +1,145 @@
+package requestid
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/config"
+	"goyave.dev/goyave/v5/slog"
+	"goyave.dev/goyave/v5/util/testutil"
+)
+
+type testRoundTripper struct {
+	request *http.Request
+}
+
+func (t *testRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.request = req
+	return &http.Response{StatusCode: http.StatusNoContent, Body: http.NoBody, Request: req}, nil
+}
+
+func TestMiddleware(t *testing.T) {
+	server := testutil.NewTestServerWithOptions(t, goyave.Options{Config: config.LoadDefault()})
+
+	t.Run("generate", func(t *testing.T) {
+		request := testutil.NewTestRequest(http.MethodGet, "/test", nil)
+		var id string
+		resp := server.TestMiddleware(&Middleware{}, request, func(response *goyave.Response, request *goyave.Request) {
+			id = FromContext(request.Context())
+			response.Status(http.StatusNoContent)
+		})
+		_ = resp.Body.Close()
+		_, err := uuid.Parse(id)
+		require.NoError(t, err)
+		assert.Equal(t, id, resp.Header.Get(DefaultHeader))
+	})
+
+	t.Run("incoming", func(t *testing.T) {
+		request := testutil.NewTestRequest(http.MethodGet, "/test", nil)
+		request.Header().Set(DefaultHeader, "incoming-id")
+		var id string
+		resp := server.TestMiddleware(&Middleware{}, request, func(response *goyave.Response, request *goyave.Request) {
+			id = FromContext(request.Context())
+			response.Status(http.StatusNoContent)
+		})
+		_ = resp.Body.Close()
+		assert.Equal(t, "incoming-id", id)
+		assert.Equal(t, "incoming-id", resp.Header.Get(DefaultHeader))
+	})
+
+	t.Run("invalid_incoming", func(t *testing.T) {
+		for _, incoming := range []string{"with space", "new\nline", strings.Repeat("a", 129), "é"} {
+			request := testutil.NewTestRequest(http.MethodGet, "/test", nil)
+			request.Header().Set(DefaultHeader, incoming)
+			resp := server.TestMiddleware(&Middleware{Generator: func() string { return "generated" }}, request, func(response *goyave.Response, _ *goyave.Request) {
+				response.Status(http.StatusNoContent)
+			})
+			_ = resp.Body.Close()
+			assert.Equal(t, "generated", resp.Header.Get(DefaultHeader))
+		}
+	})
+
+	t.Run("custom_header_ignore_incoming", func(t *testing.T) {
+		request := testutil.NewTestRequest(http.MethodGet, "/test", nil)
+		request.Header().Set("X-Correlation-ID", "incoming-id")
+		middleware := &Middleware{
+			Header:         "X-Correlation-ID",
+			IgnoreIncoming: true,
+			Generator:      func() string { return "generated" },
+		}
+		resp := server.TestMiddleware(middleware, request, func(response *goyave.Response, _ *goyave.Request) {
+			response.Status(http.StatusNoContent)
+		})
+		_ = resp.Body.Close()
+		assert.Equal(t, "generated", resp.Header.Get("X-Correlation-ID"))
+		assert.Empty(t, resp.Header.Get(DefaultHeader))
+	})
+
+	t.Run("logs", func(t *testing.T) {
+		logBuffer := &bytes.Buffer{}
+		server := testutil.NewTestServerWithOptions(t, goyave.Options{
+			Config: config.LoadDefault(),
+			Logger: slog.New(slog.NewHandler(false, logBuffer)),
+		})
+		request := testutil.NewTestRequest(http.MethodGet, "/test", nil)
+		request.Header().Set(DefaultHeader, "incoming-id")
+		resp := server.TestMiddleware(&Middleware{}, request, func(response *goyave.Response, request *goyave.Request) {
+			server.Logger.InfoContext(request.Context(), "message")
+			response.Error(fmt.Errorf("test error"))
+		})
+		_ = resp.Body.Close()
+
+		lines := strings.Split(strings.TrimSpace(logBuffer.String()), "\n")
+		require.Len(t, lines, 2)
+		assert.Contains(t, lines[0], `"msg":"message","requestId":"incoming-id"`)
+		assert.Contains(t, lines[1], `"msg":"test error","requestId":"incoming-id"`)
+	})
+}
+
+func TestTransport(t *testing.T) {
+	base := &testRoundTripper{}
+	transport := &Transport{Base: base}
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com", nil).WithContext(NewContext(context.Background(), "id"))
+	resp, err := transport.RoundTrip(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, "id", base.request.Header.Get(DefaultHeader))
+	assert.Empty(t, req.Header.Get(DefaultHeader)) // Original request not modified
+
+	t.Run("header_already_set", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil).WithContext(NewContext(context.Background(), "id"))
+		req.Header.Set("X-Correlation-ID", "other")
+		transport := &Transport{Base: base, Header: "X-Correlation-ID"}
+		resp, err := transport.RoundTrip(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Same(t, req, base.request)
+		assert.Equal(t, "other", base.request.Header.Get("X-Correlation-ID"))
+	})
+
+	t.Run("no_id", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+		resp, err := transport.RoundTrip(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Same(t, req, base.request)
+		assert.Empty(t, base.request.Header.Get(DefaultHeader))
+	})
+}
+
+func TestNewContext(t *testing.T) {
+	ctx := NewContext(context.Background(), "id")
+	assert.Equal(t, "id", FromContext(ctx))
+	assert.Empty(t, FromContext(context.Background()))
+	assert.Len(t, slog.ContextAttrs(ctx), 1)
+}
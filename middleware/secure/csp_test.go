@@ -0,0 +1,27 @@
+package secure
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCSP(t *testing.T) {
+	csp := NewCSP().
+		Add("default-src", SourceSelf).
+		Add("Script-Src", SourceSelf, SourceNonce).
+		Add("script-src", SourceSelf, "https://cdn.example.com").
+		Add("upgrade-insecure-requests")
+
+	assert.True(t, csp.UsesNonce())
+	assert.Equal(t, "default-src 'self'; script-src 'self' 'nonce-abc' https://cdn.example.com; upgrade-insecure-requests", csp.Build("abc"))
+
+	clone := csp.Clone().Remove("script-src").Add("default-src", SourceNone)
+	assert.False(t, clone.UsesNonce())
+	assert.Equal(t, "default-src 'self' 'none'; upgrade-insecure-requests", clone.Build(""))
+
+	// Original not modified
+	assert.Equal(t, "default-src 'self'; script-src 'self' 'nonce-abc' https://cdn.example.com; upgrade-insecure-requests", csp.Build("abc"))
+
+	assert.Empty(t, NewCSP().Build(""))
+}
@@ -0,0 +1,217 @@
+package secure
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/util/errors"
+)
+
+// MetaOptions the route meta key used to define security headers options specific to
+// a route or a router. The meta value is expected to be a `*secure.Options`. A `nil`
+// value disables the security headers for the route.
+//
+//	options := secure.Default()
+//	options.FrameOptions = "SAMEORIGIN"
+//	router.Get("/embed", handler).SetMeta(secure.MetaOptions, options)
+const MetaOptions = "goyave.secure"
+
+// ExtraNonce the key used in `request.Extra` to store the Content-Security-Policy
+// nonce generated for the request. Use `secure.Nonce()` to retrieve it.
+type ExtraNonce struct{}
+
+// Options holds the security headers configuration.
+// Empty values disable the corresponding header.
+type Options struct {
+	// ContentSecurityPolicy the policy written in the `Content-Security-Policy` header.
+	// If the policy uses `SourceNonce`, a new nonce is generated for each request.
+	// Default value is "default-src 'none'; frame-ancestors 'none'", which is suitable for APIs.
+	ContentSecurityPolicy *CSP
+
+	// ContentSecurityPolicyReportOnly if true, the policy is written in the
+	// `Content-Security-Policy-Report-Only` header instead.
+	ContentSecurityPolicyReportOnly bool
+
+	// HSTSMaxAge the duration for which the browser should only access the server
+	// using HTTPS (`Strict-Transport-Security`). This header is only set on requests
+	// using HTTPS. Default is one year.
+	HSTSMaxAge time.Duration
+
+	// HSTSIncludeSubdomains adds the `includeSubDomains` directive to the
+	// `Strict-Transport-Security` header. Default value is true.
+	HSTSIncludeSubdomains bool
+
+	// HSTSPreload adds the `preload` directive to the `Strict-Transport-Security` header.
+	HSTSPreload bool
+
+	// FrameOptions the value of the `X-Frame-Options` header ("DENY" or "SAMEORIGIN").
+	// Default value is "DENY".
+	FrameOptions string
+
+	// ReferrerPolicy the value of the `Referrer-Policy` header.
+	// Default value is "no-referrer".
+	ReferrerPolicy string
+
+	// PermissionsPolicy the value of the `Permissions-Policy` header,
+	// for example "camera=(), microphone=(), geolocation=()".
+	PermissionsPolicy string
+
+	// CrossOriginOpenerPolicy the value of the `Cross-Origin-Opener-Policy` header.
+	// Default value is "same-origin".
+	CrossOriginOpenerPolicy string
+
+	// CrossOriginEmbedderPolicy the value of the `Cross-Origin-Embedder-Policy` header,
+	// for example "require-corp".
+	CrossOriginEmbedderPolicy string
+
+	// ContentTypeNosniff sets the `X-Content-Type-Options: nosniff` header.
+	// Default value is true.
+	ContentTypeNosniff bool
+}
+
+// Default create new security headers options with default settings.
+// The returned value can be used as a starting point for
+// customized options.
+func Default() *Options {
+	return &Options{
+		ContentSecurityPolicy:   NewCSP().Add("default-src", SourceNone).Add("frame-ancestors", SourceNone),
+		HSTSMaxAge:              365 * 24 * time.Hour,
+		HSTSIncludeSubdomains:   true,
+		FrameOptions:            "DENY",
+		ReferrerPolicy:          "no-referrer",
+		CrossOriginOpenerPolicy: "same-origin",
+		ContentTypeNosniff:      true,
+	}
+}
+
+// Apply writes the security headers to the given response headers.
+// The nonce replaces the `SourceNonce` placeholder in the Content-Security-Policy.
+// `secure` indicates if the request is using HTTPS.
+func (o *Options) Apply(headers http.Header, nonce string, secure bool) {
+	if o.ContentSecurityPolicy != nil {
+		name := "Content-Security-Policy"
+		if o.ContentSecurityPolicyReportOnly {
+			name = "Content-Security-Policy-Report-Only"
+		}
+		headers.Set(name, o.ContentSecurityPolicy.Build(nonce))
+	}
+	if secure && o.HSTSMaxAge > 0 {
+		headers.Set("Strict-Transport-Security", o.hsts())
+	}
+	if o.ContentTypeNosniff {
+		headers.Set("X-Content-Type-Options", "nosniff")
+	}
+	setIfNotEmpty(headers, "X-Frame-Options", o.FrameOptions)
+	setIfNotEmpty(headers, "Referrer-Policy", o.ReferrerPolicy)
+	setIfNotEmpty(headers, "Permissions-Policy", o.PermissionsPolicy)
+	setIfNotEmpty(headers, "Cross-Origin-Opener-Policy", o.CrossOriginOpenerPolicy)
+	setIfNotEmpty(headers, "Cross-Origin-Embedder-Policy", o.CrossOriginEmbedderPolicy)
+}
+
+func (o *Options) hsts() string {
+	value := "max-age=" + strconv.FormatUint(uint64(o.HSTSMaxAge.Seconds()), 10)
+	if o.HSTSIncludeSubdomains {
+		value += "; includeSubDomains"
+	}
+	if o.HSTSPreload {
+		value += "; preload"
+	}
+	return value
+}
+
+func setIfNotEmpty(headers http.Header, name, value string) {
+	if value != "" {
+		headers.Set(name, value)
+	}
+}
+
+// Nonce returns the Content-Security-Policy nonce generated for the given request,
+// to be used in the `nonce` attribute of inline scripts and styles. Returns an empty
+// string if the policy of the request's route doesn't use `SourceNonce`.
+func Nonce(request *goyave.Request) string {
+	nonce, _ := request.Extra[ExtraNonce{}].(string)
+	return nonce
+}
+
+// Middleware setting the browser security headers: Content-Security-Policy,
+// Strict-Transport-Security, X-Frame-Options, X-Content-Type-Options, Referrer-Policy,
+// Permissions-Policy, Cross-Origin-Opener-Policy and Cross-Origin-Embedder-Policy.
+//
+// The options applied to a route are taken from the `MetaOptions` route meta. If the
+// route doesn't have this meta, the middleware's `Options` are used.
+//
+// The headers are set before the next handlers are executed, so handlers can
+// still override or remove them.
+//
+// **Example:**
+//
+//	options := secure.Default()
+//	options.ContentSecurityPolicy = secure.NewCSP().
+//		Add("default-src", secure.SourceSelf).
+//		Add("script-src", secure.SourceSelf, secure.SourceNonce)
+//	router.GlobalMiddleware(&secure.Middleware{Options: options})
+type Middleware struct {
+	goyave.Component
+
+	// Options the default options applied to routes not having the `MetaOptions` meta.
+	// Defaults to `secure.Default()`.
+	Options *Options
+}
+
+// Handle implementation of `goyave.Middleware`.
+func (m *Middleware) Handle(next goyave.Handler) goyave.Handler {
+	defaultOptions := m.Options
+	if defaultOptions == nil {
+		defaultOptions = Default()
+	}
+	return func(response *goyave.Response, request *goyave.Request) {
+		options := m.getOptions(request, defaultOptions)
+		if options == nil {
+			next(response, request)
+			return
+		}
+
+		nonce := ""
+		if options.ContentSecurityPolicy != nil && options.ContentSecurityPolicy.UsesNonce() {
+			var err error
+			nonce, err = generateNonce()
+			if err != nil {
+				response.Error(err)
+				return
+			}
+			request.Extra[ExtraNonce{}] = nonce
+		}
+
+		options.Apply(response.Header(), nonce, request.Scheme() == "https")
+		next(response, request)
+	}
+}
+
+func (m *Middleware) getOptions(request *goyave.Request, defaultOptions *Options) *Options {
+	if request.Route != nil {
+		if meta, ok := request.Route.LookupMeta(MetaOptions); ok {
+			if meta == nil {
+				return nil
+			}
+			options, ok := meta.(*Options)
+			if !ok {
+				panic(errors.NewSkip(fmt.Errorf("secure: route meta %q is not a *secure.Options", MetaOptions), 3))
+			}
+			return options
+		}
+	}
+	return defaultOptions
+}
+
+func generateNonce() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.New(err)
+	}
+	return base64.StdEncoding.EncodeToString(b), nil
+}
@@ -0,0 +1,138 @@
+package secure
+
+import (
+	"crypto/tls"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5"
+	"goyave.dev/goyave/v5/config"
+	"goyave.dev/goyave/v5/util/testutil"
+)
+
+func TestOptions(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		headers := http.Header{}
+		Default().Apply(headers, "", false)
+		assert.Equal(t, http.Header{
+			"Content-Security-Policy":    {"default-src 'none'; frame-ancestors 'none'"},
+			"X-Content-Type-Options":     {"nosniff"},
+			"X-Frame-Options":            {"DENY"},
+			"Referrer-Policy":            {"no-referrer"},
+			"Cross-Origin-Opener-Policy": {"same-origin"},
+		}, headers)
+
+		headers = http.Header{}
+		Default().Apply(headers, "", true)
+		assert.Equal(t, "max-age=31536000; includeSubDomains", headers.Get("Strict-Transport-Security"))
+	})
+
+	t.Run("custom", func(t *testing.T) {
+		options := &Options{
+			ContentSecurityPolicy:           NewCSP().Add("script-src", SourceNonce),
+			ContentSecurityPolicyReportOnly: true,
+			HSTSMaxAge:                      time.Hour,
+			HSTSPreload:                     true,
+			PermissionsPolicy:               "camera=()",
+			CrossOriginEmbedderPolicy:       "require-corp",
+		}
+		headers := http.Header{}
+		options.Apply(headers, "abc", true)
+		assert.Equal(t, http.Header{
+			"Content-Security-Policy-Report-Only": {"script-src 'nonce-abc'"},
+			"Strict-Transport-Security":           {"max-age=3600; preload"},
+			"Permissions-Policy":                  {"camera=()"},
+			"Cross-Origin-Embedder-Policy":        {"require-corp"},
+		}, headers)
+	})
+}
+
+func TestMiddleware(t *testing.T) {
+	server := testutil.NewTestServerWithOptions(t, goyave.Options{Config: config.LoadDefault()})
+	router := goyave.NewRouter(server.Server)
+
+	t.Run("default_options", func(t *testing.T) {
+		middleware := &Middleware{}
+		request := testutil.NewTestRequest(http.MethodGet, "/test", nil)
+		resp := server.TestMiddleware(middleware, request, func(response *goyave.Response, request *goyave.Request) {
+			assert.Empty(t, Nonce(request))
+			response.Status(http.StatusNoContent)
+		})
+		_ = resp.Body.Close()
+		assert.Equal(t, "default-src 'none'; frame-ancestors 'none'", resp.Header.Get("Content-Security-Policy"))
+		assert.Equal(t, "DENY", resp.Header.Get("X-Frame-Options"))
+		assert.Empty(t, resp.Header.Get("Strict-Transport-Security"))
+	})
+
+	t.Run("nonce", func(t *testing.T) {
+		options := Default()
+		options.ContentSecurityPolicy = NewCSP().Add("script-src", SourceSelf, SourceNonce)
+		middleware := &Middleware{Options: options}
+
+		nonces := []string{}
+		for i := 0; i < 2; i++ {
+			request := testutil.NewTestRequest(http.MethodGet, "/test", nil)
+			request.Request().TLS = &tls.ConnectionState{}
+			var nonce string
+			resp := server.TestMiddleware(middleware, request, func(response *goyave.Response, request *goyave.Request) {
+				nonce = Nonce(request)
+				response.Status(http.StatusNoContent)
+			})
+			_ = resp.Body.Close()
+			require.Len(t, nonce, 24)
+			assert.Equal(t, "script-src 'self' 'nonce-"+nonce+"'", resp.Header.Get("Content-Security-Policy"))
+			assert.Equal(t, "max-age=31536000; includeSubDomains", resp.Header.Get("Strict-Transport-Security"))
+			nonces = append(nonces, nonce)
+		}
+		assert.NotEqual(t, nonces[0], nonces[1])
+	})
+
+	t.Run("meta", func(t *testing.T) {
+		middleware := &Middleware{}
+		options := Default()
+		options.FrameOptions = "SAMEORIGIN"
+		subrouter := router.Subrouter("/meta")
+		subrouter.SetMeta(MetaOptions, options)
+		route := subrouter.Get("/test", nil)
+		disabledRoute := subrouter.Get("/disabled", nil).SetMeta(MetaOptions, nil)
+		typedNilRoute := subrouter.Get("/typed-nil", nil).SetMeta(MetaOptions, (*Options)(nil))
+		handler := func(response *goyave.Response, _ *goyave.Request) {
+			response.Status(http.StatusNoContent)
+		}
+
+		request := testutil.NewTestRequest(http.MethodGet, "/meta/test", nil)
+		request.Route = route
+		resp := server.TestMiddleware(middleware, request, handler)
+		_ = resp.Body.Close()
+		assert.Equal(t, "SAMEORIGIN", resp.Header.Get("X-Frame-Options"))
+
+		for _, r := range []*goyave.Route{disabledRoute, typedNilRoute} {
+			request = testutil.NewTestRequest(http.MethodGet, "/meta/disabled", nil)
+			request.Route = r
+			resp = server.TestMiddleware(middleware, request, handler)
+			_ = resp.Body.Close()
+			assert.Empty(t, resp.Header.Get("X-Frame-Options"))
+			assert.Empty(t, resp.Header.Get("Content-Security-Policy"))
+		}
+
+		request = testutil.NewTestRequest(http.MethodGet, "/meta/invalid", nil)
+		request.Route = subrouter.Get("/invalid", nil).SetMeta(MetaOptions, "invalid")
+		assert.Panics(t, func() {
+			middleware.getOptions(request, Default())
+		})
+	})
+
+	t.Run("handler_override", func(t *testing.T) {
+		middleware := &Middleware{}
+		request := testutil.NewTestRequest(http.MethodGet, "/test", nil)
+		resp := server.TestMiddleware(middleware, request, func(response *goyave.Response, _ *goyave.Request) {
+			response.Header().Del("X-Frame-Options")
+			response.Status(http.StatusNoContent)
+		})
+		_ = resp.Body.Close()
+		assert.Empty(t, resp.Header.Get("X-Frame-Options"))
+	})
+}
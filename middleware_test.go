@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	stdslog "log/slog"
 	"net/http"
 	"net/http/httptest"
 	"regexp"
@@ -115,6 +116,25 @@ func TestRecoveryMiddleware(t *testing.T) {
 		assert.Equal(t, http.StatusInternalServerError, response.status)
 	})
 
+	t.Run("panic_context_attrs", func(t *testing.T) {
+		logBuffer := &bytes.Buffer{}
+		server, err := New(Options{Config: config.LoadDefault(), Logger: slog.New(slog.NewHandler(false, logBuffer))})
+		require.NoError(t, err)
+		middleware := &recoveryMiddleware{}
+		middleware.Init(server)
+
+		handler := middleware.Handle(func(_ *Response, r *Request) {
+			r.WithContext(slog.ContextWithAttrs(r.Context(), stdslog.String("requestId", "123")))
+			panic(fmt.Errorf("test error"))
+		})
+
+		request := NewRequest(httptest.NewRequest(http.MethodGet, "/test", nil))
+		response := NewResponse(server, request, httptest.NewRecorder())
+		handler(response, request)
+
+		assert.Contains(t, logBuffer.String(), `"msg":"test error","requestId":"123"`)
+	})
+
 	t.Run("no_panic", func(t *testing.T) {
 		logBuffer := &bytes.Buffer{}
 		server, err := New(Options{Config: config.LoadDefault(), Logger: slog.New(slog.NewHandler(false, logBuffer))})
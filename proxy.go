@@ -0,0 +1,228 @@
+package goyave
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+)
+
+// forwardedInfo the client information resolved from the forwarding headers
+// set by trusted proxies.
+type forwardedInfo struct {
+	clientIP string
+	scheme   string
+	host     string
+}
+
+// forwardedElement a single hop of the forwarding chain.
+type forwardedElement struct {
+	addr  netip.Addr
+	proto string
+	host  string
+	valid bool
+}
+
+// parseTrustedProxies parses the given IP addresses and CIDRs.
+func parseTrustedProxies(proxies []string) ([]netip.Prefix, error) {
+	return parseIPPrefixes(proxies, "trusted proxy")
+}
+
+// parseIPPrefixes parses the given IP addresses and CIDRs. Single addresses
+// are converted to a prefix containing only this address. The kind is used
+// in the error message if one of the values cannot be parsed.
+func parseIPPrefixes(values []string, kind string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, p := range values {
+		if strings.Contains(p, "/") {
+			prefix, err := netip.ParsePrefix(p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s %q: %w", kind, p, err)
+			}
+			prefixes = append(prefixes, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %q: %w", kind, p, err)
+		}
+		addr = addr.Unmap()
+		prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+	}
+	return prefixes, nil
+}
+
+// proxyMiddleware resolves the client IP, scheme and host from the forwarding headers
+// if the request comes from a trusted proxy (see the "server.trustedProxies" config entry).
+// This middleware is automatically added globally if there is at least one trusted proxy.
+//
+// The "Forwarded" header (RFC 7239) takes precedence over the "X-Forwarded-For",
+// "X-Forwarded-Proto" and "X-Forwarded-Host" headers.
+//
+// The forwarding chain is read from right to left, skipping the trusted proxies. The client
+// is the first address that is not trusted. If an element of the chain cannot be parsed
+// (e.g. obfuscated identifiers or "unknown"), the resolution stops and the last trusted
+// address is used as client IP. The scheme and host are taken from the element
+// matching the client, as they were set by the proxy that received the client's request.
+type proxyMiddleware struct {
+	Component
+}
+
+func (m *proxyMiddleware) Handle(next Handler) Handler {
+	return func(response *Response, request *Request) {
+		peer, ok := parseNodeAddr(request.RemoteAddress())
+		if ok && m.isTrusted(peer) {
+			request.forwarded = m.resolve(request, peer)
+		}
+		next(response, request)
+	}
+}
+
+func (m *proxyMiddleware) isTrusted(addr netip.Addr) bool {
+	for _, p := range m.server.trustedProxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *proxyMiddleware) resolve(request *Request, peer netip.Addr) *forwardedInfo {
+	header := request.Header()
+	var chain []forwardedElement
+	if values := header.Values("Forwarded"); len(values) > 0 {
+		chain = parseForwarded(values)
+	} else {
+		chain = parseXForwarded(header.Values("X-Forwarded-For"), header.Values("X-Forwarded-Proto"), header.Values("X-Forwarded-Host"))
+	}
+
+	info := &forwardedInfo{clientIP: peer.String()}
+	for i := len(chain) - 1; i >= 0; i-- {
+		element := chain[i]
+		if !element.valid {
+			break
+		}
+		info.clientIP = element.addr.String()
+		info.scheme = element.proto
+		info.host = element.host
+		if !m.isTrusted(element.addr) {
+			break
+		}
+	}
+	return info
+}
+
+// parseForwarded parses the values of the "Forwarded" header (RFC 7239).
+func parseForwarded(values []string) []forwardedElement {
+	chain := []forwardedElement{}
+	for _, value := range values {
+		for _, e := range splitHeaderList(value, ',') {
+			element := forwardedElement{}
+			for _, pair := range splitHeaderList(e, ';') {
+				key, val, ok := strings.Cut(pair, "=")
+				if !ok {
+					continue
+				}
+				val = strings.Trim(strings.TrimSpace(val), `"`)
+				switch strings.ToLower(strings.TrimSpace(key)) {
+				case "for":
+					element.addr, element.valid = parseNodeAddr(val)
+				case "proto":
+					element.proto = parseProto(val)
+				case "host":
+					element.host = parseHost(val)
+				}
+			}
+			chain = append(chain, element)
+		}
+	}
+	return chain
+}
+
+// parseXForwarded parses the values of the "X-Forwarded-For", "X-Forwarded-Proto"
+// and "X-Forwarded-Host" headers. If the proto and host headers contain as many
+// elements as the "X-Forwarded-For" header, each element is associated with the address
+// at the same position. Otherwise, the last value is associated with all addresses.
+func parseXForwarded(forValues, protoValues, hostValues []string) []forwardedElement {
+	addrs := splitHeaderValues(forValues)
+	protos := splitHeaderValues(protoValues)
+	hosts := splitHeaderValues(hostValues)
+	chain := make([]forwardedElement, 0, len(addrs))
+	for i, a := range addrs {
+		element := forwardedElement{}
+		element.addr, element.valid = parseNodeAddr(a)
+		element.proto = parseProto(pickForwardedValue(protos, i, len(addrs)))
+		element.host = parseHost(pickForwardedValue(hosts, i, len(addrs)))
+		chain = append(chain, element)
+	}
+	return chain
+}
+
+func pickForwardedValue(values []string, i, length int) string {
+	switch {
+	case len(values) == 0:
+		return ""
+	case len(values) == length:
+		return values[i]
+	default:
+		return values[len(values)-1]
+	}
+}
+
+func splitHeaderValues(values []string) []string {
+	result := []string{}
+	for _, v := range values {
+		result = append(result, splitHeaderList(v, ',')...)
+	}
+	return result
+}
+
+// splitHeaderList splits the given header value using the given separator,
+// ignoring the separators inside quoted strings. Elements are trimmed.
+func splitHeaderList(value string, sep byte) []string {
+	result := []string{}
+	quoted := false
+	start := 0
+	for i := 0; i < len(value); i++ {
+		switch value[i] {
+		case '"':
+			quoted = !quoted
+		case sep:
+			if !quoted {
+				result = append(result, strings.TrimSpace(value[start:i]))
+				start = i + 1
+			}
+		}
+	}
+	return append(result, strings.TrimSpace(value[start:]))
+}
+
+// parseNodeAddr parses an IP address optionally followed by a port. IPv6 addresses
+// can be enclosed in brackets.
+func parseNodeAddr(node string) (netip.Addr, bool) {
+	if host, _, err := net.SplitHostPort(node); err == nil {
+		node = host
+	}
+	node = strings.TrimSuffix(strings.TrimPrefix(node, "["), "]")
+	addr, err := netip.ParseAddr(node)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap().WithZone(""), true
+}
+
+func parseProto(proto string) string {
+	proto = strings.ToLower(strings.TrimSpace(proto))
+	if proto != "http" && proto != "https" {
+		return ""
+	}
+	return proto
+}
+
+func parseHost(host string) string {
+	host = strings.TrimSpace(host)
+	if strings.ContainsAny(host, " /\\?#@\"") {
+		return ""
+	}
+	return host
+}
@@ -0,0 +1,156 @@
+package goyave
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5/config"
+	"goyave.dev/goyave/v5/slog"
+)
+
+func TestParseTrustedProxies(t *testing.T) {
+	prefixes, err := parseTrustedProxies([]string{"10.0.0.0/8", "192.168.1.12/16", "127.0.0.1", "::ffff:127.0.0.2", "2001:db8::/32"})
+	require.NoError(t, err)
+	expected := []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"),
+		netip.MustParsePrefix("192.168.0.0/16"),
+		netip.MustParsePrefix("127.0.0.1/32"),
+		netip.MustParsePrefix("127.0.0.2/32"),
+		netip.MustParsePrefix("2001:db8::/32"),
+	}
+	assert.Equal(t, expected, prefixes)
+
+	_, err = parseTrustedProxies([]string{"10.0.0.0/99"})
+	require.Error(t, err)
+	_, err = parseTrustedProxies([]string{"localhost"})
+	require.Error(t, err)
+}
+
+func TestProxyMiddleware(t *testing.T) {
+	cfg := config.LoadDefault()
+	cfg.Set("server.trustedProxies", []string{"10.0.0.0/8", "2001:db8::/32"})
+	server, err := New(Options{Config: cfg, Logger: slog.DiscardLogger()})
+	require.NoError(t, err)
+	assert.True(t, hasMiddleware[*proxyMiddleware](server.router.globalMiddleware.middleware))
+
+	cases := []struct {
+		desc       string
+		remoteAddr string
+		headers    map[string][]string
+		clientIP   string
+		scheme     string
+		host       string
+	}{
+		{
+			desc:       "untrusted_peer",
+			remoteAddr: "192.0.2.1:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"203.0.113.1"}, "X-Forwarded-Proto": {"https"}, "X-Forwarded-Host": {"forwarded.example.com"}},
+			clientIP:   "192.0.2.1",
+			scheme:     "http",
+			host:       "example.com",
+		},
+		{
+			desc:       "no_header",
+			remoteAddr: "10.0.0.1:1234",
+			clientIP:   "10.0.0.1",
+			scheme:     "http",
+			host:       "example.com",
+		},
+		{
+			desc:       "x_forwarded",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"203.0.113.1"}, "X-Forwarded-Proto": {"https"}, "X-Forwarded-Host": {"forwarded.example.com"}},
+			clientIP:   "203.0.113.1",
+			scheme:     "https",
+			host:       "forwarded.example.com",
+		},
+		{
+			desc:       "x_forwarded_chain",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"198.51.100.1, 203.0.113.1", "10.0.0.2"}, "X-Forwarded-Proto": {"https, http, http"}},
+			clientIP:   "203.0.113.1",
+			scheme:     "http",
+			host:       "example.com",
+		},
+		{
+			desc:       "x_forwarded_all_trusted",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"10.0.0.3, 10.0.0.2"}, "X-Forwarded-Proto": {"https"}},
+			clientIP:   "10.0.0.3",
+			scheme:     "https",
+			host:       "example.com",
+		},
+		{
+			desc:       "x_forwarded_invalid_element",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"203.0.113.1, unknown, 10.0.0.2"}},
+			clientIP:   "10.0.0.2",
+			scheme:     "http",
+			host:       "example.com",
+		},
+		{
+			desc:       "x_forwarded_invalid_proto_and_host",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"X-Forwarded-For": {"203.0.113.1"}, "X-Forwarded-Proto": {"javascript"}, "X-Forwarded-Host": {"evil.com/path"}},
+			clientIP:   "203.0.113.1",
+			scheme:     "http",
+			host:       "example.com",
+		},
+		{
+			desc:       "forwarded",
+			remoteAddr: "[2001:db8::1]:1234",
+			headers:    map[string][]string{"Forwarded": {`for="[2001:db8:cafe::17]:4711";proto=https;host="forwarded.example.com:8443", for=10.0.0.2;proto=http`}},
+			clientIP:   "2001:db8:cafe::17",
+			scheme:     "https",
+			host:       "forwarded.example.com:8443",
+		},
+		{
+			desc:       "forwarded_precedence",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"Forwarded": {"For=203.0.113.1;Proto=HTTPS"}, "X-Forwarded-For": {"198.51.100.1"}},
+			clientIP:   "203.0.113.1",
+			scheme:     "https",
+			host:       "example.com",
+		},
+		{
+			desc:       "forwarded_obfuscated",
+			remoteAddr: "10.0.0.1:1234",
+			headers:    map[string][]string{"Forwarded": {"for=_hidden;proto=https"}},
+			clientIP:   "10.0.0.1",
+			scheme:     "http",
+			host:       "example.com",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.desc, func(t *testing.T) {
+			httpReq := httptest.NewRequest(http.MethodGet, "/test", nil)
+			httpReq.RemoteAddr = c.remoteAddr
+			for k, v := range c.headers {
+				httpReq.Header[k] = v
+			}
+			request := NewRequest(httpReq)
+
+			middleware := &proxyMiddleware{}
+			middleware.Init(server)
+			executed := false
+			middleware.Handle(func(_ *Response, r *Request) {
+				executed = true
+				assert.Equal(t, c.clientIP, r.ClientIP())
+				assert.Equal(t, c.scheme, r.Scheme())
+				assert.Equal(t, c.host, r.Host())
+			})(NewResponse(server, request, httptest.NewRecorder()), request)
+			assert.True(t, executed)
+		})
+	}
+
+	t.Run("not_registered_without_trusted_proxies", func(t *testing.T) {
+		server, err := New(Options{Config: config.LoadDefault(), Logger: slog.DiscardLogger()})
+		require.NoError(t, err)
+		assert.False(t, hasMiddleware[*proxyMiddleware](server.router.globalMiddleware.middleware))
+	})
+}
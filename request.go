@@ -4,12 +4,14 @@ import (
 	"context"
 	"errors"
 	"io"
+	"net"
 	"net/http"
 	"net/url"
 	"strings"
 	"sync"
 	"time"
 
+	"goyave.dev/goyave/v5/httpsession"
 	"goyave.dev/goyave/v5/lang"
 )
 
@@ -42,11 +44,31 @@ var (
 	// ErrInvalidJSONBody error when an empty or malformed JSON body is sent.
 	ErrInvalidJSONBody = errors.New("parse middleware: could not JSON unmarshal body")
 
+	// ErrInvalidBody error when an empty or malformed body is sent and cannot be decoded
+	// by the decoder associated with its content type (e.g. XML or MessagePack).
+	ErrInvalidBody = errors.New("parse middleware: could not decode body")
+
 	// ErrInvalidContentForType error when e.g. a multipart form is not actually multipart, or empty.
 	ErrInvalidContentForType = errors.New("parse middleware: could not parse form")
 
 	// ErrErrorInRequestBody error when e.g. a incoming request is not received properly.
 	ErrErrorInRequestBody = errors.New("parse middleware: could not read body")
+
+	// ErrBodyTooLarge error when the request body or one of its files exceeds the maximum size.
+	ErrBodyTooLarge = errors.New("parse middleware: body too large")
+
+	// ErrHeadersTooLarge error when the request header fields exceed the maximum size.
+	ErrHeadersTooLarge = errors.New("parse middleware: header fields too large")
+
+	// ErrBodyTooDeep error when a structured body (e.g. JSON, XML or MessagePack) exceeds the
+	// maximum nesting depth.
+	ErrBodyTooDeep = errors.New("parse middleware: body exceeds the maximum nesting depth")
+
+	// ErrTooManyFiles error when a multipart form contains more files than allowed.
+	ErrTooManyFiles = errors.New("parse middleware: too many files")
+
+	// ErrTooManyQueryParameters error when the query contains more parameters than allowed.
+	ErrTooManyQueryParameters = errors.New("parse middleware: too many query parameters")
 )
 
 // Request represents a http request received by the server.
@@ -70,6 +92,8 @@ type Request struct {
 	Route       *Route
 	RouteParams map[string]string
 	cookies     []*http.Cookie
+	forwarded   *forwardedInfo
+	session     *httpsession.Session
 }
 
 var requestPool = sync.Pool{
@@ -91,6 +115,8 @@ func (r *Request) reset(httpRequest *http.Request) {
 	r.Now = time.Now()
 	r.Extra = map[any]any{}
 	r.cookies = nil
+	r.forwarded = nil
+	r.session = nil
 	r.Data = nil
 	r.Lang = nil
 	r.Query = nil
@@ -155,6 +181,44 @@ func (r *Request) RemoteAddress() string {
 	return r.httpRequest.RemoteAddr
 }
 
+// ClientIP returns the IP address of the client. If the request was forwarded by
+// a trusted proxy (see the "server.trustedProxies" config entry), the address is resolved
+// from the "Forwarded" or "X-Forwarded-For" headers. Otherwise, the host of the remote
+// address is returned.
+func (r *Request) ClientIP() string {
+	if r.forwarded != nil && r.forwarded.clientIP != "" {
+		return r.forwarded.clientIP
+	}
+	addr := r.httpRequest.RemoteAddr
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	return host
+}
+
+// Scheme returns the scheme ("http" or "https") used by the client. If the request was
+// forwarded by a trusted proxy, the scheme is resolved from the "Forwarded" or
+// "X-Forwarded-Proto" headers.
+func (r *Request) Scheme() string {
+	if r.forwarded != nil && r.forwarded.scheme != "" {
+		return r.forwarded.scheme
+	}
+	if r.httpRequest.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
+
+// Host returns the host requested by the client. If the request was forwarded by a
+// trusted proxy, the host is resolved from the "Forwarded" or "X-Forwarded-Host" headers.
+func (r *Request) Host() string {
+	if r.forwarded != nil && r.forwarded.host != "" {
+		return r.forwarded.host
+	}
+	return r.httpRequest.Host
+}
+
 // Cookies returns the HTTP cookies sent with the request.
 func (r *Request) Cookies() []*http.Cookie {
 	if r.cookies == nil {
@@ -163,6 +227,12 @@ func (r *Request) Cookies() []*http.Cookie {
 	return r.cookies
 }
 
+// Session returns the HTTP session of the client, or `nil` if sessions are not
+// enabled for the matched route (see `Router.Session()`).
+func (r *Request) Session() *httpsession.Session {
+	return r.session
+}
+
 // Referrer returns the referring URL, if sent in the request.
 func (r *Request) Referrer() string {
 	return r.httpRequest.Referer()
@@ -40,6 +40,9 @@ func TestRequest(t *testing.T) {
 		assert.Equal(t, "/test", r.URL().String())
 		assert.Equal(t, int64(5), r.ContentLength())
 		assert.Equal(t, "192.0.2.1:1234", r.RemoteAddress())
+		assert.Equal(t, "192.0.2.1", r.ClientIP())
+		assert.Equal(t, "http", r.Scheme())
+		assert.Equal(t, "example.com", r.Host())
 
 		cookies := r.Cookies()
 		assert.Equal(t, cookies, r.cookies)
@@ -58,6 +61,25 @@ func TestRequest(t *testing.T) {
 		assert.NotNil(t, NewRequest(httpReq).Body())
 	})
 
+	t.Run("Forwarded_accessors", func(t *testing.T) {
+		httpReq := httptest.NewRequest(http.MethodGet, "https://example.com/test", nil)
+		r := NewRequest(httpReq)
+		assert.Equal(t, "https", r.Scheme())
+
+		httpReq.RemoteAddr = "192.0.2.1"
+		assert.Equal(t, "192.0.2.1", r.ClientIP())
+
+		r.forwarded = &forwardedInfo{clientIP: "203.0.113.1", scheme: "http", host: "forwarded.example.com"}
+		assert.Equal(t, "203.0.113.1", r.ClientIP())
+		assert.Equal(t, "http", r.Scheme())
+		assert.Equal(t, "forwarded.example.com", r.Host())
+		assert.Equal(t, "192.0.2.1", r.RemoteAddress())
+
+		r.forwarded = &forwardedInfo{clientIP: "203.0.113.1"}
+		assert.Equal(t, "https", r.Scheme())
+		assert.Equal(t, "example.com", r.Host())
+	})
+
 	t.Run("BearerToken", func(t *testing.T) {
 		httpReq := httptest.NewRequest(http.MethodGet, "/test", nil)
 		httpReq.Header.Set("Authorization", "Bearer  token  ")
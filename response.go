@@ -199,7 +199,7 @@ func (r *Response) Flush() {
 	switch flusher := r.writer.(type) {
 	case Flusher:
 		if err := flusher.Flush(); err != nil {
-			r.server.Logger.Error(errorutil.New(err))
+			r.server.Logger.ErrorCtx(r.request.Context(), errorutil.New(err))
 		}
 	case http.Flusher:
 		flusher.Flush()
@@ -390,7 +390,7 @@ func (r *Response) Download(fs fs.StatFS, file string, fileName string) {
 // write to the response, or use your error status handler.
 func (r *Response) Error(err any) {
 	e := errorutil.NewSkip(err, 3) // Skipped: runtime.Callers, NewSkip, this func
-	r.server.Logger.Error(e)
+	r.server.Logger.ErrorCtx(r.request.Context(), e)
 	r.error(e)
 }
 
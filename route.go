@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/samber/lo"
 	"goyave.dev/goyave/v5/cors"
+	"goyave.dev/goyave/v5/httpsession"
 	"goyave.dev/goyave/v5/util/errors"
 	"goyave.dev/goyave/v5/validation"
 )
@@ -155,6 +157,27 @@ func (r *Route) ValidateQuery(validationRules RuleSetFunc) *Route {
 	return r
 }
 
+// Timeout set the maximum duration of the execution of this route's handler and of the
+// middleware executed after the timeout middleware. The deadline is applied to the request's
+// context, so handlers can stop their work early by watching `request.Context().Done()`.
+// If the deadline is exceeded, the response status is set to "503 Service Unavailable" and
+// is rendered by the status handler. Everything the handler writes afterwards is discarded.
+//
+// The handler is executed in a separate goroutine with a copy of the request, and its response
+// is buffered. Therefore, this is not suitable for streamed responses or websocket routes.
+// The changes made to the request (such as `request.User` or `request.Extra`) are copied back
+// to the original request when the handler returns in time, except the context.
+//
+// If the duration is strictly positive, the timeout middleware is automatically added globally.
+// A duration of zero disables the timeout for this route.
+func (r *Route) Timeout(timeout time.Duration) *Route {
+	r.Meta[MetaTimeout] = timeout
+	if timeout > 0 && !hasMiddleware[*timeoutMiddleware](r.parent.globalMiddleware.middleware) {
+		r.parent.GlobalMiddleware(&timeoutMiddleware{})
+	}
+	return r
+}
+
 // CORS set the CORS options for this route only.
 // The "OPTIONS" method is added if this route doesn't already support it.
 //
@@ -180,6 +203,17 @@ func (r *Route) CORS(options *cors.Options) *Route {
 	return r
 }
 
+// Session enable HTTP sessions for this route only using the given options.
+// If the options are not `nil`, the session middleware is automatically added globally.
+// To disable sessions, give `nil` options.
+func (r *Route) Session(options *httpsession.Options) *Route {
+	r.Meta[MetaSession] = options
+	if options != nil && !hasMiddleware[*sessionMiddleware](r.parent.globalMiddleware.middleware) {
+		r.parent.GlobalMiddleware(&sessionMiddleware{})
+	}
+	return r
+}
+
 // Middleware register middleware for this route only.
 //
 // Returns itself.
@@ -106,6 +106,17 @@ func TestRoute(t *testing.T) {
 		route.ValidateBody(nil)
 		assert.Nil(t, validationMiddleware.BodyRules)
 		assert.Nil(t, validationMiddleware.QueryRules)
+
+		type dto struct {
+			Name string `json:"name" validate:"required,string"`
+		}
+		route.ValidateBody(validation.FromStruct[dto])
+		if assert.NotNil(t, validationMiddleware.BodyRules) {
+			expected := validation.RuleSet{
+				{Path: "name", Rules: validation.List{validation.Required(), validation.String()}},
+			}
+			assert.Equal(t, expected, validationMiddleware.BodyRules(&Request{}))
+		}
 	})
 
 	t.Run("ValidateQuery", func(t *testing.T) {
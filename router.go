@@ -6,18 +6,23 @@ import (
 	"net/http"
 	"regexp"
 	"strings"
+	"time"
 
 	"maps"
 	"slices"
 
 	"github.com/samber/lo"
 	"goyave.dev/goyave/v5/cors"
+	"goyave.dev/goyave/v5/httpsession"
 	errorutil "goyave.dev/goyave/v5/util/errors"
 )
 
 // Common route meta keys.
 const (
-	MetaCORS = "goyave.cors"
+	MetaCORS              = "goyave.cors"
+	MetaTimeout           = "goyave.timeout"
+	MetaSession           = "goyave.session"
+	MetaMaintenanceExempt = "goyave.maintenance-exempt"
 )
 
 // Special route names.
@@ -95,7 +100,7 @@ var _ http.Handler = (*Router)(nil) // implements http.Handler
 var _ routeMatcher = (*Router)(nil) // implements routeMatcher
 
 // NewRouter create a new root-level Router that is pre-configured with core
-// middleware (recovery and language), as well as status handlers
+// middleware (recovery, language and maintenance), as well as status handlers
 // for all standard HTTP status codes.
 //
 // You don't need to manually build your router using this function.
@@ -113,7 +118,7 @@ func NewRouter(server *Server) *Router {
 			middleware: nil,
 		},
 		globalMiddleware: &middlewareHolder{
-			middleware: make([]Middleware, 0, 2),
+			middleware: make([]Middleware, 0, 3),
 		},
 		regexCache: make(map[string]*regexp.Regexp, 5),
 		Meta:       make(map[string]any),
@@ -132,7 +137,13 @@ func NewRouter(server *Server) *Router {
 		router.StatusHandler(&ErrorStatusHandler{}, i)
 	}
 	router.StatusHandler(&ErrorStatusHandler{}, http.StatusNotExtended, http.StatusNetworkAuthenticationRequired)
-	router.GlobalMiddleware(&recoveryMiddleware{}, &languageMiddleware{})
+	router.StatusHandler(&ParseErrorStatusHandler{}, http.StatusRequestEntityTooLarge, http.StatusRequestHeaderFieldsTooLarge)
+	router.GlobalMiddleware(&recoveryMiddleware{})
+	if len(server.trustedProxies) > 0 {
+		router.GlobalMiddleware(&proxyMiddleware{})
+	}
+	router.GlobalMiddleware(&languageMiddleware{})
+	router.GlobalMiddleware(&maintenanceMiddleware{})
 	return router
 }
 
@@ -247,6 +258,38 @@ func (r *Router) CORS(options *cors.Options) *Router {
 	return r
 }
 
+// Session enable HTTP sessions for this route group using the given options.
+// If the options are not `nil`, the session middleware is automatically added globally.
+// To disable sessions for this router, subrouters and routes, give `nil` options.
+// Sessions can be re-enabled for subrouters and routes on a case-by-case basis
+// using non-nil options. The session of a request is retrieved with `request.Session()`.
+func (r *Router) Session(options *httpsession.Options) *Router {
+	r.Meta[MetaSession] = options
+	if options == nil {
+		return r
+	}
+	if !hasMiddleware[*sessionMiddleware](r.globalMiddleware.middleware) {
+		r.GlobalMiddleware(&sessionMiddleware{})
+	}
+	return r
+}
+
+// Timeout set the maximum duration of the execution of the handlers of all routes
+// in this router and its subrouters. The deadline is applied to the request's context.
+// If the deadline is exceeded, the response status is set to "503 Service Unavailable".
+// See `Route.Timeout()` for more details.
+//
+// If the duration is strictly positive, the timeout middleware is automatically added globally.
+// A duration of zero disables the timeout for this router, its subrouters and routes.
+// The timeout can be re-enabled for subrouters and routes on a case-by-case basis.
+func (r *Router) Timeout(timeout time.Duration) *Router {
+	r.Meta[MetaTimeout] = timeout
+	if timeout > 0 && !hasMiddleware[*timeoutMiddleware](r.globalMiddleware.middleware) {
+		r.GlobalMiddleware(&timeoutMiddleware{})
+	}
+	return r
+}
+
 // StatusHandler set a handler for responses with an empty body.
 // The handler will be automatically executed if the request's life-cycle reaches its end
 // and nothing has been written in the response body.
@@ -511,7 +554,7 @@ func (r *Router) requestHandler(match *routeMatch, w http.ResponseWriter, rawReq
 	handler(response, request)
 
 	if err := r.finalize(match, response, request); err != nil {
-		r.server.Logger.Error(err)
+		r.server.Logger.ErrorCtx(request.Context(), err)
 	}
 
 	requestPool.Put(request)
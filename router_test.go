@@ -148,7 +148,7 @@ func TestRouter(t *testing.T) {
 	t.Run("GlobalMiddleware", func(t *testing.T) {
 		router := prepareRouterTest()
 		router.GlobalMiddleware(&corsMiddleware{}, &validateRequestMiddleware{})
-		assert.Len(t, router.globalMiddleware.middleware, 4)
+		assert.Len(t, router.globalMiddleware.middleware, 5)
 		for _, m := range router.globalMiddleware.middleware {
 			assert.NotNil(t, m.Server())
 		}
@@ -7,6 +7,7 @@ import (
 	"log"
 	"net"
 	"net/http"
+	"net/netip"
 	"os"
 	"os/signal"
 	"strconv"
@@ -101,6 +102,10 @@ type Server struct {
 	baseURL      string
 	proxyBaseURL string
 
+	trustedProxies []netip.Prefix
+
+	maintenance atomic.Pointer[maintenanceState]
+
 	stopChannel chan struct{}
 	sigChannel  chan os.Signal
 
@@ -142,6 +147,11 @@ func New(opts Options) (*Server, error) {
 		return nil, err
 	}
 
+	trustedProxies, err := parseTrustedProxies(cfg.GetStringSlice("server.trustedProxies"))
+	if err != nil {
+		return nil, errors.New(err)
+	}
+
 	port := cfg.GetInt("server.port")
 	host := cfg.GetString("server.host") + ":" + strconv.Itoa(port)
 
@@ -156,17 +166,18 @@ func New(opts Options) (*Server, error) {
 			ConnContext:       opts.ConnContext,
 			MaxHeaderBytes:    opts.MaxHeaderBytes,
 		},
-		ctx:           context.Background(),
-		baseContext:   opts.BaseContext,
-		config:        cfg,
-		services:      make(map[string]Service),
-		Lang:          languages,
-		stopChannel:   make(chan struct{}, 1),
-		startupHooks:  []func(*Server){},
-		shutdownHooks: []func(*Server){},
-		host:          cfg.GetString("server.host"),
-		port:          port,
-		Logger:        slogger,
+		ctx:            context.Background(),
+		baseContext:    opts.BaseContext,
+		config:         cfg,
+		services:       make(map[string]Service),
+		Lang:           languages,
+		stopChannel:    make(chan struct{}, 1),
+		startupHooks:   []func(*Server){},
+		shutdownHooks:  []func(*Server){},
+		host:           cfg.GetString("server.host"),
+		port:           port,
+		trustedProxies: trustedProxies,
+		Logger:         slogger,
 	}
 	server.server.BaseContext = server.internalBaseContext
 	server.refreshURLs()
@@ -434,6 +445,17 @@ func (s *Server) Start() error {
 
 	s.state.Store(2)
 
+	if path := s.config.GetString("server.maintenance.file"); path != "" {
+		watchCtx, cancel := context.WithCancel(s.ctx)
+		defer cancel()
+		s.checkMaintenanceFile(path, time.Time{})
+		interval := time.Duration(s.config.GetInt("server.maintenance.pollInterval")) * time.Second
+		if interval <= 0 {
+			interval = defaultMaintenancePollInterval
+		}
+		go s.watchMaintenanceFile(watchCtx, path, interval)
+	}
+
 	go func(s *Server) {
 		if s.IsReady() {
 			// We check if the server is ready to prevent startup hook execution
@@ -9,6 +9,7 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
 	"regexp"
 	"runtime"
 	"sync"
@@ -108,6 +109,15 @@ func TestServer(t *testing.T) {
 		assert.Nil(t, s)
 	})
 
+	t.Run("New_invalid_trusted_proxies", func(t *testing.T) {
+		cfg := config.LoadDefault()
+		cfg.Set("server.trustedProxies", []string{"10.0.0.0/8", "not an IP"})
+		s, err := New(Options{Config: cfg})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), `invalid trusted proxy "not an IP"`)
+		assert.Nil(t, s)
+	})
+
 	t.Run("NewWithOptions", func(t *testing.T) {
 		database.RegisterDialect("sqlite3_server_test", "file:{name}?{options}", sqlite.Open)
 		cfg := config.LoadDefault()
@@ -408,6 +418,47 @@ func TestServer(t *testing.T) {
 		assert.Equal(t, uint32(3), server.state.Load())
 	})
 
+	t.Run("StartWithMaintenanceFile", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "maintenance")
+		require.NoError(t, os.WriteFile(path, []byte(`{"retryAfter":30}`), 0o644))
+		cfg := config.LoadDefault()
+		cfg.Set("server.port", 0)
+		cfg.Set("server.maintenance.file", path)
+		server, err := New(Options{Config: cfg})
+		require.NoError(t, err)
+
+		wg := sync.WaitGroup{}
+		wg.Add(2)
+
+		server.RegisterStartupHook(func(s *Server) {
+			assert.True(t, s.IsInMaintenance())
+
+			res, err := http.Get(s.BaseURL())
+			if assert.NoError(t, err) {
+				assert.Equal(t, http.StatusServiceUnavailable, res.StatusCode)
+				assert.Equal(t, "30", res.Header.Get("Retry-After"))
+				assert.NoError(t, res.Body.Close())
+			}
+
+			server.Stop()
+			wg.Done()
+		})
+
+		server.RegisterRoutes(func(_ *Server, router *Router) {
+			router.Get("/", func(r *Response, _ *Request) {
+				r.String(http.StatusOK, "hello world")
+			})
+		})
+
+		go func() {
+			err := server.Start()
+			assert.NoError(t, err)
+			wg.Done()
+		}()
+
+		wg.Wait()
+	})
+
 	t.Run("Start_already_running", func(t *testing.T) {
 		server, err := New(Options{Config: config.LoadDefault()})
 		require.NoError(t, err)
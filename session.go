@@ -0,0 +1,150 @@
+package goyave
+
+import (
+	"context"
+	"time"
+
+	"goyave.dev/goyave/v5/httpsession"
+	errorutil "goyave.dev/goyave/v5/util/errors"
+)
+
+// sessionMiddleware loads the HTTP session of the client from the session cookie and
+// makes it available with `request.Session()`. The options are defined by the
+// `MetaSession` route meta. This middleware is automatically added globally when
+// sessions are enabled with `Router.Session()` or `Route.Session()`.
+//
+// If the cookie is missing, or if the session doesn't exist or has expired, a new
+// session is created. New sessions are only saved if they are modified, so clients
+// that never use their session don't create entries in the store.
+//
+// The session is saved, and the cookie is set, right before the response header is
+// written, or after the handler returns if nothing was written yet. Therefore, handlers
+// calling `response.WriteHeader()` directly without writing a body should modify the
+// session before calling it.
+type sessionMiddleware struct {
+	Component
+}
+
+func (m *sessionMiddleware) Handle(next Handler) Handler {
+	return func(response *Response, request *Request) {
+		o, ok := request.Route.LookupMeta(MetaSession)
+		if !ok || o == nil || o == (*httpsession.Options)(nil) {
+			next(response, request)
+			return
+		}
+		options := o.(*httpsession.Options)
+
+		token := ""
+		cookieName := options.GetCookieName()
+		for _, c := range request.Cookies() {
+			if c.Name == cookieName {
+				token = c.Value
+				break
+			}
+		}
+
+		session, err := m.load(request.Context(), options, token, request)
+		if err != nil {
+			response.Error(err)
+			return
+		}
+		request.session = session
+
+		writer := &sessionWriter{
+			CommonWriter: NewCommonWriter(response.Writer()),
+			middleware:   m,
+			options:      options,
+			request:      request,
+			response:     response,
+			token:        token,
+		}
+		response.SetWriter(writer)
+		next(response, request)
+		writer.commit()
+	}
+}
+
+// load the session identified by the given token, or create a new one if it
+// doesn't exist or has expired.
+func (m *sessionMiddleware) load(ctx context.Context, options *httpsession.Options, token string, request *Request) (*httpsession.Session, error) {
+	if token != "" {
+		data, err := options.Store.Load(ctx, token)
+		if err != nil {
+			return nil, errorutil.New(err)
+		}
+		if data != nil {
+			if !options.IsExpired(data, request.Now) {
+				return httpsession.Load(data), nil
+			}
+			if err := options.Store.Delete(ctx, token); err != nil {
+				return nil, errorutil.New(err)
+			}
+		}
+	}
+	session, err := httpsession.New(request.Now)
+	return session, errorutil.New(err)
+}
+
+// sessionWriter saves the session before the response header is written.
+type sessionWriter struct {
+	CommonWriter
+	middleware *sessionMiddleware
+	options    *httpsession.Options
+	request    *Request
+	response   *Response
+	token      string
+	committed  bool
+}
+
+// PreWrite saves the session and sets the session cookie,
+// then calls PreWrite on the child writer.
+func (w *sessionWriter) PreWrite(b []byte) {
+	w.commit()
+	w.CommonWriter.PreWrite(b)
+}
+
+func (w *sessionWriter) commit() {
+	if w.committed || w.response.IsHeaderWritten() || w.response.Hijacked() {
+		return
+	}
+	w.committed = true
+	if err := w.save(); err != nil {
+		w.middleware.Logger().ErrorCtx(w.request.Context(), err)
+	}
+}
+
+func (w *sessionWriter) save() error {
+	ctx := context.WithoutCancel(w.request.Context())
+	session := w.request.session
+	store := w.options.Store
+	secure := w.request.Scheme() == "https"
+
+	if session.IsDestroyed() || (session.IsNew() && !session.IsModified()) {
+		if w.token == "" {
+			return nil
+		}
+		w.response.Cookie(w.options.Cookie("", -1, secure))
+		return errorutil.New(store.Delete(ctx, w.token))
+	}
+
+	if session.IsRegenerated() && w.token != "" {
+		if err := store.Delete(ctx, w.token); err != nil {
+			return errorutil.New(err)
+		}
+	}
+
+	now := w.request.Now
+	data := session.Touch(now)
+	token, err := store.Save(ctx, data, w.options.TTL(data, now))
+	if err != nil {
+		return errorutil.New(err)
+	}
+	if token != w.token {
+		maxAge := time.Duration(0)
+		if w.options.AbsoluteTimeout > 0 {
+			maxAge = data.CreatedAt.Add(w.options.AbsoluteTimeout).Sub(now)
+		}
+		w.response.Cookie(w.options.Cookie(token, maxAge, secure))
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+package goyave
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5/config"
+	"goyave.dev/goyave/v5/httpsession"
+	"goyave.dev/goyave/v5/slog"
+)
+
+type testErrorSessionStore struct{}
+
+func (testErrorSessionStore) Load(_ context.Context, _ string) (*httpsession.Data, error) {
+	return nil, fmt.Errorf("load error")
+}
+
+func (testErrorSessionStore) Save(_ context.Context, _ *httpsession.Data, _ time.Duration) (string, error) {
+	return "", fmt.Errorf("save error")
+}
+
+func (testErrorSessionStore) Delete(_ context.Context, _ string) error {
+	return fmt.Errorf("delete error")
+}
+
+func prepareSessionTest(t *testing.T) (*Router, *testSyncBuffer) {
+	logBuffer := &testSyncBuffer{}
+	server, err := New(Options{Config: config.LoadDefault(), Logger: slog.New(slog.NewHandler(false, logBuffer))})
+	require.NoError(t, err)
+	return NewRouter(server), logBuffer
+}
+
+func serveSessionTest(router *Router, method, path string, cookie *http.Cookie) *http.Response {
+	recorder := httptest.NewRecorder()
+	request := httptest.NewRequest(method, path, nil)
+	if cookie != nil {
+		request.AddCookie(cookie)
+	}
+	router.ServeHTTP(recorder, request)
+	resp := recorder.Result()
+	_ = resp.Body.Close()
+	return resp
+}
+
+func findSessionCookie(resp *http.Response) *http.Cookie {
+	for _, c := range resp.Cookies() {
+		if c.Name == httpsession.DefaultCookieName {
+			return c
+		}
+	}
+	return nil
+}
+
+func TestSessionMiddleware(t *testing.T) {
+	t.Run("lifecycle", func(t *testing.T) {
+		router, _ := prepareSessionTest(t)
+		store := httpsession.NewMemoryStore()
+		router.Session(&httpsession.Options{Store: store, IdleTimeout: time.Hour})
+		var session *httpsession.Session
+		router.Get("/unused", func(response *Response, request *Request) {
+			session = request.Session()
+			response.Status(http.StatusNoContent)
+		})
+		router.Get("/set", func(response *Response, request *Request) {
+			request.Session().Set("key", "value")
+			request.Session().Flash("message", "hello")
+			response.String(http.StatusOK, "hello")
+		})
+		router.Get("/get", func(response *Response, request *Request) {
+			session = request.Session()
+			response.Status(http.StatusNoContent)
+		})
+		router.Get("/regenerate", func(response *Response, request *Request) {
+			require.NoError(t, request.Session().Regenerate())
+			response.Status(http.StatusNoContent)
+		})
+		router.Get("/destroy", func(response *Response, request *Request) {
+			request.Session().Destroy()
+			response.Status(http.StatusNoContent)
+		})
+
+		resp := serveSessionTest(router, http.MethodGet, "/unused", nil)
+		require.NotNil(t, session)
+		assert.True(t, session.IsNew())
+		assert.Nil(t, findSessionCookie(resp))
+		assert.Equal(t, 0, store.Len())
+
+		resp = serveSessionTest(router, http.MethodGet, "/set", nil)
+		cookie := findSessionCookie(resp)
+		require.NotNil(t, cookie)
+		assert.True(t, cookie.HttpOnly)
+		assert.Equal(t, 0, cookie.MaxAge)
+		assert.Equal(t, 1, store.Len())
+
+		resp = serveSessionTest(router, http.MethodGet, "/get", cookie)
+		assert.Nil(t, findSessionCookie(resp)) // Same ID: cookie not re-sent
+		assert.Equal(t, cookie.Value, session.ID())
+		assert.False(t, session.IsNew())
+		v, _ := session.Get("key")
+		assert.Equal(t, "value", v)
+		v, _ = session.Get("message")
+		assert.Equal(t, "hello", v)
+
+		// Flash consumed
+		_ = serveSessionTest(router, http.MethodGet, "/get", cookie)
+		assert.False(t, session.Has("message"))
+		assert.True(t, session.Has("key"))
+
+		resp = serveSessionTest(router, http.MethodGet, "/regenerate", cookie)
+		newCookie := findSessionCookie(resp)
+		require.NotNil(t, newCookie)
+		assert.NotEqual(t, cookie.Value, newCookie.Value)
+		assert.Equal(t, 1, store.Len())
+		data, err := store.Load(context.Background(), cookie.Value)
+		require.NoError(t, err)
+		assert.Nil(t, data)
+
+		// Old cookie: new empty session
+		_ = serveSessionTest(router, http.MethodGet, "/get", cookie)
+		assert.True(t, session.IsNew())
+		assert.False(t, session.Has("key"))
+
+		resp = serveSessionTest(router, http.MethodGet, "/destroy", newCookie)
+		deleted := findSessionCookie(resp)
+		require.NotNil(t, deleted)
+		assert.Equal(t, -1, deleted.MaxAge)
+		assert.Equal(t, 0, store.Len())
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		router, _ := prepareSessionTest(t)
+		store := httpsession.NewMemoryStore()
+		router.Session(&httpsession.Options{Store: store, IdleTimeout: time.Hour, AbsoluteTimeout: 2 * time.Hour})
+		var session *httpsession.Session
+		router.Get("/set", func(response *Response, request *Request) {
+			request.Session().Set("key", "value")
+			response.Status(http.StatusNoContent)
+		})
+		router.Get("/get", func(response *Response, request *Request) {
+			session = request.Session()
+			response.Status(http.StatusNoContent)
+		})
+
+		resp := serveSessionTest(router, http.MethodGet, "/set", nil)
+		cookie := findSessionCookie(resp)
+		require.NotNil(t, cookie)
+		assert.InDelta(t, 7200, cookie.MaxAge, 1)
+
+		// Make the session idle for too long
+		data, err := store.Load(context.Background(), cookie.Value)
+		require.NoError(t, err)
+		data.LastActivity = data.LastActivity.Add(-time.Hour)
+		_, err = store.Save(context.Background(), data, time.Hour)
+		require.NoError(t, err)
+
+		resp = serveSessionTest(router, http.MethodGet, "/get", cookie)
+		assert.True(t, session.IsNew())
+		assert.False(t, session.Has("key"))
+		deleted := findSessionCookie(resp)
+		require.NotNil(t, deleted)
+		assert.Equal(t, -1, deleted.MaxAge)
+		assert.Equal(t, 0, store.Len())
+	})
+
+	t.Run("cookie_store_status_handler", func(t *testing.T) {
+		router, _ := prepareSessionTest(t)
+		store, err := httpsession.NewCookieStore([]byte("0123456789abcdef"))
+		require.NoError(t, err)
+		router.Session(&httpsession.Options{Store: store})
+		var session *httpsession.Session
+		router.Get("/set", func(response *Response, request *Request) {
+			request.Session().Set("key", "value")
+			response.Status(http.StatusNotFound)
+		})
+		router.Get("/get", func(response *Response, request *Request) {
+			session = request.Session()
+			response.Status(http.StatusNoContent)
+		})
+
+		resp := serveSessionTest(router, http.MethodGet, "/set", nil)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		cookie := findSessionCookie(resp)
+		require.NotNil(t, cookie)
+
+		resp = serveSessionTest(router, http.MethodGet, "/get", cookie)
+		v, _ := session.Get("key")
+		assert.Equal(t, "value", v)
+		assert.NotNil(t, findSessionCookie(resp)) // Cookie store: updated every request
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		router, _ := prepareSessionTest(t)
+		router.Session(httpsession.Default())
+		subrouter := router.Subrouter("/api")
+		subrouter.Session(nil)
+		var session *httpsession.Session
+		subrouter.Get("/test", func(response *Response, request *Request) {
+			session = request.Session()
+			response.Status(http.StatusNoContent)
+		})
+		router.Get("/typed-nil", func(response *Response, request *Request) {
+			session = request.Session()
+			response.Status(http.StatusNoContent)
+		}).Session((*httpsession.Options)(nil))
+		router.Get("/enabled", func(response *Response, request *Request) {
+			session = request.Session()
+			response.Status(http.StatusNoContent)
+		})
+		subrouter.Get("/enabled", func(response *Response, request *Request) {
+			session = request.Session()
+			response.Status(http.StatusNoContent)
+		}).Session(httpsession.Default())
+
+		_ = serveSessionTest(router, http.MethodGet, "/api/test", nil)
+		assert.Nil(t, session)
+		_ = serveSessionTest(router, http.MethodGet, "/typed-nil", nil)
+		assert.Nil(t, session)
+		_ = serveSessionTest(router, http.MethodGet, "/enabled", nil)
+		assert.NotNil(t, session)
+		session = nil
+		_ = serveSessionTest(router, http.MethodGet, "/api/enabled", nil)
+		assert.NotNil(t, session)
+	})
+
+	t.Run("registration", func(t *testing.T) {
+		router, _ := prepareSessionTest(t)
+		router.Session(nil)
+		assert.False(t, hasMiddleware[*sessionMiddleware](router.globalMiddleware.middleware))
+		assert.Contains(t, router.Meta, MetaSession)
+
+		options := httpsession.Default()
+		route := router.Get("/test", func(_ *Response, _ *Request) {}).Session(options)
+		assert.Equal(t, options, route.Meta[MetaSession])
+		assert.True(t, hasMiddleware[*sessionMiddleware](router.globalMiddleware.middleware))
+
+		router.Session(options)
+		assert.Len(t, router.globalMiddleware.middleware, 4) // recovery, lang, maintenance, session
+	})
+
+	t.Run("store_error", func(t *testing.T) {
+		router, logBuffer := prepareSessionTest(t)
+		router.Session(&httpsession.Options{Store: testErrorSessionStore{}})
+		router.Get("/test", func(response *Response, request *Request) {
+			request.Session().Set("key", "value")
+			response.Status(http.StatusNoContent)
+		})
+
+		resp := serveSessionTest(router, http.MethodGet, "/test", nil)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		assert.Contains(t, logBuffer.String(), "save error")
+
+		resp = serveSessionTest(router, http.MethodGet, "/test", &http.Cookie{Name: httpsession.DefaultCookieName, Value: "token"})
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.Contains(t, logBuffer.String(), "load error")
+	})
+}
@@ -233,3 +233,58 @@ func printGroup(group []slog.Attr, buf *bytes.Buffer, indent int) {
 		printAttr(attr, buf, indent)
 	}
 }
+
+// ContextHandler is a `slog.Handler` adding the attributes stored in the context
+// (see `ContextWithAttrs()`) to every record before passing it to the wrapped handler.
+// The context attributes are added before the attributes of the record.
+//
+// Loggers created with `New()` use this handler automatically, so the context attributes
+// are also added to the records emitted using the methods of the embedded `*slog.Logger`
+// or a `*slog.Logger` created from the logger's handler.
+type ContextHandler struct {
+	handler slog.Handler
+}
+
+// NewContextHandler creates a new `ContextHandler` wrapping the given handler.
+// If the given handler is already a `*ContextHandler`, it is returned as is.
+func NewContextHandler(h slog.Handler) *ContextHandler {
+	if ctxHandler, ok := h.(*ContextHandler); ok {
+		return ctxHandler
+	}
+	return &ContextHandler{handler: h}
+}
+
+// Handler returns the wrapped handler.
+func (h *ContextHandler) Handler() slog.Handler {
+	return h.handler
+}
+
+// Enabled reports whether the wrapped handler handles records at the given level.
+func (h *ContextHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.handler.Enabled(ctx, level)
+}
+
+// Handle adds the context attributes to the given record and passes it to the wrapped handler.
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	attrs := ContextAttrs(ctx)
+	if len(attrs) == 0 {
+		return h.handler.Handle(ctx, r)
+	}
+	record := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	record.AddAttrs(attrs...)
+	r.Attrs(func(a slog.Attr) bool {
+		record.AddAttrs(a)
+		return true
+	})
+	return h.handler.Handle(ctx, record)
+}
+
+// WithAttrs returns a new `ContextHandler` wrapping the result of `WithAttrs` on the wrapped handler.
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{handler: h.handler.WithAttrs(attrs)}
+}
+
+// WithGroup returns a new `ContextHandler` wrapping the result of `WithGroup` on the wrapped handler.
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{handler: h.handler.WithGroup(name)}
+}
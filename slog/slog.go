@@ -17,6 +17,28 @@ type unwrapper interface {
 	Unwrap() []error
 }
 
+type contextAttrsKey struct{}
+
+// ContextWithAttrs returns a copy of the given context carrying the given attributes,
+// in addition to the attributes already stored in the parent context.
+// These attributes are added to every record logged with this context
+// (for example with `ErrorCtx()` or `InfoContext()`) by the `ContextHandler`.
+func ContextWithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	parent := ContextAttrs(ctx)
+	merged := make([]slog.Attr, 0, len(parent)+len(attrs))
+	merged = append(append(merged, parent...), attrs...)
+	return context.WithValue(ctx, contextAttrsKey{}, merged)
+}
+
+// ContextAttrs returns the attributes stored in the given context using `ContextWithAttrs()`.
+func ContextAttrs(ctx context.Context) []slog.Attr {
+	if ctx == nil {
+		return nil
+	}
+	attrs, _ := ctx.Value(contextAttrsKey{}).([]slog.Attr)
+	return attrs
+}
+
 // Logger an extension of standard `*slog.Logger` overriding the `Error()` and `ErrorCtx()`
 // functions so they take an error as parameter and handle `*errors.Error` gracefully.
 type Logger struct {
@@ -24,8 +46,10 @@ type Logger struct {
 }
 
 // New creates a new Logger with the given non-nil Handler and a nil context.
+// The handler is wrapped in a `ContextHandler` so the attributes stored in the
+// context of each record are added to it.
 func New(h slog.Handler) *Logger {
-	return &Logger{Logger: slog.New(h)}
+	return &Logger{Logger: slog.New(NewContextHandler(h))}
 }
 
 // With returns a new Logger that includes the given arguments, converted to
@@ -38,6 +62,21 @@ func (l *Logger) With(args ...any) *Logger {
 	return &Logger{Logger: l.Logger.With(args...)}
 }
 
+// DebugContext logs at `LevelDebug` with the given context.
+func (l *Logger) DebugContext(ctx context.Context, msg string, args ...any) {
+	l.logEnabled(ctx, slog.LevelDebug, msg, args...)
+}
+
+// InfoContext logs at `LevelInfo` with the given context.
+func (l *Logger) InfoContext(ctx context.Context, msg string, args ...any) {
+	l.logEnabled(ctx, slog.LevelInfo, msg, args...)
+}
+
+// WarnContext logs at `LevelWarn` with the given context.
+func (l *Logger) WarnContext(ctx context.Context, msg string, args ...any) {
+	l.logEnabled(ctx, slog.LevelWarn, msg, args...)
+}
+
 // DebugWithSource logs at `LevelDebug`. The given source will be used instead of the automatically collecting it from the caller.
 func (l *Logger) DebugWithSource(ctx context.Context, source uintptr, msg string, args ...any) {
 	l.log(ctx, slog.LevelDebug, source, msg, args...)
@@ -73,12 +112,12 @@ func (l *Logger) logError(ctx context.Context, source uintptr, err error, args .
 	if err != nil {
 		msg = err.Error()
 	}
-	r := l.makeRecord(slog.LevelError, msg, source, args...)
-
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
+	r := l.makeRecord(slog.LevelError, msg, source, args...)
+
 	switch e := err.(type) {
 	case *errors.Error:
 		l.handleError(ctx, e, r)
@@ -92,16 +131,28 @@ func (l *Logger) logError(ctx context.Context, source uintptr, err error, args .
 	}
 }
 
-func (l *Logger) log(ctx context.Context, level slog.Level, source uintptr, msg string, args ...any) {
-	r := l.makeRecord(level, msg, source, args...)
+func (l *Logger) logEnabled(ctx context.Context, level slog.Level, msg string, args ...any) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if !l.Enabled(ctx, level) {
+		return
+	}
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	l.log(ctx, level, pcs[0], msg, args...)
+}
 
+func (l *Logger) log(ctx context.Context, level slog.Level, source uintptr, msg string, args ...any) {
 	if ctx == nil {
 		ctx = context.Background()
 	}
 
+	r := l.makeRecord(level, msg, source, args...)
 	_ = l.Handler().Handle(ctx, r)
 }
 
+// makeRecord creates a new record.
 func (l *Logger) makeRecord(level slog.Level, msg string, pc uintptr, args ...any) slog.Record {
 	if pc == 0 {
 		var pcs [1]uintptr
@@ -140,7 +191,7 @@ func (l *Logger) handleReason(ctx context.Context, reason error, trace *slog.Att
 		if trace != nil {
 			clone.AddAttrs(*trace)
 		}
-		if _, isDevMode := l.Handler().(*DevModeHandler); !isDevMode {
+		if !isDevModeHandler(l.Handler()) {
 			clone.AddAttrs(slog.Any("reason", e.Value()))
 		}
 		_ = l.Handler().Handle(ctx, clone)
@@ -155,6 +206,14 @@ func (l *Logger) handleReason(ctx context.Context, reason error, trace *slog.Att
 	}
 }
 
+func isDevModeHandler(h slog.Handler) bool {
+	if ctxHandler, ok := h.(*ContextHandler); ok {
+		h = ctxHandler.Handler()
+	}
+	_, isDevMode := h.(*DevModeHandler)
+	return isDevMode
+}
+
 // StructValue recursively convert a structure, structure pointer or map to a `slog.GroupValue`.
 // If the given value implements `slog.LogValuer`, this value is returned instead.
 // Returns AnyValue if the type is not supported.
@@ -27,7 +27,8 @@ func TestLogger(t *testing.T) {
 	t.Run("New", func(t *testing.T) {
 		handler := NewDevModeHandler(bytes.NewBuffer(make([]byte, 0, 10)), nil)
 		l := New(handler)
-		assert.Equal(t, &Logger{Logger: slog.New(handler)}, l)
+		assert.Equal(t, &Logger{Logger: slog.New(&ContextHandler{handler: handler})}, l)
+		assert.Same(t, l.Handler(), New(l.Handler()).Handler())
 	})
 
 	t.Run("With", func(t *testing.T) {
@@ -35,7 +36,7 @@ func TestLogger(t *testing.T) {
 		l2 := l.With(slog.String("attr_1", "val1"))
 
 		handler := NewDevModeHandler(bytes.NewBuffer(make([]byte, 0, 10)), nil)
-		expected := &Logger{Logger: slog.New(handler.WithAttrs([]slog.Attr{slog.String("attr_1", "val1")}))}
+		expected := &Logger{Logger: slog.New(&ContextHandler{handler: handler.WithAttrs([]slog.Attr{slog.String("attr_1", "val1")})})}
 
 		assert.Equal(t, expected, l2)
 	})
@@ -177,6 +178,57 @@ func TestLogger(t *testing.T) {
 		assert.Regexp(t, r, buf.String())
 	})
 
+	t.Run("Log_context", func(t *testing.T) {
+		_, file, line, ok := runtime.Caller(0)
+		if !assert.True(t, ok) {
+			return
+		}
+		buf := bytes.NewBuffer(make([]byte, 0, 1024))
+		l := New(slog.NewJSONHandler(buf, &slog.HandlerOptions{AddSource: true, Level: slog.LevelInfo}))
+
+		ctx := ContextWithAttrs(context.Background(), slog.String("requestId", "123"))
+		ctx = ContextWithAttrs(ctx, slog.String("user", "456"))
+		assert.Equal(t, []slog.Attr{slog.String("requestId", "123"), slog.String("user", "456")}, ContextAttrs(ctx))
+		assert.Nil(t, ContextAttrs(context.Background()))
+		assert.Nil(t, ContextAttrs(nil)) //nolint:staticcheck
+
+		l.InfoContext(ctx, "message", "attr", "val")
+		assert.Regexp(t, regexp.MustCompile(fmt.Sprintf(`{"time":".+","level":"INFO","source":{"function":".+","file":"%s","line":%d},"msg":"message","requestId":"123","user":"456","attr":"val"}\n`, regexp.QuoteMeta(file), line+13)), buf.String())
+
+		buf.Reset()
+		l.WarnContext(ctx, "message")
+		assert.Regexp(t, regexp.MustCompile(`"level":"WARN",.+"msg":"message","requestId":"123","user":"456"}\n`), buf.String())
+
+		buf.Reset()
+		l.DebugContext(ctx, "message") // Level disabled
+		assert.Empty(t, buf.String())
+
+		buf.Reset()
+		l.ErrorCtx(ctx, fmt.Errorf("error"))
+		assert.Regexp(t, regexp.MustCompile(`"level":"ERROR",.+"msg":"error","requestId":"123","user":"456"}\n`), buf.String())
+	})
+
+	t.Run("Log_context_std_logger", func(t *testing.T) {
+		buf := bytes.NewBuffer(make([]byte, 0, 1024))
+		l := New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelInfo}))
+		ctx := ContextWithAttrs(context.Background(), slog.String("requestId", "123"))
+
+		l.Logger.With("attr", "val").InfoContext(ctx, "message", "other", "val")
+		assert.Regexp(t, regexp.MustCompile(`"msg":"message","attr":"val","requestId":"123","other":"val"}\n`), buf.String())
+
+		buf.Reset()
+		l.LogAttrs(ctx, slog.LevelWarn, "message")
+		assert.Regexp(t, regexp.MustCompile(`"level":"WARN","msg":"message","requestId":"123"}\n`), buf.String())
+
+		buf.Reset()
+		slog.New(l.Handler()).WithGroup("group").InfoContext(ctx, "message", "attr", "val")
+		assert.Regexp(t, regexp.MustCompile(`"msg":"message","group":{"requestId":"123","attr":"val"}}\n`), buf.String())
+
+		buf.Reset()
+		l.Logger.InfoContext(context.Background(), "message")
+		assert.Regexp(t, regexp.MustCompile(`"msg":"message"}\n`), buf.String())
+	})
+
 	t.Run("DiscardLogger", func(t *testing.T) {
 		logger := DiscardLogger()
 
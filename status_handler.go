@@ -64,12 +64,24 @@ func (h *ParseErrorStatusHandler) Handle(response *Response, request *Request) {
 		switch {
 		case errors.Is(err, ErrInvalidJSONBody):
 			errorMessage = lang.Get("parse.json-invalid-body")
+		case errors.Is(err, ErrInvalidBody):
+			errorMessage = lang.Get("parse.invalid-body")
 		case errors.Is(err, ErrInvalidQuery):
 			errorMessage = lang.Get("parse.invalid-query")
 		case errors.Is(err, ErrInvalidContentForType):
 			errorMessage = lang.Get("parse.invalid-content-for-type")
 		case errors.Is(err, ErrErrorInRequestBody):
 			errorMessage = lang.Get("parse.error-in-request-body")
+		case errors.Is(err, ErrBodyTooLarge):
+			errorMessage = lang.Get("parse.body-too-large")
+		case errors.Is(err, ErrHeadersTooLarge):
+			errorMessage = lang.Get("parse.headers-too-large")
+		case errors.Is(err, ErrBodyTooDeep):
+			errorMessage = lang.Get("parse.body-too-deep")
+		case errors.Is(err, ErrTooManyFiles):
+			errorMessage = lang.Get("parse.too-many-files")
+		case errors.Is(err, ErrTooManyQueryParameters):
+			errorMessage = lang.Get("parse.too-many-query-parameters")
 		default:
 			errorMessage = lang.Get(err.Error())
 		}
@@ -149,6 +149,12 @@ func TestParseErrorStatusHandler(t *testing.T) {
 			expectedMessage: "The request Content-Type indicates JSON, but the request body is empty or invalid.",
 			expectedStatus:  http.StatusBadRequest,
 		},
+		{
+			name:            "InvalidBody",
+			err:             fmt.Errorf("%w: %w", ErrInvalidBody, errors.New("msgpack: invalid code")),
+			expectedMessage: "The request body is empty or invalid for its Content-Type.",
+			expectedStatus:  http.StatusBadRequest,
+		},
 		{
 			name:            "InvalidQuery",
 			err:             ErrInvalidQuery,
@@ -167,6 +173,36 @@ func TestParseErrorStatusHandler(t *testing.T) {
 			expectedMessage: "Failed to read request body due to connection issues, timeouts, size mismatches, or corrupted data.",
 			expectedStatus:  http.StatusBadRequest,
 		},
+		{
+			name:            "BodyTooLarge",
+			err:             ErrBodyTooLarge,
+			expectedMessage: "The request body or one of its files is too large.",
+			expectedStatus:  http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:            "HeadersTooLarge",
+			err:             ErrHeadersTooLarge,
+			expectedMessage: "The request header fields are too large.",
+			expectedStatus:  http.StatusRequestHeaderFieldsTooLarge,
+		},
+		{
+			name:            "BodyTooDeep",
+			err:             ErrBodyTooDeep,
+			expectedMessage: "The request body exceeds the maximum nesting depth.",
+			expectedStatus:  http.StatusBadRequest,
+		},
+		{
+			name:            "TooManyFiles",
+			err:             ErrTooManyFiles,
+			expectedMessage: "The request contains too many files.",
+			expectedStatus:  http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:            "TooManyQueryParameters",
+			err:             ErrTooManyQueryParameters,
+			expectedMessage: "The request contains too many query parameters.",
+			expectedStatus:  http.StatusBadRequest,
+		},
 		{
 			name:            "OtherError",
 			err:             errors.New("some.other.error"),
@@ -0,0 +1,197 @@
+package goyave
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+
+	errorutil "goyave.dev/goyave/v5/util/errors"
+)
+
+// ErrHandlerTimeout returned by `Write()` on a response whose handler
+// exceeded the timeout defined with `Router.Timeout()` or `Route.Timeout()`.
+var ErrHandlerTimeout = errors.New("http: handler timeout")
+
+// statusClientClosedRequest the non-standard status recorded (but not written) when
+// the client closes the connection before a handler executed with a timeout returns.
+const statusClientClosedRequest = 499
+
+// timeoutWriter buffers the response written by a handler executed with a timeout.
+// Once the timeout is exceeded, all writes are rejected with `ErrHandlerTimeout`.
+type timeoutWriter struct {
+	header   http.Header
+	buf      bytes.Buffer
+	mu       sync.Mutex
+	status   int
+	timedOut bool
+}
+
+func (w *timeoutWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *timeoutWriter) Write(b []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut {
+		return 0, ErrHandlerTimeout
+	}
+	return w.buf.Write(b)
+}
+
+func (w *timeoutWriter) WriteHeader(status int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.timedOut || w.status != 0 {
+		return
+	}
+	w.status = status
+}
+
+// timeoutPanic a panic recovered in the goroutine executing the handler.
+type timeoutPanic struct {
+	err *errorutil.Error
+}
+
+// timeoutMiddleware executes the next handlers with a deadline derived from the request's
+// context. The deadline is defined by the `MetaTimeout` route meta.
+//
+// The next handlers are executed in a separate goroutine, with a copy of the request and a
+// separate response whose body is buffered. If the handlers return before the deadline, the
+// request copy is copied back into the original request, and the buffered response is written
+// to the original response. Otherwise, the response status is set to "503 Service Unavailable"
+// so it is rendered by the status handler, and everything the late handler writes afterwards
+// is discarded: `Write()` returns `ErrHandlerTimeout`. The status is the same as the one
+// returned by the standard `http.TimeoutHandler`: "504 Gateway Timeout" is meant for proxies
+// that didn't receive a timely response from an upstream server.
+//
+// If the client closes the connection before the handlers return, nothing is written to the
+// response. Its status is set to 499 (as reported by nginx) so it can be logged, but the
+// status handlers are not executed.
+//
+// Handlers are expected to cooperate by watching `request.Context().Done()`, or by passing
+// the request's context to blocking operations (such as database queries).
+//
+// Because the response is buffered, the timeout is not suitable for streamed responses
+// or hijacked connections (websocket).
+type timeoutMiddleware struct {
+	Component
+}
+
+func (m *timeoutMiddleware) Handle(next Handler) Handler {
+	return func(response *Response, request *Request) {
+		timeout := m.getTimeout(request)
+		if timeout <= 0 {
+			next(response, request)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(request.Context(), timeout)
+		defer cancel()
+
+		innerRequest := &Request{}
+		*innerRequest = *request
+		innerRequest.Extra = maps.Clone(request.Extra)
+		innerRequest.httpRequest = request.httpRequest.WithContext(ctx)
+
+		writer := &timeoutWriter{header: response.Header().Clone()}
+		innerResponse := &Response{}
+		innerResponse.reset(m.server, innerRequest, writer)
+
+		done := make(chan struct{})
+		panicChan := make(chan timeoutPanic, 1)
+		go func() {
+			defer func() {
+				if err := recover(); err != nil {
+					e := errorutil.NewSkip(err, 4).(*errorutil.Error) // Skipped: runtime.Callers, NewSkip, this func, runtime.panic
+					panicChan <- timeoutPanic{err: e}
+					return
+				}
+				close(done)
+			}()
+			next(innerResponse, innerRequest)
+			if err := innerResponse.close(); err != nil {
+				panic(err)
+			}
+		}()
+
+		select {
+		case p := <-panicChan:
+			panic(p.err)
+		case <-done:
+			m.copyResponse(response, innerResponse, writer)
+			httpRequest := request.httpRequest
+			*request = *innerRequest
+			request.httpRequest = httpRequest
+		case <-ctx.Done():
+			writer.mu.Lock()
+			writer.timedOut = true
+			writer.mu.Unlock()
+			go m.watchLatePanic(request.Context(), done, panicChan)
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				response.Status(http.StatusServiceUnavailable)
+			} else {
+				// The client has gone away, there is no point in writing a response.
+				response.status = statusClientClosedRequest
+				response.wroteHeader = true
+			}
+		}
+	}
+}
+
+// watchLatePanic logs the panics occurring in the handler after the timeout,
+// except the ones caused by writing to the timed out response.
+func (m *timeoutMiddleware) watchLatePanic(ctx context.Context, done <-chan struct{}, panicChan <-chan timeoutPanic) {
+	select {
+	case <-done:
+	case p := <-panicChan:
+		if !errors.Is(p.err, ErrHandlerTimeout) {
+			m.Logger().ErrorCtx(context.WithoutCancel(ctx), p.err)
+		}
+	}
+}
+
+func (m *timeoutMiddleware) copyResponse(response, innerResponse *Response, writer *timeoutWriter) {
+	header := response.Header()
+	for k := range header {
+		if _, ok := writer.header[k]; !ok {
+			header.Del(k)
+		}
+	}
+	maps.Copy(header, writer.header)
+
+	if innerResponse.err != nil {
+		response.err = innerResponse.err
+	}
+	if innerResponse.status != 0 {
+		response.Status(innerResponse.status)
+	}
+	switch {
+	case writer.buf.Len() > 0:
+		if _, err := response.Write(writer.buf.Bytes()); err != nil {
+			panic(errorutil.New(err))
+		}
+	case innerResponse.wroteHeader:
+		response.WriteHeader(writer.status)
+	}
+}
+
+func (m *timeoutMiddleware) getTimeout(request *Request) time.Duration {
+	if request.Route == nil {
+		return 0
+	}
+	t, ok := request.Route.LookupMeta(MetaTimeout)
+	if !ok || t == nil {
+		return 0
+	}
+	timeout, ok := t.(time.Duration)
+	if !ok {
+		panic(errorutil.NewSkip(fmt.Errorf("route meta %q is not a time.Duration", MetaTimeout), 3))
+	}
+	return timeout
+}
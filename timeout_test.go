@@ -0,0 +1,297 @@
+package goyave
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5/config"
+	"goyave.dev/goyave/v5/slog"
+)
+
+type testTimeoutUser struct {
+	Name string
+}
+
+// testTimeoutMiddleware executes the given procedures before and after the next handler.
+type testTimeoutMiddleware struct {
+	Component
+	before func(*Response, *Request)
+	after  func(*Response, *Request)
+}
+
+func (m *testTimeoutMiddleware) Handle(next Handler) Handler {
+	return func(response *Response, request *Request) {
+		if m.before != nil {
+			m.before(response, request)
+		}
+		next(response, request)
+		if m.after != nil {
+			m.after(response, request)
+		}
+	}
+}
+
+// testSyncBuffer a buffer safe for concurrent use, as logs may be written
+// by late handlers while the test reads them.
+type testSyncBuffer struct {
+	buf bytes.Buffer
+	mu  sync.Mutex
+}
+
+func (b *testSyncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *testSyncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// testNoWriteResponseWriter records whether anything was written to the response.
+type testNoWriteResponseWriter struct {
+	http.ResponseWriter
+	written bool
+}
+
+func (w *testNoWriteResponseWriter) WriteHeader(status int) {
+	w.written = true
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *testNoWriteResponseWriter) Write(b []byte) (int, error) {
+	w.written = true
+	return w.ResponseWriter.Write(b)
+}
+
+func prepareTimeoutTest(t *testing.T) (*Router, *testSyncBuffer) {
+	logBuffer := &testSyncBuffer{}
+	server, err := New(Options{Config: config.LoadDefault(), Logger: slog.New(slog.NewHandler(false, logBuffer))})
+	require.NoError(t, err)
+	return NewRouter(server), logBuffer
+}
+
+func serveTimeoutTest(router *Router, path string) (*http.Response, string) {
+	recorder := httptest.NewRecorder()
+	router.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, path, nil))
+	resp := recorder.Result()
+	body, _ := io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+	return resp, string(body)
+}
+
+func TestTimeoutMiddleware(t *testing.T) {
+	t.Run("in_time", func(t *testing.T) {
+		router, _ := prepareTimeoutTest(t)
+		router.GlobalMiddleware(&testTimeoutMiddleware{before: func(response *Response, _ *Request) {
+			response.Header().Set("X-Removed", "value")
+			response.Header().Set("X-Kept", "value")
+		}})
+		router.Timeout(time.Second)
+		var deadline bool
+		router.Get("/test", func(response *Response, request *Request) {
+			_, deadline = request.Context().Deadline()
+			response.Header().Del("X-Removed")
+			response.Header().Set("X-Test", "value")
+			response.String(http.StatusCreated, "hello world")
+		})
+
+		resp, body := serveTimeoutTest(router, "/test")
+		assert.True(t, deadline)
+		assert.Equal(t, http.StatusCreated, resp.StatusCode)
+		assert.Equal(t, "hello world", body)
+		assert.Equal(t, "value", resp.Header.Get("X-Test"))
+		assert.Equal(t, "value", resp.Header.Get("X-Kept"))
+		assert.Empty(t, resp.Header.Get("X-Removed"))
+	})
+
+	t.Run("request_copied_back", func(t *testing.T) {
+		router, _ := prepareTimeoutTest(t)
+		var user any
+		var extra any
+		router.GlobalMiddleware(&testTimeoutMiddleware{after: func(_ *Response, request *Request) {
+			user = request.User
+			extra = request.Extra["key"]
+			_, hasDeadline := request.Context().Deadline()
+			assert.False(t, hasDeadline)
+		}})
+		router.Timeout(time.Second)
+		router.Get("/test", func(response *Response, request *Request) {
+			request.User = &testTimeoutUser{Name: "johndoe"}
+			request.Extra["key"] = "value"
+			response.Status(http.StatusNotFound)
+		})
+
+		resp, body := serveTimeoutTest(router, "/test")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+		assert.Equal(t, "{\"error\":\"Not Found\"}\n", body)
+		assert.Equal(t, &testTimeoutUser{Name: "johndoe"}, user)
+		assert.Equal(t, "value", extra)
+	})
+
+	t.Run("write_header", func(t *testing.T) {
+		router, _ := prepareTimeoutTest(t)
+		router.Timeout(time.Second)
+		router.Get("/test", func(response *Response, _ *Request) {
+			response.WriteHeader(http.StatusAccepted)
+		})
+
+		resp, _ := serveTimeoutTest(router, "/test")
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	})
+
+	t.Run("exceeded", func(t *testing.T) {
+		router, logBuffer := prepareTimeoutTest(t)
+		router.Timeout(10 * time.Millisecond)
+		lateDone := make(chan struct{})
+		router.Get("/test", func(response *Response, request *Request) {
+			defer close(lateDone)
+			<-request.Context().Done()
+			time.Sleep(10 * time.Millisecond)
+			response.String(http.StatusOK, "late")
+		})
+
+		resp, body := serveTimeoutTest(router, "/test")
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		assert.Equal(t, "{\"error\":\"Service Unavailable\"}\n", body)
+		<-lateDone
+		time.Sleep(10 * time.Millisecond)
+		assert.Empty(t, logBuffer.String())
+	})
+
+	t.Run("client_gone", func(t *testing.T) {
+		router, logBuffer := prepareTimeoutTest(t)
+		router.Timeout(time.Second)
+		release := make(chan struct{})
+		router.GlobalMiddleware(&testTimeoutMiddleware{after: func(response *Response, _ *Request) {
+			assert.Equal(t, statusClientClosedRequest, response.GetStatus())
+			assert.True(t, response.IsHeaderWritten())
+		}})
+		router.Get("/test", func(response *Response, request *Request) {
+			<-request.Context().Done()
+			<-release
+			response.String(http.StatusOK, "late")
+		})
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		writer := &testNoWriteResponseWriter{ResponseWriter: httptest.NewRecorder()}
+		router.ServeHTTP(writer, httptest.NewRequest(http.MethodGet, "/test", nil).WithContext(ctx))
+		close(release)
+		assert.False(t, writer.written, "nothing should be written")
+		assert.Empty(t, logBuffer.String())
+	})
+
+	t.Run("late_panic", func(t *testing.T) {
+		router, logBuffer := prepareTimeoutTest(t)
+		router.Timeout(10 * time.Millisecond)
+		lateDone := make(chan struct{})
+		router.Get("/test", func(_ *Response, request *Request) {
+			defer close(lateDone)
+			<-request.Context().Done()
+			panic(fmt.Errorf("late panic"))
+		})
+
+		resp, _ := serveTimeoutTest(router, "/test")
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		<-lateDone
+		assert.Eventually(t, func() bool {
+			return strings.Contains(logBuffer.String(), `"msg":"late panic"`)
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("panic", func(t *testing.T) {
+		router, logBuffer := prepareTimeoutTest(t)
+		router.Timeout(time.Second)
+		router.Get("/test", func(_ *Response, _ *Request) {
+			panic(fmt.Errorf("test panic"))
+		})
+
+		resp, _ := serveTimeoutTest(router, "/test")
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assert.Contains(t, logBuffer.String(), `"msg":"test panic"`)
+	})
+
+	t.Run("route_override", func(t *testing.T) {
+		router, _ := prepareTimeoutTest(t)
+		router.Timeout(10 * time.Millisecond)
+		router.Get("/disabled", func(response *Response, request *Request) {
+			_, hasDeadline := request.Context().Deadline()
+			assert.False(t, hasDeadline)
+			time.Sleep(20 * time.Millisecond)
+			response.Status(http.StatusNoContent)
+		}).Timeout(0)
+
+		subrouter := router.Subrouter("/sub")
+		subrouter.Timeout(0)
+		subrouter.Get("/enabled", func(response *Response, request *Request) {
+			deadline, ok := request.Context().Deadline()
+			assert.True(t, ok)
+			assert.WithinDuration(t, time.Now().Add(time.Second), deadline, 100*time.Millisecond)
+			response.Status(http.StatusNoContent)
+		}).Timeout(time.Second)
+
+		resp, _ := serveTimeoutTest(router, "/disabled")
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+		resp, _ = serveTimeoutTest(router, "/sub/enabled")
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	})
+
+	t.Run("registration", func(t *testing.T) {
+		router, _ := prepareTimeoutTest(t)
+		router.Timeout(0)
+		assert.False(t, hasMiddleware[*timeoutMiddleware](router.globalMiddleware.middleware))
+		assert.Equal(t, time.Duration(0), router.Meta[MetaTimeout])
+
+		route := router.Get("/test", func(_ *Response, _ *Request) {}).Timeout(time.Second)
+		assert.Equal(t, time.Second, route.Meta[MetaTimeout])
+		assert.True(t, hasMiddleware[*timeoutMiddleware](router.globalMiddleware.middleware))
+
+		router.Timeout(time.Second)
+		assert.Len(t, router.globalMiddleware.middleware, 4) // recovery, lang, maintenance, timeout
+	})
+
+	t.Run("invalid_meta", func(t *testing.T) {
+		router, _ := prepareTimeoutTest(t)
+		route := router.Get("/test", func(_ *Response, _ *Request) {})
+		route.Meta[MetaTimeout] = "1s"
+		middleware := &timeoutMiddleware{}
+		middleware.Init(router.server)
+
+		request := NewRequest(httptest.NewRequest(http.MethodGet, "/test", nil))
+		request.Route = route
+		response := NewResponse(router.server, request, httptest.NewRecorder())
+		assert.Panics(t, func() {
+			middleware.Handle(func(_ *Response, _ *Request) {})(response, request)
+		})
+	})
+}
+
+func TestTimeoutWriter(t *testing.T) {
+	writer := &timeoutWriter{header: http.Header{}}
+	writer.WriteHeader(http.StatusCreated)
+	writer.WriteHeader(http.StatusOK)
+	assert.Equal(t, http.StatusCreated, writer.status)
+
+	n, err := writer.Write([]byte("hello"))
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+
+	writer.timedOut = true
+	n, err = writer.Write([]byte("hello"))
+	require.ErrorIs(t, err, ErrHandlerTimeout)
+	assert.Equal(t, 0, n)
+	assert.Equal(t, "hello", writer.buf.String())
+}
@@ -180,6 +180,14 @@ type RemoveFS interface {
 	RemoveAll(path string) error
 }
 
+// A RenameFS is a file system with a `Rename()` method.
+type RenameFS interface {
+	// Rename renames (moves) oldpath to newpath.
+	// If newpath already exists and is not a directory, Rename replaces it.
+	// If there is an error, it will be of type `*LinkError`.
+	Rename(oldpath, newpath string) error
+}
+
 // Embed is an extension of aimed at improving `embed.FS` by
 // implementing `fs.StatFS` and a `Sub()` function.
 type Embed struct {
@@ -194,6 +194,41 @@ func TestSave(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestOpenStreamedFile(t *testing.T) {
+	dir := t.TempDir()
+	storage := osfs.New(dir)
+	w, err := storage.OpenFile("streamed", os.O_WRONLY|os.O_CREATE, 0600)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("streamed content"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	file := File{
+		Header:   &multipart.FileHeader{Filename: "file.txt", Size: 16},
+		MIMEType: "text/plain; charset=utf-8",
+		Storage:  storage,
+		Path:     "streamed",
+	}
+
+	r, err := file.Open()
+	require.NoError(t, err)
+	content, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	assert.Equal(t, "streamed content", string(content))
+
+	actualName, err := file.Save(storage, "saved", "file.txt")
+	require.NoError(t, err)
+	content, err = os.ReadFile(filepath.Join(dir, "saved", actualName))
+	require.NoError(t, err)
+	assert.Equal(t, "streamed content", string(content))
+
+	file.Path = "doesn't exist"
+	r, err = file.Open()
+	require.Error(t, err)
+	assert.Nil(t, r)
+}
+
 func TestMarshalFile(t *testing.T) {
 	type testDTO struct {
 		Files []File `json:"files"`
@@ -153,6 +153,22 @@ func TestOSFS(t *testing.T) {
 		assert.False(t, fs.IsDirectory("resources/testdirremoveall"))
 	})
 
+	t.Run("Rename", func(t *testing.T) {
+		fs := &FS{}
+		path := "resources/testdirrename"
+		assert.NoError(t, fs.MkdirAll(path, 0770))
+		t.Cleanup(func() {
+			_ = fs.RemoveAll(path)
+		})
+		assert.NoError(t, fs.Rename(path, path+"-renamed"))
+		t.Cleanup(func() {
+			_ = fs.RemoveAll(path + "-renamed")
+		})
+		assert.False(t, fs.IsDirectory(path))
+		assert.True(t, fs.IsDirectory(path+"-renamed"))
+		assert.Error(t, fs.Rename(path, path+"-other"))
+	})
+
 	t.Run("Sub", func(t *testing.T) {
 		f, err := (&FS{}).Sub("resources")
 		require.NoError(t, err)
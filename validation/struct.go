@@ -0,0 +1,497 @@
+package validation
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"goyave.dev/goyave/v5/util/errors"
+)
+
+const (
+	// StructTag the name of the struct tag read by `FromStruct()` and `StructRuleSet()`.
+	StructTag = "validate"
+
+	// structTagDive the special keyword in struct tags indicating that the following
+	// rules apply to the elements of the array instead of the array itself.
+	structTagDive = "dive"
+)
+
+// TagRuleFactory function creating a new `Validator` from the parameters given in a
+// struct tag. For example, the `between=1|10` tag gives the parameters `[]string{"1", "10"}`.
+// Returns an error if the parameters are invalid.
+type TagRuleFactory func(params []string) (Validator, error)
+
+var (
+	tagRulesMu sync.RWMutex
+	tagRules   = map[string]TagRuleFactory{
+		"required":           noParam(func() Validator { return Required() }),
+		"nullable":           noParam(func() Validator { return Nullable() }),
+		"string":             noParam(func() Validator { return String() }),
+		"bool":               noParam(func() Validator { return Bool() }),
+		"int":                noParam(func() Validator { return Int() }),
+		"int8":               noParam(func() Validator { return Int8() }),
+		"int16":              noParam(func() Validator { return Int16() }),
+		"int32":              noParam(func() Validator { return Int32() }),
+		"int64":              noParam(func() Validator { return Int64() }),
+		"uint":               noParam(func() Validator { return Uint() }),
+		"uint8":              noParam(func() Validator { return Uint8() }),
+		"uint16":             noParam(func() Validator { return Uint16() }),
+		"uint32":             noParam(func() Validator { return Uint32() }),
+		"uint64":             noParam(func() Validator { return Uint64() }),
+		"float32":            noParam(func() Validator { return Float32() }),
+		"float64":            noParam(func() Validator { return Float64() }),
+		"array":              noParam(func() Validator { return Array() }),
+		"object":             noParam(func() Validator { return Object() }),
+		"file":               noParam(func() Validator { return File() }),
+		"image":              noParam(func() Validator { return Image() }),
+		"email":              noParam(func() Validator { return Email() }),
+		"url":                noParam(func() Validator { return URL() }),
+		"ip":                 noParam(func() Validator { return IP() }),
+		"ipv4":               noParam(func() Validator { return IPv4() }),
+		"ipv6":               noParam(func() Validator { return IPv6() }),
+		"json":               noParam(func() Validator { return JSON() }),
+		"timezone":           noParam(func() Validator { return Timezone() }),
+		"trim":               noParam(func() Validator { return Trim() }),
+		"alpha":              noParam(func() Validator { return Alpha() }),
+		"alpha_num":          noParam(func() Validator { return AlphaNum() }),
+		"alpha_dash":         noParam(func() Validator { return AlphaDash() }),
+		"digits":             noParam(func() Validator { return Digits() }),
+		"min":                floatParam(func(f float64) Validator { return Min(f) }),
+		"max":                floatParam(func(f float64) Validator { return Max(f) }),
+		"size":               intParam(func(i int) Validator { return Size(i) }),
+		"file_count":         intParam(func(i int) Validator { return FileCount(uint(i)) }),
+		"min_file_count":     intParam(func(i int) Validator { return MinFileCount(uint(i)) }),
+		"max_file_count":     intParam(func(i int) Validator { return MaxFileCount(uint(i)) }),
+		"same":               pathParam(func(p string) Validator { return Same(p) }),
+		"different":          pathParam(func(p string) Validator { return Different(p) }),
+		"greater_than":       pathParam(func(p string) Validator { return GreaterThan(p) }),
+		"greater_than_equal": pathParam(func(p string) Validator { return GreaterThanEqual(p) }),
+		"lower_than":         pathParam(func(p string) Validator { return LowerThan(p) }),
+		"lower_than_equal":   pathParam(func(p string) Validator { return LowerThanEqual(p) }),
+		"starts_with":        stringsParam(func(s []string) Validator { return StartsWith(s...) }),
+		"ends_with":          stringsParam(func(s []string) Validator { return EndsWith(s...) }),
+		"doesnt_start_with":  stringsParam(func(s []string) Validator { return DoesntStartWith(s...) }),
+		"doesnt_end_with":    stringsParam(func(s []string) Validator { return DoesntEndWith(s...) }),
+		"in":                 stringsParam(func(s []string) Validator { return In(s) }),
+		"not_in":             stringsParam(func(s []string) Validator { return NotIn(s) }),
+		"keys_in":            stringsParam(func(s []string) Validator { return KeysIn(s...) }),
+		"mime":               stringsParam(func(s []string) Validator { return MIME(s...) }),
+		"extension":          stringsParam(func(s []string) Validator { return Extension(s...) }),
+		"between": func(params []string) (Validator, error) {
+			if len(params) != 2 {
+				return nil, fmt.Errorf("expected 2 parameters, got %d", len(params))
+			}
+			min, err := strconv.ParseFloat(params[0], 64)
+			if err != nil {
+				return nil, err
+			}
+			max, err := strconv.ParseFloat(params[1], 64)
+			if err != nil {
+				return nil, err
+			}
+			return Between(min, max), nil
+		},
+		"date": func(params []string) (Validator, error) {
+			return Date(params...), nil
+		},
+		"uuid": func(params []string) (Validator, error) {
+			versions := make([]uuid.Version, 0, len(params))
+			for _, p := range params {
+				v, err := strconv.ParseUint(p, 10, 8)
+				if err != nil {
+					return nil, err
+				}
+				versions = append(versions, uuid.Version(v))
+			}
+			return UUID(versions...), nil
+		},
+		"regex": func(params []string) (Validator, error) {
+			if len(params) != 1 {
+				return nil, fmt.Errorf("expected 1 parameter, got %d", len(params))
+			}
+			regex, err := regexp.Compile(params[0])
+			if err != nil {
+				return nil, err
+			}
+			return Regex(regex), nil
+		},
+	}
+
+	// typedTagRules the rules whose validator depends on the type of the field. They
+	// are only used if no rule with the same name was registered with `RegisterTagRule()`.
+	typedTagRules = map[string]func(t reflect.Type) TagRuleFactory{
+		"distinct": distinctTagRule,
+	}
+
+	// rawParamTagRules the rules taking a single parameter that is not split. The
+	// parameter extends to the end of the tag so it can contain commas and pipes.
+	rawParamTagRules = map[string]struct{}{
+		"regex": {},
+	}
+
+	structRulesCache sync.Map // map[reflect.Type][]*structFieldRules
+)
+
+// RegisterTagRule registers a new rule that can be used in struct tags (see `FromStruct()`),
+// or replaces an existing one. The name should match the name of the validator returned
+// by the factory.
+//
+// This function is not meant to be called after the rule sets generated from
+// struct tags started being used.
+func RegisterTagRule(name string, factory TagRuleFactory) {
+	tagRulesMu.Lock()
+	defer tagRulesMu.Unlock()
+	tagRules[name] = factory
+	structRulesCache.Range(func(key, _ any) bool {
+		structRulesCache.Delete(key)
+		return true
+	})
+}
+
+// FromStruct generates the validation `RuleSet` matching the struct tags of the given DTO type `T`.
+// Its signature makes it usable as a `goyave.RuleSetFunc` without instantiating the request type:
+//
+//	router.Post("/users", ctrl.Create).ValidateBody(validation.FromStruct[dto.CreateUser])
+//
+// See `StructRuleSet()` for the struct tags syntax.
+func FromStruct[T any, R any](_ R) RuleSet {
+	return StructRuleSet[T]()
+}
+
+// StructRuleSet generates a new `RuleSet` from the struct tags of the given DTO type `T`.
+// A new `RuleSet` is returned on every call.
+//
+// The rules are read from the "validate" tag and separated by commas. Parameters are given
+// after a "=" sign and separated by a "|" (e.g. `validate:"required,string,between=3|255"`).
+// The names of the rules are the same as the names of the validators (see `Validator.Name()`).
+// Rules requiring a path parameter (e.g. `same=password`) expect the full path of the
+// compared field. Additional rules can be registered with `RegisterTagRule()`.
+//
+// The parameter of the "regex" rule is not split: it extends to the end of the tag so the
+// pattern can contain commas and pipes. This rule must therefore be the last one of the tag
+// (e.g. `validate:"required,string,regex=^(a|b)$"`). The "distinct" rule uses the element
+// type of the field: numeric elements should be converted with the matching type rule
+// (e.g. `validate:"array,distinct,dive,int"`).
+//
+// The name of each field is taken from its "json" tag. If the field doesn't have a
+// "json" tag, the name of the struct field is used. Fields with the `json:"-"` or
+// `validate:"-"` tag are ignored. Fields of embedded structs are promoted
+// the same way `encoding/json` does.
+//
+// Fields having a struct type (or pointer to struct) are explored recursively, resulting
+// in paths such as `user.address.city`. The elements of slices of structs are explored too
+// (`items[].name`). The rules following the special keyword "dive" apply to the elements
+// of the array instead of the array itself:
+//
+//	Tags []string `json:"tags" validate:"required,array,dive,string,max=20"`
+//
+// Types implementing `json.Unmarshaler` or `encoding.TextUnmarshaler` (such as `time.Time`)
+// are not explored.
+//
+// Panics if the type is not a struct or if a tag is invalid.
+func StructRuleSet[T any]() RuleSet {
+	t := reflect.TypeFor[T]()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		panic(errors.NewSkip(fmt.Errorf("cannot generate validation rules from non-struct type %s", t), 3))
+	}
+
+	var fields []*structFieldRules
+	if cached, ok := structRulesCache.Load(t); ok {
+		fields = cached.([]*structFieldRules)
+	} else {
+		var err error
+		fields, err = parseStruct(t, "", map[reflect.Type]struct{}{})
+		if err != nil {
+			panic(errors.NewSkip(err, 3))
+		}
+		structRulesCache.Store(t, fields)
+	}
+
+	ruleSet := make(RuleSet, 0, len(fields))
+	for _, f := range fields {
+		ruleSet = append(ruleSet, f.fieldRules())
+	}
+	return ruleSet
+}
+
+type tagRule struct {
+	factory TagRuleFactory
+	params  []string
+}
+
+type structFieldRules struct {
+	path  string
+	rules []tagRule
+}
+
+// fieldRules creates a new `FieldRules`. The rules were already validated
+// when parsing the struct so the factories cannot return an error.
+func (f *structFieldRules) fieldRules() *FieldRules {
+	list := make(List, 0, len(f.rules))
+	for _, r := range f.rules {
+		v, _ := r.factory(r.params)
+		list = append(list, v)
+	}
+	return &FieldRules{Path: f.path, Rules: list}
+}
+
+var (
+	jsonUnmarshalerType = reflect.TypeFor[json.Unmarshaler]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+func parseStruct(t reflect.Type, prefix string, visited map[reflect.Type]struct{}) ([]*structFieldRules, error) {
+	if _, ok := visited[t]; ok {
+		// Recursive type, stop exploring to avoid infinite recursion.
+		return nil, nil
+	}
+	visited[t] = struct{}{}
+	defer delete(visited, t)
+
+	result := []*structFieldRules{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag, hasTag := field.Tag.Lookup(StructTag)
+		if !field.IsExported() && (!field.Anonymous || hasTag) {
+			continue
+		}
+		if tag == "-" {
+			continue
+		}
+		name, hasName := jsonName(field)
+		if name == "-" {
+			continue
+		}
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+
+		if field.Anonymous && !hasName && !hasTag {
+			if fieldType.Kind() == reflect.Struct && !isLeafType(fieldType) {
+				promoted, err := parseStruct(fieldType, prefix, visited)
+				if err != nil {
+					return nil, err
+				}
+				result = append(result, promoted...)
+			}
+			continue
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+
+		path := prefix + name
+		if hasTag {
+			fields, err := parseTag(path, tag, fieldType)
+			if err != nil {
+				return nil, fmt.Errorf("%s.%s: %w", t.String(), field.Name, err)
+			}
+			result = append(result, fields...)
+		}
+
+		elemPath := path
+		for fieldType.Kind() == reflect.Slice || fieldType.Kind() == reflect.Array {
+			if isLeafType(fieldType) {
+				break
+			}
+			elemPath += "[]"
+			fieldType = fieldType.Elem()
+			for fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+		}
+		if fieldType.Kind() == reflect.Struct && !isLeafType(fieldType) {
+			nested, err := parseStruct(fieldType, elemPath+".", visited)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, nested...)
+		}
+	}
+	return result, nil
+}
+
+func parseTag(path, tag string, fieldType reflect.Type) ([]*structFieldRules, error) {
+	current := &structFieldRules{path: path, rules: []tagRule{}}
+	result := []*structFieldRules{current}
+
+	tagRulesMu.RLock()
+	defer tagRulesMu.RUnlock()
+	for tag != "" {
+		r, rest, _ := strings.Cut(tag, ",")
+		tag = rest
+		r = strings.TrimSpace(r)
+		if r == "" {
+			continue
+		}
+		if r == structTagDive {
+			current = &structFieldRules{path: current.path + "[]", rules: []tagRule{}}
+			result = append(result, current)
+			fieldType = diveType(fieldType)
+			continue
+		}
+
+		name, rawParams, hasParams := strings.Cut(r, "=")
+		var params []string
+		if _, raw := rawParamTagRules[name]; raw && hasParams {
+			if rest != "" {
+				rawParams += "," + rest
+				tag = ""
+			}
+			params = []string{rawParams}
+		} else if hasParams {
+			params = strings.Split(rawParams, "|")
+		}
+		factory, ok := tagRules[name]
+		if !ok {
+			typed, isTyped := typedTagRules[name]
+			if !isTyped {
+				return nil, fmt.Errorf("unknown validation rule %q", name)
+			}
+			factory = typed(fieldType)
+		}
+		if _, err := factory(params); err != nil {
+			return nil, fmt.Errorf("invalid parameters for validation rule %q: %w", name, err)
+		}
+		current.rules = append(current.rules, tagRule{factory: factory, params: params})
+	}
+	return result, nil
+}
+
+// diveType returns the type of the elements of the given slice or array type, or
+// `nil` if the type is unknown or not a slice nor an array.
+func diveType(t reflect.Type) reflect.Type {
+	if t == nil || (t.Kind() != reflect.Slice && t.Kind() != reflect.Array) {
+		return nil
+	}
+	t = t.Elem()
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// distinctTagRule returns the factory of the "distinct" rule matching the element
+// type of the given slice type. Falls back to strings if the element type is unknown.
+func distinctTagRule(t reflect.Type) TagRuleFactory {
+	var elemKind reflect.Kind
+	if elem := diveType(t); elem != nil {
+		elemKind = elem.Kind()
+	}
+	switch elemKind {
+	case reflect.Int:
+		return noParam(func() Validator { return Distinct[int]() })
+	case reflect.Int8:
+		return noParam(func() Validator { return Distinct[int8]() })
+	case reflect.Int16:
+		return noParam(func() Validator { return Distinct[int16]() })
+	case reflect.Int32:
+		return noParam(func() Validator { return Distinct[int32]() })
+	case reflect.Int64:
+		return noParam(func() Validator { return Distinct[int64]() })
+	case reflect.Uint:
+		return noParam(func() Validator { return Distinct[uint]() })
+	case reflect.Uint8:
+		return noParam(func() Validator { return Distinct[uint8]() })
+	case reflect.Uint16:
+		return noParam(func() Validator { return Distinct[uint16]() })
+	case reflect.Uint32:
+		return noParam(func() Validator { return Distinct[uint32]() })
+	case reflect.Uint64:
+		return noParam(func() Validator { return Distinct[uint64]() })
+	case reflect.Float32:
+		return noParam(func() Validator { return Distinct[float32]() })
+	case reflect.Float64:
+		return noParam(func() Validator { return Distinct[float64]() })
+	case reflect.Bool:
+		return noParam(func() Validator { return Distinct[bool]() })
+	default:
+		return noParam(func() Validator { return Distinct[string]() })
+	}
+}
+
+func jsonName(field reflect.StructField) (string, bool) {
+	tag, ok := field.Tag.Lookup("json")
+	if !ok {
+		return field.Name, false
+	}
+	name, _, _ := strings.Cut(tag, ",")
+	if name == "" {
+		return field.Name, false
+	}
+	return name, true
+}
+
+func isLeafType(t reflect.Type) bool {
+	return t.Implements(jsonUnmarshalerType) || t.Implements(textUnmarshalerType) ||
+		reflect.PointerTo(t).Implements(jsonUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+func noParam(f func() Validator) TagRuleFactory {
+	return func(params []string) (Validator, error) {
+		if len(params) != 0 {
+			return nil, fmt.Errorf("expected no parameter, got %d", len(params))
+		}
+		return f(), nil
+	}
+}
+
+func floatParam(f func(float64) Validator) TagRuleFactory {
+	return func(params []string) (Validator, error) {
+		if len(params) != 1 {
+			return nil, fmt.Errorf("expected 1 parameter, got %d", len(params))
+		}
+		fl, err := strconv.ParseFloat(params[0], 64)
+		if err != nil {
+			return nil, err
+		}
+		return f(fl), nil
+	}
+}
+
+func intParam(f func(int) Validator) TagRuleFactory {
+	return func(params []string) (Validator, error) {
+		if len(params) != 1 {
+			return nil, fmt.Errorf("expected 1 parameter, got %d", len(params))
+		}
+		i, err := strconv.Atoi(params[0])
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 {
+			return nil, fmt.Errorf("expected positive integer, got %d", i)
+		}
+		return f(i), nil
+	}
+}
+
+func pathParam(f func(string) Validator) TagRuleFactory {
+	return func(params []string) (Validator, error) {
+		if len(params) != 1 || params[0] == "" {
+			return nil, fmt.Errorf("expected 1 path parameter, got %d", len(params))
+		}
+		return f(params[0]), nil
+	}
+}
+
+func stringsParam(f func([]string) Validator) TagRuleFactory {
+	return func(params []string) (Validator, error) {
+		if len(params) == 0 {
+			return nil, fmt.Errorf("expected at least 1 parameter")
+		}
+		return f(params), nil
+	}
+}
@@ -0,0 +1,207 @@
+package validation
+
+import (
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"goyave.dev/goyave/v5/lang"
+)
+
+type testStructAddress struct {
+	City    string `json:"city" validate:"required,string,max=100"`
+	ZipCode string `json:"zipCode" validate:"nullable,digits,size=5"`
+}
+
+type testStructItem struct {
+	Name     string `json:"name" validate:"required,string"`
+	Quantity int    `json:"quantity" validate:"required,int,between=1|10"`
+}
+
+type testStructEmbedded struct {
+	Promoted string `json:"promoted" validate:"string"`
+}
+
+type testStructNode struct {
+	Children []*testStructNode `json:"children" validate:"array"`
+	Name     string            `json:"name" validate:"required,string"`
+}
+
+type testStructDTO struct {
+	testStructEmbedded
+	CreatedAt  time.Time          `json:"createdAt" validate:"date"`
+	Address    *testStructAddress `json:"address" validate:"required,object"`
+	Ignored    string             `json:"-" validate:"required"`
+	Email      string             `json:"email,omitempty" validate:"required,string,email,max=255"`
+	NoTag      string             `json:"noTag"`
+	NoJSONTag  string             `validate:"string"`
+	Role       string             `json:"role" validate:"in=admin|user"`
+	Excluded   string             `json:"excluded" validate:"-"`
+	ID         uuid.UUID          `json:"id" validate:"uuid=4"`
+	Code       string             `json:"code" validate:"regex=^[a-z]+$"`
+	Tags       []string           `json:"tags" validate:"array,dive,string,max=20"`
+	Matrix     [][]int            `json:"matrix" validate:"array,dive,array,dive,int"`
+	Items      []testStructItem   `json:"items" validate:"required,array,min=1"`
+	unexported string             `validate:"required"` //nolint:unused
+}
+
+func TestStructRuleSet(t *testing.T) {
+	ruleSet := StructRuleSet[testStructDTO]()
+
+	expected := RuleSet{
+		{Path: "promoted", Rules: List{String()}},
+		{Path: "createdAt", Rules: List{Date()}},
+		{Path: "address", Rules: List{Required(), Object()}},
+		{Path: "address.city", Rules: List{Required(), String(), Max(100)}},
+		{Path: "address.zipCode", Rules: List{Nullable(), Digits(), Size(5)}},
+		{Path: "email", Rules: List{Required(), String(), Email(), Max(255)}},
+		{Path: "NoJSONTag", Rules: List{String()}},
+		{Path: "role", Rules: List{In([]string{"admin", "user"})}},
+		{Path: "id", Rules: List{UUID(4)}},
+		{Path: "code", Rules: List{Regex(regexp.MustCompile("^[a-z]+$"))}},
+		{Path: "tags", Rules: List{Array()}},
+		{Path: "tags[]", Rules: List{String(), Max(20)}},
+		{Path: "matrix", Rules: List{Array()}},
+		{Path: "matrix[]", Rules: List{Array()}},
+		{Path: "matrix[][]", Rules: List{Int()}},
+		{Path: "items", Rules: List{Required(), Array(), Min(1)}},
+		{Path: "items[].name", Rules: List{Required(), String()}},
+		{Path: "items[].quantity", Rules: List{Required(), Int(), Between(1, 10)}},
+	}
+	assert.Equal(t, expected, ruleSet)
+
+	t.Run("new_instance_every_call", func(t *testing.T) {
+		other := StructRuleSet[testStructDTO]()
+		assert.Equal(t, ruleSet, other)
+		assert.NotSame(t, ruleSet[0].Rules.(List)[0], other[0].Rules.(List)[0])
+	})
+
+	t.Run("pointer", func(t *testing.T) {
+		assert.Equal(t, StructRuleSet[testStructAddress](), StructRuleSet[*testStructAddress]())
+	})
+
+	t.Run("recursive_type", func(t *testing.T) {
+		expected := RuleSet{
+			{Path: "children", Rules: List{Array()}},
+			{Path: "name", Rules: List{Required(), String()}},
+		}
+		assert.Equal(t, expected, StructRuleSet[testStructNode]())
+	})
+
+	t.Run("not_a_struct", func(t *testing.T) {
+		assert.Panics(t, func() {
+			StructRuleSet[[]string]()
+		})
+	})
+
+	t.Run("unknown_rule", func(t *testing.T) {
+		type invalid struct {
+			Field string `validate:"unknown_rule"`
+		}
+		assert.Panics(t, func() {
+			StructRuleSet[invalid]()
+		})
+	})
+
+	t.Run("regex_with_separators", func(t *testing.T) {
+		type dto struct {
+			Choice string   `json:"choice" validate:"required,string,regex=^(a|b),{1|2}$"`
+			Codes  []string `json:"codes" validate:"array,dive,regex=^[a-z]{2,3}$"`
+		}
+		expected := RuleSet{
+			{Path: "choice", Rules: List{Required(), String(), Regex(regexp.MustCompile("^(a|b),{1|2}$"))}},
+			{Path: "codes", Rules: List{Array()}},
+			{Path: "codes[]", Rules: List{Regex(regexp.MustCompile("^[a-z]{2,3}$"))}},
+		}
+		assert.Equal(t, expected, StructRuleSet[dto]())
+	})
+
+	t.Run("distinct_element_type", func(t *testing.T) {
+		type dto struct {
+			Names   []string  `json:"names" validate:"array,distinct"`
+			IDs     []uint    `json:"ids" validate:"array,distinct,dive,uint"`
+			Scores  []float64 `json:"scores" validate:"array,distinct"`
+			Matrix  [][]int   `json:"matrix" validate:"array,dive,array,distinct"`
+			Unknown []any     `json:"unknown" validate:"array,distinct"`
+		}
+		expected := RuleSet{
+			{Path: "names", Rules: List{Array(), Distinct[string]()}},
+			{Path: "ids", Rules: List{Array(), Distinct[uint]()}},
+			{Path: "ids[]", Rules: List{Uint()}},
+			{Path: "scores", Rules: List{Array(), Distinct[float64]()}},
+			{Path: "matrix", Rules: List{Array()}},
+			{Path: "matrix[]", Rules: List{Array(), Distinct[int]()}},
+			{Path: "unknown", Rules: List{Array(), Distinct[string]()}},
+		}
+		assert.Equal(t, expected, StructRuleSet[dto]())
+
+		errs, _ := Validate(&Options{
+			Data:  map[string]any{"ids": []any{1.0, 2.0, 1.0}, "scores": []any{1.5, 2.5}},
+			Rules: StructRuleSet[dto](),
+		})
+		require.NotNil(t, errs)
+		assert.Contains(t, errs.Fields, "ids")
+		assert.NotContains(t, errs.Fields, "scores")
+	})
+
+	t.Run("invalid_params", func(t *testing.T) {
+		type invalid struct {
+			Field string `validate:"between=1"`
+		}
+		assert.Panics(t, func() {
+			StructRuleSet[invalid]()
+		})
+	})
+}
+
+func TestFromStruct(t *testing.T) {
+	type request struct{}
+	type ruleSetFunc func(*request) RuleSet
+
+	var f ruleSetFunc = FromStruct[testStructAddress]
+	ruleSet := f(&request{})
+	assert.Equal(t, StructRuleSet[testStructAddress](), ruleSet)
+
+	errs, validationErrs := Validate(&Options{
+		Data:     map[string]any{"city": "Paris", "zipCode": "123"},
+		Rules:    ruleSet,
+		Language: lang.New().GetDefault(),
+	})
+	require.Empty(t, validationErrs)
+	require.NotNil(t, errs)
+	assert.Contains(t, errs.Fields, "zipCode")
+	assert.NotContains(t, errs.Fields, "city")
+}
+
+type testTagRuleValidator struct {
+	BaseValidator
+}
+
+func (v *testTagRuleValidator) Validate(_ *Context) bool { return true }
+func (v *testTagRuleValidator) Name() string             { return "test_tag_rule" }
+
+func TestRegisterTagRule(t *testing.T) {
+	type dto struct {
+		Field string `json:"field" validate:"test_tag_rule"`
+	}
+	assert.Panics(t, func() {
+		StructRuleSet[dto]()
+	})
+
+	RegisterTagRule("test_tag_rule", func(_ []string) (Validator, error) {
+		return &testTagRuleValidator{}, nil
+	})
+	t.Cleanup(func() {
+		tagRulesMu.Lock()
+		delete(tagRules, "test_tag_rule")
+		tagRulesMu.Unlock()
+	})
+
+	expected := RuleSet{
+		{Path: "field", Rules: List{&testTagRuleValidator{}}},
+	}
+	assert.Equal(t, expected, StructRuleSet[dto]())
+}
@@ -245,7 +245,7 @@ func (u *Upgrader) serve(c *ws.Conn, request *goyave.Request, handler func(*Conn
 			if errorHandler, ok := u.Controller.(ErrorHandler); ok {
 				errorHandler.OnError(request, err)
 			} else {
-				u.Logger().Error(err)
+				u.Logger().ErrorCtx(request.Context(), err)
 			}
 			_ = conn.CloseWithError(err)
 		} else {